@@ -0,0 +1,17 @@
+package main
+
+import "context"
+
+// agentSummary describes `memtop agent` in the top-level usage.
+const agentSummary = "run without a terminal, feeding the outputs and alerts given by the monitor options"
+
+// runAgent is `memtop agent`: the monitor without tcell, for running as a
+// service. It polls every interval and hands each pass to the configured
+// sinks (the /metrics endpoint, JSON lines, CSV, Graphite, webhooks, and
+// alert hooks) until ctx is cancelled. Remote control through /control/
+// (with -control) and the error and event logs keep working; without
+// -event-log and -log, events go to stdout and errors to stderr, where a
+// service manager collects them.
+func runAgent(ctx context.Context, u *ui) {
+	runHeadless(ctx, u, func() error { return nil })
+}
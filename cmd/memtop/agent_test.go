@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunAgentFeedsSinksUntilCancelled(t *testing.T) {
+	ln := startSectionServer(t, map[string]string{"": "STAT curr_items 7\r\nSTAT version 1.6.21\r\n"})
+	defer ln.Close()
+	u := newUI(10*time.Millisecond, nil, newSession(ln.Addr().String(), time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &cancellingSink{cancel: cancel, after: 3}
+	u.sinks.add("test", out)
+
+	done := make(chan struct{})
+	go func() {
+		runAgent(ctx, u)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("agent kept running after its context was cancelled")
+	}
+	if out.writes != 3 || u.current().current.Values["curr_items"] != 7 {
+		t.Fatalf("want three passes of the server's stats, got %d writes of %+v", out.writes, u.current().current)
+	}
+}
+
+// cancellingSink cancels the agent once it has been written after times.
+type cancellingSink struct {
+	cancel context.CancelFunc
+	after  int
+	writes int
+}
+
+func (c *cancellingSink) write(u *ui) error {
+	if c.writes++; c.writes == c.after {
+		c.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sparklineWidth is how many recent values an alert's sparkline shows.
+const sparklineWidth = 30
+
+// States of a script alert.
+const (
+	alertFiring   = "firing"
+	alertResolved = "resolved"
+)
+
+// alertInfo is a script alert with the optional details a rule passed to
+// alert(): the metric it watches, its value and threshold, and a chat
+// webhook and hook command overriding the global ones. State and Sparkline
+// are filled in when the alert is raised or cleared.
+type alertInfo struct {
+	Message   string
+	State     string
+	Metric    string
+	Value     *float64
+	Threshold *float64
+	Webhook   string
+	Exec      []string
+	Sparkline string
+}
+
+// logAlert records an alert being raised or cleared, keeping its details on
+// the event for the outputs that act on alerts.
+func (s *session) logAlert(t time.Time, a alertInfo) {
+	message := a.Message
+	if a.State == alertResolved {
+		message = "cleared: " + message
+	}
+	s.events.add(event{Time: t, Server: s.addr, Kind: eventAlert, Message: message, Alert: &a})
+}
+
+// formatAlertNumber prints an alert's value or threshold, empty if unset.
+func formatAlertNumber(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', 6, 64)
+}
+
+// chatWebhook posts script alerts, raised and cleared, to a Slack-compatible
+// incoming webhook as an attachment with the server, metric, value,
+// threshold, and a sparkline of the metric. Mattermost, Rocket.Chat, and
+// other chat tools accepting Slack's format work too. A rule's own webhook
+// takes precedence over url; alerts with neither are skipped.
+type chatWebhook struct {
+	url    string
+	client *http.Client
+	seq    int
+}
+
+func newChatWebhook(url string) *chatWebhook {
+	return &chatWebhook{url: url, client: &http.Client{Timeout: defaultTimeout}}
+}
+
+// slackField is one short field of a Slack attachment.
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+type slackAttachment struct {
+	Fallback string       `json:"fallback"`
+	Color    string       `json:"color"`
+	Title    string       `json:"title"`
+	Text     string       `json:"text,omitempty"`
+	Fields   []slackField `json:"fields"`
+	Ts       int64        `json:"ts"`
+}
+
+type slackMessage struct {
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// slackPayload formats one alert event as a Slack message.
+func slackPayload(e event) slackMessage {
+	a := e.Alert
+	color, verb := "danger", "Alert"
+	if a.State == alertResolved {
+		color, verb = "good", "Resolved"
+	}
+	fields := []slackField{{Title: "Server", Value: e.Server, Short: true}}
+	for _, f := range []slackField{
+		{Title: "Metric", Value: a.Metric},
+		{Title: "Value", Value: formatAlertNumber(a.Value)},
+		{Title: "Threshold", Value: formatAlertNumber(a.Threshold)},
+	} {
+		if f.Value != "" {
+			f.Short = true
+			fields = append(fields, f)
+		}
+	}
+	att := slackAttachment{
+		Fallback: fmt.Sprintf("%s on %s: %s", verb, e.Server, a.Message),
+		Color:    color,
+		Title:    a.Message,
+		Fields:   fields,
+		Ts:       e.Time.Unix(),
+	}
+	if a.Sparkline != "" {
+		att.Text = "`" + a.Sparkline + "`"
+	}
+	return slackMessage{Text: fmt.Sprintf("memtop %s: %s", verb, e.Server), Attachments: []slackAttachment{att}}
+}
+
+func (c *chatWebhook) write(u *ui) error {
+	events, seq := u.events.since(c.seq)
+	c.seq = seq
+	var errs []error
+	for _, e := range events {
+		if e.Alert == nil {
+			continue
+		}
+		url := c.url
+		if e.Alert.Webhook != "" {
+			url = e.Alert.Webhook
+		}
+		if url == "" {
+			continue
+		}
+		if err := c.post(url, slackPayload(e)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *chatWebhook) post(url string, msg slackMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", url, resp.Status)
+	}
+	return nil
+}
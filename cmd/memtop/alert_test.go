@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const alertScript = `
+def on_sample(sample, state):
+    rate = sample.rates.get("evictions", 0)
+    metric("evictions", rate)
+    if rate > 100:
+        alert("eviction storm", metric="evictions", value=rate, threshold=100)
+`
+
+func TestChatWebhookPostsRaisedAndClearedAlerts(t *testing.T) {
+	var posts []slackMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var msg slackMessage
+		json.NewDecoder(r.Body).Decode(&msg)
+		posts = append(posts, msg)
+	}))
+	defer srv.Close()
+
+	sc, err := loadScript("rules.star", alertScript)
+	if err != nil {
+		t.Fatalf("loadScript: %v", err)
+	}
+	sess := newSession("10.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	hook := newChatWebhook(srv.URL)
+	now := time.Unix(1000, 0)
+	feed := func(evictions float64) {
+		sess.current = &statsSnapshot{Timestamp: now}
+		sess.rates = map[string]float64{"evictions": evictions}
+		runScript(sess, sc, now)
+		if sess.scriptErr != nil {
+			t.Fatalf("script failed: %v", sess.scriptErr)
+		}
+		if err := hook.write(u); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+
+	feed(10)
+	feed(50)
+	feed(500)
+	feed(800)
+	feed(0)
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want the alert and its clearing: %+v", len(posts), posts)
+	}
+	fired := posts[0].Attachments[0]
+	if fired.Color != "danger" || fired.Title != "eviction storm" || len(fired.Fields) != 4 || fired.Fields[2].Value != "500" || fired.Fields[3].Value != "100" {
+		t.Fatalf("fired attachment = %+v", fired)
+	}
+	if !strings.HasPrefix(fired.Text, "`") || len([]rune(fired.Text)) != 5 {
+		t.Fatalf("want a three-sample sparkline, got %q", fired.Text)
+	}
+	if cleared := posts[1].Attachments[0]; cleared.Color != "good" || !strings.Contains(posts[1].Text, "Resolved") {
+		t.Fatalf("cleared message = %+v", posts[1])
+	}
+}
+
+func TestChatWebhookPrefersTheRuleWebhook(t *testing.T) {
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { posts++ }))
+	defer srv.Close()
+
+	sess := newSession("10.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	sess.logAlert(time.Now(), alertInfo{Message: "no hook", State: alertFiring})
+	sess.logAlert(time.Now(), alertInfo{Message: "own hook", State: alertFiring, Webhook: srv.URL})
+	if err := newChatWebhook("").write(u); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if posts != 1 {
+		t.Fatalf("want only the alert with its own webhook posted, got %d posts", posts)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]float64{0, 4, 8}, 30); got != "▁▄█" {
+		t.Fatalf("sparkline = %q", got)
+	}
+	if got := sparkline([]float64{1, 2, 3}, 2); got != "▅█" {
+		t.Fatalf("sparkline should keep the newest values, got %q", got)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	// defaultAnomalyWindow is how many past samples feed the rolling mean.
+	defaultAnomalyWindow = 30
+	// defaultAnomalySigma is how far from the mean a value must stray to be
+	// highlighted.
+	defaultAnomalySigma = 3.0
+	// minAnomalySamples avoids flagging everything while the window fills.
+	minAnomalySamples = 5
+)
+
+// rollingStats keeps a sliding window of recent values per metric so each new
+// value can be scored against the metric's own recent behaviour.
+type rollingStats struct {
+	window  int
+	samples map[string][]float64
+}
+
+// newRollingStats creates a tracker that remembers window samples per metric.
+func newRollingStats(window int) *rollingStats {
+	if window < minAnomalySamples {
+		window = minAnomalySamples
+	}
+	return &rollingStats{window: window, samples: make(map[string][]float64)}
+}
+
+// observe scores each value against the window preceding it and then adds it
+// to the window. Metrics without enough history or with a perfectly flat
+// history get no score, since a z-score is meaningless there.
+func (r *rollingStats) observe(values map[string]float64) map[string]float64 {
+	scores := make(map[string]float64)
+	for key, v := range values {
+		past := r.samples[key]
+		if len(past) >= minAnomalySamples {
+			mean, std := meanStdDev(past)
+			if std > 0 {
+				scores[key] = (v - mean) / std
+			}
+		}
+		r.samples[key] = appendBounded(past, v, r.window)
+	}
+	return scores
+}
+
+// meanStdDev returns the population mean and standard deviation.
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
+
+// isAnomalous reports whether a metric's latest rate is outside the sigma band.
+func (s *session) isAnomalous(key string) bool {
+	z, ok := s.zscores[key]
+	return ok && math.Abs(z) > s.anomalySigma
+}
+
+// anomalyStyle is used to highlight metrics flagged by the rolling statistics.
+var anomalyStyle = theme.bad.Bold(true)
+
+// renderAnomaliesPanel lists metrics whose rates currently deviate from their
+// rolling mean by more than the configured number of standard deviations.
+func renderAnomaliesPanel(s *session) []panelLine {
+	var keys []string
+	for key := range s.zscores {
+		if s.isAnomalous(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return []panelLine{plainLine(fmt.Sprintf("No rates beyond %.1f sigma.", s.anomalySigma))}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return math.Abs(s.zscores[keys[i]]) > math.Abs(s.zscores[keys[j]])
+	})
+	nf := numberFormat{"anomalies"}
+	lines := make([]panelLine, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, panelLine{
+			Text:  fmt.Sprintf("%-22s %11s%s  z=%+.1f", key, nf.rate(key, displayRate(s.rates[key], s.interval)), rateUnit(s.interval), s.zscores[key]),
+			Style: anomalyStyle,
+		})
+	}
+	return lines
+}
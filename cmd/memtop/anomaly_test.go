@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMeanStdDev(t *testing.T) {
+	mean, std := meanStdDev([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if mean != 5 || std != 2 {
+		t.Fatalf("meanStdDev = %.2f, %.2f; want 5, 2", mean, std)
+	}
+	if mean, std := meanStdDev(nil); mean != 0 || std != 0 {
+		t.Fatalf("empty input should yield zeros, got %.2f, %.2f", mean, std)
+	}
+}
+
+func TestRollingStatsScoresAgainstPriorWindow(t *testing.T) {
+	r := newRollingStats(10)
+	for _, v := range []float64{9, 11, 9, 11, 9, 11} {
+		if scores := r.observe(map[string]float64{"cmd_get": v, "flat": 1}); len(scores) > 0 && math.Abs(scores["cmd_get"]) > 2 {
+			t.Fatalf("steady values should not score highly, got %v", scores)
+		}
+	}
+
+	scores := r.observe(map[string]float64{"cmd_get": 20, "flat": 5})
+	if got := scores["cmd_get"]; math.Abs(got-10) > 1e-9 {
+		t.Fatalf("spike z-score = %.2f, want 10", got)
+	}
+	if _, ok := scores["flat"]; ok {
+		t.Fatalf("metrics with zero variance should not be scored")
+	}
+}
+
+func TestRollingStatsWindowIsBounded(t *testing.T) {
+	r := newRollingStats(5)
+	for i := 0; i < 20; i++ {
+		r.observe(map[string]float64{"cmd_get": float64(i)})
+	}
+	if got := len(r.samples["cmd_get"]); got != 5 {
+		t.Fatalf("window holds %d samples, want 5", got)
+	}
+}
+
+func TestSessionFlagsAnomalies(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 0)
+	sess.rates = map[string]float64{"evictions": 50, "cmd_get": 10}
+	sess.zscores = map[string]float64{"evictions": 4.5, "cmd_get": -1}
+
+	if !sess.isAnomalous("evictions") || sess.isAnomalous("cmd_get") || sess.isAnomalous("missing") {
+		t.Fatalf("isAnomalous mismatch for zscores %v", sess.zscores)
+	}
+	lines := renderAnomaliesPanel(sess)
+	if len(lines) != 1 || lines[0].Style != anomalyStyle {
+		t.Fatalf("expected one highlighted anomaly, got %+v", lines)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// advisorStreak is how many slab samples in a row a class must evict
+	// before a move is suggested, so a single burst doesn't trigger one.
+	advisorStreak = 3
+	// maxAdvice caps the suggestions shown at once.
+	maxAdvice = 3
+)
+
+// slabMove is a suggested `slabs reassign`: one page from a class with
+// spare pages to a class that is evicting, with the chunk counts before and
+// after the move.
+type slabMove struct {
+	From, To              int
+	EvictRate             float64
+	FromBefore, FromAfter float64
+	ToBefore, ToAfter     float64
+}
+
+// chunksPerPage reports how many chunks one of the class's pages holds.
+func (c slabClass) chunksPerPage() float64 {
+	if c.TotalPages <= 0 {
+		return 0
+	}
+	return c.TotalChunks / c.TotalPages
+}
+
+// freePages counts whole pages' worth of unused chunks.
+func (c slabClass) freePages() int {
+	per := c.chunksPerPage()
+	if per <= 0 {
+		return 0
+	}
+	return int(math.Floor((c.TotalChunks - c.UsedChunks) / per))
+}
+
+// trackEvictions updates how many slab samples in a row each class has
+// been evicting.
+func (s *session) trackEvictions(classes []slabClass) {
+	streak := make(map[int]int, len(classes))
+	for _, c := range classes {
+		if c.EvictRate > 0 {
+			streak[c.ID] = s.evictStreak[c.ID] + 1
+		}
+	}
+	s.evictStreak = streak
+}
+
+// adviseMoves pairs the classes under sustained eviction pressure, busiest
+// first, with classes that evict nothing and have whole free pages, the
+// emptiest first. Each free page is offered once.
+func adviseMoves(classes []slabClass, streak map[int]int) []slabMove {
+	var receivers, donors []slabClass
+	spare := make(map[int]int)
+	for _, c := range classes {
+		switch {
+		case c.EvictRate > 0 && streak[c.ID] >= advisorStreak:
+			receivers = append(receivers, c)
+		case c.EvictRate == 0 && c.freePages() > 0:
+			donors = append(donors, c)
+			spare[c.ID] = c.freePages()
+		}
+	}
+	sort.SliceStable(receivers, func(i, j int) bool { return receivers[i].EvictRate > receivers[j].EvictRate })
+	sort.SliceStable(donors, func(i, j int) bool { return donors[i].freePages() > donors[j].freePages() })
+
+	var moves []slabMove
+	for _, to := range receivers {
+		for _, from := range donors {
+			if spare[from.ID] == 0 {
+				continue
+			}
+			spare[from.ID]--
+			moves = append(moves, slabMove{
+				From: from.ID, To: to.ID, EvictRate: to.EvictRate,
+				FromBefore: from.TotalChunks, FromAfter: from.TotalChunks - from.chunksPerPage(),
+				ToBefore: to.TotalChunks, ToAfter: to.TotalChunks + to.chunksPerPage(),
+			})
+			break
+		}
+		if len(moves) == maxAdvice {
+			break
+		}
+	}
+	return moves
+}
+
+// String describes the move and its projected effect.
+func (m slabMove) String() string {
+	return fmt.Sprintf("move a page from class %d to class %d (evicting %.2f/s): class %d %.0f -> %.0f chunks, class %d %.0f -> %.0f chunks",
+		m.From, m.To, m.EvictRate, m.To, m.ToBefore, m.ToAfter, m.From, m.FromBefore, m.FromAfter)
+}
+
+// reassignSlab asks the server to move one page between classes. The slab
+// rebalancer must be enabled (memcached -o slab_reassign, the default since
+// 1.4.25); it answers BUSY while a previous move is still running.
+func reassignSlab(addr string, from, to int) error {
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintf(conn, "slabs reassign %d %d\r\n", from, to); err != nil {
+		return err
+	}
+	reply, err := readLine(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("slabs reassign %d %d: %s", from, to, reply)
+	}
+	return nil
+}
+
+// applyAdvice executes the first suggested move for the selected server and
+// records the outcome in the event log.
+func applyAdvice(u *ui, now time.Time) {
+	s := u.current()
+	moves := adviseMoves(parseSlabClasses(s.slabs, s.itemRates), s.evictStreak)
+	if len(moves) == 0 {
+		return
+	}
+	m := moves[0]
+	if err := reassignSlab(s.addr, m.From, m.To); err != nil {
+		s.logEvent(now, eventSlabMove, fmt.Sprintf("slab move failed: %v", err))
+		return
+	}
+	s.logEvent(now, eventSlabMove, fmt.Sprintf("moved a page from class %d to class %d", m.From, m.To))
+	// The classes change once the move completes; wait for fresh pressure.
+	s.evictStreak = nil
+}
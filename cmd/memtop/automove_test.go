@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdviseMovesNeedsSustainedPressure(t *testing.T) {
+	classes := []slabClass{
+		{ID: 1, ChunkSize: 96, TotalPages: 4, TotalChunks: 400, UsedChunks: 100},
+		{ID: 5, ChunkSize: 240, TotalPages: 2, TotalChunks: 200, UsedChunks: 200, EvictRate: 4},
+		{ID: 7, ChunkSize: 480, TotalPages: 1, TotalChunks: 50, UsedChunks: 50, EvictRate: 9},
+	}
+	sess := newSession("127.0.0.1:11211", time.Second)
+	for i := 0; i < advisorStreak-1; i++ {
+		sess.trackEvictions(classes)
+	}
+	if moves := adviseMoves(classes, sess.evictStreak); len(moves) != 0 {
+		t.Fatalf("no advice before %d evicting samples, got %v", advisorStreak, moves)
+	}
+	sess.trackEvictions(classes)
+	moves := adviseMoves(classes, sess.evictStreak)
+	if len(moves) != 2 || moves[0].To != 7 || moves[1].To != 5 || moves[0].From != 1 {
+		t.Fatalf("moves = %+v", moves)
+	}
+	if m := moves[1]; m.ToBefore != 200 || m.ToAfter != 300 || m.FromBefore != 400 || m.FromAfter != 300 {
+		t.Fatalf("projection = %+v", m)
+	}
+
+	classes[1].EvictRate = 0
+	sess.trackEvictions(classes)
+	if moves := adviseMoves(classes, sess.evictStreak); len(moves) != 1 || moves[0].To != 7 {
+		t.Fatalf("a class that stopped evicting should drop out, got %+v", moves)
+	}
+}
+
+func TestReassignSlab(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer ln.Close()
+	commands := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			line = strings.TrimSpace(line)
+			commands <- line
+			if line == "slabs reassign 1 5" {
+				fmt.Fprint(conn, "OK\r\n")
+			} else {
+				fmt.Fprint(conn, "BUSY currently processing reassign request\r\n")
+			}
+			conn.Close()
+		}
+	}()
+
+	if err := reassignSlab(ln.Addr().String(), 1, 5); err != nil {
+		t.Fatalf("reassignSlab: %v", err)
+	}
+	if got := <-commands; got != "slabs reassign 1 5" {
+		t.Fatalf("sent %q", got)
+	}
+	if err := reassignSlab(ln.Addr().String(), 2, 5); err == nil || !strings.Contains(err.Error(), "BUSY") {
+		t.Fatalf("a refused move should report the reply, got %v", err)
+	}
+}
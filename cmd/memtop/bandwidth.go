@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// linkWarnPercent is the share of the link's capacity, in either direction,
+// from which the bandwidth line warns that the network is the bottleneck.
+const linkWarnPercent = 80
+
+// linkPrefixes are the decimal multipliers link speeds are quoted in.
+var linkPrefixes = []struct {
+	prefix string
+	factor float64
+}{
+	{"T", 1e12},
+	{"G", 1e9},
+	{"M", 1e6},
+	{"k", 1e3},
+	{"K", 1e3},
+}
+
+// parseLinkSpeed reads a NIC capacity in bits per second such as "10G",
+// "25Gbit", "1Gbps", or "100Mbit/s". A bare number is bits per second; the
+// empty string means unknown and gives 0.
+func parseLinkSpeed(s string) (float64, error) {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return 0, nil
+	}
+	for _, suffix := range []string{"bit/s", "bps", "bit", "b"} {
+		if trimmed, ok := strings.CutSuffix(text, suffix); ok {
+			text = trimmed
+			break
+		}
+	}
+	factor := 1.0
+	for _, p := range linkPrefixes {
+		if trimmed, ok := strings.CutSuffix(text, p.prefix); ok {
+			text, factor = trimmed, p.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid link speed %q: want bits per second such as 1G, 10Gbit, or 100Mbit/s", s)
+	}
+	return n * factor, nil
+}
+
+// formatLinkSpeed renders a link speed the way NICs are labelled, as in
+// "10 Gbit/s".
+func formatLinkSpeed(bits float64) string {
+	for _, p := range linkPrefixes[:4] {
+		if bits >= p.factor {
+			return strconv.FormatFloat(bits/p.factor, 'f', -1, 64) + " " + p.prefix + "bit/s"
+		}
+	}
+	return strconv.FormatFloat(bits, 'f', -1, 64) + " bit/s"
+}
+
+// linkUtilization returns the read and write byte rates as percentages of
+// the server's link capacity. A NIC is full duplex, so each direction is
+// measured against the whole link. It reports false when the capacity is
+// unknown.
+func linkUtilization(s *session) (read, write float64, ok bool) {
+	if s.linkSpeed <= 0 {
+		return 0, 0, false
+	}
+	bytesPerSecond := s.linkSpeed / 8
+	return rateValue(s.rates, "bytes_read") / bytesPerSecond * 100, rateValue(s.rates, "bytes_written") / bytesPerSecond * 100, true
+}
+
+// describeBandwidth renders the summary's bandwidth line, with each
+// direction's share of the link when -link-speed or the config gives one,
+// and the style to draw it in: a warning once either direction passes
+// linkWarnPercent, since no tuning of memcached helps a saturated NIC.
+func describeBandwidth(s *session) (string, tcell.Style) {
+	text := fmt.Sprintf("Bandwidth: read %s  write %s",
+		formatBytesRate(rateValue(s.rates, "bytes_read"), s.interval),
+		formatBytesRate(rateValue(s.rates, "bytes_written"), s.interval))
+	read, write, ok := linkUtilization(s)
+	if !ok {
+		return text, tcell.StyleDefault
+	}
+	nf := numberFormat{"summary"}
+	text = fmt.Sprintf("Bandwidth: read %s (%s%%)  write %s (%s%%) of %s",
+		formatBytesRate(rateValue(s.rates, "bytes_read"), s.interval), nf.percent("link", read),
+		formatBytesRate(rateValue(s.rates, "bytes_written"), s.interval), nf.percent("link", write),
+		formatLinkSpeed(s.linkSpeed))
+	if max(read, write) >= linkWarnPercent {
+		return text + "  network near saturation", theme.bad.Bold(true)
+	}
+	return text, tcell.StyleDefault
+}
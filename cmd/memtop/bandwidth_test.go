@@ -0,0 +1,54 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLinkSpeed(t *testing.T) {
+	for input, want := range map[string]float64{
+		"":          0,
+		"10G":       10e9,
+		"25Gbit":    25e9,
+		"1Gbps":     1e9,
+		"100Mbit/s": 100e6,
+		"2.5Gb":     2.5e9,
+		"1000000":   1e6,
+	} {
+		if got, err := parseLinkSpeed(input); err != nil || got != want {
+			t.Errorf("parseLinkSpeed(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	for _, input := range []string{"fast", "10GB", "-1G", "0"} {
+		if _, err := parseLinkSpeed(input); err == nil {
+			t.Errorf("parseLinkSpeed(%q) should fail", input)
+		}
+	}
+	if got := formatLinkSpeed(2.5e9); got != "2.5 Gbit/s" {
+		t.Errorf("formatLinkSpeed = %q", got)
+	}
+}
+
+func TestDescribeBandwidthWarnsNearLinkCapacity(t *testing.T) {
+	s := newSession("a:11211", time.Second)
+	s.rates = map[string]float64{"bytes_read": 12.5e6, "bytes_written": 110e6}
+	if text, _ := describeBandwidth(s); strings.Contains(text, "%") {
+		t.Fatalf("no link share without a link speed: %q", text)
+	}
+
+	// 1 Gbit/s carries 125 MB/s each way.
+	s.linkSpeed = 1e9
+	text, style := describeBandwidth(s)
+	if !strings.Contains(text, "(10.0%)") || !strings.Contains(text, "(88.0%) of 1 Gbit/s") || !strings.HasSuffix(text, "network near saturation") {
+		t.Fatalf("unexpected bandwidth line %q", text)
+	}
+	if style != theme.bad.Bold(true) {
+		t.Fatalf("saturated link should be highlighted")
+	}
+
+	s.rates["bytes_written"] = 50e6
+	if text, _ := describeBandwidth(s); strings.Contains(text, "saturation") {
+		t.Fatalf("40%% of the link is no warning: %q", text)
+	}
+}
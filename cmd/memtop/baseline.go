@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+)
+
+// baselineNotable is the change versus the baseline, in percent, from which
+// a stat is highlighted.
+const baselineNotable = 50
+
+// loadSnapshotFile reads the numeric stats of a saved snapshot: the object
+// `memtop stats -format json` prints, or a record from -jsonl (whose stats
+// are under "values"). Non-numeric stats are skipped.
+func loadSnapshotFile(path string) (map[string]float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if nested, ok := raw["values"]; ok {
+		raw = nil
+		if err := json.Unmarshal(nested, &raw); err != nil {
+			return nil, fmt.Errorf("%s: values: %w", path, err)
+		}
+	}
+	values := make(map[string]float64, len(raw))
+	for key, msg := range raw {
+		var v float64
+		if json.Unmarshal(msg, &v) == nil {
+			values[key] = v
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s: no numeric stats", path)
+	}
+	return values, nil
+}
+
+// percentChange is how far now is from then, in percent. It is NaN when
+// there is nothing to compare, and infinite for a stat that grew from zero.
+func percentChange(then, now float64) float64 {
+	switch {
+	case then == now:
+		return 0
+	case then == 0:
+		return math.Inf(1)
+	}
+	return (now - then) / math.Abs(then) * 100
+}
+
+// formatChange prints a percentage change for the stats table.
+func formatChange(change float64) string {
+	switch {
+	case math.IsNaN(change):
+		return ""
+	case math.IsInf(change, 0):
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", change)
+}
+
+// baselineChange compares a stat with the baseline, NaN when the baseline
+// lacks it or the stat is not numeric.
+func (u *ui) baselineChange(s *session, key string) float64 {
+	then, ok := u.baseline[key]
+	now, numeric := s.current.Values[key]
+	if !ok || !numeric {
+		return math.NaN()
+	}
+	return percentChange(then, now)
+}
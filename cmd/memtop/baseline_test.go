@@ -0,0 +1,74 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestLoadSnapshotFile(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "stats.json")
+	os.WriteFile(plain, []byte(`{"cmd_get": 100, "version": "1.6.21"}`), 0o644)
+	values, err := loadSnapshotFile(plain)
+	if err != nil || len(values) != 1 || values["cmd_get"] != 100 {
+		t.Fatalf("stats -format json file = %v, %v", values, err)
+	}
+
+	record := filepath.Join(dir, "record.json")
+	os.WriteFile(record, []byte(`{"server": "a:11211", "up": true, "values": {"evictions": 4}}`), 0o644)
+	if values, err := loadSnapshotFile(record); err != nil || values["evictions"] != 4 {
+		t.Fatalf("-jsonl record = %v, %v", values, err)
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, []byte(`{"version": "1.6.21"}`), 0o644)
+	if _, err := loadSnapshotFile(empty); err == nil {
+		t.Fatalf("a snapshot without numbers should be rejected")
+	}
+}
+
+func TestStatsViewComparesWithBaseline(t *testing.T) {
+	if got := formatChange(percentChange(200, 150)); got != "-25.0%" {
+		t.Fatalf("percentChange = %s", got)
+	}
+	if got := formatChange(percentChange(0, 3)); got != "new" || !math.IsNaN(percentChange(math.NaN(), 1)) {
+		t.Fatalf("growth from zero = %s", got)
+	}
+
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(100, 10)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{
+		Values: map[string]float64{"cmd_get": 300, "evictions": 9},
+		Raw:    map[string]string{"cmd_get": "300", "evictions": "9", "version": "1.6.21"},
+	}
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	u.baseline = map[string]float64{"cmd_get": 100, "evictions": 9}
+	u.statsCursor = 1 // keep the cursor's highlight off the rows checked
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	if header := lineFromCells(cells, width, 2); !strings.Contains(header, "Baseline") {
+		t.Fatalf("header should gain a baseline column, got %q", header)
+	}
+	// Rows: Server heading, version, Commands heading, cmd_get, Memory
+	// heading, evictions.
+	if row := lineFromCells(cells, width, 6); !strings.HasSuffix(strings.TrimSpace(row), "+200.0%") || cells[6*width].Style != theme.warn {
+		t.Fatalf("cmd_get row should show a highlighted +200%%, got %q", row)
+	}
+	if row := lineFromCells(cells, width, 8); !strings.HasSuffix(strings.TrimSpace(row), "+0.0%") {
+		t.Fatalf("unchanged stat = %q", row)
+	}
+}
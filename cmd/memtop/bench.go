@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// benchKeyPrefix marks the keys `memtop bench` writes, so they can be told
+// apart from the application's.
+const benchKeyPrefix = "memtop:bench:"
+
+// benchResult collects one operation type's latencies and outcomes.
+type benchResult struct {
+	latencies []time.Duration
+	hits      int
+	errors    int
+}
+
+func (r *benchResult) merge(o *benchResult) {
+	r.latencies = append(r.latencies, o.latencies...)
+	r.hits += o.hits
+	r.errors += o.errors
+}
+
+// latencyPercentile returns the p-th percentile (0-100) of sorted latencies.
+func latencyPercentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i]
+}
+
+// benchWorker runs gets and sets on its own connection until ctx ends, with
+// getRatio of the operations gets over keys random keys.
+func benchWorker(ctx context.Context, addr string, keys int, value string, getRatio float64, ttl time.Duration, seed int64) (gets, sets *benchResult, err error) {
+	// Dialed without ctx, which would close the connection under the last
+	// operation; the dial deadline is replaced by one per operation.
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	rng := rand.New(rand.NewSource(seed))
+	gets, sets = &benchResult{}, &benchResult{}
+	for ctx.Err() == nil {
+		key := fmt.Sprintf("%s%d", benchKeyPrefix, rng.Intn(keys))
+		conn.SetDeadline(time.Now().Add(defaultTimeout))
+		start := time.Now()
+		if rng.Float64() < getRatio {
+			_, _, found, err := textGets(conn, r, key)
+			if err != nil {
+				gets.errors++
+				return gets, sets, err
+			}
+			gets.latencies = append(gets.latencies, time.Since(start))
+			if found {
+				gets.hits++
+			}
+			continue
+		}
+		reply, err := textStore(conn, r, fmt.Sprintf("set %s 0 %d %d", key, expiration(ttl), len(value)), value)
+		if err != nil {
+			sets.errors++
+			return gets, sets, err
+		}
+		sets.latencies = append(sets.latencies, time.Since(start))
+		if reply != "STORED" {
+			sets.errors++
+		}
+	}
+	return gets, sets, nil
+}
+
+// writeBenchReport prints throughput and latency percentiles per operation.
+func writeBenchReport(w io.Writer, elapsed time.Duration, gets, sets *benchResult) {
+	total := len(gets.latencies) + len(sets.latencies)
+	fmt.Fprintf(w, "%d operations in %s: %.0f ops/s\n", total, elapsed.Round(time.Millisecond), float64(total)/elapsed.Seconds())
+	fmt.Fprintf(w, "%-4s %9s %9s %9s %9s %9s %9s\n", "op", "count", "p50", "p90", "p99", "max", "errors")
+	for _, op := range []struct {
+		name string
+		r    *benchResult
+	}{{"get", gets}, {"set", sets}} {
+		sorted := append([]time.Duration(nil), op.r.latencies...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		fmt.Fprintf(w, "%-4s %9d %9s %9s %9s %9s %9d\n", op.name, len(sorted),
+			latencyPercentile(sorted, 50).Round(time.Microsecond),
+			latencyPercentile(sorted, 90).Round(time.Microsecond),
+			latencyPercentile(sorted, 99).Round(time.Microsecond),
+			latencyPercentile(sorted, 100).Round(time.Microsecond),
+			op.r.errors)
+	}
+	if n := len(gets.latencies); n > 0 {
+		fmt.Fprintf(w, "get hit ratio %.1f%%\n", float64(gets.hits)/float64(n)*100)
+	}
+}
+
+// runBench implements `memtop bench`: a small load generator that runs gets
+// and sets from -concurrency connections for -duration and reports
+// throughput and latency percentiles, for sanity-checking a server or the
+// network path to it. It writes to the cache, so it refuses to run without
+// -yes; its keys start with memtop:bench: and expire after -ttl.
+func runBench(args []string, std streams) int {
+	fs, conn := newCommandFlags("bench", std)
+	yes := fs.Bool("yes", false, "confirm that the server may be loaded and written to")
+	duration := fs.Duration("duration", 10*time.Second, "how long to run")
+	concurrency := fs.Int("concurrency", 4, "number of connections issuing requests")
+	keys := fs.Int("keys", 1000, "number of distinct keys used")
+	size := fs.Int("size", 100, "value size in bytes")
+	getRatio := fs.Float64("get-ratio", 0.9, "share of operations that are gets, between 0 and 1")
+	ttl := fs.Duration("ttl", time.Minute, "expiry of the keys written")
+	if _, ok := parseCommandArgs(fs, args, 0); !ok {
+		return 2
+	}
+	if *duration <= 0 || *concurrency < 1 || *keys < 1 || *size < 0 || *getRatio < 0 || *getRatio > 1 || *ttl <= 0 {
+		fmt.Fprintln(std.Err, "-duration, -concurrency, -keys, and -ttl must be positive, -size not negative, and -get-ratio between 0 and 1")
+		return 2
+	}
+	if !*yes {
+		fmt.Fprintf(std.Err, "refusing to load %s without -yes\n", conn.addr())
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+	if statsProtocol == protocolBinary {
+		fmt.Fprintln(std.Err, "bench only speaks the ASCII protocol")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	value := strings.Repeat("x", *size)
+	gets, sets := &benchResult{}, &benchResult{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+	start := time.Now()
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			g, s, err := benchWorker(ctx, conn.addr(), *keys, value, *getRatio, *ttl, seed)
+			mu.Lock()
+			defer mu.Unlock()
+			if g != nil {
+				gets.merge(g)
+				sets.merge(s)
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}(start.UnixNano() + int64(i))
+	}
+	wg.Wait()
+	writeBenchReport(std.Out, time.Since(start), gets, sets)
+	if firstErr != nil {
+		fmt.Fprintf(std.Err, "bench: %v\n", firstErr)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBenchReportsBothOperations(t *testing.T) {
+	store, ln := startFakeStore(t)
+	defer ln.Close()
+
+	if code, _, errOut := runCommand(t, ln.Addr().String(), "", "bench", "-duration", "200ms"); code != 2 || !strings.Contains(errOut, "without -yes") {
+		t.Fatalf("bench without -yes = %d %q", code, errOut)
+	}
+	code, out, errOut := runCommand(t, ln.Addr().String(), "", "bench", "-yes", "-duration", "200ms", "-concurrency", "2", "-keys", "10", "-get-ratio", "0.5")
+	if code != 0 {
+		t.Fatalf("bench exited %d: %s", code, errOut)
+	}
+	for _, want := range []string{"ops/s", "\nget ", "\nset ", "get hit ratio"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("bench printed %q, want %q", out, want)
+		}
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for key := range store.items {
+		if !strings.HasPrefix(key, benchKeyPrefix) {
+			t.Fatalf("bench wrote %q outside its prefix", key)
+		}
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	if got := latencyPercentile(sorted, 50); got != 5 {
+		t.Fatalf("p50 = %d, want 5", got)
+	}
+	if got := latencyPercentile(sorted, 100); got != 10 {
+		t.Fatalf("max = %d, want 10", got)
+	}
+	if got := latencyPercentile(nil, 99); got != 0 {
+		t.Fatalf("p99 of nothing = %d", got)
+	}
+}
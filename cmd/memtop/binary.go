@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// protocol selects how stats are requested from servers.
+type protocol int
+
+const (
+	protocolASCII protocol = iota
+	protocolBinary
+)
+
+// statsProtocol is used for stats requests and the subcommands; main sets
+// it from -protocol.
+var statsProtocol = protocolASCII
+
+// saslCredentials authenticate binary protocol connections with SASL PLAIN.
+// Nil skips authentication.
+var saslCredentials *saslAuth
+
+// saslAuth holds SASL PLAIN credentials.
+type saslAuth struct {
+	User     string
+	Password string
+}
+
+// Binary protocol constants, from memcached's protocol_binary.h.
+const (
+	binaryRequestMagic  = 0x80
+	binaryResponseMagic = 0x81
+	binaryHeaderLen     = 24
+
+	opGet      = 0x00
+	opSet      = 0x01
+	opDelete   = 0x04
+	opFlush    = 0x08
+	opStat     = 0x10
+	opSASLAuth = 0x21
+
+	statusOK          = 0x00
+	statusKeyNotFound = 0x01
+	statusAuthError   = 0x20
+	statusUnknownCmd  = 0x81
+)
+
+// binaryPacket is one request or response; only the fields memtop uses are
+// kept.
+type binaryPacket struct {
+	Opcode byte
+	Status uint16
+	Key    []byte
+	Value  []byte
+}
+
+// parseProtocol converts the -protocol flag.
+func parseProtocol(value string) (protocol, error) {
+	switch value {
+	case "", "ascii":
+		return protocolASCII, nil
+	case "binary":
+		return protocolBinary, nil
+	}
+	return protocolASCII, fmt.Errorf("unknown protocol %q (want ascii or binary)", value)
+}
+
+// writeBinaryRequest sends one request packet.
+func writeBinaryRequest(w io.Writer, opcode byte, extras, key, value []byte) error {
+	header := make([]byte, binaryHeaderLen)
+	header[0] = binaryRequestMagic
+	header[1] = opcode
+	binary.BigEndian.PutUint16(header[2:4], uint16(len(key)))
+	header[4] = byte(len(extras))
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(extras)+len(key)+len(value)))
+	packet := append(header, extras...)
+	packet = append(packet, key...)
+	packet = append(packet, value...)
+	_, err := w.Write(packet)
+	return err
+}
+
+// readBinaryResponse reads one response packet, skipping any extras.
+func readBinaryResponse(r io.Reader) (*binaryPacket, error) {
+	header := make([]byte, binaryHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != binaryResponseMagic {
+		return nil, fmt.Errorf("unexpected binary response magic 0x%02x (is the server speaking ASCII only?)", header[0])
+	}
+	keyLen := int(binary.BigEndian.Uint16(header[2:4]))
+	extrasLen := int(header[4])
+	bodyLen := int(binary.BigEndian.Uint32(header[8:12]))
+	if bodyLen > maxBodySize {
+		return nil, fmt.Errorf("malformed binary response: body of %d bytes exceeds %d", bodyLen, maxBodySize)
+	}
+	if keyLen+extrasLen > bodyLen {
+		return nil, fmt.Errorf("malformed binary response: key %d + extras %d exceed body %d", keyLen, extrasLen, bodyLen)
+	}
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return &binaryPacket{
+		Opcode: header[1],
+		Status: binary.BigEndian.Uint16(header[6:8]),
+		Key:    body[extrasLen : extrasLen+keyLen],
+		Value:  body[extrasLen+keyLen:],
+	}, nil
+}
+
+// binaryError turns a non-OK response into an error, using the message the
+// server put in the value when there is one.
+func binaryError(p *binaryPacket) error {
+	msg := string(p.Value)
+	switch {
+	case msg != "":
+	case p.Status == statusAuthError:
+		msg = "authentication failed"
+	case p.Status == statusUnknownCmd:
+		msg = "unknown command"
+	default:
+		msg = "error"
+	}
+	return fmt.Errorf("binary protocol status 0x%02x: %s", p.Status, msg)
+}
+
+// saslPlain authenticates the connection with SASL PLAIN.
+func saslPlain(conn net.Conn, auth *saslAuth) error {
+	payload := []byte("\x00" + auth.User + "\x00" + auth.Password)
+	if err := writeBinaryRequest(conn, opSASLAuth, nil, []byte("PLAIN"), payload); err != nil {
+		return err
+	}
+	resp, err := readBinaryResponse(conn)
+	if err != nil {
+		return err
+	}
+	if resp.Status != statusOK {
+		return fmt.Errorf("sasl: %w", binaryError(resp))
+	}
+	return nil
+}
+
+// dialBinary connects and, when credentials are configured, authenticates.
+func dialBinary(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := dialServer(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if saslCredentials != nil {
+		if err := saslPlain(conn, saslCredentials); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// fetchBinaryStats issues the binary STAT command. The server answers with
+// one packet per stat and ends the list with an empty key.
+func fetchBinaryStats(ctx context.Context, addr, section string) (*statsSnapshot, error) {
+	conn, err := dialBinary(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err := writeBinaryRequest(conn, opStat, nil, []byte(section), nil); err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]string, statsSizeHint(section))
+	for {
+		resp, err := readBinaryResponse(conn)
+		if err != nil {
+			return nil, err
+		}
+		if resp.Status != statusOK {
+			return nil, binaryError(resp)
+		}
+		if len(resp.Key) == 0 {
+			break
+		}
+		raw[internKey(resp.Key)] = string(resp.Value)
+	}
+	rememberStatsSize(section, len(raw))
+	return newStatsSnapshot(time.Now(), raw), nil
+}
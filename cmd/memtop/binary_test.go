@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// writeBinaryResponse mirrors writeBinaryRequest for the fake server.
+func writeBinaryResponse(w io.Writer, opcode byte, status uint16, key, value string) {
+	var buf bytes.Buffer
+	writeBinaryRequest(&buf, opcode, nil, []byte(key), []byte(value))
+	packet := buf.Bytes()
+	packet[0] = binaryResponseMagic
+	packet[6], packet[7] = byte(status>>8), byte(status)
+	w.Write(packet)
+}
+
+// readBinaryRequest parses a request packet by presenting it to the response
+// reader with the magic byte flipped.
+func readBinaryRequest(r io.Reader) (*binaryPacket, error) {
+	header := make([]byte, binaryHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if header[0] != binaryRequestMagic {
+		return nil, io.ErrUnexpectedEOF
+	}
+	header[0] = binaryResponseMagic
+	return readBinaryResponse(io.MultiReader(bytes.NewReader(header), r))
+}
+
+// startBinaryServer serves binary STAT, GET, SET and DELETE requests,
+// requiring SASL PLAIN for user ops when password is not empty.
+func startBinaryServer(t *testing.T, password string) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	var mu sync.Mutex
+	items := map[string]string{}
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		authed := password == ""
+		for {
+			req, err := readBinaryRequest(conn)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			value, found := items[string(req.Key)]
+			mu.Unlock()
+			switch {
+			case req.Opcode == opSASLAuth:
+				if string(req.Key) != "PLAIN" || string(req.Value) != "\x00ops\x00"+password {
+					writeBinaryResponse(conn, opSASLAuth, statusAuthError, "", "Auth failure")
+					return
+				}
+				authed = true
+				writeBinaryResponse(conn, opSASLAuth, statusOK, "", "Authenticated")
+			case !authed:
+				writeBinaryResponse(conn, req.Opcode, statusAuthError, "", "")
+				return
+			case req.Opcode == opStat && len(req.Key) == 0:
+				writeBinaryResponse(conn, opStat, statusOK, "curr_items", "12")
+				writeBinaryResponse(conn, opStat, statusOK, "version", "1.6.21")
+				writeBinaryResponse(conn, opStat, statusOK, "", "")
+			case (req.Opcode == opGet || req.Opcode == opDelete) && !found:
+				writeBinaryResponse(conn, req.Opcode, statusKeyNotFound, "", "Not found")
+			case req.Opcode == opGet:
+				writeBinaryResponse(conn, opGet, statusOK, "", value)
+			case req.Opcode == opSet:
+				mu.Lock()
+				items[string(req.Key)] = string(req.Value)
+				mu.Unlock()
+				writeBinaryResponse(conn, opSet, statusOK, "", "")
+			case req.Opcode == opDelete:
+				mu.Lock()
+				delete(items, string(req.Key))
+				mu.Unlock()
+				writeBinaryResponse(conn, opDelete, statusOK, "", "")
+			default:
+				writeBinaryResponse(conn, req.Opcode, statusUnknownCmd, "", "")
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+	return ln
+}
+
+func withBinaryProtocol(t *testing.T, auth *saslAuth) {
+	t.Helper()
+	prevProtocol, prevAuth := statsProtocol, saslCredentials
+	statsProtocol, saslCredentials = protocolBinary, auth
+	t.Cleanup(func() { statsProtocol, saslCredentials = prevProtocol, prevAuth })
+}
+
+func TestFetchStatsOverBinaryProtocol(t *testing.T) {
+	ln := startBinaryServer(t, "")
+	defer ln.Close()
+	withBinaryProtocol(t, nil)
+
+	snapshot, err := fetchStats(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("fetchStats returned error: %v", err)
+	}
+	if got := snapshot.Values["curr_items"]; got != 12 {
+		t.Fatalf("curr_items = %.0f, want 12", got)
+	}
+	if got := snapshot.Raw["version"]; got != "1.6.21" {
+		t.Fatalf("version = %q, want 1.6.21", got)
+	}
+}
+
+func TestFetchStatsOverBinaryProtocolWithSASL(t *testing.T) {
+	ln := startBinaryServer(t, "s3cret")
+	defer ln.Close()
+	withBinaryProtocol(t, &saslAuth{User: "ops", Password: "s3cret"})
+
+	snapshot, err := fetchStats(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("fetchStats returned error: %v", err)
+	}
+	if got := snapshot.Values["curr_items"]; got != 12 {
+		t.Fatalf("curr_items = %.0f, want 12", got)
+	}
+}
+
+func TestBinaryProtocolReportsAuthFailure(t *testing.T) {
+	ln := startBinaryServer(t, "s3cret")
+	defer ln.Close()
+	withBinaryProtocol(t, &saslAuth{User: "ops", Password: "wrong"})
+
+	_, err := fetchStats(context.Background(), ln.Addr().String())
+	if err == nil || !strings.Contains(err.Error(), "Auth failure") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestParseProtocol(t *testing.T) {
+	tests := map[string]protocol{"": protocolASCII, "ascii": protocolASCII, "binary": protocolBinary}
+	for input, want := range tests {
+		if got, err := parseProtocol(input); err != nil || got != want {
+			t.Errorf("parseProtocol(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := parseProtocol("meta"); err == nil {
+		t.Errorf("expected an error for an unknown protocol")
+	}
+}
+
+func TestReadBinaryResponseRejectsHugeBody(t *testing.T) {
+	header := make([]byte, binaryHeaderLen)
+	header[0] = binaryResponseMagic
+	header[8], header[9], header[10], header[11] = 0xff, 0xff, 0xff, 0xff
+	_, err := readBinaryResponse(bytes.NewReader(header))
+	if err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Fatalf("a 4 GiB body should be refused before reading it, got %v", err)
+	}
+}
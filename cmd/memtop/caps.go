@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// feature is an optional server capability some views and panels depend on.
+type feature int
+
+const (
+	featureNone feature = iota
+	featureMeta
+	featureLRUCrawler
+	featureExtstore
+	featureWatch
+	featureProxy
+	featureTLS
+	featureUDP
+)
+
+// featureNames label features in the capabilities panel, in display order,
+// with the first release that shipped each one. Extstore, the proxy, and TLS
+// also need a build or startup option, so a new enough version only makes
+// them possible.
+var featureNames = []struct {
+	feature feature
+	name    string
+	since   [3]int
+}{
+	{featureMeta, "meta commands", [3]int{1, 6, 0}},
+	{featureLRUCrawler, "lru_crawler", [3]int{1, 4, 18}},
+	{featureExtstore, "extstore", [3]int{1, 5, 4}},
+	{featureWatch, "watch", [3]int{1, 5, 0}},
+	{featureProxy, "proxy", [3]int{1, 6, 13}},
+	{featureTLS, "tls", [3]int{1, 5, 13}},
+	{featureUDP, "udp", [3]int{1, 2, 0}},
+}
+
+// featureSince returns the first release with f, or zero for featureNone.
+func featureSince(f feature) [3]int {
+	for _, entry := range featureNames {
+		if entry.feature == f {
+			return entry.since
+		}
+	}
+	return [3]int{}
+}
+
+// featureName returns the panel label of f.
+func featureName(f feature) string {
+	for _, entry := range featureNames {
+		if entry.feature == f {
+			return entry.name
+		}
+	}
+	return ""
+}
+
+// capabilities records what the server was found to support. It is detected
+// once per server process, keyed by version and pid.
+type capabilities struct {
+	Version string
+	version [3]int
+	// MaxConns is the -c connection limit from `stats settings`, 0 if unknown.
+	MaxConns float64
+	// UDPPort is the -U port from `stats settings`, 0 when UDP is off or
+	// unknown.
+	UDPPort float64
+	// IdleTimeout is the idle_timeout setting in seconds, 0 when off or
+	// unknown.
+	IdleTimeout float64
+	key         string
+	features    map[feature]bool
+}
+
+// has reports whether the server supports f; featureNone always is.
+func (c *capabilities) has(f feature) bool {
+	return f == featureNone || c.features[f]
+}
+
+// parseVersion extracts the numeric major, minor and patch parts of a version
+// string such as "1.6.21" or "1.4.5-rc1".
+func parseVersion(v string) [3]int {
+	var out [3]int
+	for i, part := range strings.SplitN(v, ".", 3) {
+		end := 0
+		for end < len(part) && part[end] >= '0' && part[end] <= '9' {
+			end++
+		}
+		out[i], _ = strconv.Atoi(part[:end])
+	}
+	return out
+}
+
+// versionAtLeast compares a parsed version against major.minor.patch.
+func versionAtLeast(v [3]int, major, minor, patch int) bool {
+	want := [3]int{major, minor, patch}
+	for i := range v {
+		if v[i] != want[i] {
+			return v[i] > want[i]
+		}
+	}
+	return true
+}
+
+// detectCapabilities works out supported features from the server version and
+// `stats settings`. Settings may be nil when the server refused them, in which
+// case only version-based features are reported.
+func detectCapabilities(stats, settings *statsSnapshot) *capabilities {
+	version := stats.Raw["version"]
+	v := parseVersion(version)
+	c := &capabilities{
+		Version:  version,
+		version:  v,
+		key:      version + "/" + stats.Raw["pid"],
+		features: make(map[feature]bool),
+	}
+	c.features[featureMeta] = versionAtLeast(v, 1, 6, 0)
+	// The logger and its watch command ship with every 1.5+ release.
+	c.features[featureWatch] = versionAtLeast(v, 1, 5, 0)
+	if settings != nil {
+		c.features[featureLRUCrawler] = settings.Raw["lru_crawler"] == "yes"
+		c.features[featureTLS] = settings.Raw["ssl_enabled"] == "yes"
+		_, c.features[featureExtstore] = settings.Raw["ext_path"]
+		c.MaxConns = settings.Values["maxconns"]
+		c.UDPPort = settings.Values["udpport"]
+		c.IdleTimeout = settings.Values["idle_timeout"]
+		c.features[featureUDP] = c.UDPPort > 0
+	}
+	for key := range stats.Raw {
+		switch {
+		case strings.HasPrefix(key, "extstore_"):
+			c.features[featureExtstore] = true
+		case strings.HasPrefix(key, "proxy_"):
+			// Only servers started in proxy mode report proxy_* stats.
+			c.features[featureProxy] = true
+		case isUDPStat(key):
+			c.features[featureUDP] = true
+		}
+	}
+	return c
+}
+
+// tooOld reports whether the server's version predates f. An unparsable
+// version is never too old.
+func (c *capabilities) tooOld(f feature) bool {
+	return c.version != [3]int{} && !versionAtLeast(c.version, featureSince(f)[0], featureSince(f)[1], featureSince(f)[2])
+}
+
+// featureStatus describes f for the capabilities panel: "yes", "needs 1.6.0+"
+// when the version predates it, or "not enabled" when the version has it but
+// the server was built or started without it.
+func (c *capabilities) featureStatus(f feature) string {
+	switch {
+	case c.has(f):
+		return "yes"
+	case c.tooOld(f):
+		since := featureSince(f)
+		return fmt.Sprintf("needs %d.%d.%d+", since[0], since[1], since[2])
+	default:
+		return "not enabled"
+	}
+}
+
+// capsStale reports whether capabilities need (re)detecting: never detected,
+// or the server was upgraded or restarted since.
+func (s *session) capsStale() bool {
+	if s.current == nil {
+		return false
+	}
+	return s.caps == nil || s.caps.key != s.current.Raw["version"]+"/"+s.current.Raw["pid"]
+}
+
+// refreshCapabilities detects capabilities after the first successful sample
+// and after each restart.
+func refreshCapabilities(ctx context.Context, s *session) {
+	if s.lastErr != nil || !s.capsStale() {
+		return
+	}
+	settings, err := fetchStatsSection(ctx, s.addr, "settings")
+	if ctx.Err() != nil {
+		// Try again next pass rather than settle on partial capabilities.
+		return
+	}
+	if err != nil {
+		s.logWarning("capabilities", fmt.Sprintf("stats settings failed, guessing features from the version: %v", err))
+		settings = nil
+	}
+	s.caps = detectCapabilities(s.current, settings)
+	if _, ok := lastTLS(s.addr); ok {
+		// Talking TLS settles it whatever stats settings said.
+		s.caps.features[featureTLS] = true
+	}
+}
+
+// supports reports whether the server has f. Until detection has run
+// everything is assumed supported so nothing is hidden prematurely.
+func (s *session) supports(f feature) bool {
+	return s.caps == nil || s.caps.has(f)
+}
+
+// unsupportedReason explains why the server lacks f, for the note shown in
+// place of a view that depends on it; it is "" while f is supported.
+func (s *session) unsupportedReason(f feature) string {
+	if s.supports(f) {
+		return ""
+	}
+	if s.caps.tooOld(f) {
+		since := featureSince(f)
+		return fmt.Sprintf("%s needs memcached %d.%d.%d or newer and this server runs %s", featureName(f), since[0], since[1], since[2], s.caps.Version)
+	}
+	return fmt.Sprintf("%s is not enabled on this server", featureName(f))
+}
+
+// renderCapabilitiesPanel lists the detected version and a feature matrix:
+// the release each feature arrived in and whether this server has it, with
+// features the server lacks highlighted.
+func renderCapabilitiesPanel(s *session) []panelLine {
+	if s.caps == nil {
+		return []panelLine{plainLine("Waiting for first sample...")}
+	}
+	lines := []panelLine{
+		plainLine(fmt.Sprintf("%-16s %s", "version", s.caps.Version)),
+		plainLine(fmt.Sprintf("%-16s %-8s %s", "feature", "since", "server")),
+	}
+	for _, f := range featureNames {
+		text := fmt.Sprintf("%-16s %-8s %s", f.name, fmt.Sprintf("%d.%d.%d", f.since[0], f.since[1], f.since[2]), s.caps.featureStatus(f.feature))
+		if s.caps.has(f.feature) {
+			lines = append(lines, plainLine(text))
+		} else {
+			lines = append(lines, panelLine{Text: text, Style: theme.warn})
+		}
+	}
+	return lines
+}
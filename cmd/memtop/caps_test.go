@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseVersionAndCompare(t *testing.T) {
+	tests := map[string][3]int{
+		"1.6.21":    {1, 6, 21},
+		"1.4.5-rc1": {1, 4, 5},
+		"1.5":       {1, 5, 0},
+		"":          {0, 0, 0},
+	}
+	for input, want := range tests {
+		if got := parseVersion(input); got != want {
+			t.Errorf("parseVersion(%q) = %v, want %v", input, got, want)
+		}
+	}
+	if !versionAtLeast([3]int{1, 6, 0}, 1, 6, 0) || versionAtLeast([3]int{1, 5, 22}, 1, 6, 0) || !versionAtLeast([3]int{2, 0, 0}, 1, 6, 9) {
+		t.Fatalf("versionAtLeast comparisons wrong")
+	}
+}
+
+func TestDetectCapabilities(t *testing.T) {
+	stats := &statsSnapshot{Raw: map[string]string{"version": "1.6.21", "pid": "42"}}
+	settings := &statsSnapshot{Raw: map[string]string{"lru_crawler": "yes", "ext_path": "/mnt/ext:64g"}}
+	caps := detectCapabilities(stats, settings)
+	for _, f := range []feature{featureMeta, featureLRUCrawler, featureExtstore, featureWatch} {
+		if !caps.has(f) {
+			t.Errorf("feature %d should be detected", f)
+		}
+	}
+
+	old := detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.4.15"}}, nil)
+	if old.has(featureMeta) || old.has(featureLRUCrawler) || old.has(featureWatch) || !old.has(featureNone) {
+		t.Fatalf("old server capabilities wrong: %+v", old.features)
+	}
+}
+
+func TestFeatureMatrix(t *testing.T) {
+	settings := &statsSnapshot{Raw: map[string]string{"lru_crawler": "yes", "ssl_enabled": "yes"}}
+	caps := detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21"}}, settings)
+	for f, want := range map[feature]string{
+		featureMeta:     "yes",
+		featureTLS:      "yes",
+		featureProxy:    "not enabled",
+		featureExtstore: "not enabled",
+	} {
+		if got := caps.featureStatus(f); got != want {
+			t.Errorf("1.6.21 %s = %q, want %q", featureName(f), got, want)
+		}
+	}
+
+	old := detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.4.15"}}, nil)
+	if got := old.featureStatus(featureMeta); got != "needs 1.6.0+" {
+		t.Errorf("1.4.15 meta commands = %q", got)
+	}
+	// A version memtop can't read is never blamed.
+	odd := detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "custom"}}, nil)
+	if got := odd.featureStatus(featureMeta); got != "not enabled" {
+		t.Errorf("unparsable version meta commands = %q", got)
+	}
+
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if reason := sess.unsupportedReason(featureProxy); reason != "" || strings.Contains(viewHelp(viewSummary, sess), "n/a") {
+		t.Fatalf("nothing should be flagged before detection: %q", reason)
+	}
+	sess.caps = old
+	if reason := sess.unsupportedReason(featureProxy); reason != "proxy needs memcached 1.6.13 or newer and this server runs 1.4.15" {
+		t.Errorf("unexpected reason %q", reason)
+	}
+	if help := viewHelp(viewSummary, sess); !strings.Contains(help, "6 proxy (n/a)") || strings.Contains(help, "stats (n/a)") {
+		t.Errorf("footer should flag only the proxy view: %q", help)
+	}
+}
+
+func TestCapabilitiesRedetectAfterRestart(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if sess.capsStale() || !sess.supports(featureLRUCrawler) {
+		t.Fatalf("before the first sample nothing should be detected or hidden")
+	}
+	sess.current = &statsSnapshot{Raw: map[string]string{"version": "1.6.21", "pid": "42"}}
+	if !sess.capsStale() {
+		t.Fatalf("first sample should trigger detection")
+	}
+	sess.caps = detectCapabilities(sess.current, nil)
+	if sess.capsStale() {
+		t.Fatalf("same process should not be re-detected")
+	}
+	sess.current = &statsSnapshot{Raw: map[string]string{"version": "1.6.21", "pid": "43"}}
+	if !sess.capsStale() {
+		t.Fatalf("a restart should trigger detection again")
+	}
+}
+
+func TestUnsupportedPanelShowsNote(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(200, 40)
+
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{Values: map[string]float64{}, Raw: map[string]string{"version": "1.4.15"}}
+	sess.caps = detectCapabilities(sess.current, nil)
+	drawPanelsView(screen, 0, sess)
+	screen.Show()
+
+	cells, width, height := screen.GetContents()
+	var all []string
+	for row := 0; row < height; row++ {
+		all = append(all, lineFromCells(cells, width, row))
+	}
+	if !strings.Contains(strings.Join(all, "\n"), notSupported) {
+		t.Fatalf("metadump panel should explain it is unsupported:\n%s", strings.Join(all, "\n"))
+	}
+}
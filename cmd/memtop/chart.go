@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// chartMode selects how history charts are drawn.
+type chartMode int
+
+const (
+	chartAuto chartMode = iota
+	chartBraille
+	chartBlock
+)
+
+// brailleBase is the Unicode code point of the empty braille pattern.
+const brailleBase = 0x2800
+
+// brailleDots maps a sub-row (0 = bottom) to the dot bit for the left and right
+// columns of a braille cell.
+var brailleDots = [4][2]rune{
+	{0x40, 0x80},
+	{0x04, 0x20},
+	{0x02, 0x10},
+	{0x01, 0x08},
+}
+
+// blockLevels are the eighth-height block glyphs used when braille is not
+// available, indexed by the number of filled eighths.
+var blockLevels = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// parseChartMode converts the -chart flag into a chartMode.
+func parseChartMode(value string) (chartMode, error) {
+	switch value {
+	case "", "auto":
+		return chartAuto, nil
+	case "braille":
+		return chartBraille, nil
+	case "block":
+		return chartBlock, nil
+	}
+	return chartAuto, fmt.Errorf("unknown chart style %q (want auto, braille or block)", value)
+}
+
+// resolveChartMode turns chartAuto into a concrete mode by asking the terminal
+// whether it can render braille glyphs.
+func resolveChartMode(screen tcell.Screen, mode chartMode) chartMode {
+	if mode != chartAuto {
+		return mode
+	}
+	if screen.CanDisplay(brailleBase|0xff, false) {
+		return chartBraille
+	}
+	return chartBlock
+}
+
+// drawChart renders values as a filled area chart inside the given box, with a
+// title row on top. Braille packs two samples per cell and four dots per row;
+// block mode uses one sample per cell and eighth-height glyphs.
+// Markers are indices into values whose columns are highlighted.
+func drawChart(screen tcell.Screen, x, y, width, height int, title string, values []float64, markers []int, ceiling float64, mode chartMode) {
+	if width <= 0 || height < 2 {
+		return
+	}
+	mode = resolveChartMode(screen, mode)
+
+	samplesPerCell := 1
+	levelsPerCell := len(blockLevels) - 1
+	if mode == chartBraille {
+		samplesPerCell = 2
+		levelsPerCell = len(brailleDots)
+	}
+	visible := lastN(values, width*samplesPerCell)
+
+	latest := math.NaN()
+	if len(visible) > 0 {
+		latest = visible[len(visible)-1]
+	}
+	top := ceiling
+	if top <= 0 {
+		top = maxFinite(visible)
+	}
+	if top <= 0 {
+		top = 1
+	}
+	drawText(screen, x, y, tcell.StyleDefault.Bold(true), fmt.Sprintf("%s  now %s  max %.2f", title, formatChartValue(latest), top))
+
+	rows := height - 1
+	totalLevels := rows * levelsPerCell
+	style := theme.accent
+
+	// Right-align the data so the newest sample always sits at the edge.
+	offset := width*samplesPerCell - len(visible)
+	for col := 0; col < width; col++ {
+		levels := make([]int, samplesPerCell)
+		for i := range levels {
+			idx := col*samplesPerCell + i - offset
+			if idx < 0 || idx >= len(visible) {
+				levels[i] = -1
+				continue
+			}
+			levels[i] = chartLevel(visible[idx], top, totalLevels)
+		}
+		for row := 0; row < rows; row++ {
+			floor := (rows - 1 - row) * levelsPerCell
+			var r rune
+			if mode == chartBraille {
+				r = brailleCell(levels, floor)
+			} else {
+				r = blockCell(levels[0], floor)
+			}
+			if r == ' ' || r == brailleBase {
+				continue
+			}
+			screen.SetContent(x+col, y+1+row, r, nil, style)
+		}
+	}
+
+	start := len(values) - len(visible)
+	for _, idx := range markers {
+		if idx < start {
+			continue
+		}
+		col := (idx - start + offset) / samplesPerCell
+		if col < 0 || col >= width {
+			continue
+		}
+		for row := 0; row < rows; row++ {
+			r, _, _, _ := screen.GetContent(x+col, y+1+row)
+			if r == ' ' || r == 0 {
+				r = '┆'
+			}
+			screen.SetContent(x+col, y+1+row, r, nil, noteMarkerStyle)
+		}
+	}
+}
+
+// chartLevel scales a value into the number of filled dots or eighths; NaN
+// values produce -1 so the column is left blank.
+func chartLevel(v, top float64, totalLevels int) int {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return -1
+	}
+	level := int(math.Round(v / top * float64(totalLevels)))
+	if level < 0 {
+		level = 0
+	}
+	if level > totalLevels {
+		level = totalLevels
+	}
+	if level == 0 && v > 0 {
+		level = 1
+	}
+	return level
+}
+
+// brailleCell builds the braille glyph for one cell whose lowest dot row sits
+// at the given level floor.
+func brailleCell(levels []int, floor int) rune {
+	r := rune(brailleBase)
+	for side, level := range levels {
+		for dot := 0; dot < len(brailleDots); dot++ {
+			if level > floor+dot {
+				r |= brailleDots[dot][side]
+			}
+		}
+	}
+	return r
+}
+
+// blockCell returns the eighth-block glyph for one cell.
+func blockCell(level, floor int) rune {
+	filled := level - floor
+	if filled <= 0 {
+		return ' '
+	}
+	if filled >= len(blockLevels) {
+		filled = len(blockLevels) - 1
+	}
+	return blockLevels[filled]
+}
+
+// sparkline renders the last width values as one line of block glyphs,
+// scaled to their maximum, for places that only take text. Gaps are blank.
+func sparkline(values []float64, width int) string {
+	values = lastN(values, width)
+	if len(values) == 0 {
+		return ""
+	}
+	top := maxFinite(values)
+	if top == 0 {
+		top = 1
+	}
+	out := make([]rune, len(values))
+	for i, v := range values {
+		out[i] = ' '
+		if level := chartLevel(v, top, len(blockLevels)-1); level >= 0 {
+			out[i] = blockLevels[max(level, 1)]
+		}
+	}
+	return string(out)
+}
+
+// lastN returns at most the final n values.
+func lastN[T any](values []T, n int) []T {
+	if len(values) > n {
+		return values[len(values)-n:]
+	}
+	return values
+}
+
+// maxFinite returns the largest finite value, ignoring gaps.
+func maxFinite(values []float64) float64 {
+	top := 0.0
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) && v > top {
+			top = v
+		}
+	}
+	return top
+}
+
+// formatChartValue prints the latest sample, showing a dash for gaps.
+func formatChartValue(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// drawHistoryCharts lays the charted series out in a two-column grid inside
+// the given box, adding a second row of charts when there is enough height.
+func drawHistoryCharts(screen tcell.Screen, x, y, width, height int, h *history, markers []int, mode chartMode) {
+	const minChartHeight = 3
+	if height < minChartHeight || width < 20 {
+		return
+	}
+	columns := 2
+	rows := 1
+	if height >= 2*minChartHeight+1 {
+		rows = 2
+	}
+	chartWidth := (width - (columns - 1)) / columns
+	chartHeight := height
+	if rows > 1 {
+		chartHeight = (height - 1) / rows
+	}
+
+	for i, series := range chartedSeries {
+		row, col := i/columns, i%columns
+		if row >= rows {
+			break
+		}
+		cx := x + col*(chartWidth+1)
+		cy := y + row*(chartHeight+1)
+		drawChart(screen, cx, cy, chartWidth, chartHeight, series.Label, h.values(series.Name), markers, series.Max, mode)
+	}
+}
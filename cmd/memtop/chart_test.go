@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseChartMode(t *testing.T) {
+	tests := map[string]chartMode{"": chartAuto, "auto": chartAuto, "braille": chartBraille, "block": chartBlock}
+	for input, want := range tests {
+		got, err := parseChartMode(input)
+		if err != nil || got != want {
+			t.Fatalf("parseChartMode(%q) = %v, %v; want %v", input, got, err, want)
+		}
+	}
+	if _, err := parseChartMode("sixel"); err == nil {
+		t.Fatalf("expected error for unknown chart style")
+	}
+}
+
+func TestBrailleCellStacksDotsFromBottom(t *testing.T) {
+	// Left column two dots high, right column full height.
+	got := brailleCell([]int{2, 4}, 0)
+	want := rune(brailleBase | 0x40 | 0x04 | 0x80 | 0x20 | 0x10 | 0x08)
+	if got != want {
+		t.Fatalf("brailleCell = %U, want %U", got, want)
+	}
+	if got := brailleCell([]int{-1, -1}, 0); got != brailleBase {
+		t.Fatalf("gaps should render an empty cell, got %U", got)
+	}
+	// A level below the cell floor leaves the upper cell empty.
+	if got := brailleCell([]int{3, 3}, 4); got != brailleBase {
+		t.Fatalf("upper cell should be empty, got %U", got)
+	}
+}
+
+func TestChartLevel(t *testing.T) {
+	if got := chartLevel(50, 100, 8); got != 4 {
+		t.Fatalf("chartLevel(50/100, 8) = %d, want 4", got)
+	}
+	if got := chartLevel(0.01, 100, 8); got != 1 {
+		t.Fatalf("tiny positive values should stay visible, got %d", got)
+	}
+	if got := chartLevel(500, 100, 8); got != 8 {
+		t.Fatalf("values above the ceiling should clamp, got %d", got)
+	}
+	if got := chartLevel(math.NaN(), 100, 8); got != -1 {
+		t.Fatalf("NaN should map to a gap, got %d", got)
+	}
+}
+
+func TestDrawChartBlockFallback(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(10, 3)
+
+	drawChart(screen, 0, 0, 10, 3, "Gets/s", []float64{0, 5, 10}, nil, 0, chartBlock)
+	screen.Show()
+
+	cells, width, _ := screen.GetContents()
+	if title := lineFromCells(cells, width, 0); !strings.HasPrefix(title, "Gets/s") {
+		t.Fatalf("chart title missing, got %q", title)
+	}
+	bottom := []rune(lineFromCells(cells, width, 2))
+	top := []rune(lineFromCells(cells, width, 1))
+	if len(bottom) != 10 || bottom[9] != '█' || top[9] != '█' {
+		t.Fatalf("newest sample should fill the right edge, got top %q bottom %q", string(top), string(bottom))
+	}
+	if bottom[8] != '█' || top[8] != ' ' {
+		t.Fatalf("half-height sample should fill only the bottom row, got top %q bottom %q", string(top), string(bottom))
+	}
+}
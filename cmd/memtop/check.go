@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exit codes of `memtop check`, the ones Nagios-compatible monitoring
+// systems expect from a plugin.
+const (
+	checkOK       = 0
+	checkWarning  = 1
+	checkCritical = 2
+	checkUnknown  = 3
+)
+
+var checkStates = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkLimit is a warning and a critical threshold for one measurement.
+// When low is set, values below the thresholds are the bad ones.
+type checkLimit struct {
+	warn, crit float64
+	low        bool
+	set        bool
+}
+
+// parseCheckLimit reads a "warn,crit" pair; an empty string disables the
+// check.
+func parseCheckLimit(s string, low bool) (checkLimit, error) {
+	if s == "" {
+		return checkLimit{}, nil
+	}
+	warn, crit, ok := strings.Cut(s, ",")
+	w, errW := strconv.ParseFloat(warn, 64)
+	c, errC := strconv.ParseFloat(crit, 64)
+	if !ok || errW != nil || errC != nil {
+		return checkLimit{}, fmt.Errorf("invalid threshold %q: want warn,crit", s)
+	}
+	return checkLimit{warn: w, crit: c, low: low, set: true}, nil
+}
+
+// state rates a value against the limit.
+func (l checkLimit) state(v float64) int {
+	beyond := func(limit float64) bool {
+		if l.low {
+			return v < limit
+		}
+		return v > limit
+	}
+	switch {
+	case !l.set:
+		return checkOK
+	case beyond(l.crit):
+		return checkCritical
+	case beyond(l.warn):
+		return checkWarning
+	}
+	return checkOK
+}
+
+// checkMeasure is one measured value with its label, unit, and limit.
+type checkMeasure struct {
+	name, label, unit string
+	value             float64
+	limit             checkLimit
+}
+
+// perfdata formats the measure as Nagios performance data, which knows no
+// per-second unit.
+func (m checkMeasure) perfdata() string {
+	unit := m.unit
+	if unit != "%" {
+		unit = ""
+	}
+	s := fmt.Sprintf("%s=%.2f%s", m.name, m.value, unit)
+	if m.limit.set {
+		s += fmt.Sprintf(";%g;%g", m.limit.warn, m.limit.crit)
+	}
+	return s
+}
+
+// runCheck implements `memtop check`, a Nagios-style plugin: it samples the
+// server, rates its hit ratio, memory use, connection use, and evictions
+// against warn,crit thresholds, prints one status line with performance
+// data, and exits 0 (OK), 1 (WARNING), 2 (CRITICAL), or 3 (UNKNOWN, for an
+// unreachable server or bad options).
+func runCheck(args []string, std streams) int {
+	fs, conn := newCommandFlags("check", std)
+	hitRatioLimit := fs.String("hit-ratio", "", "warn,crit: hit ratio percentages below which to warn or fail (for example 80,50)")
+	memoryLimit := fs.String("memory", "", "warn,crit: memory use percentages above which to warn or fail (for example 90,98)")
+	connLimit := fs.String("connections", fmt.Sprintf("%d,%d", connWarnPercent, connCriticalPercent), "warn,crit: connection use percentages of the limit above which to warn or fail")
+	evictionLimit := fs.String("evictions", "", "warn,crit: evictions per second above which to warn or fail")
+	over := fs.Duration("over", time.Second, "sample twice this far apart, for the eviction rate and a current rather than lifetime hit ratio (0 samples once)")
+	if _, ok := parseCommandArgs(fs, args, 0); !ok {
+		return checkUnknown
+	}
+	var limits [4]checkLimit
+	for i, l := range []struct {
+		value string
+		low   bool
+	}{{*hitRatioLimit, true}, {*memoryLimit, false}, {*connLimit, false}, {*evictionLimit, false}} {
+		var err error
+		if limits[i], err = parseCheckLimit(l.value, l.low); err != nil {
+			fmt.Fprintln(std.Err, err)
+			return checkUnknown
+		}
+	}
+	if limits[3].set && *over <= 0 {
+		fmt.Fprintln(std.Err, "-evictions needs -over to measure a rate")
+		return checkUnknown
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return checkUnknown
+	}
+	defer closeConn()
+
+	stats, err := fetchStats(context.Background(), conn.addr())
+	var rates map[string]float64
+	if err == nil && *over > 0 {
+		time.Sleep(*over)
+		var next *statsSnapshot
+		if next, err = fetchStats(context.Background(), conn.addr()); err == nil {
+			rates, stats = calculateRates(next, stats), next
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(std.Out, "MEMCACHED UNKNOWN - %s: %v\n", conn.addr(), err)
+		return checkUnknown
+	}
+
+	hit := hitRatio(stats)
+	if gets := rateValue(rates, "get_hits") + rateValue(rates, "get_misses"); gets > 0 {
+		hit = rateValue(rates, "get_hits") / gets * 100
+	}
+	measures := []checkMeasure{
+		{"hit_ratio", "hit ratio", "%", hit, limits[0]},
+		{"memory", "memory", "%", memoryPercent(stats), limits[1]},
+	}
+	if maxConns := stats.Values["max_connections"]; maxConns > 0 {
+		measures = append(measures, checkMeasure{"connections", "connections", "%", stats.Values["curr_connections"] / maxConns * 100, limits[2]})
+	}
+	if rates != nil {
+		measures = append(measures, checkMeasure{"evictions", "evictions", "/s", rateValue(rates, "evictions"), limits[3]})
+	}
+
+	state := checkOK
+	var summary, perf []string
+	for _, m := range measures {
+		s := m.limit.state(m.value)
+		state = max(state, s)
+		text := fmt.Sprintf("%s %.2f%s", m.label, m.value, m.unit)
+		if s != checkOK {
+			text += " (" + checkStates[s] + ")"
+		}
+		summary = append(summary, text)
+		perf = append(perf, m.perfdata())
+	}
+	fmt.Fprintf(std.Out, "MEMCACHED %s - %s | %s\n", checkStates[state], strings.Join(summary, ", "), strings.Join(perf, " "))
+	return state
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+func TestCheckRatesTheServerAgainstThresholds(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetStats("",
+		map[string]string{"get_hits": "900", "get_misses": "100", "bytes": "50", "limit_maxbytes": "100", "curr_connections": "10", "max_connections": "100", "evictions": "0"},
+		map[string]string{"get_hits": "940", "get_misses": "160", "bytes": "95", "limit_maxbytes": "100", "curr_connections": "10", "max_connections": "100", "evictions": "50"},
+	)
+
+	code, out, errOut := runCommand(t, srv.Addr(), "", "check", "-over", "100ms", "-hit-ratio", "80,50", "-memory", "90,98")
+	if code != checkCritical {
+		t.Fatalf("check exited %d (%s): %s", code, errOut, out)
+	}
+	for _, want := range []string{"MEMCACHED CRITICAL - ", "hit ratio 40.00% (CRITICAL)", "memory 95.00% (WARNING)", "| hit_ratio=40.00%;80;50 memory=95.00%;90;98 connections=10.00%;80;90 evictions="} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("check printed %q, want %q", out, want)
+		}
+	}
+
+	code, out, _ = runCommand(t, srv.Addr(), "", "check", "-over", "0", "-memory", "90,98")
+	if code != checkWarning || !strings.HasPrefix(out, "MEMCACHED WARNING - ") {
+		t.Fatalf("check of the last sample = %d %q", code, out)
+	}
+}
+
+func TestCheckStates(t *testing.T) {
+	limit, _ := parseCheckLimit("80,50", true)
+	for _, tc := range []struct {
+		value float64
+		want  int
+	}{{90, checkOK}, {70, checkWarning}, {40, checkCritical}} {
+		if got := limit.state(tc.value); got != tc.want {
+			t.Fatalf("hit ratio %.0f rated %d, want %d", tc.value, got, tc.want)
+		}
+	}
+	if _, err := parseCheckLimit("80", false); err == nil {
+		t.Fatalf("a single threshold should be rejected")
+	}
+}
+
+func TestCheckIsUnknownWhenTheServerIsDown(t *testing.T) {
+	srv := memstatstest.NewServer()
+	addr := srv.Addr()
+	srv.Close()
+	code, out, _ := runCommand(t, addr, "", "check", "-over", "0")
+	if code != checkUnknown || !strings.HasPrefix(out, "MEMCACHED UNKNOWN - ") {
+		t.Fatalf("check of a closed server = %d %q", code, out)
+	}
+}
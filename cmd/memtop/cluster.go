@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"mymemcache-top/memstats"
+)
+
+// clusterGroup aggregates the servers sharing one value of the grouping tag.
+// Totals and Rates combine the members that are up stat by stat, following
+// memstats.AggregationOf; ratios are derived from the totals, never averaged
+// across members.
+type clusterGroup struct {
+	Name    string
+	Servers []*session
+	Up      int
+	Totals  map[string]float64
+	Rates   map[string]float64
+}
+
+// hitRatio is computed from summed counters so busy servers weigh more than
+// idle ones.
+func (g clusterGroup) hitRatio() float64 {
+	hits, misses := g.Totals["get_hits"], g.Totals["get_misses"]
+	if hits+misses == 0 {
+		return 0
+	}
+	return hits / (hits + misses) * 100
+}
+
+// memoryPercent reports the group's combined memory use against its combined
+// limit.
+func (g clusterGroup) memoryPercent() float64 {
+	if g.Totals["limit_maxbytes"] <= 0 {
+		return 0
+	}
+	return g.Totals["bytes"] / g.Totals["limit_maxbytes"] * 100
+}
+
+// groupServers splits servers by the value of tag, keeping each server's
+// configured order inside a group and sorting groups by name. Servers without
+// the tag land in a "-" group; an empty tag puts everyone in one "all" group.
+func groupServers(servers []*session, tag string) []clusterGroup {
+	byName := make(map[string]*clusterGroup)
+	var names []string
+	for _, s := range servers {
+		name := "all"
+		if tag != "" {
+			name = s.tags[tag]
+			if name == "" {
+				name = "-"
+			}
+		}
+		g, ok := byName[name]
+		if !ok {
+			g = &clusterGroup{Name: name}
+			byName[name] = g
+			names = append(names, name)
+		}
+		g.add(s)
+	}
+	sort.Strings(names)
+	groups := make([]clusterGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, *byName[name])
+	}
+	return groups
+}
+
+// add folds one server's latest sample into the group totals.
+func (g *clusterGroup) add(s *session) {
+	g.Servers = append(g.Servers, s)
+	if s.lastErr != nil || s.current == nil {
+		return
+	}
+	g.Up++
+	if g.Up == 1 {
+		g.Totals, g.Rates = maps.Clone(s.current.Values), make(map[string]float64, len(s.rates))
+	} else {
+		aggregateValues(g.Totals, s.current.Values)
+	}
+	// Only counters have rates worth adding up; the rate of uptime or of
+	// the server clock means nothing for a group.
+	for name, rate := range s.rates {
+		if memstats.AggregationOf(name) == memstats.Sum {
+			g.Rates[name] += rate
+		}
+	}
+}
+
+// aggregateValues folds one server's values into totals by each stat's
+// aggregation rule. A stat that must agree across servers is dropped once
+// one server differs or lacks it.
+func aggregateValues(totals, values map[string]float64) {
+	for name, v := range values {
+		prev, ok := totals[name]
+		switch memstats.AggregationOf(name) {
+		case memstats.Sum:
+			totals[name] = prev + v
+		case memstats.Min:
+			if !ok || v < prev {
+				totals[name] = v
+			}
+		case memstats.Max:
+			if !ok || v > prev {
+				totals[name] = v
+			}
+		case memstats.Same:
+			if ok && prev != v {
+				delete(totals, name)
+			}
+		}
+	}
+	for name := range totals {
+		if _, ok := values[name]; !ok && memstats.AggregationOf(name) == memstats.Same {
+			delete(totals, name)
+		}
+	}
+}
+
+// drawClusterView renders the health ranking, then per-group totals followed
+// by each member server, highlighting the server selected with Tab. Down
+// servers stay listed, marked DOWN with when they last answered.
+func drawClusterView(screen tcell.Screen, line int, u *ui) {
+	_, height := screen.Size()
+	baseStyle := tcell.StyleDefault
+	bold := baseStyle.Bold(true)
+
+	groupLabel := u.groupBy
+	if groupLabel == "" {
+		groupLabel = "none"
+	}
+	drawText(screen, 0, line, baseStyle, fmt.Sprintf("Servers: %d   group by: %s (g to cycle)", len(u.servers), groupLabel))
+	line += 2
+	if restart := rollingRestartLines(u, time.Now()); len(restart) > 0 {
+		for _, l := range restart {
+			drawText(screen, 0, line, l.Style, l.Text)
+			line++
+		}
+		line++
+	}
+	if len(u.servers) > 1 {
+		if health := healthLines(u); len(health) > 0 {
+			for _, l := range health {
+				drawText(screen, 0, line, l.Style, l.Text)
+				line++
+			}
+			line++
+		}
+	}
+
+	format := "%-28s %5s %10s %10s %10s %7s %7s"
+	unit := rateUnit(u.interval)
+	drawText(screen, 0, line, bold, fmt.Sprintf(format, "Server", "Up", "Gets"+unit, "Sets"+unit, "Evict"+unit, "Hit%", "Mem%"))
+	line++
+
+	var rows []panelLine
+	addRow := func(style tcell.Style, text string) {
+		rows = append(rows, panelLine{Text: text, Style: style})
+	}
+	selected := u.current()
+	nf := numberFormat{"cluster"}
+	now := time.Now()
+	for _, g := range groupServers(u.servers, u.groupBy) {
+		// The totals cover the members that are up; a down member marks
+		// the group but doesn't blank it.
+		groupStyle := bold
+		if g.Up < len(g.Servers) {
+			groupStyle = theme.warn.Bold(true)
+		}
+		addRow(groupStyle, fmt.Sprintf(format, g.Name,
+			fmt.Sprintf("%d/%d", g.Up, len(g.Servers)),
+			nf.rate("cmd_get", displayRate(rateValue(g.Rates, "cmd_get"), u.interval)),
+			nf.rate("cmd_set", displayRate(rateValue(g.Rates, "cmd_set"), u.interval)),
+			nf.rate("evictions", displayRate(rateValue(g.Rates, "evictions"), u.interval)),
+			nf.percent("hit_ratio", g.hitRatio()),
+			nf.percent("memory", g.memoryPercent()),
+		))
+		for _, s := range g.Servers {
+			style, downStyle := baseStyle, theme.bad.Bold(true)
+			if s == selected {
+				style, downStyle = style.Reverse(true), downStyle.Reverse(true)
+			}
+			name := "  " + s.addr
+			switch {
+			case s.lastErr != nil:
+				addRow(downStyle, fmt.Sprintf("%-28s %5s  %s: %v", name, "DOWN", describeLastSeen(s, now), s.lastErr))
+			case s.current == nil:
+				addRow(style, fmt.Sprintf("%-28s %5s", name, "..."))
+			default:
+				addRow(style, fmt.Sprintf(format, name, "up",
+					nf.rate("cmd_get", displayRate(rateValue(s.rates, "cmd_get"), u.interval)),
+					nf.rate("cmd_set", displayRate(rateValue(s.rates, "cmd_set"), u.interval)),
+					nf.rate("evictions", displayRate(rateValue(s.rates, "evictions"), u.interval)),
+					nf.percent("hit_ratio", hitRatio(s.current)),
+					nf.percent("memory", memoryPercent(s.current)),
+				))
+			}
+		}
+	}
+
+	u.clusterOffset = clampOffset(u.clusterOffset, len(rows), height-1-line)
+	for _, row := range rows[u.clusterOffset:] {
+		if line >= height-1 {
+			break
+		}
+		drawText(screen, 0, line, row.Style, row.Text)
+		line++
+	}
+}
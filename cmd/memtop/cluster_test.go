@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func clusterTestServer(addr string, tags map[string]string, hits, misses float64) *session {
+	s := newSession(addr, time.Second)
+	s.tags = tags
+	s.current = &statsSnapshot{Values: map[string]float64{
+		"get_hits":       hits,
+		"get_misses":     misses,
+		"bytes":          512,
+		"limit_maxbytes": 1024,
+	}}
+	s.rates = map[string]float64{"cmd_get": 10}
+	return s
+}
+
+func TestGroupServersAggregatesByTag(t *testing.T) {
+	down := newSession("10.0.0.3:11211", time.Second)
+	down.tags = map[string]string{"dc": "eu1"}
+	down.lastErr = errors.New("connection refused")
+	servers := []*session{
+		clusterTestServer("10.0.0.1:11211", map[string]string{"dc": "us1"}, 90, 10),
+		clusterTestServer("10.0.0.2:11211", map[string]string{"dc": "eu1"}, 30, 70),
+		down,
+		clusterTestServer("10.0.0.4:11211", nil, 1, 0),
+	}
+
+	groups := groupServers(servers, "dc")
+	var names []string
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	if got := strings.Join(names, ","); got != "-,eu1,us1" {
+		t.Fatalf("group names = %s", got)
+	}
+	eu := groups[1]
+	if len(eu.Servers) != 2 || eu.Up != 1 || rateValue(eu.Rates, "cmd_get") != 10 || eu.hitRatio() != 30 || eu.memoryPercent() != 50 {
+		t.Fatalf("unexpected eu1 group %+v", eu)
+	}
+
+	all := groupServers(servers, "")
+	if len(all) != 1 || all[0].Up != 3 || all[0].hitRatio() != float64(121)/201*100 {
+		t.Fatalf("ungrouped totals unexpected %+v", all)
+	}
+}
+
+func TestGroupServersFollowsAggregationRules(t *testing.T) {
+	a := clusterTestServer("10.0.0.1:11211", nil, 900, 100)
+	b := clusterTestServer("10.0.0.2:11211", nil, 0, 0)
+	a.current.Values["uptime"], b.current.Values["uptime"] = 86400, 60
+	a.current.Values["pid"], b.current.Values["pid"] = 10, 11
+	a.current.Values["pointer_size"], b.current.Values["pointer_size"] = 64, 64
+	a.current.Values["limit_maxbytes"] = 3072
+	a.rates["uptime"], b.rates["uptime"] = 1, 1
+	b.rates["cmd_get"] = 30
+
+	g := groupServers([]*session{a, b}, "")[0]
+	if g.Totals["uptime"] != 60 || g.Totals["pointer_size"] != 64 {
+		t.Fatalf("min and same rules not applied: %v", g.Totals)
+	}
+	if _, ok := g.Totals["pid"]; ok {
+		t.Fatalf("differing pids should be dropped: %v", g.Totals)
+	}
+	if rateValue(g.Rates, "cmd_get") != 40 || rateValue(g.Rates, "uptime") != 0 {
+		t.Fatalf("rates = %v, want counters summed only", g.Rates)
+	}
+	// An idle server doesn't pull the ratio towards its own, and memory is
+	// bytes over limits, not the mean of two percentages (50% and 12.5%).
+	if g.hitRatio() != 90 || g.memoryPercent() != float64(1024)/4096*100 {
+		t.Fatalf("hit ratio %v, memory %v", g.hitRatio(), g.memoryPercent())
+	}
+}
+
+func TestUISelectionAndGroupCycling(t *testing.T) {
+	u := newUI(time.Second, nil,
+		clusterTestServer("a:11211", map[string]string{"role": "sessions", "dc": "eu1"}, 1, 1),
+		clusterTestServer("b:11211", map[string]string{"dc": "us1"}, 1, 1),
+	)
+	if u.servers[0].events != u.servers[1].events {
+		t.Fatalf("servers should share one event log")
+	}
+
+	u.selectServer(-1)
+	if u.current().addr != "b:11211" {
+		t.Fatalf("selection should wrap backwards, got %s", u.current().addr)
+	}
+	if got := u.serverLabel(); got != "b:11211 [2/2] dc=us1" {
+		t.Fatalf("serverLabel = %q", got)
+	}
+
+	var seen []string
+	for i := 0; i < 3; i++ {
+		u.cycleGroupBy()
+		seen = append(seen, u.groupBy)
+	}
+	if got := strings.Join(seen, ","); got != "dc,role," {
+		t.Fatalf("group cycle = %q", got)
+	}
+}
+
+func TestDrawClusterViewHighlightsSelectedServer(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(100, 20)
+
+	u := newUI(time.Second, nil,
+		clusterTestServer("a:11211", map[string]string{"dc": "eu1"}, 1, 1),
+		clusterTestServer("b:11211", map[string]string{"dc": "us1"}, 1, 1),
+	)
+	u.view = viewCluster
+	u.groupBy = "dc"
+	u.selectServer(1)
+	drawScreen(screen, u)
+
+	cells, width, height := screen.GetContents()
+	found := false
+	for row := 0; row < height; row++ {
+		line := lineFromCells(cells, width, row)
+		if !strings.Contains(line, "b:11211") || strings.HasPrefix(line, "mymemcache-top") {
+			continue
+		}
+		found = true
+		_, _, attrs := cells[row*width].Style.Decompose()
+		if attrs&tcell.AttrReverse == 0 {
+			t.Fatalf("selected server row should be highlighted: %q", line)
+		}
+	}
+	if !found {
+		t.Fatalf("selected server missing from cluster view")
+	}
+	if footer := lineFromCells(cells, width, height-1); !strings.Contains(footer, "Tab server") {
+		t.Fatalf("footer should mention server switching, got %q", footer)
+	}
+}
+
+func TestDrawClusterViewKeepsDownServers(t *testing.T) {
+	lost := clusterTestServer("b:11211", map[string]string{"dc": "eu1"}, 1, 1)
+	lost.current.Timestamp = time.Now().Add(-90 * time.Second)
+	lost.lastErr = errors.New("connection refused")
+	never := newSession("c:11211", time.Second)
+	never.tags = map[string]string{"dc": "eu1"}
+	never.lastErr = errors.New("no such host")
+	u := newUI(time.Second, nil, clusterTestServer("a:11211", map[string]string{"dc": "eu1"}, 3, 1), lost, never)
+	u.view = viewCluster
+
+	text := screenText(t, u)
+	for _, want := range []string{
+		"b:11211                     DOWN  last seen 1m30s ago: connection refused",
+		"c:11211                     DOWN  never seen: no such host",
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("cluster view should show %q:\n%s", want, text)
+		}
+	}
+	// The healthy node still makes up the totals.
+	if !strings.Contains(text, "all                            1/3") || !strings.Contains(text, "75.0") {
+		t.Fatalf("healthy node should still be aggregated:\n%s", text)
+	}
+}
+
+func TestSuspendStopsPollingAndShowsBanner(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	// Wide enough for the whole footer, which ends with the suspend key.
+	screen.SetSize(200, 20)
+
+	u := newUI(2*time.Second, nil, newSession("127.0.0.1:11211", 2*time.Second))
+	u.toggleSuspend(time.Now())
+	drawScreen(screen, u)
+	cells, width, _ := screen.GetContents()
+	if banner := lineFromCells(cells, width, 1); !strings.Contains(banner, "SUSPENDED") {
+		t.Fatalf("suspended banner missing, got %q", banner)
+	}
+	if footer := lineFromCells(cells, width, 19); !strings.Contains(footer, "p resume") {
+		t.Fatalf("footer should offer resume, got %q", footer)
+	}
+
+	u.toggleSuspend(time.Now())
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if banner := lineFromCells(cells, width, 1); strings.Contains(banner, "SUSPENDED") {
+		t.Fatalf("banner should clear after resuming, got %q", banner)
+	}
+	var messages []string
+	for _, e := range u.events.recent(2) {
+		messages = append(messages, e.Message)
+	}
+	if strings.Join(messages, ",") != "polling resumed,polling suspended" {
+		t.Fatalf("suspend and resume should be logged, got %v", messages)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// collectorScope says when a collector runs.
+type collectorScope int
+
+const (
+	// collectEveryPoll runs against every server after its stats poll
+	// succeeds, within the same poll deadline.
+	collectEveryPoll collectorScope = iota
+	// collectForView runs against the selected server while one of the
+	// collector's views is active, and on view and server switches.
+	collectForView
+)
+
+// collector registers a data source beyond the general `stats` reply. Each
+// one owns a namespaced section of the session's sample (the slab table,
+// the TTL sample, proxy stats, ...) and fills it from Collect, which
+// records its own result and errors. Wanted, when set, skips the collector
+// for servers or settings it doesn't apply to. New sources are added here
+// instead of growing the poll itself.
+type collector struct {
+	Name    string
+	Scope   collectorScope
+	Views   []view
+	Wanted  func(u *ui, s *session) bool
+	Collect func(ctx context.Context, u *ui, s *session)
+}
+
+// collectors lists the extra data sources, in the order they run.
+var collectors = []collector{
+	{
+		Name:    "probe",
+		Scope:   collectEveryPoll,
+		Wanted:  func(u *ui, s *session) bool { return s.probe != nil },
+		Collect: func(ctx context.Context, u *ui, s *session) { s.runProbe(ctx, time.Now()) },
+	},
+	{
+		Name:    "watched",
+		Scope:   collectEveryPoll,
+		Wanted:  func(u *ui, s *session) bool { return len(s.watchKeys) > 0 && s.supports(featureMeta) },
+		Collect: collectWatchedKeys,
+	},
+	{
+		Name:  "slabs",
+		Scope: collectForView,
+		Views: []view{viewSlabs, viewSummary},
+		// The summary only needs slab stats to attribute evictions.
+		Wanted: func(u *ui, s *session) bool {
+			return u.view != viewSummary || rateValue(s.rates, "evictions") > 0
+		},
+		Collect: collectSlabs,
+	},
+	{
+		Name:  "ttl",
+		Scope: collectForView,
+		Views: []view{viewPanels},
+		Wanted: func(u *ui, s *session) bool {
+			return s.supports(featureLRUCrawler) && s.metadumpDue(time.Now())
+		},
+		Collect: collectTTLs,
+	},
+	{
+		Name:    "plugins",
+		Scope:   collectForView,
+		Views:   []view{viewPanels},
+		Wanted:  func(u *ui, s *session) bool { return len(u.plugins) > 0 },
+		Collect: func(ctx context.Context, u *ui, s *session) { runPlugins(ctx, s, u.plugins) },
+	},
+	{
+		Name:    "proxy",
+		Scope:   collectForView,
+		Views:   []view{viewProxy},
+		Wanted:  func(u *ui, s *session) bool { return s.caps != nil && s.caps.has(featureProxy) },
+		Collect: collectProxy,
+	},
+}
+
+// runCollectors runs the collectors of scope that apply to s, stopping once
+// ctx is cancelled. View collectors only run when their view is active and
+// their refresh interval, if one is set, has passed.
+func runCollectors(ctx context.Context, u *ui, s *session, scope collectorScope) {
+	now := time.Now()
+	for _, c := range collectors {
+		if ctx.Err() != nil {
+			return
+		}
+		if c.Scope != scope || (scope == collectForView && !slices.Contains(c.Views, u.view)) {
+			continue
+		}
+		if scope == collectForView && !s.collectorDue(c.Name, u.viewRefresh[c.Name], now) {
+			continue
+		}
+		if c.Wanted != nil && !c.Wanted(u, s) {
+			continue
+		}
+		c.Collect(ctx, u, s)
+		if scope == collectForView {
+			s.markCollected(c.Name, now)
+		}
+	}
+}
+
+// collectWatchedKeys fetches the config's watch_keys with meta commands for
+// the watched keys panel.
+func collectWatchedKeys(ctx context.Context, u *ui, s *session) {
+	keys, err := fetchWatchedKeys(ctx, s.addr, s.watchKeys)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.logError("watched", err)
+	}
+	s.recordWatchedKeys(keys, err)
+}
+
+// collectSlabs fetches slab and item stats for the slab view and the
+// summary's eviction line.
+func collectSlabs(ctx context.Context, u *ui, s *session) {
+	slabs, err := fetchSlabs(ctx, s.addr)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.logError("slabs", err)
+	}
+	s.recordSlabs(slabs, err)
+}
+
+// collectTTLs fetches a metadump sample of key expiries for the TTL panel.
+func collectTTLs(ctx context.Context, u *ui, s *session) {
+	ttls, err := fetchTTLSample(ctx, s.addr, s.metadumpLimit, serverTime(s.current))
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.logError("ttl", err)
+	}
+	s.recordTTLs(ttls, err)
+}
+
+// collectProxy fetches the proxy stats section for the proxy view.
+func collectProxy(ctx context.Context, u *ui, s *session) {
+	proxy, err := fetchStatsSection(ctx, s.addr, "proxy")
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.logError("proxy", err)
+	}
+	s.recordProxy(proxy, err)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRunCollectorsHonoursScopeViewsAndWanted(t *testing.T) {
+	var ran []string
+	record := func(name string) func(context.Context, *ui, *session) {
+		return func(context.Context, *ui, *session) { ran = append(ran, name) }
+	}
+	saved := collectors
+	defer func() { collectors = saved }()
+	collectors = []collector{
+		{Name: "every", Scope: collectEveryPoll, Collect: record("every")},
+		{Name: "slabs", Scope: collectForView, Views: []view{viewSlabs}, Collect: record("slabs")},
+		{Name: "proxy", Scope: collectForView, Views: []view{viewProxy}, Collect: record("proxy")},
+		{Name: "unwanted", Scope: collectForView, Views: []view{viewSlabs}, Wanted: func(*ui, *session) bool { return false }, Collect: record("unwanted")},
+	}
+
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	u.view = viewSlabs
+	runCollectors(context.Background(), u, u.current(), collectForView)
+	if !slices.Equal(ran, []string{"slabs"}) {
+		t.Fatalf("view collectors ran %q, want only slabs", ran)
+	}
+
+	ran = nil
+	runCollectors(context.Background(), u, u.current(), collectEveryPoll)
+	if !slices.Equal(ran, []string{"every"}) {
+		t.Fatalf("poll collectors ran %q, want only every", ran)
+	}
+
+	ran = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	runCollectors(ctx, u, u.current(), collectEveryPoll)
+	if len(ran) != 0 {
+		t.Fatalf("collectors ran %q after cancellation", ran)
+	}
+}
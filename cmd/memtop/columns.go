@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// statsColumns lists the optional columns of the stats table in the order
+// they are shown. min, max, and avg summarize the rate over the anomaly
+// window; baseline only shows with -baseline loaded.
+var statsColumns = []struct {
+	name  string
+	width int
+}{
+	{"value", 16},
+	{"delta", 12},
+	{"rate", 12},
+	{"min", 10},
+	{"max", 10},
+	{"avg", 10},
+	{"sigma", 6},
+	{"baseline", 9},
+}
+
+// defaultStatsColumns are shown unless the config file's "columns" says
+// otherwise.
+var defaultStatsColumns = []string{"value", "delta", "rate", "sigma", "baseline"}
+
+// validateColumns rejects column names the stats table does not know.
+func validateColumns(names []string) error {
+	for _, name := range names {
+		if statsColumnWidth(name) == 0 {
+			return fmt.Errorf("unknown column %q", name)
+		}
+	}
+	return nil
+}
+
+func statsColumnWidth(name string) int {
+	for _, c := range statsColumns {
+		if c.name == name {
+			return c.width
+		}
+	}
+	return 0
+}
+
+// statsColumnTitle is the header of a column, with the rate unit on rate
+// columns.
+func statsColumnTitle(name string, s *session) string {
+	title := strings.ToUpper(name[:1]) + name[1:]
+	switch name {
+	case "rate", "min", "max", "avg":
+		title += rateUnit(s.interval)
+	}
+	return title
+}
+
+// statsColumnCell renders one stat's entry in a column, empty where the
+// column does not apply to the stat.
+func statsColumnCell(name string, u *ui, s *session, key string) string {
+	switch name {
+	case "value":
+		return s.current.Raw[key]
+	case "delta":
+		return statDelta(s.current, s.prev, key)
+	case "rate":
+		if r, ok := s.rates[key]; ok {
+			return numberFormat{"stats"}.rate(key, displayRate(r, s.interval))
+		}
+	case "min", "max", "avg":
+		if s.anomalies == nil || len(s.anomalies.samples[key]) == 0 {
+			return ""
+		}
+		return numberFormat{"stats"}.rate(key, displayRate(summarizeRates(s.anomalies.samples[key], name), s.interval))
+	case "sigma":
+		if z, ok := s.zscores[key]; ok {
+			return fmt.Sprintf("%+.1f", z)
+		}
+	case "baseline":
+		return formatChange(u.baselineChange(s, key))
+	}
+	return ""
+}
+
+// summarizeRates returns the minimum, maximum, or mean of recent rates.
+func summarizeRates(rates []float64, how string) float64 {
+	switch how {
+	case "min":
+		low := math.Inf(1)
+		for _, r := range rates {
+			low = min(low, r)
+		}
+		return low
+	case "max":
+		high := math.Inf(-1)
+		for _, r := range rates {
+			high = max(high, r)
+		}
+		return high
+	}
+	mean, _ := meanStdDev(rates)
+	return mean
+}
+
+// shownColumns is the chosen columns that apply right now: baseline only
+// with a baseline loaded.
+func (u *ui) shownColumns() []string {
+	shown := make([]string, 0, len(u.columns))
+	for _, name := range u.columns {
+		if name != "baseline" || u.baseline != nil {
+			shown = append(shown, name)
+		}
+	}
+	return shown
+}
+
+// columnChooser is the dialog opened with c in the stats view: it lists every
+// column with a checkbox, applies changes at once, and can save the choice
+// to the config file.
+type columnChooser struct {
+	cursor int
+	status string
+}
+
+// handleKey applies a key press to the dialog, changing u's columns. It
+// reports whether the dialog is finished.
+func (c *columnChooser) handleKey(evt *tcell.EventKey, u *ui) bool {
+	switch evt.Key() {
+	case tcell.KeyEnter, tcell.KeyEscape, tcell.KeyCtrlC:
+		return true
+	case tcell.KeyUp:
+		c.cursor = max(0, c.cursor-1)
+	case tcell.KeyDown:
+		c.cursor = min(len(statsColumns)-1, c.cursor+1)
+	case tcell.KeyRune:
+		switch evt.Rune() {
+		case ' ':
+			u.toggleColumn(statsColumns[c.cursor].name)
+			c.status = ""
+		case 's', 'S':
+			if u.configPath == "" {
+				c.status = "no -config file to save to"
+			} else if err := saveColumns(u.configPath, u.columns); err != nil {
+				c.status = "save failed: " + err.Error()
+			} else {
+				c.status = "saved to " + u.configPath
+			}
+		case 'c', 'C', 'q':
+			return true
+		}
+	}
+	return false
+}
+
+// toggleColumn shows or hides a column, keeping the table's column order.
+func (u *ui) toggleColumn(name string) {
+	on := make(map[string]bool, len(u.columns))
+	for _, c := range u.columns {
+		on[c] = true
+	}
+	on[name] = !on[name]
+	u.columns = u.columns[:0:0]
+	for _, c := range statsColumns {
+		if on[c.name] {
+			u.columns = append(u.columns, c.name)
+		}
+	}
+}
+
+// lines renders the dialog's checkbox list and key help.
+func (c *columnChooser) lines(u *ui) []panelLine {
+	on := make(map[string]bool, len(u.columns))
+	for _, name := range u.columns {
+		on[name] = true
+	}
+	lines := []panelLine{plainLine("Columns")}
+	for i, col := range statsColumns {
+		box := "[ ]"
+		if on[col.name] {
+			box = "[x]"
+		}
+		line := plainLine(fmt.Sprintf("%s %s", box, col.name))
+		if i == c.cursor {
+			line.Style = line.Style.Reverse(true)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, plainLine("Space toggle  s save  Enter close"))
+	if c.status != "" {
+		lines = append(lines, plainLine(c.status))
+	}
+	return lines
+}
+
+// draw paints the dialog at the given position.
+func (c *columnChooser) draw(screen tcell.Screen, x, y int, u *ui) {
+	drawBox(screen, x, y, c.lines(u))
+}
+
+// drawBox paints lines inside a border, as a dialog over the view.
+func drawBox(screen tcell.Screen, x, y int, lines []panelLine) {
+	width := 0
+	for _, l := range lines {
+		width = max(width, utf8.RuneCountInString(l.Text))
+	}
+	border := tcell.StyleDefault.Bold(true)
+	drawText(screen, x, y, border, "+"+strings.Repeat("-", width+2)+"+")
+	for i, l := range lines {
+		drawText(screen, x, y+1+i, border, "| ")
+		drawText(screen, x+2, y+1+i, l.Style, fmt.Sprintf("%-*s", width, l.Text))
+		drawText(screen, x+2+width, y+1+i, border, " |")
+	}
+	drawText(screen, x, y+1+len(lines), border, "+"+strings.Repeat("-", width+2)+"+")
+}
+
+// saveColumns writes the chosen columns into the config file's "columns",
+// keeping the rest of the file's settings.
+func saveColumns(path string, columns []string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return fmt.Errorf("parse config: %w", err)
+	}
+	if fields["columns"], err = json.Marshal(columns); err != nil {
+		return err
+	}
+	out, err := json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(out, '\n'), info.Mode().Perm())
+}
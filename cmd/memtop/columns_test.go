@@ -0,0 +1,105 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestColumnChooserTogglesAndSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memtop.json")
+	if err := os.WriteFile(path, []byte(`{"servers": [{"addr": "cache1:11211"}], "group_by": "dc"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	u := newUI(2*time.Second, nil, newSession("127.0.0.1:11211", 2*time.Second))
+	u.configPath = path
+	c := &columnChooser{}
+	keys := []*tcell.EventKey{
+		tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), // delta off
+		tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone),
+		tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), // min on
+		tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone),
+	}
+	for _, evt := range keys {
+		if c.handleKey(evt, u) {
+			t.Fatalf("dialog closed early")
+		}
+	}
+	want := []string{"value", "rate", "min", "sigma", "baseline"}
+	if !reflect.DeepEqual(u.columns, want) {
+		t.Fatalf("columns = %q, want %q", u.columns, want)
+	}
+	if !reflect.DeepEqual(defaultStatsColumns, []string{"value", "delta", "rate", "sigma", "baseline"}) {
+		t.Fatalf("toggling changed the defaults: %q", defaultStatsColumns)
+	}
+	if c.status != "saved to "+path {
+		t.Fatalf("status = %q", c.status)
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatalf("saved config does not load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg.Columns, want) || cfg.GroupBy != "dc" || len(cfg.Servers) != 1 {
+		t.Fatalf("saved config = %+v", cfg)
+	}
+	if !c.handleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), u) {
+		t.Fatalf("Enter should close the dialog")
+	}
+
+	u.configPath = ""
+	c.handleKey(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone), u)
+	if !strings.Contains(c.status, "no -config") {
+		t.Fatalf("saving without a config file: status %q", c.status)
+	}
+}
+
+func TestParseConfigRejectsUnknownColumns(t *testing.T) {
+	if _, err := parseConfig([]byte(`{"columns": ["value", "median"]}`)); err == nil || !strings.Contains(err.Error(), "median") {
+		t.Fatalf("unknown column accepted: %v", err)
+	}
+}
+
+func TestDrawStatsViewShowsChosenColumns(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 10)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"cmd_get": 100},
+		Raw:       map[string]string{"cmd_get": "100"},
+	}
+	sess.rates = map[string]float64{"cmd_get": 4}
+	sess.anomalies.observe(map[string]float64{"cmd_get": 2})
+	sess.anomalies.observe(map[string]float64{"cmd_get": 4})
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	u.columns = []string{"rate", "min", "max", "avg", "baseline"}
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	if header := strings.Fields(lineFromCells(cells, width, 2)); strings.Join(header, " ") != "Metric Rate/s Min/s Max/s Avg/s" {
+		t.Fatalf("header = %q, baseline should only show with -baseline", header)
+	}
+	if row := strings.Fields(lineFromCells(cells, width, 4)); strings.Join(row, " ") != "cmd_get 4.00 2.00 4.00 3.00" {
+		t.Fatalf("row = %q", row)
+	}
+
+	u.chooser = &columnChooser{}
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if line := lineFromCells(cells, width, 5); !strings.Contains(line, "[ ] value") {
+		t.Fatalf("chooser not drawn, got %q", line)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// streams are the standard streams a subcommand reads and writes, swapped
+// for buffers in tests.
+type streams struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// defaultStreams returns the process's standard streams.
+func defaultStreams() streams {
+	return streams{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+}
+
+// subcommand is one of memtop's commands, such as `memtop get KEY`. run
+// returns the process exit code; it is nil for the commands that start the
+// monitor (top, agent, and replay), which main runs itself.
+type subcommand struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string, std streams) int
+}
+
+// subcommands lists the commands in the order shown by -help. It is filled
+// in by init because the commands' usage text looks the list up.
+var subcommands []subcommand
+
+func init() {
+	subcommands = []subcommand{
+		{"top", "[host [port]]", "run the interactive monitor (the default without a command)", nil},
+		{"agent", "[host [port]]", agentSummary, nil},
+		{"replay", "FILE", "replay a -jsonl recording in the monitor", nil},
+		{"get", "KEY", "print a key's value", runGet},
+		{"set", "KEY [-ttl d] [-value v | -file path]", "store a value (reads stdin without -value or -file)", runSet},
+		{"delete", "KEY", "delete a key", runDelete},
+		{"flush", "-yes [-delay d]", "invalidate every item on the server", runFlush},
+		{"stats", "[slabs|items|settings] [-format text|json|openmetrics]", "print a raw stats report", runStats},
+		{"export", "[-format jsonl|csv|openmetrics] [-count n] [-interval d]", "sample the server and print the samples", runExport},
+		{"check", "[-hit-ratio w,c] [-memory w,c] [-connections w,c] [-evictions w,c]", "check the server's health, Nagios plugin style", runCheck},
+		{"bench", "-yes [-duration d] [-concurrency n] [-get-ratio r]", "load the server with gets and sets and report latencies", runBench},
+		{"dump-keys", "[-prefix p] [-limit n] [-format jsonl|tsv]", "stream key metadata from lru_crawler metadump", runDumpKeys},
+		{"diff", "A.json B.json [-sort percent|absolute] [-limit n]", "list the stats that changed most between two saved snapshots", runDiff},
+	}
+}
+
+// lookupSubcommand finds a subcommand by name.
+func lookupSubcommand(name string) (subcommand, bool) {
+	for _, cmd := range subcommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return subcommand{}, false
+}
+
+// splitCommand picks the command from the command line and returns it with
+// the arguments it runs with. Connection flags given before the command are
+// shared with it, so `memtop -host cache1 stats slabs` works as well as
+// `memtop stats slabs -host cache1`. Without a command, the arguments are
+// the monitor's and the command is top.
+func splitCommand(args []string) (string, []string) {
+	global := flag.NewFlagSet("memtop", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	addConnFlags(global)
+	if err := global.Parse(args); err != nil || global.NArg() == 0 {
+		return "top", args
+	}
+	rest := global.Args()
+	if _, ok := lookupSubcommand(rest[0]); !ok {
+		return "top", args
+	}
+	shared := args[:len(args)-len(rest)]
+	return rest[0], append(append([]string(nil), shared...), rest[1:]...)
+}
+
+// printSubcommands writes the one-line summaries used in the top-level usage.
+func printSubcommands(w io.Writer) {
+	for _, cmd := range subcommands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// newCommandFlags prepares a flag set for a subcommand with the shared
+// connection flags already registered.
+func newCommandFlags(name string, std streams) (*flag.FlagSet, *connOptions) {
+	fs := newOfflineFlags(name, std)
+	return fs, addConnFlags(fs)
+}
+
+// newOfflineFlags prepares a flag set for a subcommand that works on files
+// and never connects to a server.
+func newOfflineFlags(name string, std streams) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(std.Err)
+	cmd, _ := lookupSubcommand(name)
+	fs.Usage = func() {
+		fmt.Fprintf(std.Err, "Usage: %s %s [options] %s\n\n%s.\n\nOptions:\n", os.Args[0], name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseCommandArgs parses flags that may appear before or after positional
+// arguments and checks that exactly want positional arguments were given; a
+// negative want leaves the count to the caller.
+func parseCommandArgs(fs *flag.FlagSet, args []string, want int) ([]string, bool) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, false
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	if want >= 0 && len(positional) != want {
+		fs.Usage()
+		return nil, false
+	}
+	return positional, true
+}
+
+// connectCommand applies the connection flags, reporting failures the way
+// every subcommand does.
+func connectCommand(conn *connOptions, std streams) (func(), bool) {
+	closeConn, err := conn.apply()
+	if err != nil {
+		fmt.Fprintln(std.Err, err)
+		return nil, false
+	}
+	return closeConn, true
+}
+
+// keyArg validates a key given on the command line.
+func keyArg(key string, std streams) bool {
+	if !validKey(key) {
+		fmt.Fprintf(std.Err, "invalid key %q: keys must be 1-%d bytes without whitespace\n", key, maxKeyLength)
+		return false
+	}
+	return true
+}
+
+// runGet implements `memtop get KEY`, printing the raw value.
+func runGet(args []string, std streams) int {
+	fs, conn := newCommandFlags("get", std)
+	rest, ok := parseCommandArgs(fs, args, 1)
+	if !ok || !keyArg(rest[0], std) {
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+
+	value, found, err := getKey(conn.addr(), rest[0])
+	if err != nil {
+		fmt.Fprintf(std.Err, "get %s: %v\n", rest[0], err)
+		return 1
+	}
+	if !found {
+		fmt.Fprintf(std.Err, "%s: not found\n", rest[0])
+		return 1
+	}
+	std.Out.Write(value)
+	return 0
+}
+
+// runSet implements `memtop set KEY`, taking the value from -value, -file or
+// standard input.
+func runSet(args []string, std streams) int {
+	fs, conn := newCommandFlags("set", std)
+	ttl := fs.Duration("ttl", 0, "time to live (0 never expires)")
+	value := fs.String("value", "", "value to store")
+	file := fs.String("file", "", "read the value from this file (- for stdin)")
+	rest, ok := parseCommandArgs(fs, args, 1)
+	if !ok || !keyArg(rest[0], std) {
+		return 2
+	}
+	valueSet := false
+	fs.Visit(func(f *flag.Flag) { valueSet = valueSet || f.Name == "value" })
+	if valueSet && *file != "" {
+		fmt.Fprintln(std.Err, "-value and -file are mutually exclusive")
+		return 2
+	}
+
+	data := []byte(*value)
+	if !valueSet {
+		var err error
+		switch *file {
+		case "", "-":
+			data, err = io.ReadAll(std.In)
+		default:
+			data, err = os.ReadFile(*file)
+		}
+		if err != nil {
+			fmt.Fprintf(std.Err, "read value: %v\n", err)
+			return 1
+		}
+	}
+
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+	if err := setKey(conn.addr(), rest[0], data, *ttl); err != nil {
+		fmt.Fprintf(std.Err, "set %s: %v\n", rest[0], err)
+		return 1
+	}
+	return 0
+}
+
+// runDelete implements `memtop delete KEY`. A missing key is reported and
+// exits 1 so scripts can tell it apart from a deletion.
+func runDelete(args []string, std streams) int {
+	fs, conn := newCommandFlags("delete", std)
+	rest, ok := parseCommandArgs(fs, args, 1)
+	if !ok || !keyArg(rest[0], std) {
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+
+	deleted, err := deleteKey(conn.addr(), rest[0])
+	if err != nil {
+		fmt.Fprintf(std.Err, "delete %s: %v\n", rest[0], err)
+		return 1
+	}
+	if !deleted {
+		fmt.Fprintf(std.Err, "%s: not found\n", rest[0])
+		return 1
+	}
+	return 0
+}
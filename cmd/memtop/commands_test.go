@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runCommand runs a subcommand against addr with buffered streams, restoring
+// the connection globals the command configures.
+func runCommand(t *testing.T, addr string, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	prevDialer, prevProtocol, prevAuth := serverDialer, statsProtocol, saslCredentials
+	t.Cleanup(func() { serverDialer, statsProtocol, saslCredentials = prevDialer, prevProtocol, prevAuth })
+	t.Setenv("ALL_PROXY", "")
+	t.Setenv("all_proxy", "")
+
+	host, port, _ := net.SplitHostPort(addr)
+	cmd, ok := lookupSubcommand(args[0])
+	if !ok {
+		t.Fatalf("unknown subcommand %q", args[0])
+	}
+	var out, errOut bytes.Buffer
+	std := streams{In: strings.NewReader(stdin), Out: &out, Err: &errOut}
+	code := cmd.run(append(args[1:], "-host", host, "-port", port), std)
+	return code, out.String(), errOut.String()
+}
+
+func TestKeySubcommands(t *testing.T) {
+	store, ln := startFakeStore(t)
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	if code, _, errOut := runCommand(t, addr, "", "set", "greeting", "-value", "hello"); code != 0 {
+		t.Fatalf("set exited %d: %s", code, errOut)
+	}
+	if code, out, _ := runCommand(t, addr, "", "get", "greeting"); code != 0 || out != "hello" {
+		t.Fatalf("get = %d %q, want 0 \"hello\"", code, out)
+	}
+
+	if code, _, errOut := runCommand(t, addr, "from stdin", "set", "greeting"); code != 0 {
+		t.Fatalf("set from stdin exited %d: %s", code, errOut)
+	}
+	store.mu.Lock()
+	got := store.items["greeting"]
+	store.mu.Unlock()
+	if got != "from stdin" {
+		t.Fatalf("stored %q, want value read from stdin", got)
+	}
+
+	if code, _, errOut := runCommand(t, addr, "", "delete", "greeting"); code != 0 {
+		t.Fatalf("delete exited %d: %s", code, errOut)
+	}
+	if code, _, errOut := runCommand(t, addr, "", "delete", "greeting"); code != 1 || !strings.Contains(errOut, "not found") {
+		t.Fatalf("deleting a missing key = %d %q, want 1 and not found", code, errOut)
+	}
+	if code, _, errOut := runCommand(t, addr, "", "get", "greeting"); code != 1 || !strings.Contains(errOut, "not found") {
+		t.Fatalf("getting a missing key = %d %q, want 1 and not found", code, errOut)
+	}
+}
+
+func TestKeySubcommandsRejectBadArguments(t *testing.T) {
+	tests := [][]string{
+		{"get", "has space"},
+		{"get"},
+		{"get", "a", "b"},
+		{"set", "k", "-value", "v", "-file", "value.txt"},
+	}
+	for _, args := range tests {
+		if code, _, _ := runCommand(t, "127.0.0.1:1", "", args...); code != 2 {
+			t.Errorf("%v exited %d, want 2", args, code)
+		}
+	}
+}
+
+func TestKeySubcommandsOverBinaryProtocol(t *testing.T) {
+	ln := startBinaryServer(t, "")
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	if code, _, errOut := runCommand(t, addr, "", "set", "-protocol", "binary", "k", "-value", "v1"); code != 0 {
+		t.Fatalf("set exited %d: %s", code, errOut)
+	}
+	if code, out, _ := runCommand(t, addr, "", "get", "-protocol", "binary", "k"); code != 0 || out != "v1" {
+		t.Fatalf("get = %d %q, want 0 \"v1\"", code, out)
+	}
+	if code, _, _ := runCommand(t, addr, "", "delete", "-protocol", "binary", "k"); code != 0 {
+		t.Fatalf("delete exited %d", code)
+	}
+	if code, _, _ := runCommand(t, addr, "", "get", "-protocol", "binary", "k"); code != 1 {
+		t.Fatalf("get after delete exited %d, want 1", code)
+	}
+}
+
+func TestExpiration(t *testing.T) {
+	if got := expiration(0); got != 0 {
+		t.Errorf("expiration(0) = %d, want 0", got)
+	}
+	if got := expiration(90 * time.Second); got != 90 {
+		t.Errorf("expiration(90s) = %d, want 90", got)
+	}
+	long := 60 * 24 * time.Hour
+	if got, want := expiration(long), time.Now().Add(long).Unix(); got < want-1 || got > want {
+		t.Errorf("expiration(60d) = %d, want absolute time near %d", got, want)
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		name string
+		rest []string
+	}{
+		{nil, "top", nil},
+		{[]string{"-interval", "1s", "cache1"}, "top", []string{"-interval", "1s", "cache1"}},
+		{[]string{"top", "-demo"}, "top", []string{"-demo"}},
+		{[]string{"stats", "slabs"}, "stats", []string{"slabs"}},
+		{[]string{"-host", "cache1", "-port=12000", "stats", "slabs"}, "stats", []string{"-host", "cache1", "-port=12000", "slabs"}},
+		{[]string{"-host", "cache1", "agent", "-listen", ":9150"}, "agent", []string{"-host", "cache1", "-listen", ":9150"}},
+		{[]string{"-host", "cache1", "cache2"}, "top", []string{"-host", "cache1", "cache2"}},
+	} {
+		name, rest := splitCommand(tc.args)
+		if name != tc.name || strings.Join(rest, " ") != strings.Join(tc.rest, " ") {
+			t.Fatalf("splitCommand(%q) = %q %q, want %q %q", tc.args, name, rest, tc.name, tc.rest)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Below this size the regular layouts clip in ways that hide the numbers that
+// matter, so drawScreen shows the compact card instead.
+const (
+	minLayoutWidth  = 40
+	minLayoutHeight = 10
+)
+
+// tooSmallForLayout reports whether the terminal is below the minimum layout.
+func tooSmallForLayout(width, height int) bool {
+	return width < minLayoutWidth || height < minLayoutHeight
+}
+
+// compactItems returns the essentials shown on the compact card.
+func compactItems(s *session) []string {
+	switch {
+	case s.lastErr != nil:
+		return []string{"ERROR", s.lastErr.Error()}
+	case s.current == nil:
+		return []string{"waiting for stats"}
+	}
+	nf := numberFormat{"compact"}
+	return []string{
+		fmt.Sprintf("hit %s%%", nf.percent("hit_ratio", hitRatio(s.current))),
+		fmt.Sprintf("mem %s%%", nf.percent("memory", memoryPercent(s.current))),
+		fmt.Sprintf("gets%s %s", rateUnit(s.interval), nf.rate("cmd_get", displayRate(rateValue(s.rates, "cmd_get"), s.interval))),
+	}
+}
+
+// drawCompactCard renders the essentials for the selected server: one per
+// line when the height allows it, otherwise all on the first line.
+func drawCompactCard(screen tcell.Screen, u *ui) {
+	_, height := screen.Size()
+	s := u.current()
+	style := tcell.StyleDefault
+	if s.lastErr != nil {
+		style = theme.bad
+	}
+	items := compactItems(s)
+
+	if u.prompt != nil {
+		height--
+		drawText(screen, 0, height, style.Bold(true), u.prompt.footer())
+	}
+	if height > len(items) {
+		drawText(screen, 0, 0, tcell.StyleDefault.Bold(true), u.serverLabel())
+		for i, item := range items {
+			drawText(screen, 0, i+1, style, item)
+		}
+		return
+	}
+	if height > 0 {
+		drawText(screen, 0, 0, style, strings.Join(items, "  "))
+	}
+}
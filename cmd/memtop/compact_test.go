@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestTinyTerminalShowsCompactCard(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"get_hits": 90, "get_misses": 10, "bytes": 25, "limit_maxbytes": 100},
+		Raw:       map[string]string{"version": "1.6.21"},
+	}
+	sess.rates = map[string]float64{"cmd_get": 1234}
+	u := newUI(2*time.Second, nil, sess)
+
+	screen.SetSize(30, 6)
+	drawScreen(screen, u)
+	cells, width, _ := screen.GetContents()
+	var got []string
+	for row := 0; row < 4; row++ {
+		got = append(got, strings.TrimSpace(lineFromCells(cells, width, row)))
+	}
+	if want := "127.0.0.1:11211|hit 90.0%|mem 25.0%|gets/s 1234"; strings.Join(got, "|") != want {
+		t.Fatalf("compact card = %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	screen.SetSize(80, 2)
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if line := lineFromCells(cells, width, 0); !strings.HasPrefix(line, "hit 90.0%  mem 25.0%  gets/s 1234") {
+		t.Fatalf("short terminal should fit the card on one line, got %q", line)
+	}
+
+	sess.lastErr = errors.New("connection refused")
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if line := lineFromCells(cells, width, 0); !strings.HasPrefix(line, "ERROR  connection refused") {
+		t.Fatalf("errors should replace the figures, got %q", line)
+	}
+
+	sess.lastErr = nil
+	screen.SetSize(100, 30)
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if header := lineFromCells(cells, width, 0); !strings.HasPrefix(header, "mymemcache-top") {
+		t.Fatalf("full layout should return once the terminal grows, got %q", header)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPort is used for configured servers that omit a port.
+const defaultPort = "11211"
+
+// config is the optional JSON file passed with -config. It lets a fleet be
+// described once instead of on the command line.
+type config struct {
+	Servers []serverConfig `json:"servers"`
+	// GroupBy names the tag the cluster view groups servers by.
+	GroupBy string `json:"group_by"`
+	// WatchKeys are looked up on every server each interval.
+	WatchKeys []string `json:"watch_keys"`
+	// Plugins add external panels to the panels view.
+	Plugins []pluginConfig `json:"plugins"`
+	// Columns are the stats table's columns; the column chooser saves them.
+	Columns []string `json:"columns"`
+	// ViewRefresh maps view collectors to Go durations such as "10s", the
+	// least time between their runs; -view-refresh overrides entries.
+	ViewRefresh map[string]string `json:"view_refresh"`
+	// Precision sets the decimals numbers are shown with, as -precision
+	// does; -precision overrides entries.
+	Precision map[string]int `json:"precision"`
+	// LinkSpeed is the NIC capacity of the servers' hosts, such as "10G";
+	// a server's own link_speed and -link-speed take precedence.
+	LinkSpeed string `json:"link_speed"`
+
+	// viewRefresh is ViewRefresh parsed.
+	viewRefresh map[string]time.Duration
+}
+
+// serverConfig describes one monitored server and its free-form labels,
+// for example {"dc": "eu1", "role": "session-cache"}.
+type serverConfig struct {
+	Addr string            `json:"addr"`
+	Tags map[string]string `json:"tags"`
+	// LinkSpeed is the NIC capacity of this server's host, such as "25G".
+	LinkSpeed string `json:"link_speed"`
+}
+
+// loadConfig reads and validates a config file.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfig(data)
+}
+
+// parseConfig decodes config JSON, rejecting unknown fields so typos in
+// option names are reported instead of silently ignored.
+func parseConfig(data []byte) (*config, error) {
+	var cfg config
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+	servers := make([]serverConfig, 0, len(cfg.Servers))
+	for i, srv := range cfg.Servers {
+		if strings.TrimSpace(srv.Addr) == "" {
+			return nil, fmt.Errorf("config: server %d has no addr", i+1)
+		}
+		addrs, err := expandAddr(srv.Addr)
+		if err != nil {
+			return nil, fmt.Errorf("config: server %d: %w", i+1, err)
+		}
+		if _, err := parseLinkSpeed(srv.LinkSpeed); err != nil {
+			return nil, fmt.Errorf("config: server %d: %w", i+1, err)
+		}
+		// Every instance of a port list shares the entry's tags and link.
+		for _, addr := range addrs {
+			instance := srv
+			instance.Addr = addr
+			servers = append(servers, instance)
+		}
+	}
+	cfg.Servers = servers
+	for _, key := range cfg.WatchKeys {
+		if !validKey(key) {
+			return nil, fmt.Errorf("config: watch key %q is empty, too long or contains whitespace", key)
+		}
+	}
+	if err := validateColumns(cfg.Columns); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	cfg.viewRefresh = make(map[string]time.Duration, len(cfg.ViewRefresh))
+	for name, value := range cfg.ViewRefresh {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("config: view refresh %s: %w", name, err)
+		}
+		cfg.viewRefresh[name] = d
+	}
+	if err := validateViewRefresh(cfg.viewRefresh); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if _, err := parseLinkSpeed(cfg.LinkSpeed); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := validatePrecision(cfg.Precision); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	for _, p := range cfg.Plugins {
+		if _, err := newPlugin(p); err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+	}
+	return &cfg, nil
+}
+
+// normalizeAddr appends the default memcached port when addr has none.
+func normalizeAddr(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if _, _, err := net.SplitHostPort(addr); err == nil {
+		return addr
+	}
+	return net.JoinHostPort(strings.Trim(addr, "[]"), defaultPort)
+}
+
+// maxExpandedPorts bounds the instances one address may expand to, so a
+// mistyped range such as 11211-65535 fails instead of opening thousands of
+// connections.
+const maxExpandedPorts = 256
+
+// expandAddr turns an address whose port is a list or range, such as
+// host:11211,11212 or host:11211-11214, into one address per port, for the
+// common setup of several memcached instances on one machine. Ports may mix
+// both forms (host:11211-11213,11220); repeats are dropped. An address with a
+// single port or none expands to itself, with the default port added.
+func expandAddr(spec string) ([]string, error) {
+	spec = strings.TrimSpace(spec)
+	host, ports, err := net.SplitHostPort(spec)
+	if err != nil || !strings.ContainsAny(ports, ",-") {
+		return []string{normalizeAddr(spec)}, nil
+	}
+	var addrs []string
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(ports, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		lo, err := parsePort(first)
+		if err != nil {
+			return nil, fmt.Errorf("address %s: %w", spec, err)
+		}
+		hi := lo
+		if isRange {
+			if hi, err = parsePort(last); err != nil {
+				return nil, fmt.Errorf("address %s: %w", spec, err)
+			}
+			if hi < lo {
+				return nil, fmt.Errorf("address %s: port range %s runs backwards", spec, part)
+			}
+		}
+		for port := lo; port <= hi; port++ {
+			if seen[port] {
+				continue
+			}
+			if len(addrs) == maxExpandedPorts {
+				return nil, fmt.Errorf("address %s: more than %d ports", spec, maxExpandedPorts)
+			}
+			seen[port] = true
+			addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(port)))
+		}
+	}
+	return addrs, nil
+}
+
+// parsePort parses one port of a port list.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
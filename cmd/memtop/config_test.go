@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseConfigNormalizesAddresses(t *testing.T) {
+	cfg, err := parseConfig([]byte(`{
+		"group_by": "dc",
+		"servers": [
+			{"addr": "cache-1", "tags": {"dc": "eu1"}},
+			{"addr": "cache-2:11311"},
+			{"addr": "::1"},
+			{"addr": "cache-3:11211-11212", "tags": {"dc": "us1"}, "link_speed": "25G"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("parseConfig returned error: %v", err)
+	}
+	want := []string{"cache-1:11211", "cache-2:11311", "[::1]:11211", "cache-3:11211", "cache-3:11212"}
+	if len(cfg.Servers) != len(want) {
+		t.Fatalf("got %d servers, want %d", len(cfg.Servers), len(want))
+	}
+	for i, srv := range cfg.Servers {
+		if srv.Addr != want[i] {
+			t.Fatalf("server %d addr = %q, want %q", i, srv.Addr, want[i])
+		}
+	}
+	if cfg.GroupBy != "dc" || cfg.Servers[0].Tags["dc"] != "eu1" || cfg.Servers[4].Tags["dc"] != "us1" || cfg.Servers[4].LinkSpeed != "25G" {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestParseConfigRejectsBadInput(t *testing.T) {
+	tests := map[string]string{
+		"unknown field": `{"servers": [{"addr": "a", "tag": {}}]}`,
+		"missing addr":  `{"servers": [{"tags": {"dc": "eu1"}}]}`,
+		"not json":      `servers: [a]`,
+		"bad refresh":   `{"view_refresh": {"slabs": "soon"}}`,
+		"refresh name":  `{"view_refresh": {"summary": "10s"}}`,
+		"bad ports":     `{"servers": [{"addr": "a:11212-11211"}]}`,
+		"bad link":      `{"servers": [{"addr": "a", "link_speed": "fast"}]}`,
+		"bad default":   `{"link_speed": "10GB"}`,
+	}
+	for name, input := range tests {
+		if _, err := parseConfig([]byte(input)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestExpandAddr(t *testing.T) {
+	tests := map[string][]string{
+		"cache":                     {"cache:11211"},
+		"cache:11311":               {"cache:11311"},
+		"cache:11211,11213":         {"cache:11211", "cache:11213"},
+		"cache:11211-11213,11212,9": {"cache:11211", "cache:11212", "cache:11213", "cache:9"},
+		"[::1]:11211-11212":         {"[::1]:11211", "[::1]:11212"},
+	}
+	for spec, want := range tests {
+		got, err := expandAddr(spec)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("expandAddr(%q) = %v, %v; want %v", spec, got, err, want)
+		}
+	}
+
+	for spec, msg := range map[string]string{
+		"cache:11211,x":     "invalid port",
+		"cache:0-3":         "invalid port",
+		"cache:11214-11211": "backwards",
+		"cache:1-1000":      "more than 256 ports",
+	} {
+		if _, err := expandAddr(spec); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("expandAddr(%q) = %v, want an error mentioning %q", spec, err, msg)
+		}
+	}
+}
+
+func TestServerArgs(t *testing.T) {
+	fs := flag.NewFlagSet("memtop", flag.ContinueOnError)
+	conn := addConnFlags(fs)
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"127.0.0.1:11211"}},
+		{[]string{"cache"}, []string{"cache:11211"}},
+		{[]string{"cache", "11311"}, []string{"cache:11311"}},
+		{[]string{"cache", "11211-11212"}, []string{"cache:11211", "cache:11212"}},
+		{[]string{"cache:11211,11213"}, []string{"cache:11211", "cache:11213"}},
+	}
+	for _, tc := range tests {
+		got, err := serverArgs(conn, tc.args)
+		if err != nil || !slices.Equal(got, tc.want) {
+			t.Errorf("serverArgs(%q) = %v, %v; want %v", tc.args, got, err, tc.want)
+		}
+	}
+	for _, args := range [][]string{{"cache", "port"}, {"cache:11211", "11212"}, {"a", "1", "2"}} {
+		if _, err := serverArgs(conn, args); err == nil {
+			t.Errorf("serverArgs(%q) should fail", args)
+		}
+	}
+}
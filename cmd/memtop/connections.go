@@ -0,0 +1,66 @@
+package main
+
+import "fmt"
+
+// Connection usage above these percentages of the limit is highlighted;
+// running out of connections is a classic memcached outage.
+const (
+	connWarnPercent     = 80
+	connCriticalPercent = 90
+)
+
+// connectionLimit is the server's connection limit: maxconns from
+// `stats settings`, or max_connections from the general stats.
+func connectionLimit(s *session) float64 {
+	if s.caps != nil && s.caps.MaxConns > 0 {
+		return s.caps.MaxConns
+	}
+	return s.current.Values["max_connections"]
+}
+
+// renderConnectionsPanel shows connection churn and how close the server is
+// to its connection limit. Rejections and listen_disabled_num mean clients
+// are already being turned away; conn_yields means busy connections are
+// being paused to serve others.
+func renderConnectionsPanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	v := s.current.Values
+	current, limit := v["curr_connections"], connectionLimit(s)
+
+	nf := numberFormat{"connections"}
+	usage := plainLine(fmt.Sprintf("%-18s %s", "current", nf.count("curr_connections", current)))
+	if limit > 0 {
+		percent := current / limit * 100
+		usage.Text = fmt.Sprintf("%-18s %s of %s (%s%%)", "current", nf.count("curr_connections", current),
+			nf.count("max_connections", limit), nf.percent("curr_connections", percent))
+		switch {
+		case percent >= connCriticalPercent:
+			usage.Style = theme.bad.Bold(true)
+		case percent >= connWarnPercent:
+			usage.Style = theme.warn
+		}
+	}
+	lines := []panelLine{usage}
+	if accepting, ok := v["accepting_conns"]; ok && accepting == 0 {
+		lines = append(lines, panelLine{Text: "not accepting new connections", Style: theme.bad.Bold(true)})
+	}
+	for _, c := range []struct{ label, key string }{
+		{"opened", "total_connections"},
+		{"rejected", "rejected_connections"},
+		{"listen disabled", "listen_disabled_num"},
+		{"yields", "conn_yields"},
+	} {
+		if _, ok := v[c.key]; !ok {
+			continue
+		}
+		rate := rateValue(s.rates, c.key)
+		line := plainLine(fmt.Sprintf("%-18s %s", c.label+rateUnit(s.interval), nf.rate(c.key, displayRate(rate, s.interval))))
+		if rate > 0 && c.key != "total_connections" {
+			line.Style = theme.warn
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
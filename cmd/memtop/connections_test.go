@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConnectionsPanel(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if lines := renderConnectionsPanel(sess); lines != nil {
+		t.Fatalf("no panel before the first sample, got %+v", lines)
+	}
+	sess.current = &statsSnapshot{Values: map[string]float64{
+		"curr_connections": 950, "max_connections": 4096, "accepting_conns": 0,
+		"total_connections": 100, "listen_disabled_num": 3, "conn_yields": 0,
+	}}
+	sess.rates = map[string]float64{"total_connections": 25, "listen_disabled_num": 0.5}
+	sess.caps = detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21"}},
+		&statsSnapshot{Values: map[string]float64{"maxconns": 1024}, Raw: map[string]string{}})
+
+	lines := renderConnectionsPanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	want := "current 950 of 1024 (92.8%)|not accepting new connections|opened/s 25.00|listen disabled/s 0.50|yields/s 0.00"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[0].Style != theme.bad.Bold(true) || lines[3].Style != theme.warn || lines[2].Style == theme.warn {
+		t.Fatalf("near the limit and listen_disabled should be highlighted, churn alone should not")
+	}
+
+	sess.caps = nil
+	if line := renderConnectionsPanel(sess)[0].Text; !strings.Contains(line, "of 4096") {
+		t.Fatalf("without settings the limit should fall back to max_connections, got %q", line)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// connOptions are the flags that control how memtop reaches servers. The
+// monitor and every subcommand register the same set.
+type connOptions struct {
+	host          *string
+	port          *int
+	protocol      *string
+	saslUser      *string
+	proxy         *string
+	ssh           *string
+	sshKey        *string
+	sshKnownHosts *string
+	tls           *bool
+	tlsCA         *string
+	tlsCert       *string
+	tlsKey        *string
+	tlsServerName *string
+	tlsInsecure   *bool
+	tcpKeepAlive  *time.Duration
+	tcpNoDelay    *bool
+	sourceAddr    *string
+	maxLineSize   *int
+}
+
+// addConnFlags registers the connection flags on fs.
+func addConnFlags(fs *flag.FlagSet) *connOptions {
+	return &connOptions{
+		host:          fs.String("host", "127.0.0.1", "memcached host"),
+		port:          fs.Int("port", 11211, "memcached port"),
+		protocol:      fs.String("protocol", "ascii", "protocol used for requests: ascii or binary"),
+		saslUser:      fs.String("sasl-user", "", "SASL username for -protocol binary (password from MEMTOP_SASL_PASSWORD)"),
+		proxy:         fs.String("proxy", "", "connect through a `socks5://` or `http://` proxy (default from ALL_PROXY)"),
+		ssh:           fs.String("ssh", "", "reach servers through an SSH tunnel to `user@bastion[:port]`"),
+		sshKey:        fs.String("ssh-key", "", "private key for -ssh (default: ssh-agent and ~/.ssh/id_*)"),
+		sshKnownHosts: fs.String("ssh-known-hosts", "", "known_hosts file used to verify the -ssh host (default ~/.ssh/known_hosts)"),
+		tls:           fs.Bool("tls", false, "connect to servers over TLS (memcached built with -Z / ssl_enabled)"),
+		tlsCA:         fs.String("tls-ca", "", "PEM `file` of the CAs that sign the server certificates (default: the system roots)"),
+		tlsCert:       fs.String("tls-cert", "", "client certificate `file` for servers that require one (with -tls-key)"),
+		tlsKey:        fs.String("tls-key", "", "private key `file` of -tls-cert"),
+		tlsServerName: fs.String("tls-server-name", "", "name to verify the server certificates against (default: each server's host)"),
+		tlsInsecure:   fs.Bool("tls-insecure", false, "skip verifying the server certificates"),
+		tcpKeepAlive:  fs.Duration("tcp-keepalive", 0, "TCP keepalive period of server connections (0 uses Go's default of 15s, negative disables keepalives)"),
+		tcpNoDelay:    fs.Bool("tcp-nodelay", true, "disable Nagle's algorithm on server connections; -tcp-nodelay=false batches small writes"),
+		maxLineSize:   fs.Int("max-line-size", defaultMaxLineSize, "longest reply line read from a server, in bytes; longer lines fail the request with an error instead of being cut short"),
+		sourceAddr:    fs.String("source-addr", "", "local IP `address` to connect to servers from, on multi-homed hosts"),
+	}
+}
+
+// addr returns the server address given by -host and -port.
+func (o *connOptions) addr() string {
+	return net.JoinHostPort(*o.host, strconv.Itoa(*o.port))
+}
+
+// serverArgs returns the servers named by the positional arguments: a host
+// and an optional port, or host:port, where the port may be a list or range
+// expanded by expandAddr. Without arguments it is the -host and -port
+// server.
+func serverArgs(o *connOptions, args []string) ([]string, error) {
+	switch {
+	case len(args) == 0:
+		return []string{o.addr()}, nil
+	case len(args) > 2:
+		return nil, fmt.Errorf("too many arguments: %s", strings.Join(args, " "))
+	}
+	if _, _, err := net.SplitHostPort(args[0]); err == nil {
+		if len(args) > 1 {
+			return nil, fmt.Errorf("port given twice: %s and %s", args[0], args[1])
+		}
+		return expandAddr(args[0])
+	}
+	ports := strconv.Itoa(*o.port)
+	if len(args) > 1 {
+		ports = args[1]
+		if !strings.ContainsAny(ports, ",-") {
+			if _, err := parsePort(ports); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return expandAddr(net.JoinHostPort(strings.Trim(args[0], "[]"), ports))
+}
+
+// apply configures the protocol and dialer from the flags. The returned
+// function closes any SSH tunnel that was opened.
+func (o *connOptions) apply() (func(), error) {
+	if *o.maxLineSize <= 0 {
+		return nil, errors.New("-max-line-size must be positive")
+	}
+	maxLineSize = *o.maxLineSize
+	tcp, err := newTCPDialer(*o.tcpKeepAlive, *o.tcpNoDelay, *o.sourceAddr)
+	if err != nil {
+		return nil, err
+	}
+	serverDialer = tcp
+	statsProtocol, err = parseProtocol(*o.protocol)
+	if err != nil {
+		return nil, err
+	}
+	if *o.saslUser != "" {
+		if statsProtocol != protocolBinary {
+			return nil, errors.New("-sasl-user requires -protocol binary")
+		}
+		saslCredentials = &saslAuth{User: *o.saslUser, Password: os.Getenv("MEMTOP_SASL_PASSWORD")}
+	}
+
+	if *o.tls {
+		if serverTLS, err = newTLSConfig(*o.tlsCA, *o.tlsCert, *o.tlsKey, *o.tlsServerName, *o.tlsInsecure); err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
+	} else if *o.tlsCA != "" || *o.tlsCert != "" || *o.tlsKey != "" || *o.tlsServerName != "" || *o.tlsInsecure {
+		return nil, errors.New("the -tls-* options require -tls")
+	}
+
+	serverDialer, err = proxyDialer(*o.proxy, serverDialer)
+	if err != nil {
+		return nil, err
+	}
+	if *o.ssh == "" {
+		return func() {}, nil
+	}
+	tunnel, err := newSSHTunnel(*o.ssh, *o.sshKey, *o.sshKnownHosts, serverDialer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up ssh tunnel: %w", err)
+	}
+	serverDialer = tunnel
+	return func() { tunnel.Close() }, nil
+}
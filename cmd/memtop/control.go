@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// controlTimeout bounds how long a control request waits for the sampling
+// loop, which may be busy polling slow servers.
+const controlTimeout = 5 * time.Second
+
+// controlRequest is one remote control action. The HTTP handler only queues
+// it; the sampling loop owns the ui state, applies it, and reports back on
+// done.
+type controlRequest struct {
+	action string
+	value  string
+	done   chan error
+}
+
+// serveControl accepts POST /control/<action>?value=... and hands the action
+// to the sampling loop through requests.
+func serveControl(requests chan<- controlRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "control requests must be POST", http.StatusMethodNotAllowed)
+			return
+		}
+		req := controlRequest{
+			action: strings.TrimPrefix(r.URL.Path, "/control/"),
+			value:  r.FormValue("value"),
+			done:   make(chan error, 1),
+		}
+		if req.action == "interval" {
+			// Reject a bad value before it costs the pass in flight.
+			if _, err := parseControlInterval(req.value); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			// Don't make a new interval wait for a pass stuck on a slow
+			// server; the sampling loop picks the request up right away.
+			inflight.abort()
+		}
+		timeout := time.NewTimer(controlTimeout)
+		defer timeout.Stop()
+		select {
+		case requests <- req:
+		case <-timeout.C:
+			http.Error(w, "memtop is not accepting control requests", http.StatusServiceUnavailable)
+			return
+		}
+		var err error
+		select {
+		case err = <-req.done:
+		case <-timeout.C:
+			http.Error(w, "timed out waiting for memtop", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}
+}
+
+// parseControlInterval reads the value of an interval request.
+func parseControlInterval(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid interval %q", value)
+	}
+	if err := validateInterval(d); err != nil {
+		return 0, err
+	}
+	return d, nil
+}
+
+// applyControl carries out one control action and notes it on the event
+// timeline, so a change made from outside the terminal is visible in it.
+func (u *ui) applyControl(action, value string, now time.Time) error {
+	switch action {
+	case "interval":
+		d, err := parseControlInterval(value)
+		if err != nil {
+			return err
+		}
+		u.interval = d
+		for _, s := range u.servers {
+			s.interval = d
+		}
+		u.events.add(event{Time: now, Kind: eventControl, Message: "remote: refresh interval " + d.String()})
+	case "server":
+		i, err := u.findServer(value)
+		if err != nil {
+			return err
+		}
+		u.selected = i
+		u.events.add(event{Time: now, Kind: eventControl, Message: "remote: showing " + u.current().addr})
+	case "pause", "resume":
+		if u.suspended != (action == "pause") {
+			u.toggleSuspend(now)
+		}
+	case "reset":
+		for _, s := range u.servers {
+			s.resetRates()
+		}
+		u.events.add(event{Time: now, Kind: eventControl, Message: "remote: rates reset"})
+	default:
+		return fmt.Errorf("unknown control action %q (want interval, server, pause, resume, or reset)", action)
+	}
+	return nil
+}
+
+// findServer resolves a server by address or by its 1-based position in the
+// server list.
+func (u *ui) findServer(value string) (int, error) {
+	if value == "" {
+		return 0, errors.New("server needs a value")
+	}
+	for i, s := range u.servers {
+		if s.addr == value {
+			return i, nil
+		}
+	}
+	if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= len(u.servers) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("no server %q", value)
+}
+
+// handleControl applies req, moves the sampling ticker to a new interval,
+// and answers the waiting HTTP handler.
+func (u *ui) handleControl(req controlRequest, ticker *time.Ticker) {
+	interval := u.interval
+	err := u.applyControl(req.action, req.value, time.Now())
+	if u.interval != interval {
+		ticker.Reset(u.interval)
+	}
+	req.done <- err
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyControlActions(t *testing.T) {
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second), newSession("b:11211", time.Second))
+	now := time.Unix(1700000000, 0)
+
+	if err := u.applyControl("interval", "5s", now); err != nil {
+		t.Fatalf("interval: %v", err)
+	}
+	if u.interval != 5*time.Second || u.servers[1].interval != 5*time.Second {
+		t.Fatalf("interval = %s / %s, want 5s", u.interval, u.servers[1].interval)
+	}
+	if err := u.applyControl("server", "b:11211", now); err != nil || u.selected != 1 {
+		t.Fatalf("server by address: selected %d, err %v", u.selected, err)
+	}
+	if err := u.applyControl("server", "1", now); err != nil || u.selected != 0 {
+		t.Fatalf("server by position: selected %d, err %v", u.selected, err)
+	}
+	if err := u.applyControl("pause", "", now); err != nil || !u.suspended {
+		t.Fatalf("pause: suspended %v, err %v", u.suspended, err)
+	}
+	// A repeated pause must not flip polling back on.
+	if err := u.applyControl("pause", "", now); err != nil || !u.suspended {
+		t.Fatalf("second pause: suspended %v, err %v", u.suspended, err)
+	}
+	if err := u.applyControl("resume", "", now); err != nil || u.suspended {
+		t.Fatalf("resume: suspended %v, err %v", u.suspended, err)
+	}
+	u.servers[0].rates = map[string]float64{"cmd_get": 10}
+	if err := u.applyControl("reset", "", now); err != nil || len(u.servers[0].rates) != 0 {
+		t.Fatalf("reset: rates %v, err %v", u.servers[0].rates, err)
+	}
+
+	for _, bad := range [][2]string{{"interval", "soon"}, {"interval", "-1s"}, {"server", "c:11211"}, {"server", "3"}, {"reboot", ""}} {
+		if err := u.applyControl(bad[0], bad[1], now); err == nil {
+			t.Errorf("%s %q accepted", bad[0], bad[1])
+		}
+	}
+	if got := u.events.recent(1)[0]; got.Kind != eventControl || got.Message != "remote: rates reset" {
+		t.Fatalf("latest event = %+v", got)
+	}
+}
+
+func TestControlEndpointReachesLoop(t *testing.T) {
+	control := make(chan controlRequest)
+	ln, err := startHTTPServer("127.0.0.1:0", control)
+	if err != nil {
+		t.Fatalf("startHTTPServer: %v", err)
+	}
+	defer ln.Close()
+
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	u.control = control
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	go func() {
+		for req := range control {
+			u.handleControl(req, ticker)
+		}
+	}()
+	defer close(control)
+
+	base := "http://" + ln.Addr().String() + "/control/"
+	resp, err := http.PostForm(base+"interval", url.Values{"value": {"2s"}})
+	if err != nil {
+		t.Fatalf("POST interval: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST interval status %d", resp.StatusCode)
+	}
+
+	resp, err = http.PostForm(base+"interval", url.Values{"value": {"never"}})
+	if err != nil {
+		t.Fatalf("POST bad interval: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "invalid interval") {
+		t.Fatalf("bad interval: status %d body %q", resp.StatusCode, body)
+	}
+
+	resp, err = http.Get(base + "pause")
+	if err != nil {
+		t.Fatalf("GET pause: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("GET pause status %d, want 405", resp.StatusCode)
+	}
+}
+
+func TestRejectedIntervalKeepsPassInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	completed := make(chan bool, 1)
+	go func() {
+		completed <- inflight.run(context.Background(), func(ctx context.Context) {
+			close(started)
+			select {
+			case <-ctx.Done():
+			case <-release:
+			}
+		})
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	serveControl(make(chan controlRequest))(rec, httptest.NewRequest(http.MethodPost, "/control/interval?value=never", nil))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid interval") {
+		t.Fatalf("bad interval: status %d body %q", rec.Code, rec.Body.String())
+	}
+	close(release)
+	if !<-completed {
+		t.Fatalf("a rejected interval should not abort the pass in flight")
+	}
+}
+
+func TestControlEndpointOffWithoutControlFlag(t *testing.T) {
+	ln, err := startHTTPServer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatalf("startHTTPServer: %v", err)
+	}
+	defer ln.Close()
+	resp, err := http.PostForm("http://"+ln.Addr().String()+"/control/pause", nil)
+	if err != nil {
+		t.Fatalf("POST pause: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("POST pause without -control: status %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "fmt"
+
+// cpuWarnPercent of the worker threads' capacity is highlighted as
+// saturation.
+const cpuWarnPercent = 80
+
+// renderCPUPanel turns the rusage counters, which count CPU seconds, into
+// cores busy over the last interval, and relates them to the worker thread
+// count, which bounds how many cores memcached can keep busy.
+func renderCPUPanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	if _, ok := s.current.Values["rusage_user"]; !ok {
+		return []panelLine{plainLine(notSupported)}
+	}
+	if s.elapsed <= 0 {
+		return []panelLine{plainLine("Waiting for two samples...")}
+	}
+	user, system := rateValue(s.rates, "rusage_user"), rateValue(s.rates, "rusage_system")
+	lines := []panelLine{
+		plainLine(fmt.Sprintf("%-8s %6.2f cores", "user", user)),
+		plainLine(fmt.Sprintf("%-8s %6.2f cores", "system", system)),
+	}
+	total := plainLine(fmt.Sprintf("%-8s %6.2f cores", "total", user+system))
+	if threads := s.current.Values["threads"]; threads > 0 {
+		percent := (user + system) / threads * 100
+		total.Text += fmt.Sprintf(" (%.0f%% of %.0f threads)", percent, threads)
+		if percent >= cpuWarnPercent {
+			total.Style = theme.bad.Bold(true)
+		}
+	}
+	return append(lines, total)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCPUPanel(t *testing.T) {
+	start := time.Unix(1000, 0)
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.record(&statsSnapshot{Timestamp: start, Values: map[string]float64{"rusage_user": 100, "rusage_system": 20, "threads": 4}}, nil)
+	if lines := renderCPUPanel(sess); !strings.HasPrefix(lines[0].Text, "Waiting") {
+		t.Fatalf("one sample has no rate, got %+v", lines)
+	}
+	sess.record(&statsSnapshot{Timestamp: start.Add(10 * time.Second), Values: map[string]float64{"rusage_user": 127.5, "rusage_system": 26, "threads": 4}}, nil)
+
+	lines := renderCPUPanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "user 2.75 cores|system 0.60 cores|total 3.35 cores (84% of 4 threads)"; strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[2].Style != theme.bad.Bold(true) {
+		t.Fatalf("over 80%% of the threads should be highlighted")
+	}
+
+	sess.current = &statsSnapshot{Values: map[string]float64{}}
+	if lines := renderCPUPanel(sess); lines[0].Text != notSupported {
+		t.Fatalf("servers without rusage stats should say so, got %+v", lines)
+	}
+}
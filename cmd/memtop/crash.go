@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// finisher is the part of tcell.Screen needed to hand the terminal back.
+type finisher interface {
+	Fini()
+}
+
+// restoreOnPanic is deferred by every goroutine that runs while the screen is
+// active. A panic would otherwise leave the terminal in raw mode (and, in a
+// goroutine other than main, skip main's deferred Fini entirely), so it
+// restores the terminal, optionally writes a crash report, and re-panics so
+// the usual trace is still printed. Poll workers, which come and go with
+// each pass, hand their panics to the pass instead (see pollServers).
+func restoreOnPanic(screen finisher, reportPath string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	crash(screen, reportPath, r)
+}
+
+// crash restores the terminal after the panic r, writes the crash report if
+// asked to, and re-panics.
+func crash(screen finisher, reportPath string, r any) {
+	screen.Fini()
+	if reportPath != "" {
+		if err := writeCrashReport(reportPath, r, debug.Stack()); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write crash report: %v\n", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "crash report written to %s\n", reportPath)
+		}
+	}
+	panic(r)
+}
+
+// panicGuard does restoreOnPanic's job for goroutines that don't hold the
+// screen themselves: background work such as alert hooks, and the -demo
+// servers, which start before the screen exists. main arms it once the
+// screen is up; until then, and for a nil guard, a panic is only re-raised.
+type panicGuard struct {
+	mu         sync.Mutex
+	screen     finisher
+	reportPath string
+}
+
+// arm makes later panics restore screen and write a report to reportPath.
+func (g *panicGuard) arm(screen finisher, reportPath string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.screen, g.reportPath = screen, reportPath
+}
+
+// restore is deferred like restoreOnPanic.
+func (g *panicGuard) restore() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if g == nil {
+		panic(r)
+	}
+	g.mu.Lock()
+	screen, reportPath := g.screen, g.reportPath
+	g.mu.Unlock()
+	if screen == nil {
+		panic(r)
+	}
+	crash(screen, reportPath, r)
+}
+
+// writeCrashReport records a panic with enough context to file a bug.
+func writeCrashReport(path string, value any, stack []byte) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "memtop crash at %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "go: %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&b, "args: %s\n", strings.Join(os.Args, " "))
+	fmt.Fprintf(&b, "panic: %v\n\n%s", value, stack)
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeScreen struct{ finished bool }
+
+func (f *fakeScreen) Fini() { f.finished = true }
+
+func TestRestoreOnPanicFinishesScreenAndRepanics(t *testing.T) {
+	screen := &fakeScreen{}
+	report := filepath.Join(t.TempDir(), "crash.txt")
+
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		defer restoreOnPanic(screen, report)
+		panic("boom")
+	}()
+
+	if recovered != "boom" {
+		t.Fatalf("panic should propagate, recovered %v", recovered)
+	}
+	if !screen.finished {
+		t.Fatalf("screen should be restored before re-panicking")
+	}
+	data, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatalf("read crash report: %v", err)
+	}
+	if text := string(data); !strings.Contains(text, "panic: boom") || !strings.Contains(text, "goroutine") {
+		t.Fatalf("crash report lacks the panic and stack:\n%s", text)
+	}
+}
+
+func TestRestoreOnPanicIgnoresNormalReturn(t *testing.T) {
+	screen := &fakeScreen{}
+	func() {
+		defer restoreOnPanic(screen, "")
+	}()
+	if screen.finished {
+		t.Fatalf("screen should be left alone without a panic")
+	}
+}
+
+func TestPanicGuardRestoresOnceArmed(t *testing.T) {
+	var g panicGuard
+	run := func() (recovered any) {
+		defer func() { recovered = recover() }()
+		defer g.restore()
+		panic("boom")
+	}
+
+	if r := run(); r != "boom" {
+		t.Fatalf("an unarmed guard should re-panic, recovered %v", r)
+	}
+	screen := &fakeScreen{}
+	g.arm(screen, "")
+	if r := run(); r != "boom" || !screen.finished {
+		t.Fatalf("an armed guard should restore the screen and re-panic, recovered %v, finished %v", r, screen.finished)
+	}
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Shape of the synthetic traffic behind -demo.
+const (
+	demoDayLength    = 10 * time.Minute // one compressed day/night cycle
+	demoMaxBytes     = 1 << 30
+	demoMaxConns     = 1024
+	demoStormChance  = 1.0 / 300 // chance per second of an eviction storm starting
+	demoStormMin     = 20 * time.Second
+	demoStormMax     = 45 * time.Second
+	demoVersion      = "1.6.21"
+	demoMetadumpKeys = 500
+	demoItemSize     = 420  // average bytes per item
+	demoFull         = 0.98 // share of memory in use once the cache is warm
+)
+
+// demoNode describes one synthetic server: its tags and how busy it is
+// relative to the others.
+type demoNode struct {
+	tags  map[string]string
+	scale float64
+}
+
+var demoNodes = []demoNode{
+	{map[string]string{"dc": "east", "role": "sessions"}, 1},
+	{map[string]string{"dc": "east", "role": "pages"}, 0.6},
+	{map[string]string{"dc": "west", "role": "sessions"}, 0.8},
+}
+
+// demoSlabClass is one slab class of the synthetic server and the share of
+// the items stored in it.
+type demoSlabClass struct {
+	id        int
+	chunkSize float64
+	share     float64
+}
+
+var demoSlabClasses = []demoSlabClass{
+	{1, 96, 0.05}, {2, 120, 0.08}, {3, 152, 0.12}, {4, 192, 0.15}, {5, 240, 0.14},
+	{6, 304, 0.12}, {7, 384, 0.1}, {8, 480, 0.08}, {9, 600, 0.06}, {10, 752, 0.04},
+	{11, 944, 0.03}, {12, 1184, 0.03},
+}
+
+// demoGenerator fakes one memcached server's counters. Traffic follows a
+// compressed diurnal cycle with some noise; once memory fills up, sets
+// evict, and now and then an eviction storm (a burst of sets that wipes the
+// working set) drops the hit ratio for a while. State advances with wall
+// time whenever a stats reply is built, so any refresh interval works.
+type demoGenerator struct {
+	mu       sync.Mutex
+	rng      *rand.Rand
+	scale    float64
+	started  time.Time
+	last     time.Time
+	pid      int
+	stormEnd time.Time
+	counters map[string]float64
+	bytes    float64
+	conns    float64
+	evicted  map[int]float64
+}
+
+func newDemoGenerator(now time.Time, scale float64, seed int64) *demoGenerator {
+	rng := rand.New(rand.NewSource(seed))
+	g := &demoGenerator{
+		rng:      rng,
+		scale:    scale,
+		started:  now.Add(-time.Duration(rng.Intn(30*86400)) * time.Second),
+		last:     now,
+		pid:      1000 + rng.Intn(30000),
+		counters: make(map[string]float64),
+		evicted:  make(map[int]float64),
+		bytes:    demoMaxBytes * demoFull,
+		conns:    200 * scale,
+	}
+	// Start warm, with a plausible history rather than zeros.
+	age := now.Sub(g.started).Seconds()
+	for key, perSec := range map[string]float64{
+		"cmd_get": 3000, "get_hits": 2700, "get_misses": 300, "cmd_set": 450,
+		"total_items": 450, "total_connections": 5, "bytes_read": 600_000, "bytes_written": 2_000_000,
+		"cmd_touch": 20, "touch_hits": 18, "touch_misses": 2, "delete_hits": 10, "delete_misses": 5,
+		"incr_hits": 30, "incr_misses": 1, "get_expired": 15, "get_flushed": 0,
+	} {
+		g.counters[key] = math.Floor(perSec * scale * age)
+	}
+	return g
+}
+
+// diurnal is the traffic level at t, between 0.3 at night and 1 at midday.
+func (g *demoGenerator) diurnal(t time.Time) float64 {
+	phase := float64(t.Sub(g.started)%demoDayLength) / float64(demoDayLength)
+	return 0.65 - 0.35*math.Cos(2*math.Pi*phase)
+}
+
+// storming reports whether an eviction storm is under way at t.
+func (g *demoGenerator) storming(t time.Time) bool {
+	return t.Before(g.stormEnd)
+}
+
+// advance moves the counters forward to now.
+func (g *demoGenerator) advance(now time.Time) {
+	dt := now.Sub(g.last).Seconds()
+	if dt <= 0 {
+		return
+	}
+	g.last = now
+	if !g.storming(now) && g.rng.Float64() < 1-math.Pow(1-demoStormChance, dt) {
+		g.stormEnd = now.Add(demoStormMin + time.Duration(g.rng.Int63n(int64(demoStormMax-demoStormMin))))
+	}
+	level := g.diurnal(now) * g.scale * (1 + 0.1*g.rng.NormFloat64())
+	if level < 0 {
+		level = 0
+	}
+	gets := 5000 * level * dt
+	hitRatio := 0.9 + 0.03*g.rng.Float64()
+	sets := gets * 0.15
+	newItems := 0.1 // most sets overwrite keys already cached
+	if g.storming(now) {
+		hitRatio = 0.55 + 0.1*g.rng.Float64()
+		sets *= 4
+		newItems = 1
+	}
+	hits := math.Floor(gets * hitRatio)
+	gets = math.Floor(gets)
+	sets = math.Floor(sets)
+	c := g.counters
+	c["cmd_get"] += gets
+	c["get_hits"] += hits
+	c["get_misses"] += gets - hits
+	c["cmd_set"] += sets
+	c["total_items"] += sets
+	c["get_expired"] += math.Floor((gets - hits) * 0.05)
+	c["cmd_touch"] += math.Floor(gets * 0.004)
+	c["touch_hits"] += math.Floor(gets * 0.0036)
+	c["delete_hits"] += math.Floor(sets * 0.02)
+	c["incr_hits"] += math.Floor(gets * 0.006)
+	c["bytes_read"] += math.Floor(sets*(demoItemSize+40) + gets*30)
+	c["bytes_written"] += math.Floor(hits*(demoItemSize+20) + (gets-hits)*5 + sets*8)
+
+	// Memory fills up with new items; past the limit every new byte evicts.
+	g.bytes += sets * newItems * demoItemSize
+	if over := g.bytes - demoMaxBytes*demoFull; over > 0 {
+		g.bytes -= over
+		evictions := math.Floor(over / demoItemSize)
+		c["evictions"] += evictions
+		for _, class := range demoSlabClasses {
+			g.evicted[class.id] += math.Floor(evictions * class.share)
+		}
+	}
+
+	target := 200 * g.scale * (0.5 + g.diurnal(now))
+	g.conns += (target - g.conns) * math.Min(dt/10, 1)
+	c["total_connections"] += math.Floor(g.rng.Float64() * 5 * dt)
+	c["rusage_user"] += dt * 0.05 * level
+	c["rusage_system"] += dt * 0.03 * level
+}
+
+// stats renders the general stats reply.
+func (g *demoGenerator) stats(now time.Time) [][2]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.advance(now)
+	items := math.Floor(g.bytes / demoItemSize)
+	stats := [][2]string{
+		{"pid", fmt.Sprint(g.pid)},
+		{"uptime", fmt.Sprint(int64(now.Sub(g.started).Seconds()))},
+		{"time", fmt.Sprint(now.Unix())},
+		{"version", demoVersion},
+		{"libevent", "2.1.12-stable"},
+		{"pointer_size", "64"},
+		{"rusage_user", fmt.Sprintf("%.6f", g.counters["rusage_user"])},
+		{"rusage_system", fmt.Sprintf("%.6f", g.counters["rusage_system"])},
+		{"max_connections", fmt.Sprint(demoMaxConns)},
+		{"curr_connections", fmt.Sprint(int64(g.conns))},
+		{"total_connections", fmt.Sprint(int64(g.counters["total_connections"]))},
+		{"rejected_connections", "0"},
+		{"connection_structures", fmt.Sprint(int64(g.conns) + 5)},
+		{"conn_yields", "0"},
+		{"listen_disabled_num", "0"},
+		{"threads", "4"},
+		{"accepting_conns", "1"},
+	}
+	for _, key := range []string{
+		"cmd_get", "cmd_set", "cmd_touch", "get_hits", "get_misses", "get_expired", "get_flushed",
+		"delete_hits", "delete_misses", "incr_hits", "incr_misses", "touch_hits", "touch_misses",
+		"bytes_read", "bytes_written", "evictions", "total_items",
+	} {
+		stats = append(stats, [2]string{key, fmt.Sprint(int64(g.counters[key]))})
+	}
+	return append(stats,
+		[2]string{"cmd_flush", "0"},
+		[2]string{"limit_maxbytes", fmt.Sprint(demoMaxBytes)},
+		[2]string{"bytes", fmt.Sprint(int64(g.bytes))},
+		[2]string{"curr_items", fmt.Sprint(int64(items))},
+		[2]string{"expired_unfetched", fmt.Sprint(int64(g.counters["get_expired"] / 3))},
+		[2]string{"evicted_unfetched", fmt.Sprint(int64(g.counters["evictions"] / 4))},
+		[2]string{"reclaimed", fmt.Sprint(int64(g.counters["get_expired"] * 2))},
+		[2]string{"hash_power_level", "22"},
+		[2]string{"hash_bytes", "33554432"},
+		[2]string{"slabs_moved", "0"},
+		[2]string{"lru_crawler_starts", fmt.Sprint(int64(now.Sub(g.started).Seconds() / 60))},
+		[2]string{"lru_maintainer_juggles", fmt.Sprint(int64(g.counters["cmd_set"] / 2))},
+	)
+}
+
+// settings renders the `stats settings` reply.
+func (g *demoGenerator) settings() [][2]string {
+	return [][2]string{
+		{"maxbytes", fmt.Sprint(demoMaxBytes)},
+		{"maxconns", fmt.Sprint(demoMaxConns)},
+		{"tcpport", "11211"},
+		{"evictions", "on"},
+		{"growth_factor", "1.25"},
+		{"chunk_size", "48"},
+		{"num_threads", "4"},
+		{"item_size_max", "1048576"},
+		{"slab_reassign", "yes"},
+		{"slab_automove", "1"},
+		{"lru_crawler", "yes"},
+		{"lru_maintainer_thread", "yes"},
+	}
+}
+
+// slabs renders the `stats slabs` reply.
+func (g *demoGenerator) slabs(now time.Time) [][2]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.advance(now)
+	var stats [][2]string
+	var malloced float64
+	for _, class := range demoSlabClasses {
+		perPage := math.Floor(1048576 / class.chunkSize)
+		used := math.Floor(g.bytes * class.share / class.chunkSize)
+		pages := math.Ceil(used / perPage)
+		total := pages * perPage
+		malloced += pages * 1048576
+		id := class.id
+		stats = append(stats,
+			[2]string{fmt.Sprintf("%d:chunk_size", id), fmt.Sprint(class.chunkSize)},
+			[2]string{fmt.Sprintf("%d:chunks_per_page", id), fmt.Sprint(perPage)},
+			[2]string{fmt.Sprintf("%d:total_pages", id), fmt.Sprint(pages)},
+			[2]string{fmt.Sprintf("%d:total_chunks", id), fmt.Sprint(total)},
+			[2]string{fmt.Sprintf("%d:used_chunks", id), fmt.Sprint(used)},
+			[2]string{fmt.Sprintf("%d:free_chunks", id), fmt.Sprint(total - used)},
+		)
+	}
+	return append(stats,
+		[2]string{"active_slabs", fmt.Sprint(len(demoSlabClasses))},
+		[2]string{"total_malloced", fmt.Sprint(int64(malloced))},
+	)
+}
+
+// items renders the `stats items` reply.
+func (g *demoGenerator) items(now time.Time) [][2]string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.advance(now)
+	var stats [][2]string
+	for _, class := range demoSlabClasses {
+		prefix := fmt.Sprintf("items:%d:", class.id)
+		stats = append(stats,
+			[2]string{prefix + "number", fmt.Sprint(int64(g.bytes * class.share / class.chunkSize))},
+			[2]string{prefix + "age", fmt.Sprint(3600 + class.id*600)},
+			[2]string{prefix + "evicted", fmt.Sprint(int64(g.evicted[class.id]))},
+			[2]string{prefix + "evicted_nonzero", fmt.Sprint(int64(g.evicted[class.id] / 2))},
+			[2]string{prefix + "outofmemory", "0"},
+		)
+	}
+	return stats
+}
+
+// metadump renders `lru_crawler metadump all` lines for a sample of keys
+// with a spread of TTLs.
+func (g *demoGenerator) metadump(now time.Time) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	rng := rand.New(rand.NewSource(int64(g.pid)))
+	ttls := []int64{-1, 30, 300, 1800, 7200, 86400 * 2}
+	lines := make([]string, 0, demoMetadumpKeys)
+	for i := 0; i < demoMetadumpKeys; i++ {
+		class := demoSlabClasses[rng.Intn(len(demoSlabClasses))]
+		exp := int64(-1)
+		if ttl := ttls[rng.Intn(len(ttls))]; ttl > 0 {
+			exp = now.Unix() + rng.Int63n(ttl) + 1
+		}
+		fetch := "no"
+		if rng.Intn(3) > 0 {
+			fetch = "yes"
+		}
+		key := url.QueryEscape(fmt.Sprintf("user:%d:session", rng.Intn(1_000_000)))
+		lines = append(lines, fmt.Sprintf("key=%s exp=%d la=%d cas=%d fetch=%s cls=%d size=%d",
+			key, exp, now.Unix()-rng.Int63n(3600), i+1, fetch, class.id, int(class.chunkSize)-rng.Intn(20)))
+	}
+	return lines
+}
+
+// demoServer answers the memcached text protocol commands memtop sends from
+// a demoGenerator, on a loopback port.
+type demoServer struct {
+	gen    *demoGenerator
+	ln     net.Listener
+	panics *panicGuard
+}
+
+// startDemoServers starts one server per demo node and returns their
+// configs for the monitor, and a function stopping them. The servers run for
+// the whole session, so their goroutines defer panics' restore.
+func startDemoServers(panics *panicGuard) ([]serverConfig, func(), error) {
+	var servers []serverConfig
+	var running []*demoServer
+	stop := func() {
+		for _, s := range running {
+			s.ln.Close()
+		}
+	}
+	now := time.Now()
+	for i, node := range demoNodes {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			stop()
+			return nil, nil, fmt.Errorf("demo server: %w", err)
+		}
+		s := &demoServer{gen: newDemoGenerator(now, node.scale, now.UnixNano()+int64(i)), ln: ln, panics: panics}
+		running = append(running, s)
+		go s.serve()
+		servers = append(servers, serverConfig{Addr: ln.Addr().String(), Tags: node.tags})
+	}
+	return servers, stop, nil
+}
+
+func (s *demoServer) serve() {
+	defer s.panics.restore()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *demoServer) handle(conn net.Conn) {
+	defer s.panics.restore()
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if !s.reply(w, fields) {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// reply writes the answer to one command and reports whether the connection
+// stays open.
+func (s *demoServer) reply(w *bufio.Writer, fields []string) bool {
+	now := time.Now()
+	switch fields[0] {
+	case "stats":
+		section := ""
+		if len(fields) > 1 {
+			section = fields[1]
+		}
+		var stats [][2]string
+		switch section {
+		case "":
+			stats = s.gen.stats(now)
+		case "settings":
+			stats = s.gen.settings()
+		case "slabs":
+			stats = s.gen.slabs(now)
+		case "items":
+			stats = s.gen.items(now)
+		default:
+			w.WriteString("ERROR\r\n")
+			return true
+		}
+		for _, kv := range stats {
+			fmt.Fprintf(w, "STAT %s %s\r\n", kv[0], kv[1])
+		}
+		w.WriteString("END\r\n")
+	case "lru_crawler":
+		if len(fields) < 2 || fields[1] != "metadump" {
+			w.WriteString("ERROR\r\n")
+			return true
+		}
+		for _, line := range s.gen.metadump(now) {
+			w.WriteString(line + "\r\n")
+		}
+		w.WriteString("END\r\n")
+	case "mg":
+		w.WriteString("EN\r\n")
+	case "version":
+		w.WriteString("VERSION " + demoVersion + "\r\n")
+	case "quit":
+		return false
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+	return true
+}
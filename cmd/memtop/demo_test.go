@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDemoGeneratorCountersOnlyGrow(t *testing.T) {
+	now := time.Now()
+	g := newDemoGenerator(now, 1, 1)
+	prev := newStatsSnapshot(now, statsMap(g.stats(now)))
+	for i := 1; i <= 50; i++ {
+		now = now.Add(2 * time.Second)
+		next := newStatsSnapshot(now, statsMap(g.stats(now)))
+		for _, key := range []string{"cmd_get", "get_hits", "cmd_set", "evictions", "total_items", "bytes_read"} {
+			if next.Values[key] < prev.Values[key] {
+				t.Fatalf("%s went backwards: %.0f -> %.0f", key, prev.Values[key], next.Values[key])
+			}
+		}
+		if next.Values["bytes"] > next.Values["limit_maxbytes"] {
+			t.Fatalf("bytes %.0f over the limit", next.Values["bytes"])
+		}
+		prev = next
+	}
+}
+
+func TestDemoGeneratorStormsDropTheHitRatioAndEvict(t *testing.T) {
+	now := time.Now()
+	g := newDemoGenerator(now, 1, 1)
+	g.stormEnd = time.Time{}
+	before := g.counters["evictions"]
+	g.advance(now.Add(time.Second))
+	calm := g.counters["evictions"] - before
+
+	g.stormEnd = now.Add(time.Hour)
+	gets, hits, evictions := g.counters["cmd_get"], g.counters["get_hits"], g.counters["evictions"]
+	g.advance(now.Add(2 * time.Second))
+	ratio := (g.counters["get_hits"] - hits) / (g.counters["cmd_get"] - gets)
+	if ratio > 0.7 {
+		t.Fatalf("hit ratio during a storm = %.2f, want it to drop", ratio)
+	}
+	if stormed := g.counters["evictions"] - evictions; stormed <= calm*5 {
+		t.Fatalf("storm evicted %.0f/s, calm %.0f/s", stormed, calm)
+	}
+}
+
+func TestDemoGeneratorDiurnalCycle(t *testing.T) {
+	g := newDemoGenerator(time.Now(), 1, 1)
+	night, noon := g.diurnal(g.started), g.diurnal(g.started.Add(demoDayLength/2))
+	if night > 0.31 || noon < 0.99 {
+		t.Fatalf("diurnal level night %.2f, midday %.2f", night, noon)
+	}
+}
+
+func TestDemoServersAnswerTheMonitor(t *testing.T) {
+	servers, stop, err := startDemoServers(nil)
+	if err != nil {
+		t.Fatalf("startDemoServers: %v", err)
+	}
+	defer stop()
+	if len(servers) != len(demoNodes) || servers[0].Tags["dc"] == "" {
+		t.Fatalf("servers = %+v", servers)
+	}
+	ctx := context.Background()
+	stats, err := fetchStats(ctx, servers[0].Addr)
+	if err != nil {
+		t.Fatalf("fetchStats: %v", err)
+	}
+	if stats.Raw["version"] != demoVersion || stats.Values["limit_maxbytes"] != demoMaxBytes {
+		t.Fatalf("stats = %v", stats.Raw)
+	}
+	slabs, err := fetchSlabs(ctx, servers[0].Addr)
+	if err != nil {
+		t.Fatalf("fetchSlabs: %v", err)
+	}
+	if classes := parseSlabClasses(slabs, nil); len(classes) != len(demoSlabClasses) {
+		t.Fatalf("got %d slab classes, want %d", len(classes), len(demoSlabClasses))
+	}
+	var keys int
+	if _, err := fetchMetadump(ctx, servers[0].Addr, 0, func(metadumpEntry) { keys++ }); err != nil || keys != demoMetadumpKeys {
+		t.Fatalf("metadump gave %d keys, err %v", keys, err)
+	}
+}
+
+// statsMap turns a demo reply into the raw stats map fetchStats builds.
+func statsMap(stats [][2]string) map[string]string {
+	m := make(map[string]string, len(stats))
+	for _, kv := range stats {
+		m[kv[0]] = kv[1]
+	}
+	return m
+}
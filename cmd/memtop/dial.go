@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// dialer opens raw connections to servers. It matches the Dial method of
+// net.Dialer and ssh.Client so tunnels can stand in for direct dialing.
+type dialer interface {
+	Dial(network, addr string) (net.Conn, error)
+}
+
+// serverDialer is used for every server connection. main replaces it when a
+// tunnel is configured.
+var serverDialer dialer = &net.Dialer{Timeout: defaultTimeout}
+
+// tcpDialer dials servers directly with the -tcp-* options: the keepalive
+// period (negative disables keepalives), Nagle's algorithm, and the local
+// address to dial from on multi-homed hosts. Through -proxy or -ssh they
+// apply to the connection to the proxy or bastion.
+type tcpDialer struct {
+	net.Dialer
+	noDelay bool
+}
+
+// newTCPDialer validates the options. source is an IP address, or empty to
+// let the system choose.
+func newTCPDialer(keepAlive time.Duration, noDelay bool, source string) (*tcpDialer, error) {
+	d := &tcpDialer{Dialer: net.Dialer{Timeout: defaultTimeout, KeepAlive: keepAlive}, noDelay: noDelay}
+	if source != "" {
+		ip := net.ParseIP(source)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid -source-addr %q: not an IP address", source)
+		}
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return d, nil
+}
+
+// Dial dials without a context.
+func (d *tcpDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext dials and applies -tcp-nodelay; Go enables TCP_NODELAY by
+// default.
+func (d *tcpDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := d.Dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+	if tc, ok := conn.(*net.TCPConn); ok && !d.noDelay {
+		if err := tc.SetNoDelay(false); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// contextDialer is implemented by dialers that can abandon a connection
+// attempt, such as net.Dialer and the SOCKS dialer.
+type contextDialer interface {
+	DialContext(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// dialContext dials through serverDialer, cancelling the attempt with ctx
+// when the dialer supports it and checking ctx afterwards when it doesn't.
+func dialContext(ctx context.Context, addr string) (net.Conn, error) {
+	if d, ok := serverDialer.(contextDialer); ok {
+		return d.DialContext(ctx, "tcp", addr)
+	}
+	conn, err := serverDialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// contextConn closes its connection as soon as ctx is cancelled, which
+// unblocks any read or write in progress instead of leaving it to run into
+// the request deadline.
+type contextConn struct {
+	net.Conn
+	stop func() bool
+}
+
+// watchContext ties conn's lifetime to ctx.
+func watchContext(ctx context.Context, conn net.Conn) net.Conn {
+	return &contextConn{Conn: conn, stop: context.AfterFunc(ctx, func() { conn.Close() })}
+}
+
+// Close stops watching the context before closing the connection.
+func (c *contextConn) Close() error {
+	c.stop()
+	return c.Conn.Close()
+}
+
+// deadlineConn emulates deadlines on connections that don't support them,
+// such as SSH channels, by closing the connection once the deadline passes.
+// memtop only uses deadlines as an overall request timeout, so failing the
+// whole connection is enough.
+type deadlineConn struct {
+	net.Conn
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+// SetDeadline arms (or, for the zero time, disarms) the close timer.
+func (c *deadlineConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+	if !t.IsZero() {
+		c.timer = time.AfterFunc(time.Until(t), func() { c.Conn.Close() })
+	}
+	return nil
+}
+
+// SetReadDeadline behaves like SetDeadline.
+func (c *deadlineConn) SetReadDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+// SetWriteDeadline behaves like SetDeadline.
+func (c *deadlineConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+// Close stops the timer before closing the underlying connection.
+func (c *deadlineConn) Close() error {
+	c.SetDeadline(time.Time{})
+	return c.Conn.Close()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestTCPDialerAppliesOptions(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	d, err := newTCPDialer(30*time.Second, false, "127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.KeepAlive != 30*time.Second {
+		t.Fatalf("keepalive = %s", d.KeepAlive)
+	}
+	conn, err := d.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if ip := conn.LocalAddr().(*net.TCPAddr).IP; !ip.Equal(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("dialed from %s, want 127.0.0.1", ip)
+	}
+
+	if _, err := newTCPDialer(0, true, "eth0"); err == nil {
+		t.Fatalf("a source address that is not an IP should be rejected")
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// defaultDiffLimit is how many changed stats `memtop diff` prints by default.
+const defaultDiffLimit = 20
+
+// statChange is one stat's difference between two snapshots.
+type statChange struct {
+	Key     string
+	A, B    float64
+	Delta   float64
+	Percent float64 // +Inf for a stat that grew from zero
+}
+
+// diffSnapshots lists the stats present in both snapshots whose value
+// changed, and how many stats only one of them has.
+func diffSnapshots(a, b map[string]float64) (changes []statChange, onlyA, onlyB int) {
+	for key, av := range a {
+		bv, ok := b[key]
+		if !ok {
+			onlyA++
+			continue
+		}
+		if av != bv {
+			changes = append(changes, statChange{Key: key, A: av, B: bv, Delta: bv - av, Percent: percentChange(av, bv)})
+		}
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			onlyB++
+		}
+	}
+	return changes, onlyA, onlyB
+}
+
+// sortChanges orders changes by the size of their percentage or absolute
+// change, largest first, breaking ties by the other measure and then name.
+func sortChanges(changes []statChange, byPercent bool) {
+	sort.Slice(changes, func(i, j int) bool {
+		pi, pj := math.Abs(changes[i].Percent), math.Abs(changes[j].Percent)
+		ai, aj := math.Abs(changes[i].Delta), math.Abs(changes[j].Delta)
+		if !byPercent {
+			pi, pj, ai, aj = ai, aj, pi, pj
+		}
+		switch {
+		case pi != pj:
+			return pi > pj
+		case ai != aj:
+			return ai > aj
+		}
+		return statsKeyLess(changes[i].Key, changes[j].Key)
+	})
+}
+
+// runDiff implements `memtop diff A.json B.json`, for post-incident analysis
+// and for checking what a change did.
+func runDiff(args []string, std streams) int {
+	fs := newOfflineFlags("diff", std)
+	order := fs.String("sort", "percent", "order by percent or absolute change")
+	limit := fs.Int("limit", defaultDiffLimit, "print at most this many stats (0 for all)")
+	rest, ok := parseCommandArgs(fs, args, 2)
+	if !ok {
+		return 2
+	}
+	if *order != "percent" && *order != "absolute" {
+		fmt.Fprintf(std.Err, "unknown sort %q (want percent or absolute)\n", *order)
+		return 2
+	}
+	if *limit < 0 {
+		fmt.Fprintln(std.Err, "-limit must not be negative")
+		return 2
+	}
+	a, err := loadSnapshotFile(rest[0])
+	if err != nil {
+		fmt.Fprintf(std.Err, "diff: %v\n", err)
+		return 1
+	}
+	b, err := loadSnapshotFile(rest[1])
+	if err != nil {
+		fmt.Fprintf(std.Err, "diff: %v\n", err)
+		return 1
+	}
+
+	changes, onlyA, onlyB := diffSnapshots(a, b)
+	sortChanges(changes, *order == "percent")
+	if *limit > 0 && len(changes) > *limit {
+		changes = changes[:*limit]
+	}
+	if err := writeChanges(std.Out, changes); err != nil {
+		fmt.Fprintf(std.Err, "diff: %v\n", err)
+		return 1
+	}
+	if onlyA > 0 || onlyB > 0 {
+		fmt.Fprintf(std.Out, "\n%d stats only in %s, %d only in %s\n", onlyA, rest[0], onlyB, rest[1])
+	}
+	return 0
+}
+
+// writeChanges prints the changes as a table.
+func writeChanges(w io.Writer, changes []statChange) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintln(w, "no stats changed")
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%-32s %16s %16s %16s %9s\n", "Metric", "A", "B", "Change", "Change%"); err != nil {
+		return err
+	}
+	for _, c := range changes {
+		delta := strconv.FormatFloat(c.Delta, 'f', -1, 64)
+		if c.Delta > 0 {
+			delta = "+" + delta
+		}
+		if _, err := fmt.Fprintf(w, "%-32s %16s %16s %16s %9s\n", c.Key,
+			strconv.FormatFloat(c.A, 'f', -1, 64), strconv.FormatFloat(c.B, 'f', -1, 64), delta, formatChange(c.Percent)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
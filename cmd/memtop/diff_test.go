@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runDiffCommand runs `memtop diff`, which takes no connection flags.
+func runDiffCommand(args ...string) (int, string, string) {
+	var out, errOut bytes.Buffer
+	code := runDiff(args, streams{Out: &out, Err: &errOut})
+	return code, out.String(), errOut.String()
+}
+
+func TestDiffSubcommand(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	os.WriteFile(a, []byte(`{"cmd_get": 1000, "evictions": 10, "curr_items": 50, "pid": 7, "old_stat": 1}`), 0o644)
+	os.WriteFile(b, []byte(`{"cmd_get": 1500, "evictions": 40, "curr_items": 50, "pid": 7, "auth_errors": 0, "conn_yields": 2}`), 0o644)
+
+	code, out, errOut := runDiffCommand(a, b)
+	if code != 0 {
+		t.Fatalf("diff exited %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	var rows []string
+	for _, l := range lines[1:3] {
+		rows = append(rows, strings.Join(strings.Fields(l), " "))
+	}
+	if want := "evictions 10 40 +30 +300.0%|cmd_get 1000 1500 +500 +50.0%"; strings.Join(rows, "|") != want {
+		t.Fatalf("rows by percent = %q, want %q\n%s", strings.Join(rows, "|"), want, out)
+	}
+	if !strings.HasSuffix(out, "1 stats only in "+a+", 2 only in "+b+"\n") {
+		t.Fatalf("missing stats summary:\n%s", out)
+	}
+
+	code, out, _ = runDiffCommand("-sort", "absolute", "-limit", "1", a, b)
+	if lines := strings.Split(out, "\n"); code != 0 || !strings.HasPrefix(lines[1], "cmd_get") || strings.HasPrefix(lines[2], "evictions") {
+		t.Fatalf("absolute order with a limit = %d\n%s", code, out)
+	}
+
+	if code, _, _ := runDiffCommand(a); code != 2 {
+		t.Fatalf("one file exited %d, want 2", code)
+	}
+	if code, _, _ := runDiffCommand(a, filepath.Join(dir, "missing.json")); code != 1 {
+		t.Fatalf("a missing file exited %d, want 1", code)
+	}
+}
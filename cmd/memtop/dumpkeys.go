@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// defaultDumpRate keeps a full key dump from competing with production
+// traffic; the crawler only advances as fast as memtop reads.
+const defaultDumpRate = 10000
+
+// keyDumpWriter formats metadump entries for dump-keys.
+type keyDumpWriter interface {
+	Write(metadumpEntry) error
+}
+
+type jsonKeyWriter struct{ enc *json.Encoder }
+
+func (w jsonKeyWriter) Write(e metadumpEntry) error { return w.enc.Encode(e) }
+
+type tsvKeyWriter struct{ w io.Writer }
+
+func (w tsvKeyWriter) Write(e metadumpEntry) error {
+	_, err := fmt.Fprintf(w.w, "%s\t%d\t%d\t%d\t%d\t%t\n", e.Key, e.Exp, e.LastAccess, e.Class, e.Size, e.Fetched)
+	return err
+}
+
+// newKeyDumpWriter returns the writer for -format, writing the TSV header
+// straight away.
+func newKeyDumpWriter(format string, w io.Writer) (keyDumpWriter, error) {
+	switch format {
+	case "jsonl", "json":
+		return jsonKeyWriter{json.NewEncoder(w)}, nil
+	case "tsv":
+		_, err := fmt.Fprintln(w, "key\texp\tla\tcls\tsize\tfetch")
+		return tsvKeyWriter{w}, err
+	}
+	return nil, fmt.Errorf("unknown format %q (want jsonl or tsv)", format)
+}
+
+// pacer spaces out work to at most rate operations per second.
+type pacer struct {
+	rate  float64
+	start time.Time
+	n     int
+}
+
+// wait blocks until the next operation is due, reporting whether it slept.
+func (p *pacer) wait() bool {
+	if p.rate <= 0 {
+		return false
+	}
+	if p.n == 0 {
+		p.start = time.Now()
+	}
+	p.n++
+	due := p.start.Add(time.Duration(float64(p.n) / p.rate * float64(time.Second)))
+	if d := time.Until(due); d > 0 {
+		time.Sleep(d)
+		return true
+	}
+	return false
+}
+
+// runDumpKeys implements `memtop dump-keys`, streaming the key space from
+// `lru_crawler metadump` for offline analysis.
+func runDumpKeys(args []string, std streams) int {
+	fs, conn := newCommandFlags("dump-keys", std)
+	prefix := fs.String("prefix", "", "only dump keys starting with this prefix")
+	limit := fs.Int("limit", 0, "stop after this many keys (0 for all)")
+	format := fs.String("format", "jsonl", "output format: jsonl or tsv")
+	rate := fs.Float64("rate", defaultDumpRate, "maximum keys read per second (0 for unlimited)")
+	if _, ok := parseCommandArgs(fs, args, 0); !ok {
+		return 2
+	}
+	out := bufio.NewWriter(std.Out)
+	defer out.Flush()
+	w, err := newKeyDumpWriter(*format, out)
+	if err != nil {
+		fmt.Fprintln(std.Err, err)
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+
+	pace := &pacer{rate: *rate}
+	count := 0
+	var writeErr error
+	err = streamMetadump(context.Background(), conn.addr(), func(e metadumpEntry) bool {
+		if pace.wait() {
+			out.Flush()
+		}
+		if !strings.HasPrefix(e.Key, *prefix) {
+			return true
+		}
+		if writeErr = w.Write(e); writeErr != nil {
+			return false
+		}
+		count++
+		return *limit <= 0 || count < *limit
+	})
+	if err == nil {
+		err = writeErr
+	}
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		fmt.Fprintf(std.Err, "dump-keys: %v\n", err)
+		return 1
+	}
+	return 0
+}
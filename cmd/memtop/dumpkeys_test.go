@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+// startMetadumpServer answers every `lru_crawler metadump all` with lines.
+func startMetadumpServer(lines ...string) *memstatstest.Server {
+	srv := memstatstest.NewServer()
+	srv.SetReply("lru_crawler metadump all", strings.Join(lines, "\r\n")+"\r\nEND\r\n")
+	return srv
+}
+
+func TestDumpKeysFiltersAndLimits(t *testing.T) {
+	srv := startMetadumpServer(
+		"key=user%3A1 exp=-1 la=100 cas=1 fetch=yes cls=1 size=64",
+		"key=session%3A1 exp=2000 la=101 cas=2 fetch=no cls=2 size=128",
+		"key=user%3A2 exp=3000 la=102 cas=3 fetch=no cls=1 size=70",
+		"key=user%3A3 exp=-1 la=103 cas=4 fetch=no cls=1 size=80",
+	)
+	defer srv.Close()
+
+	code, out, errOut := runCommand(t, srv.Addr(), "", "dump-keys", "-prefix", "user:", "-limit", "2", "-rate", "0")
+	if code != 0 {
+		t.Fatalf("dump-keys exited %d: %s", code, errOut)
+	}
+	want := `{"key":"user:1","exp":-1,"la":100,"cls":1,"size":64,"fetch":true}
+{"key":"user:2","exp":3000,"la":102,"cls":1,"size":70,"fetch":false}
+`
+	if out != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+
+	code, out, _ = runCommand(t, srv.Addr(), "", "dump-keys", "-format", "tsv", "-prefix", "session:")
+	if code != 0 || out != "key\texp\tla\tcls\tsize\tfetch\nsession:1\t2000\t101\t2\t128\tfalse\n" {
+		t.Fatalf("tsv dump = %d %q", code, out)
+	}
+}
+
+func TestDumpKeysRejectsUnknownFormat(t *testing.T) {
+	code, _, errOut := runCommand(t, "127.0.0.1:1", "", "dump-keys", "-format", "xml")
+	if code != 2 || !strings.Contains(errOut, "unknown format") {
+		t.Fatalf("dump-keys -format xml = %d %q", code, errOut)
+	}
+}
+
+func TestPacerLimitsRate(t *testing.T) {
+	p := &pacer{rate: 100}
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		p.wait()
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Fatalf("4 operations at 100/s took %v, want at least 30ms", elapsed)
+	}
+	if (&pacer{}).wait() {
+		t.Fatalf("a zero rate should never sleep")
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// defaultErrorLimit bounds the in-app error log.
+const defaultErrorLimit = 500
+
+// Levels of error log entries.
+const (
+	levelError = "error"
+	levelWarn  = "warn"
+)
+
+// logEntry is one error or warning, such as a failed poll or a stats reply
+// that made no sense. Repeats of the newest entry are folded into it, so a
+// server that stays down is one entry with a count rather than one per poll.
+type logEntry struct {
+	Time    time.Time // first occurrence
+	Last    time.Time // latest repeat
+	Count   int
+	Level   string
+	Server  string
+	Source  string // what failed: "stats", a collector's name, "script", ...
+	Message string
+}
+
+// String formats an entry the same way on screen and in the log file.
+func (e logEntry) String() string {
+	subject := e.Source + ": " + e.Message
+	if e.Server != "" {
+		subject = e.Server + ": " + subject
+	}
+	return fmt.Sprintf("%s  %-5s  %s", e.Time.In(displayLocation).Format(defaultTimeFormat), e.Level, subject)
+}
+
+// errorLog keeps recent errors and warnings from every server in memory and
+// optionally writes each occurrence to a file (-log).
+type errorLog struct {
+	mu      sync.Mutex // servers are polled concurrently
+	limit   int
+	entries []logEntry
+	out     io.Writer
+}
+
+// newErrorLog creates a log holding at most limit entries; out may be nil.
+func newErrorLog(limit int, out io.Writer) *errorLog {
+	if limit <= 0 {
+		limit = defaultErrorLimit
+	}
+	return &errorLog{limit: limit, out: out}
+}
+
+// add records an entry, folding it into the newest one if it repeats it. A
+// failing file is dropped; the failure itself is the last thing logged.
+func (l *errorLog) add(e logEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	e.Last = e.Time
+	if n := len(l.entries); n > 0 {
+		last := &l.entries[n-1]
+		if last.Level == e.Level && last.Server == e.Server && last.Source == e.Source && last.Message == e.Message {
+			last.Count++
+			last.Last = e.Time
+			l.write(e)
+			return
+		}
+	}
+	l.entries = appendBounded(l.entries, e, l.limit)
+	l.write(e)
+}
+
+// write appends e to the log file, if any.
+func (l *errorLog) write(e logEntry) {
+	if l.out == nil {
+		return
+	}
+	if _, err := fmt.Fprintln(l.out, e.String()); err != nil {
+		l.out = nil
+		l.entries = appendBounded(l.entries, logEntry{Time: e.Time, Last: e.Time, Count: 1, Level: levelError, Source: "log", Message: fmt.Sprintf("log file disabled: %v", err)}, l.limit)
+	}
+}
+
+// recent returns up to n of the newest entries, newest first.
+func (l *errorLog) recent(n int) []logEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]logEntry, 0, min(n, len(l.entries)))
+	for i := len(l.entries) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, l.entries[i])
+	}
+	return out
+}
+
+// logError records a failure attributed to this server.
+func (s *session) logError(source string, err error) {
+	s.errors.add(logEntry{Time: time.Now(), Level: levelError, Server: s.addr, Source: source, Message: err.Error()})
+}
+
+// logWarning records something odd about this server that isn't a failure.
+func (s *session) logWarning(source, message string) {
+	s.errors.add(logEntry{Time: time.Now(), Level: levelWarn, Server: s.addr, Source: source, Message: message})
+}
+
+// drawLogView lists the error log from the given row, newest first, for the
+// whole fleet. Arrow and paging keys scroll it.
+func drawLogView(screen tcell.Screen, line int, u *ui) {
+	_, height := screen.Size()
+	entries := u.errors.recent(u.errors.limit)
+	if len(entries) == 0 {
+		drawText(screen, 0, line, tcell.StyleDefault, "No errors or warnings logged.")
+		return
+	}
+	drawText(screen, 0, line, tcell.StyleDefault.Bold(true), fmt.Sprintf("%-8s %-5s %6s  %s", "Time", "Level", "Count", "Message"))
+	line++
+	rows := height - 1 - line
+	u.logOffset = clampOffset(u.logOffset, len(entries), rows)
+	for _, e := range entries[u.logOffset:] {
+		if line >= height-1 {
+			break
+		}
+		style := theme.bad
+		if e.Level == levelWarn {
+			style = theme.warn
+		}
+		subject := e.Source + ": " + e.Message
+		if e.Server != "" {
+			subject = e.Server + ": " + subject
+		}
+		count := ""
+		if e.Count > 1 {
+			count = fmt.Sprintf("x%d", e.Count)
+			subject += fmt.Sprintf(" (last %s)", clockTime(e.Last))
+		}
+		drawText(screen, 0, line, style, fmt.Sprintf("%-8s %-5s %6s  %s", clockTime(e.Time), e.Level, count, subject))
+		line++
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorLogFoldsRepeatsAndWritesEveryOccurrence(t *testing.T) {
+	var file strings.Builder
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.errors = newErrorLog(10, &file)
+
+	sess.record(nil, errors.New("connection refused"))
+	sess.record(nil, errors.New("connection refused"))
+	sess.logWarning("stats", "server returned no stats")
+
+	recent := sess.errors.recent(10)
+	if len(recent) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(recent), recent)
+	}
+	if recent[0].Level != levelWarn || recent[0].Source != "stats" {
+		t.Fatalf("newest entry = %+v, want the warning", recent[0])
+	}
+	if recent[1].Count != 2 || recent[1].Level != levelError || recent[1].Server != "127.0.0.1:11211" {
+		t.Fatalf("folded entry = %+v, want two connection failures", recent[1])
+	}
+	if got := strings.Count(file.String(), "\n"); got != 3 {
+		t.Fatalf("log file has %d lines, want 3:\n%s", got, file.String())
+	}
+	if !strings.Contains(file.String(), "127.0.0.1:11211: stats: connection refused") {
+		t.Fatalf("log file missing the failure:\n%s", file.String())
+	}
+}
+
+func TestErrorLogDropsFailingFile(t *testing.T) {
+	log := newErrorLog(3, failingWriter{})
+	log.add(logEntry{Time: time.Now(), Level: levelError, Source: "stats", Message: "timeout"})
+
+	if log.out != nil {
+		t.Fatalf("failing writer should be dropped")
+	}
+	recent := log.recent(5)
+	if len(recent) != 2 || recent[0].Source != "log" || recent[1].Message != "timeout" {
+		t.Fatalf("unexpected entries %+v", recent)
+	}
+}
+
+func TestErrorLogIsBounded(t *testing.T) {
+	log := newErrorLog(2, nil)
+	for _, msg := range []string{"one", "two", "three"} {
+		log.add(logEntry{Time: time.Now(), Level: levelError, Source: "stats", Message: msg})
+	}
+	recent := log.recent(5)
+	if len(recent) != 2 || recent[0].Message != "three" || recent[1].Message != "two" {
+		t.Fatalf("unexpected entries %+v", recent)
+	}
+}
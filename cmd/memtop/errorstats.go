@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// errorCounters are failure counters that stay at zero on a healthy server
+// and are easy to miss among the other stats. Any stat ending in _errors is
+// watched too.
+var errorCounters = []string{"auth_errors", "store_too_large", "store_no_memory", "lrutail_reflocked"}
+
+// isErrorCounter reports whether a general stat counts failures.
+func isErrorCounter(key string) bool {
+	for _, c := range errorCounters {
+		if key == c {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, "_errors")
+}
+
+// renderErrorsPanel lists the error counters that are non-zero, with their
+// rate over the last interval. Counters still rising are shown in the
+// alarming style; the panel is hidden while every counter is zero.
+func renderErrorsPanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	var keys []string
+	for key, value := range s.current.Values {
+		if value > 0 && isErrorCounter(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	nf := numberFormat{"errors"}
+	lines := make([]panelLine, 0, len(keys))
+	for _, key := range keys {
+		rate := rateValue(s.rates, key)
+		line := panelLine{Text: fmt.Sprintf("%-20s %10s  %s%s", key, nf.count(key, s.current.Values[key]),
+			nf.signed(numberRate, key, displayRate(rate, s.interval)), rateUnit(s.interval)), Style: theme.warn}
+		if rate > 0 {
+			line.Style = theme.bad.Bold(true)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
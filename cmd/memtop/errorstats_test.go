@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorsPanelShowsNonZeroCounters(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{Values: map[string]float64{"auth_errors": 0, "store_no_memory": 0, "get_hits": 5}}
+	if lines := renderErrorsPanel(sess); len(lines) != 0 {
+		t.Fatalf("all-zero counters should hide the panel, got %+v", lines)
+	}
+
+	sess.current.Values["auth_errors"] = 12
+	sess.current.Values["store_too_large"] = 3
+	sess.current.Values["ssl_handshake_errors"] = 1
+	sess.rates = map[string]float64{"auth_errors": 0.5}
+	lines := renderErrorsPanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "auth_errors 12 +0.50/s|ssl_handshake_errors 1 +0.00/s|store_too_large 3 +0.00/s"; strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[0].Style != theme.bad.Bold(true) || lines[1].Style != theme.warn {
+		t.Fatalf("rising counters should stand out more than old ones")
+	}
+}
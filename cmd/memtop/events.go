@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// defaultEventLimit bounds the on-screen event log.
+const defaultEventLimit = 200
+
+// Event kinds recorded in the log.
+const (
+	eventFlush      = "flush"
+	eventRestart    = "restart"
+	eventConnLost   = "conn-lost"
+	eventConnOK     = "conn-ok"
+	eventAlert      = "alert"
+	eventProbe      = "probe"
+	eventSuspend    = "suspend"
+	eventSleep      = "sleep"
+	eventHashGrow   = "hash-grow"
+	eventRewarm     = "rewarm"
+	eventSlabMove   = "slab-move"
+	eventControl    = "control"
+	eventLogFailure = "log-error"
+)
+
+// event is one notable occurrence, such as a restart or flush_all. Server is
+// empty for events that are not tied to one server, like user notes.
+type event struct {
+	Time    time.Time
+	Server  string
+	Kind    string
+	Message string
+	Alert   *alertInfo // details of a script alert raised or cleared
+}
+
+// String formats an event the same way on screen and in the log file, in the
+// display time zone.
+func (e event) String() string {
+	return fmt.Sprintf("%s  %-9s  %s", e.Time.In(displayLocation).Format(defaultTimeFormat), e.Kind, e.subject())
+}
+
+// subject prefixes the message with the server it concerns, if any.
+func (e event) subject() string {
+	if e.Server == "" {
+		return e.Message
+	}
+	return e.Server + ": " + e.Message
+}
+
+// eventLog keeps recent events in memory and optionally mirrors them to a
+// writer so they survive after memtop exits.
+type eventLog struct {
+	mu     sync.Mutex // servers are polled concurrently
+	limit  int
+	events []event
+	seq    int // events ever added, so readers can ask for what is new
+	out    io.Writer
+	failed bool // the file was dropped after a write error
+}
+
+// newEventLog creates a log holding at most limit events; out may be nil.
+func newEventLog(limit int, out io.Writer) *eventLog {
+	if limit <= 0 {
+		limit = defaultEventLimit
+	}
+	return &eventLog{limit: limit, out: out}
+}
+
+// add records an event and appends it to the log file if one is configured.
+// A failing file is dropped after logging the failure on screen, so a full
+// disk doesn't turn into an error on every tick; events it misses are counted
+// as dropped.
+func (l *eventLog) add(e event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = appendBounded(l.events, e, l.limit)
+	l.seq++
+	if l.out == nil {
+		if l.failed {
+			selfDroppedEvents.Add(1)
+		}
+		return
+	}
+	if _, err := fmt.Fprintln(l.out, e.String()); err != nil {
+		selfDroppedEvents.Add(1)
+		l.out, l.failed = nil, true
+		l.events = appendBounded(l.events, event{Time: e.Time, Kind: eventLogFailure, Message: fmt.Sprintf("event log file disabled: %v", err)}, l.limit)
+		l.seq++
+	}
+}
+
+// recent returns up to n of the newest events, newest first.
+func (l *eventLog) recent(n int) []event {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]event, 0, min(n, len(l.events)))
+	for i := len(l.events) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, l.events[i])
+	}
+	return out
+}
+
+// since returns the events added after sequence number seq, oldest first, as
+// far as they are still held, and the sequence number to pass next time.
+func (l *eventLog) since(seq int) ([]event, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := min(l.seq-seq, len(l.events))
+	return append([]event(nil), l.events[len(l.events)-n:]...), l.seq
+}
+
+// logEvent records an event attributed to this server.
+func (s *session) logEvent(t time.Time, kind, message string) {
+	s.events.add(event{Time: t, Server: s.addr, Kind: kind, Message: message})
+}
+
+// detectEvents compares a fresh snapshot with the last good one and logs
+// flushes, restarts, and hash table expansions. Rates alone hide them:
+// counters reset to zero on a restart, a flush only shows up as a tick of
+// cmd_flush, and an expansion can come and go between two samples.
+func (s *session) detectEvents(prev, curr *statsSnapshot) {
+	if prev == nil || curr == nil {
+		return
+	}
+	if curr.Values["uptime"] < prev.Values["uptime"] {
+		s.logEvent(curr.Timestamp, eventRestart, fmt.Sprintf("server restarted (uptime %s, version %s)",
+			formatUptime(curr.Values["uptime"]), curr.Raw["version"]))
+		return
+	}
+	if flushes := curr.Values["cmd_flush"] - prev.Values["cmd_flush"]; flushes > 0 {
+		s.logEvent(curr.Timestamp, eventFlush, fmt.Sprintf("flush_all detected (%.0f since last sample)", flushes))
+	}
+	if was, now := prev.Values["hash_power_level"], curr.Values["hash_power_level"]; now > was && was > 0 {
+		s.logEvent(curr.Timestamp, eventHashGrow, fmt.Sprintf("hash table grew from 2^%.0f to 2^%.0f buckets", was, now))
+	}
+}
+
+// eventStyle colours event kinds so restarts and outages stand out.
+func eventStyle(kind string) tcell.Style {
+	switch kind {
+	case eventRestart, eventConnLost, eventLogFailure:
+		return theme.bad
+	case eventFlush, eventAlert, eventProbe, eventSuspend, eventSleep, eventHashGrow, eventSlabMove, eventControl:
+		return theme.warn
+	case eventConnOK, eventRewarm:
+		return theme.good
+	}
+	return tcell.StyleDefault
+}
+
+// renderEventsPanel shows the newest events first.
+func renderEventsPanel(s *session) []panelLine {
+	recent := s.events.recent(10)
+	if len(recent) == 0 {
+		return []panelLine{plainLine("No events yet.")}
+	}
+	lines := make([]panelLine, 0, len(recent))
+	for _, e := range recent {
+		lines = append(lines, panelLine{Text: fmt.Sprintf("%s %-9s %s", clockTime(e.Time), e.Kind, e.subject()), Style: eventStyle(e.Kind)})
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionLogsRestartsFlushesAndOutages(t *testing.T) {
+	var file strings.Builder
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.events = newEventLog(10, &file)
+	start := time.Now()
+
+	sess.record(&statsSnapshot{Timestamp: start, Values: map[string]float64{"uptime": 100, "cmd_flush": 1}}, nil)
+	sess.record(&statsSnapshot{Timestamp: start.Add(time.Second), Values: map[string]float64{"uptime": 101, "cmd_flush": 2}}, nil)
+	sess.record(nil, errors.New("connection refused"))
+	sess.record(nil, errors.New("connection refused"))
+	sess.record(&statsSnapshot{Timestamp: start.Add(3 * time.Second), Values: map[string]float64{"uptime": 2}, Raw: map[string]string{"version": "1.6.21"}}, nil)
+
+	var kinds []string
+	for _, e := range sess.events.events {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []string{eventFlush, eventConnLost, eventConnOK, eventRestart}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Fatalf("event kinds = %v, want %v", kinds, want)
+	}
+	if got := strings.Count(file.String(), "\n"); got != len(want) {
+		t.Fatalf("event file has %d lines, want %d:\n%s", got, len(want), file.String())
+	}
+	if !strings.Contains(file.String(), "server restarted") {
+		t.Fatalf("event file missing restart entry:\n%s", file.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestEventLogDropsFailingFile(t *testing.T) {
+	log := newEventLog(3, failingWriter{})
+	log.add(event{Time: time.Now(), Kind: eventFlush, Message: "one"})
+	log.add(event{Time: time.Now(), Kind: eventFlush, Message: "two"})
+
+	if log.out != nil {
+		t.Fatalf("failing writer should be dropped")
+	}
+	recent := log.recent(5)
+	if len(recent) != 3 || recent[0].Message != "two" || recent[1].Kind != eventLogFailure {
+		t.Fatalf("unexpected events %+v", recent)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// runExport implements `memtop export`: it samples the server -count times,
+// -interval apart, and prints every sample as a JSON line, a CSV row, or an
+// OpenMetrics exposition, for cron jobs and one-off captures that don't
+// want a monitor running. Rates are included from the second sample on.
+func runExport(args []string, std streams) int {
+	fs, conn := newCommandFlags("export", std)
+	format := fs.String("format", "jsonl", "output format: jsonl, csv (with a header row), or openmetrics")
+	count := fs.Int("count", 1, "number of samples to take")
+	interval := fs.Duration("interval", time.Second, "time between samples")
+	metrics := fs.String("metrics", "", "only export the stats matching this `regexp`, such as 'cmd_.*|evictions|bytes'")
+	if _, ok := parseCommandArgs(fs, args, 0); !ok {
+		return 2
+	}
+	if *count < 1 {
+		fmt.Fprintln(std.Err, "-count must be positive")
+		return 2
+	}
+	if err := validateInterval(*interval); err != nil {
+		fmt.Fprintln(std.Err, err)
+		return 2
+	}
+	filter, err := parseMetricFilter(*metrics)
+	if err != nil {
+		fmt.Fprintln(std.Err, err)
+		return 2
+	}
+	var write func(u *ui) error
+	switch *format {
+	case "jsonl":
+		write = newJSONLWriter(std.Out).write
+	case "csv":
+		write = newCSVSink(std.Out, true).write
+	case "openmetrics":
+		write = func(u *ui) error { return writeOpenMetrics(std.Out, sampleRecords(u)) }
+	default:
+		fmt.Fprintf(std.Err, "unknown format %q (want jsonl, csv, or openmetrics)\n", *format)
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+
+	sess := newSession(conn.addr(), *interval)
+	u := newUI(*interval, nil, sess)
+	u.metrics = filter
+	code := 0
+	for i := 0; i < *count; i++ {
+		if i > 0 {
+			time.Sleep(*interval)
+		}
+		sample(context.Background(), u)
+		if sess.lastErr != nil {
+			fmt.Fprintf(std.Err, "export: %v\n", sess.lastErr)
+			code = 1
+		}
+		if err := write(u); err != nil {
+			fmt.Fprintf(std.Err, "export: %v\n", err)
+			return 1
+		}
+	}
+	return code
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+func TestExportWritesEverySample(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetStats("", map[string]string{"cmd_get": "10", "uptime": "5"}, map[string]string{"cmd_get": "20", "uptime": "6"})
+	srv.SetStats("settings", map[string]string{"maxconns": "1024"})
+
+	code, out, errOut := runCommand(t, srv.Addr(), "", "export", "-count", "2", "-interval", "100ms")
+	if code != 0 {
+		t.Fatalf("export exited %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"cmd_get":10`) || strings.Contains(lines[0], `"rates"`) {
+		t.Fatalf("export printed:\n%s", out)
+	}
+	if !strings.Contains(lines[1], `"cmd_get":20`) || !strings.Contains(lines[1], `"rates"`) {
+		t.Fatalf("the second sample should carry rates:\n%s", lines[1])
+	}
+
+	code, out, _ = runCommand(t, srv.Addr(), "", "export", "-format", "csv")
+	if rows := strings.Split(strings.TrimSpace(out), "\n"); code != 0 || len(rows) != 2 || !strings.HasPrefix(rows[0], "timestamp,server,up") {
+		t.Fatalf("export -format csv = %d:\n%s", code, out)
+	}
+	if code, _, _ := runCommand(t, srv.Addr(), "", "export", "-format", "xml"); code != 2 {
+		t.Fatalf("unknown format exited %d, want 2", code)
+	}
+}
+
+func TestExportFailsForAnUnreachableServer(t *testing.T) {
+	srv := memstatstest.NewServer()
+	addr := srv.Addr()
+	srv.Close()
+	code, out, _ := runCommand(t, addr, "", "export")
+	if code != 1 || !strings.Contains(out, `"up":false`) {
+		t.Fatalf("export of a closed server = %d %q", code, out)
+	}
+}
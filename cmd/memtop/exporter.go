@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sampleRecord is one server's latest sample as handed to outputs outside
+// the TUI. The maps are shared with the session, which replaces rather than
+// modifies them, so records can be read from other goroutines.
+type sampleRecord struct {
+	Server    string             `json:"server"`
+	Tags      map[string]string  `json:"tags,omitempty"`
+	Up        bool               `json:"up"`
+	Error     string             `json:"error,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Values    map[string]float64 `json:"values,omitempty"`
+	Rates     map[string]float64 `json:"rates,omitempty"`
+
+	// counters are the exact integer values behind Values, used when the
+	// record is written out.
+	counters map[string]uint64
+}
+
+// MarshalJSON writes integer stats from the exact counters, so a value above
+// 2^53 keeps every digit.
+func (r sampleRecord) MarshalJSON() ([]byte, error) {
+	type plain sampleRecord
+	out := struct {
+		plain
+		Values map[string]any `json:"values,omitempty"`
+	}{plain: plain(r)}
+	if len(r.Values) > 0 {
+		out.Values = make(map[string]any, len(r.Values))
+		for key, value := range r.Values {
+			out.Values[key] = value
+			if counter, ok := r.counters[key]; ok {
+				out.Values[key] = counter
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+// formatValue prints a stat for text output, exactly for integer counters.
+func (r sampleRecord) formatValue(key string) string {
+	if counter, ok := r.counters[key]; ok {
+		return strconv.FormatUint(counter, 10)
+	}
+	return strconv.FormatFloat(r.Values[key], 'g', -1, 64)
+}
+
+// newSampleRecord captures the session's latest sample. A server whose last
+// poll failed gets the error and the current time instead of stale values.
+func newSampleRecord(s *session) sampleRecord {
+	rec := sampleRecord{Server: s.addr, Tags: s.tags}
+	switch {
+	case s.lastErr != nil:
+		rec.Error, rec.Timestamp = s.lastErr.Error(), time.Now()
+	case s.current != nil:
+		rec.Up, rec.Timestamp, rec.Values, rec.Rates, rec.counters = true, s.current.Timestamp, s.current.Values, s.rates, s.current.Counters
+	}
+	return rec
+}
+
+// sampleRecords captures the latest sample of every server, narrowed to the
+// stats -metrics selects.
+func sampleRecords(u *ui) []sampleRecord {
+	records := make([]sampleRecord, 0, len(u.servers))
+	for _, s := range u.servers {
+		records = append(records, newSampleRecord(s).filtered(u.metrics))
+	}
+	return records
+}
+
+// sampleBoard holds the latest record per server for the HTTP exporter,
+// which reads it from its own goroutines while the main loop samples.
+type sampleBoard struct {
+	mu      sync.Mutex
+	records []sampleRecord
+}
+
+// published is updated after every sampling pass.
+var published sampleBoard
+
+// publish replaces the records with the state of every server.
+func (b *sampleBoard) publish(u *ui) {
+	records := sampleRecords(u)
+	b.mu.Lock()
+	b.records = records
+	b.mu.Unlock()
+}
+
+// latest returns the most recently published records.
+func (b *sampleBoard) latest() []sampleRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.records
+}
+
+// serveMetrics exposes the latest samples in the OpenMetrics text format.
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", openMetricsContentType)
+	writeMetrics(w, published.latest())
+}
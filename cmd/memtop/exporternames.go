@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// writeMetrics renders /metrics; -metric-names picks it.
+var writeMetrics = writeOpenMetrics
+
+// setMetricNames selects how /metrics names its series: "memtop" derives a
+// family from every stat, "exporter" uses the names of the official
+// memcached_exporter so dashboards built for it work unchanged.
+func setMetricNames(name string) error {
+	switch name {
+	case "memtop":
+		writeMetrics = writeOpenMetrics
+	case "exporter":
+		writeMetrics = writeExporterMetrics
+	default:
+		return fmt.Errorf("unknown metric naming %q (want memtop or exporter)", name)
+	}
+	return nil
+}
+
+// exporterMetric maps a stat to its memcached_exporter family. Command
+// counters share memcached_commands with command and status labels.
+type exporterMetric struct {
+	stat    string
+	family  string
+	counter bool
+	help    string
+	labels  string
+}
+
+// exporterCommand is one series of memcached_commands_total.
+func exporterCommand(stat, command, status string) exporterMetric {
+	return exporterMetric{
+		stat: stat, family: "memcached_commands", counter: true,
+		help:   "Total number of all requests broken down by command (get, set, etc.) and status.",
+		labels: fmt.Sprintf(`command="%s",status="%s"`, command, status),
+	}
+}
+
+// exporterMetrics lists the memcached_exporter series memtop can fill from
+// the general stats, in the exporter's names and types.
+var exporterMetrics = []exporterMetric{
+	{stat: "uptime", family: "memcached_uptime_seconds", help: "Number of seconds since the server started."},
+	{stat: "time", family: "memcached_time_seconds", help: "current UNIX time according to the server."},
+	{stat: "rusage_user", family: "memcached_process_user_cpu_seconds", counter: true, help: "Accumulated user time for this process."},
+	{stat: "rusage_system", family: "memcached_process_system_cpu_seconds", counter: true, help: "Accumulated system time for this process."},
+	{stat: "bytes", family: "memcached_current_bytes", help: "Current number of bytes used to store items."},
+	{stat: "limit_maxbytes", family: "memcached_limit_bytes", help: "Number of bytes this server is allowed to use for storage."},
+	{stat: "total_malloced", family: "memcached_malloced_bytes", help: "Number of bytes of memory allocated to slab pages."},
+	{stat: "curr_items", family: "memcached_current_items", help: "Current number of items stored by this instance."},
+	{stat: "total_items", family: "memcached_items", counter: true, help: "Total number of items stored during the life of this instance."},
+	{stat: "evictions", family: "memcached_items_evicted", counter: true, help: "Total number of valid items removed from cache to free memory for new items."},
+	{stat: "reclaimed", family: "memcached_items_reclaimed", counter: true, help: "Total number of times an entry was stored using memory from an expired entry."},
+	{stat: "expired_unfetched", family: "memcached_item_expired_unfetched", counter: true, help: "Total number of items expired that were never fetched."},
+	{stat: "evicted_unfetched", family: "memcached_item_evicted_unfetched", counter: true, help: "Total number of items evicted that were never fetched."},
+	{stat: "bytes_read", family: "memcached_read_bytes", counter: true, help: "Total number of bytes read by this server from network."},
+	{stat: "bytes_written", family: "memcached_written_bytes", counter: true, help: "Total number of bytes sent by this server to network."},
+	{stat: "curr_connections", family: "memcached_current_connections", help: "Current number of open connections."},
+	{stat: "total_connections", family: "memcached_connections", counter: true, help: "Total number of connections opened since the server started running."},
+	{stat: "rejected_connections", family: "memcached_connections_rejected", counter: true, help: "Total number of connections rejected due to hitting the memcached's -c limit in maxconns_fast mode."},
+	{stat: "conn_yields", family: "memcached_connections_yielded", counter: true, help: "Total number of connections yielded running due to hitting the memcached's -R limit."},
+	{stat: "listen_disabled_num", family: "memcached_connections_listener_disabled", counter: true, help: "Number of times that memcached has hit its connections limit and disabled its listener."},
+	{stat: "accepting_conns", family: "memcached_accepting_connections", help: "The Memcached server is currently accepting new connections."},
+	{stat: "max_connections", family: "memcached_max_connections", help: "Maximum number of clients allowed."},
+	{stat: "threads", family: "memcached_threads", help: "Number of worker threads requested."},
+	{stat: "slabs_moved", family: "memcached_slabs_moved", counter: true, help: "Total number of slab pages moved."},
+	exporterCommand("get_hits", "get", "hit"),
+	exporterCommand("get_misses", "get", "miss"),
+	exporterCommand("get_expired", "get", "expired"),
+	exporterCommand("get_flushed", "get", "flushed"),
+	exporterCommand("cmd_set", "set", "hit"),
+	exporterCommand("cas_hits", "cas", "hit"),
+	exporterCommand("cas_misses", "cas", "miss"),
+	exporterCommand("cas_badval", "cas", "badval"),
+	exporterCommand("incr_hits", "incr", "hit"),
+	exporterCommand("incr_misses", "incr", "miss"),
+	exporterCommand("decr_hits", "decr", "hit"),
+	exporterCommand("decr_misses", "decr", "miss"),
+	exporterCommand("delete_hits", "delete", "hit"),
+	exporterCommand("delete_misses", "delete", "miss"),
+	exporterCommand("touch_hits", "touch", "hit"),
+	exporterCommand("touch_misses", "touch", "miss"),
+	exporterCommand("cmd_flush", "flush", "hit"),
+}
+
+// writeExporterMetrics writes the records in the OpenMetrics text format
+// under memcached_exporter's names. Every series also carries memtop's
+// server and tag labels, which queries written for the exporter ignore.
+// Stats the exporter has no name for are left out.
+func writeExporterMetrics(w io.Writer, records []sampleRecord) error {
+	families := map[string]*openMetricsFamily{
+		"memcached_up": {help: "Could the memcached server be reached."},
+	}
+	for _, rec := range records {
+		labels := recordLabels(rec)
+		up := 0
+		if rec.Up {
+			up = 1
+		}
+		families["memcached_up"].samples = append(families["memcached_up"].samples, fmt.Sprintf("memcached_up{%s} %d", labels, up))
+		if !rec.Up {
+			continue
+		}
+		for _, m := range exporterMetrics {
+			if _, ok := rec.Values[m.stat]; !ok {
+				continue
+			}
+			fam := families[m.family]
+			if fam == nil {
+				fam = &openMetricsFamily{counter: m.counter, help: m.help}
+				families[m.family] = fam
+			}
+			name, sampleLabels := m.family, labels
+			if m.counter {
+				name += "_total"
+			}
+			if m.labels != "" {
+				sampleLabels += "," + m.labels
+			}
+			fam.samples = append(fam.samples, fmt.Sprintf("%s{%s} %s", name, sampleLabels, rec.formatValue(m.stat)))
+		}
+	}
+	return writeFamilies(w, families)
+}
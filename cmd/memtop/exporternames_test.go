@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteExporterMetricsUsesExporterNames(t *testing.T) {
+	records := []sampleRecord{{
+		Server: "cache-1:11211",
+		Up:     true,
+		Values: map[string]float64{"get_hits": 8, "get_misses": 2, "cmd_set": 5, "bytes": 1024, "curr_items": 3, "uptime": 60, "items:2:evicted": 4},
+	}}
+	var buf bytes.Buffer
+	if err := writeExporterMetrics(&buf, records); err != nil {
+		t.Fatalf("writeExporterMetrics: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE memcached_commands counter\n",
+		`memcached_commands_total{server="cache-1:11211",command="get",status="hit"} 8`,
+		`memcached_commands_total{server="cache-1:11211",command="get",status="miss"} 2`,
+		`memcached_commands_total{server="cache-1:11211",command="set",status="hit"} 5`,
+		"# TYPE memcached_current_bytes gauge\n",
+		`memcached_current_bytes{server="cache-1:11211"} 1024`,
+		`memcached_current_items{server="cache-1:11211"} 3`,
+		`memcached_uptime_seconds{server="cache-1:11211"} 60`,
+		`memcached_up{server="cache-1:11211"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "# TYPE memcached_commands ") != 1 || strings.Contains(out, "items_evicted") || !strings.HasSuffix(out, "# EOF\n") {
+		t.Fatalf("want one commands family, no unmapped stats, and # EOF:\n%s", out)
+	}
+}
+
+func TestSetMetricNames(t *testing.T) {
+	defer setMetricNames("memtop")
+	if err := setMetricNames("prometheus"); err == nil {
+		t.Fatalf("unknown naming accepted")
+	}
+	if err := setMetricNames("exporter"); err != nil {
+		t.Fatalf("setMetricNames: %v", err)
+	}
+	var buf bytes.Buffer
+	writeMetrics(&buf, []sampleRecord{{Server: "a", Up: true, Values: map[string]float64{"bytes": 1}}})
+	if !strings.Contains(buf.String(), "memcached_current_bytes") {
+		t.Fatalf("exporter naming not used:\n%s", buf.String())
+	}
+}
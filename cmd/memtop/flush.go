@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// runFlush implements `memtop flush`, which invalidates every item on a
+// server. It refuses to run without -yes so a stray invocation in a script
+// or shell history cannot empty a cache, and it reports what it did on stderr
+// and, with -event-log, in the same log file the monitor writes.
+func runFlush(args []string, std streams) int {
+	fs, conn := newCommandFlags("flush", std)
+	yes := fs.Bool("yes", false, "confirm that every item on the server should be invalidated")
+	delay := fs.Duration("delay", 0, "invalidate items after this delay instead of immediately (whole seconds)")
+	eventLogPath := fs.String("event-log", "", "also append the flush to this event log file")
+	if _, ok := parseCommandArgs(fs, args, 0); !ok {
+		return 2
+	}
+	if *delay < 0 || *delay%time.Second != 0 {
+		fmt.Fprintf(std.Err, "invalid -delay %v: must be a non-negative whole number of seconds\n", *delay)
+		return 2
+	}
+	if !*yes {
+		fmt.Fprintf(std.Err, "refusing to flush %s without -yes\n", conn.addr())
+		return 2
+	}
+
+	log := newEventLog(defaultEventLimit, nil)
+	if *eventLogPath != "" {
+		f, err := os.OpenFile(*eventLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(std.Err, "failed to open event log: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		log = newEventLog(defaultEventLimit, f)
+	}
+
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+	if err := flushAll(conn.addr(), *delay); err != nil {
+		fmt.Fprintf(std.Err, "flush %s: %v\n", conn.addr(), err)
+		return 1
+	}
+
+	message := "flush_all sent by memtop flush"
+	if *delay > 0 {
+		message += fmt.Sprintf(", items expire in %v", *delay)
+	}
+	log.add(event{Time: time.Now(), Server: conn.addr(), Kind: eventFlush, Message: message})
+	// The log also holds a failure notice if the file could not be written.
+	for _, e := range log.events {
+		fmt.Fprintln(std.Err, e.String())
+	}
+	return 0
+}
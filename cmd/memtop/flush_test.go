@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlushRequiresConfirmation(t *testing.T) {
+	store, ln := startFakeStore(t)
+	defer ln.Close()
+	store.set("k", "v")
+
+	code, _, errOut := runCommand(t, ln.Addr().String(), "", "flush")
+	if code != 2 || !strings.Contains(errOut, "without -yes") {
+		t.Fatalf("flush without -yes = %d %q", code, errOut)
+	}
+	if code, _, _ := runCommand(t, ln.Addr().String(), "", "flush", "-yes", "-delay", "1500ms"); code != 2 {
+		t.Fatalf("fractional delay exited %d, want 2", code)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.flushes) != 0 || store.items["k"] != "v" {
+		t.Fatalf("refused flushes must not reach the server: %v", store.flushes)
+	}
+}
+
+func TestFlushLogsWhatItDid(t *testing.T) {
+	store, ln := startFakeStore(t)
+	defer ln.Close()
+	store.set("k", "v")
+	logPath := filepath.Join(t.TempDir(), "events.log")
+
+	code, _, errOut := runCommand(t, ln.Addr().String(), "", "flush", "-yes", "-event-log", logPath)
+	if code != 0 || !strings.Contains(errOut, "flush_all sent") {
+		t.Fatalf("flush = %d %q", code, errOut)
+	}
+	code, _, errOut = runCommand(t, ln.Addr().String(), "", "flush", "-yes", "-delay", "30s", "-event-log", logPath)
+	if code != 0 || !strings.Contains(errOut, "items expire in 30s") {
+		t.Fatalf("delayed flush = %d %q", code, errOut)
+	}
+
+	store.mu.Lock()
+	flushes := strings.Join(store.flushes, ",")
+	_, exists := store.items["k"]
+	store.mu.Unlock()
+	if flushes != "flush_all 0,flush_all 30" || exists {
+		t.Fatalf("server saw %q (item left: %v)", flushes, exists)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("read event log: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 2 || !strings.Contains(lines[0], "flush") {
+		t.Fatalf("unexpected event log:\n%s", data)
+	}
+}
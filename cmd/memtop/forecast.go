@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// forecastWindow is how many recent samples feed the memory growth trend; a
+// regression over several ticks smooths out the sawtooth of sets and expiries.
+const forecastWindow = 30
+
+// linearSlope fits a least-squares line through the samples and returns its
+// slope per second. NaN samples are skipped; at least three points spanning a
+// non-zero time range are required.
+func linearSlope(times []time.Time, values []float64) (float64, bool) {
+	n := min(len(times), len(values))
+	if n == 0 {
+		return 0, false
+	}
+	origin := times[0]
+	var count, sumX, sumY, sumXY, sumXX float64
+	for i := 0; i < n; i++ {
+		if math.IsNaN(values[i]) {
+			continue
+		}
+		x := times[i].Sub(origin).Seconds()
+		y := values[i]
+		count++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	if count < 3 {
+		return 0, false
+	}
+	denominator := count*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (count*sumXY - sumX*sumY) / denominator, true
+}
+
+// memoryETA projects how long until memory usage reaches limit_maxbytes based
+// on the recent trend of the memory series. It reports false when usage is
+// flat, shrinking, or there is not enough history yet.
+func memoryETA(h *history, current float64) (time.Duration, bool) {
+	if h == nil {
+		return 0, false
+	}
+	times := lastN(h.times, forecastWindow)
+	values := lastN(h.values("memory"), forecastWindow)
+	slope, ok := linearSlope(times, values)
+	if !ok || slope <= 0 {
+		return 0, false
+	}
+	remaining := 100 - current
+	if remaining <= 0 {
+		return 0, true
+	}
+	return time.Duration(remaining / slope * float64(time.Second)), true
+}
+
+// describeMemoryETA renders the memory forecast for the summary line.
+func describeMemoryETA(h *history, stats *statsSnapshot, rates map[string]float64) string {
+	if rateValue(rates, "evictions") > 0 {
+		return "Full: evicting now"
+	}
+	current := memoryPercent(stats)
+	if current >= 99.9 {
+		return "Full: at limit"
+	}
+	eta, ok := memoryETA(h, current)
+	if !ok {
+		return "Full in: not growing"
+	}
+	return "Full in: ~" + formatUptime(eta.Seconds())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestLinearSlope(t *testing.T) {
+	start := time.Now()
+	times := []time.Time{start, start.Add(10 * time.Second), start.Add(20 * time.Second), start.Add(30 * time.Second)}
+	slope, ok := linearSlope(times, []float64{10, 12, math.NaN(), 16})
+	if !ok || math.Abs(slope-0.2) > 1e-9 {
+		t.Fatalf("linearSlope = %.4f, %v; want 0.2, true", slope, ok)
+	}
+	if _, ok := linearSlope(times[:2], []float64{1, 2}); ok {
+		t.Fatalf("two points should not be enough for a trend")
+	}
+}
+
+func TestDescribeMemoryETA(t *testing.T) {
+	h := newHistory(10)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		h.add(&statsSnapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+			Values:    map[string]float64{"bytes": float64(40 + i*10), "limit_maxbytes": 100},
+		}, nil)
+	}
+	latest := &statsSnapshot{Values: map[string]float64{"bytes": 80, "limit_maxbytes": 100}}
+
+	// 10 percentage points per minute with 20 left means two minutes to go.
+	if got, want := describeMemoryETA(h, latest, nil), "Full in: ~00h 02m 00s"; got != want {
+		t.Fatalf("describeMemoryETA = %q, want %q", got, want)
+	}
+	if got := describeMemoryETA(h, latest, map[string]float64{"evictions": 1}); got != "Full: evicting now" {
+		t.Fatalf("evictions should override the forecast, got %q", got)
+	}
+	if got := describeMemoryETA(newHistory(10), latest, nil); got != "Full in: not growing" {
+		t.Fatalf("empty history should not forecast, got %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// staleFactor is how many intervals may pass without a successful sample
+// before the header's freshness indicator starts flashing.
+const staleFactor = 2
+
+// describeFreshness says how long ago the server last answered, and whether
+// that is long enough to count as a stall.
+func describeFreshness(s *session, now time.Time) (string, bool) {
+	if s.current == nil {
+		if s.lastErr != nil {
+			return "no successful sample", true
+		}
+		return "", false
+	}
+	age := now.Sub(s.current.Timestamp)
+	if age < 0 {
+		age = 0
+	}
+	return "updated " + age.Truncate(agePrecision(s.interval)).String() + " ago", age > staleFactor*s.interval
+}
+
+// describeLastSeen says when a server that is down last answered, from the
+// sample kept since, or that it never has.
+func describeLastSeen(s *session, now time.Time) string {
+	if s.current == nil {
+		return "never seen"
+	}
+	age := max(now.Sub(s.current.Timestamp), 0)
+	return "last seen " + age.Truncate(agePrecision(s.interval)).String() + " ago"
+}
+
+// freshnessStyle flashes a stale indicator by swapping to reverse video every
+// other second; the main loop redraws once a second so the flash is visible
+// even with long intervals.
+func freshnessStyle(stale bool, now time.Time) tcell.Style {
+	style := tcell.StyleDefault.Bold(true)
+	if !stale {
+		return style
+	}
+	style = theme.bad.Bold(true)
+	if now.Unix()%2 == 0 {
+		style = style.Reverse(true)
+	}
+	return style
+}
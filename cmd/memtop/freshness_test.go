@@ -0,0 +1,64 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestDescribeFreshness(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+
+	if text, stale := describeFreshness(sess, now); text != "" || stale {
+		t.Fatalf("no sample yet should show nothing, got %q %v", text, stale)
+	}
+	sess.lastErr = errors.New("connection refused")
+	if text, stale := describeFreshness(sess, now); text != "no successful sample" || !stale {
+		t.Fatalf("failing from the start = %q %v", text, stale)
+	}
+
+	sess.current = &statsSnapshot{Timestamp: now.Add(-3500 * time.Millisecond)}
+	if text, stale := describeFreshness(sess, now); text != "updated 3s ago" || stale {
+		t.Fatalf("recent sample = %q %v", text, stale)
+	}
+	sess.current.Timestamp = now.Add(-5 * time.Second)
+	if text, stale := describeFreshness(sess, now); text != "updated 5s ago" || !stale {
+		t.Fatalf("sample older than two intervals = %q %v", text, stale)
+	}
+}
+
+func TestFreshnessStyleFlashesWhenStale(t *testing.T) {
+	even, odd := time.Unix(100, 0), time.Unix(101, 0)
+	if freshnessStyle(false, even) != freshnessStyle(false, odd) {
+		t.Fatalf("a fresh indicator should not flash")
+	}
+	_, _, evenAttrs := freshnessStyle(true, even).Decompose()
+	_, _, oddAttrs := freshnessStyle(true, odd).Decompose()
+	if evenAttrs&tcell.AttrReverse == 0 || oddAttrs&tcell.AttrReverse != 0 {
+		t.Fatalf("a stale indicator should alternate reverse video each second")
+	}
+}
+
+func TestFreshnessFollowsHeaderWithWideLabels(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(160, 24)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.tags = map[string]string{"dc": "zürich"}
+	sess.record(&statsSnapshot{Timestamp: time.Now(), Values: map[string]float64{"uptime": 10}, Raw: map[string]string{"uptime": "10"}}, nil)
+	u := newUI(2*time.Second, nil, sess, newSession("127.0.0.1:11212", 2*time.Second))
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	if header := lineFromCells(cells, width, 0); !strings.Contains(header, "dc=zürich  (refresh 2s)  updated") {
+		t.Fatalf("freshness should follow the header two columns on, got %q", header)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// gaugeLabelWidth keeps gauge bars aligned regardless of label length.
+const gaugeLabelWidth = 12
+
+// drawGauge renders a labelled horizontal bar that stretches across the given
+// width so utilisation can be judged at a glance instead of parsing numbers.
+// Bars are coloured by severity; higherIsBetter flips the scale for metrics
+// such as hit ratio where a full bar is the healthy state.
+func drawGauge(screen tcell.Screen, x, y, width int, label string, percent float64, higherIsBetter bool) {
+	if width <= 0 {
+		return
+	}
+	percent = clampPercent(percent)
+	shown := numberFormat{"gauges"}.percent("", percent)
+	suffix := fmt.Sprintf(" %5s%%", shown)
+	head := fmt.Sprintf("%-*s[", gaugeLabelWidth, label)
+	barWidth := width - len(head) - len(suffix) - 1
+	if barWidth < 1 {
+		drawText(screen, x, y, tcell.StyleDefault, fmt.Sprintf("%s %s%%", label, shown))
+		return
+	}
+
+	filled := gaugeFill(percent, barWidth)
+	barStyle := gaugeStyle(percent, higherIsBetter)
+
+	drawText(screen, x, y, tcell.StyleDefault, head)
+	pos := x + len(head)
+	drawText(screen, pos, y, barStyle, strings.Repeat("|", filled))
+	drawText(screen, pos+barWidth, y, tcell.StyleDefault, "]"+suffix)
+}
+
+// gaugeFill converts a percentage into the number of filled cells, rounding so
+// small but non-zero values still show a sliver of bar.
+func gaugeFill(percent float64, width int) int {
+	if width <= 0 {
+		return 0
+	}
+	percent = clampPercent(percent)
+	filled := int(math.Round(percent / 100 * float64(width)))
+	if filled == 0 && percent > 0 {
+		filled = 1
+	}
+	if filled > width {
+		filled = width
+	}
+	return filled
+}
+
+// gaugeStyle picks a traffic-light colour for a gauge so pressure stands out
+// without reading the percentage.
+func gaugeStyle(percent float64, higherIsBetter bool) tcell.Style {
+	severity := percent
+	if higherIsBetter {
+		severity = 100 - percent
+	}
+	switch {
+	case severity >= 90:
+		return theme.bad.Bold(true)
+	case severity >= 70:
+		return theme.warn
+	default:
+		return theme.good
+	}
+}
+
+// clampPercent keeps gauge inputs within 0-100 so malformed stats (for example
+// bytes briefly exceeding limit_maxbytes) cannot overflow the bar.
+func clampPercent(percent float64) float64 {
+	if math.IsNaN(percent) || percent < 0 {
+		return 0
+	}
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
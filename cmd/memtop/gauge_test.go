@@ -0,0 +1,76 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestGaugeFill(t *testing.T) {
+	tests := []struct {
+		name    string
+		percent float64
+		width   int
+		want    int
+	}{
+		{name: "empty", percent: 0, width: 10, want: 0},
+		{name: "half", percent: 50, width: 10, want: 5},
+		{name: "full", percent: 100, width: 10, want: 10},
+		{name: "sliver", percent: 0.1, width: 10, want: 1},
+		{name: "overflow", percent: 250, width: 10, want: 10},
+		{name: "noWidth", percent: 50, width: 0, want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := gaugeFill(tc.percent, tc.width); got != tc.want {
+				t.Fatalf("gaugeFill(%.1f, %d) = %d, want %d", tc.percent, tc.width, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGaugeStyleSeverity(t *testing.T) {
+	fg := func(style tcell.Style) tcell.Color {
+		c, _, _ := style.Decompose()
+		return c
+	}
+	if got := fg(gaugeStyle(95, false)); got != tcell.ColorRed {
+		t.Fatalf("95%% memory should be red, got %v", got)
+	}
+	if got := fg(gaugeStyle(95, true)); got != tcell.ColorGreen {
+		t.Fatalf("95%% hit ratio should be green, got %v", got)
+	}
+	if got := fg(gaugeStyle(75, false)); got != tcell.ColorYellow {
+		t.Fatalf("75%% memory should be yellow, got %v", got)
+	}
+}
+
+func TestDrawGaugeSpansWidth(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(60, 3)
+
+	drawGauge(screen, 0, 1, 60, "Memory", 50, false)
+	screen.Show()
+
+	cells, width, _ := screen.GetContents()
+	line := lineFromCells(cells, width, 1)
+	if !strings.HasPrefix(line, "Memory      [") {
+		t.Fatalf("gauge label missing, got %q", line)
+	}
+	if !strings.HasSuffix(line, "]  50.0%") {
+		t.Fatalf("gauge should end with percentage at the right edge, got %q", line)
+	}
+	if len(line) != 60 {
+		t.Fatalf("gauge should span the full width, got %d cells", len(line))
+	}
+	bars := strings.Count(line, "|")
+	if bars == 0 || bars > 40 {
+		t.Fatalf("unexpected bar fill count %d in %q", bars, line)
+	}
+}
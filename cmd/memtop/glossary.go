@@ -0,0 +1,54 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+// glossaryJSON describes memcached's stats, keyed by name. Settings are
+// prefixed with "settings:" since some share names with stats, and numeric
+// segments such as slab class ids are written as "*".
+//
+//go:embed glossary.json
+var glossaryJSON []byte
+
+// statInfo explains one stat: whether it is a counter that only grows, a
+// gauge, a setting, or plain information, what it counts, and what it means.
+type statInfo struct {
+	Kind        string `json:"kind"`
+	Unit        string `json:"unit"`
+	Description string `json:"description"`
+}
+
+// String renders the kind and unit, as in "counter, bytes".
+func (i statInfo) String() string {
+	if i.Unit == "" {
+		return i.Kind
+	}
+	return i.Kind + ", " + i.Unit
+}
+
+var glossary = func() map[string]statInfo {
+	g := make(map[string]statInfo)
+	if err := json.Unmarshal(glossaryJSON, &g); err != nil {
+		panic("glossary.json: " + err.Error())
+	}
+	return g
+}()
+
+// describeStat looks a stat of the given `stats` section up in the glossary.
+func describeStat(section, key string) (statInfo, bool) {
+	segments := strings.Split(key, ":")
+	for i, s := range segments {
+		if s != "" && strings.Trim(s, "0123456789") == "" {
+			segments[i] = "*"
+		}
+	}
+	key = strings.Join(segments, ":")
+	if section == "settings" {
+		key = "settings:" + key
+	}
+	info, ok := glossary[key]
+	return info, ok
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescribeStat(t *testing.T) {
+	for _, tc := range []struct {
+		section, key, want string
+	}{
+		{"", "evictions", "counter, items"},
+		{"", "bytes", "gauge, bytes"},
+		{"settings", "evictions", "setting"},
+		{"items", "items:12:evicted", "counter, items"},
+		{"slabs", "3:chunk_size", "setting, bytes"},
+	} {
+		info, ok := describeStat(tc.section, tc.key)
+		if !ok || info.String() != tc.want || info.Description == "" {
+			t.Errorf("describeStat(%q, %q) = %+v, %v; want %s", tc.section, tc.key, info, ok, tc.want)
+		}
+	}
+	if _, ok := describeStat("", "no_such_stat"); ok {
+		t.Errorf("unknown stat described")
+	}
+	for key, info := range glossary {
+		switch info.Kind {
+		case "counter", "gauge", "setting", "info":
+		default:
+			t.Errorf("%s has kind %q", key, info.Kind)
+		}
+	}
+}
+
+func TestStatsDescribe(t *testing.T) {
+	ln := startSectionServer(t, map[string]string{
+		"": "STAT curr_items 5\r\nSTAT x_custom 1\r\n",
+	})
+	defer ln.Close()
+
+	code, out, errOut := runCommand(t, ln.Addr().String(), "", "stats", "-describe")
+	if code != 0 {
+		t.Fatalf("stats -describe exited %d: %s", code, errOut)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "# Items currently stored. (gauge, items)") || strings.Fields(lines[1])[2] != "1" || strings.Contains(lines[1], "#") {
+		t.Fatalf("stats -describe printed:\n%s", out)
+	}
+	if code, _, _ := runCommand(t, ln.Addr().String(), "", "stats", "-describe", "-format", "json"); code != 2 {
+		t.Fatalf("-describe with json exited %d, want 2", code)
+	}
+}
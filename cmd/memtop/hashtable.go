@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// hashExpandLoad is the items per bucket at which memcached doubles its hash
+// table.
+const hashExpandLoad = 1.5
+
+// renderHashTablePanel shows the hash table's size, memory, and load, and
+// highlights an expansion in progress: while the table doubles, the
+// maintenance thread holds locks that show up as latency blips that are
+// otherwise hard to explain.
+func renderHashTablePanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	v := s.current.Values
+	power, ok := v["hash_power_level"]
+	if !ok {
+		return []panelLine{plainLine(notSupported)}
+	}
+	buckets := math.Pow(2, power)
+	lines := []panelLine{
+		plainLine(fmt.Sprintf("%-10s 2^%.0f (%s)", "buckets", power, numberFormat{"hashtable"}.count("hash_buckets", buckets))),
+		plainLine(fmt.Sprintf("%-10s %s", "memory", formatBytes(v["hash_bytes"]))),
+	}
+	load := v["curr_items"] / buckets
+	loadLine := plainLine(fmt.Sprintf("%-10s %.2f items per bucket (grows at %.1f)", "load", load, hashExpandLoad))
+	if load >= hashExpandLoad {
+		loadLine.Style = theme.warn
+	}
+	lines = append(lines, loadLine)
+	if v["hash_is_expanding"] > 0 {
+		// memcached raises hash_power_level as the expansion starts.
+		return append(lines, panelLine{Text: fmt.Sprintf("%-10s expanding from 2^%.0f buckets", "status", power-1), Style: theme.bad.Bold(true)})
+	}
+	return append(lines, plainLine(fmt.Sprintf("%-10s stable", "status")))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHashTablePanel(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{Values: map[string]float64{"hash_power_level": 16, "hash_bytes": 524288, "hash_is_expanding": 0, "curr_items": 131072}}
+	lines := renderHashTablePanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "buckets 2^16 (65536)|memory 512.0 KB|load 2.00 items per bucket (grows at 1.5)|status stable"; strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[2].Style != theme.warn {
+		t.Fatalf("a load past the expansion point should be highlighted")
+	}
+
+	sess.current.Values["hash_power_level"] = 17
+	sess.current.Values["hash_is_expanding"] = 1
+	lines = renderHashTablePanel(sess)
+	if last := lines[len(lines)-1]; !strings.Contains(last.Text, "expanding from 2^16") || last.Style != theme.bad.Bold(true) {
+		t.Fatalf("expansion not highlighted: %+v", last)
+	}
+
+	sess.current = &statsSnapshot{Values: map[string]float64{}}
+	if lines := renderHashTablePanel(sess); lines[0].Text != notSupported {
+		t.Fatalf("servers without hash stats should say so, got %+v", lines)
+	}
+}
+
+func TestDetectEventsLogsHashTableGrowth(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	prev := &statsSnapshot{Values: map[string]float64{"uptime": 10, "hash_power_level": 16}}
+	curr := &statsSnapshot{Timestamp: time.Unix(1000, 0), Values: map[string]float64{"uptime": 12, "hash_power_level": 17}}
+	sess.detectEvents(prev, curr)
+	events := sess.events.recent(1)
+	if len(events) != 1 || events[0].Kind != eventHashGrow || events[0].Message != "hash table grew from 2^16 to 2^17 buckets" {
+		t.Fatalf("events = %+v", events)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// healthRankLimit is how many of the least healthy servers the cluster view
+// lists above its table.
+const healthRankLimit = 5
+
+// Scores below these are highlighted in the ranking.
+const (
+	healthWarnScore     = 80
+	healthCriticalScore = 50
+)
+
+// healthSlowPoll is the average stats fetch time that costs a server the
+// whole latency weight.
+const healthSlowPoll = 250 * time.Millisecond
+
+// healthFactor is one ingredient of the health score: how bad a server looks
+// on one axis, from 0 (fine) to 1 (as bad as it gets), and how much of the
+// 100 points that axis is worth. ok is false when the server doesn't report
+// what the factor needs; the factor then costs nothing.
+type healthFactor struct {
+	Name    string
+	Weight  float64
+	Badness func(s *session) (badness float64, ok bool)
+}
+
+// healthFactors weigh the usual ways a memcached node goes wrong. The
+// weights add up to 100.
+var healthFactors = []healthFactor{
+	{Name: "hit ratio", Weight: 30, Badness: func(s *session) (float64, bool) {
+		ratio := s.windowHitRatio()
+		if math.IsNaN(ratio) {
+			if s.current.Values["get_hits"]+s.current.Values["get_misses"] == 0 {
+				return 0, false
+			}
+			ratio = hitRatio(s.current)
+		}
+		// 95% and above is healthy; 50% and below is as bad as it gets.
+		return clampUnit((95 - ratio) / 45), true
+	}},
+	{Name: "evictions", Weight: 25, Badness: func(s *session) (float64, bool) {
+		evicted := rateValue(s.rates, "evictions")
+		if evicted == 0 {
+			return 0, true
+		}
+		// Evictions per set: at one for one every write pushes out an item.
+		return clampUnit(evicted / max(rateValue(s.rates, "cmd_set"), 1)), true
+	}},
+	{Name: "memory", Weight: 15, Badness: func(s *session) (float64, bool) {
+		if s.current.Values["limit_maxbytes"] <= 0 {
+			return 0, false
+		}
+		// A cache is meant to be full; only the last 15% counts as pressure.
+		return clampUnit((memoryPercent(s.current) - 85) / 15), true
+	}},
+	{Name: "connections", Weight: 20, Badness: func(s *session) (float64, bool) {
+		limit := connectionLimit(s)
+		if limit <= 0 {
+			return 0, false
+		}
+		percent := s.current.Values["curr_connections"] / limit * 100
+		return clampUnit((percent - 50) / (connCriticalPercent - 50)), true
+	}},
+	{Name: "latency", Weight: 10, Badness: func(s *session) (float64, bool) {
+		_, avg, _, ok := s.latencySummary()
+		return clampUnit(float64(avg) / float64(healthSlowPoll)), ok
+	}},
+}
+
+// clampUnit limits v to [0, 1].
+func clampUnit(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+// nodeHealth is a server's composite health score, from 0 to 100, and the
+// factor that cost it the most points.
+type nodeHealth struct {
+	Server *session
+	Score  float64
+	Worst  string
+}
+
+// healthOf scores one server. A server that is down scores 0; one that has
+// not answered yet has no score.
+func healthOf(s *session) (nodeHealth, bool) {
+	h := nodeHealth{Server: s, Score: 100}
+	switch {
+	case s.lastErr != nil:
+		h.Score, h.Worst = 0, "down"
+		return h, true
+	case s.current == nil:
+		return h, false
+	}
+	var worstCost float64
+	for _, f := range healthFactors {
+		badness, ok := f.Badness(s)
+		if !ok {
+			continue
+		}
+		cost := f.Weight * badness
+		h.Score -= cost
+		if cost > worstCost {
+			worstCost, h.Worst = cost, f.Name
+		}
+	}
+	return h, true
+}
+
+// rankHealth scores every server that has reported, least healthy first;
+// ties keep the configured order.
+func rankHealth(servers []*session) []nodeHealth {
+	var ranked []nodeHealth
+	for _, s := range servers {
+		if h, ok := healthOf(s); ok {
+			ranked = append(ranked, h)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score < ranked[j].Score })
+	return ranked
+}
+
+// healthLines lists the least healthy servers for the cluster view,
+// highlighting the selected one like the table below does.
+func healthLines(u *ui) []panelLine {
+	ranked := rankHealth(u.servers)
+	if len(ranked) == 0 {
+		return nil
+	}
+	lines := []panelLine{{Text: "Health (worst first)", Style: tcell.StyleDefault.Bold(true)}}
+	for _, h := range ranked[:min(len(ranked), healthRankLimit)] {
+		line := plainLine(fmt.Sprintf("  %-26s %5.0f", h.Server.addr, h.Score))
+		if h.Worst != "" {
+			line.Text += "  worst: " + h.Worst
+		}
+		switch {
+		case h.Score < healthCriticalScore:
+			line.Style = theme.bad.Bold(true)
+		case h.Score < healthWarnScore:
+			line.Style = theme.warn
+		}
+		if h.Server == u.current() {
+			line.Style = line.Style.Reverse(true)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
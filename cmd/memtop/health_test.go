@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestHealthScoreWeighsFactors(t *testing.T) {
+	healthy := clusterTestServer("a:11211", nil, 99, 1)
+	if h, ok := healthOf(healthy); !ok || h.Score != 100 || h.Worst != "" {
+		t.Fatalf("healthy server scored %+v", h)
+	}
+
+	// A 50% hit ratio costs the whole hit ratio weight.
+	missing := clusterTestServer("b:11211", nil, 50, 50)
+	if h, _ := healthOf(missing); h.Score != 70 || h.Worst != "hit ratio" {
+		t.Fatalf("missing server scored %+v", h)
+	}
+
+	// Evicting on half of all sets, with memory full and connections at 70%
+	// of the limit.
+	pressed := clusterTestServer("c:11211", nil, 99, 1)
+	pressed.rates = map[string]float64{"cmd_set": 100, "evictions": 50}
+	pressed.current.Values["bytes"] = 1024
+	pressed.current.Values["curr_connections"] = 700
+	pressed.current.Values["max_connections"] = 1000
+	h, _ := healthOf(pressed)
+	if want := 100 - 12.5 - 15 - 10.0; h.Score != want || h.Worst != "memory" {
+		t.Fatalf("pressed server scored %+v, want %.1f", h, want)
+	}
+
+	down := newSession("d:11211", time.Second)
+	down.lastErr = errors.New("connection refused")
+	if h, ok := healthOf(down); !ok || h.Score != 0 || h.Worst != "down" {
+		t.Fatalf("down server scored %+v", h)
+	}
+	if _, ok := healthOf(newSession("e:11211", time.Second)); ok {
+		t.Fatalf("a server that never answered should have no score")
+	}
+
+	ranked := rankHealth([]*session{healthy, missing, newSession("e:11211", time.Second), pressed, down})
+	var order []string
+	for _, h := range ranked {
+		order = append(order, h.Server.addr)
+	}
+	if got := strings.Join(order, ","); got != "d:11211,c:11211,b:11211,a:11211" {
+		t.Fatalf("ranking = %s", got)
+	}
+}
+
+func TestClusterViewRanksWorstServerFirst(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(100, 20)
+
+	u := newUI(time.Second, nil,
+		clusterTestServer("a:11211", nil, 99, 1),
+		clusterTestServer("b:11211", nil, 10, 90),
+	)
+	u.view = viewCluster
+	drawScreen(screen, u)
+
+	cells, width, height := screen.GetContents()
+	for row := 0; row < height; row++ {
+		if !strings.HasPrefix(lineFromCells(cells, width, row), "Health (worst first)") {
+			continue
+		}
+		if worst := lineFromCells(cells, width, row+1); !strings.Contains(worst, "b:11211") || !strings.Contains(worst, "worst: hit ratio") {
+			t.Fatalf("worst server should lead the ranking, got %q", worst)
+		}
+		return
+	}
+	t.Fatalf("health ranking missing from cluster view")
+}
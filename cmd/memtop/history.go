@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// defaultHistoryLimit bounds how many samples are retained per series; it is
+// enough to fill a wide terminal with braille charts (two samples per cell).
+const defaultHistoryLimit = 600
+
+// historySeries describes a value memtop charts over time. Value reports false
+// when the sample does not carry enough data (for example the first tick after
+// a rate reset) so the chart shows a gap instead of a misleading zero.
+type historySeries struct {
+	Name  string
+	Label string
+	Max   float64 // fixed chart ceiling; zero means auto-scale to the data
+	Value func(stats *statsSnapshot, rates map[string]float64) (float64, bool)
+}
+
+// chartedSeries lists the series recorded on every sample, in display order.
+var chartedSeries = []historySeries{
+	{Name: "get_rate", Label: "Gets/s", Value: rateSeries("cmd_get")},
+	{Name: "set_rate", Label: "Sets/s", Value: rateSeries("cmd_set")},
+	{Name: "hit_ratio", Label: "Hit ratio %", Max: 100, Value: func(stats *statsSnapshot, _ map[string]float64) (float64, bool) {
+		return hitRatio(stats), true
+	}},
+	{Name: "memory", Label: "Memory %", Max: 100, Value: func(stats *statsSnapshot, _ map[string]float64) (float64, bool) {
+		return memoryPercent(stats), true
+	}},
+}
+
+// rateSeries builds a series extractor for a per-second rate.
+func rateSeries(key string) func(*statsSnapshot, map[string]float64) (float64, bool) {
+	return func(_ *statsSnapshot, rates map[string]float64) (float64, bool) {
+		v, ok := rates[key]
+		return v, ok
+	}
+}
+
+// history keeps a bounded window of recent samples for each charted series so
+// the UI can show trends rather than only the latest reading.
+type history struct {
+	limit  int
+	times  []time.Time
+	series map[string][]float64
+}
+
+// newHistory creates an empty history that retains at most limit samples.
+func newHistory(limit int) *history {
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return &history{
+		limit:  limit,
+		series: make(map[string][]float64),
+	}
+}
+
+// add appends one sample for every charted series, trimming the oldest
+// entries once the limit is reached. Missing values are stored as NaN.
+func (h *history) add(stats *statsSnapshot, rates map[string]float64) {
+	if h == nil || stats == nil {
+		return
+	}
+	h.times = appendBounded(h.times, stats.Timestamp, h.limit)
+	for _, s := range chartedSeries {
+		v, ok := s.Value(stats, rates)
+		if !ok {
+			v = math.NaN()
+		}
+		h.series[s.Name] = appendBounded(h.series[s.Name], v, h.limit)
+	}
+}
+
+// values returns the recorded samples for a series, oldest first.
+func (h *history) values(name string) []float64 {
+	if h == nil {
+		return nil
+	}
+	return h.series[name]
+}
+
+// len reports how many samples are currently retained.
+func (h *history) len() int {
+	if h == nil {
+		return 0
+	}
+	return len(h.times)
+}
+
+// appendBounded appends v and drops the oldest elements beyond limit.
+func appendBounded[T any](items []T, v T, limit int) []T {
+	items = append(items, v)
+	if over := len(items) - limit; over > 0 {
+		items = append(items[:0], items[over:]...)
+	}
+	return items
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistoryAddTrimsToLimit(t *testing.T) {
+	h := newHistory(3)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		stats := &statsSnapshot{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Values:    map[string]float64{"get_hits": float64(i), "get_misses": 1},
+		}
+		h.add(stats, map[string]float64{"cmd_get": float64(i * 10)})
+	}
+
+	if got := h.len(); got != 3 {
+		t.Fatalf("history length = %d, want 3", got)
+	}
+	gets := h.values("get_rate")
+	if len(gets) != 3 || gets[0] != 20 || gets[2] != 40 {
+		t.Fatalf("get_rate history = %v, want [20 30 40]", gets)
+	}
+}
+
+func TestHistoryRecordsGapsForMissingRates(t *testing.T) {
+	h := newHistory(10)
+	h.add(&statsSnapshot{Timestamp: time.Now(), Values: map[string]float64{}}, map[string]float64{})
+
+	gets := h.values("get_rate")
+	if len(gets) != 1 || !math.IsNaN(gets[0]) {
+		t.Fatalf("missing rate should be stored as NaN, got %v", gets)
+	}
+	if mem := h.values("memory"); len(mem) != 1 || mem[0] != 0 {
+		t.Fatalf("memory series should record zero without limit_maxbytes, got %v", mem)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// commandRatio names the hit and miss counters of one command. cas counts a
+// cas against a changed item (cas_badval) as a miss too.
+type commandRatio struct {
+	Command string
+	Hits    string
+	Misses  []string
+}
+
+// commandRatios lists the commands with hit/miss counter pairs. Miss storms
+// mean different things per command: touch and delete misses usually point
+// at clients working on keys that already expired or were never set.
+var commandRatios = []commandRatio{
+	{"get", "get_hits", []string{"get_misses"}},
+	{"touch", "touch_hits", []string{"touch_misses"}},
+	{"incr", "incr_hits", []string{"incr_misses"}},
+	{"decr", "decr_hits", []string{"decr_misses"}},
+	{"delete", "delete_hits", []string{"delete_misses"}},
+	{"cas", "cas_hits", []string{"cas_misses", "cas_badval"}},
+}
+
+// ratioOf returns hits as a percentage of hits plus misses, or NaN when
+// there were none.
+func ratioOf(values map[string]float64, c commandRatio) (float64, float64) {
+	hits := values[c.Hits]
+	misses := 0.0
+	for _, key := range c.Misses {
+		misses += values[key]
+	}
+	if hits+misses <= 0 {
+		return math.NaN(), 0
+	}
+	return hits / (hits + misses) * 100, misses
+}
+
+// renderCommandRatiosPanel shows each command's lifetime hit ratio next to
+// the ratio and miss rate over the last interval. Commands that missed more
+// than they hit recently are highlighted.
+func renderCommandRatiosPanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	nf := numberFormat{"hitratio"}
+	lines := []panelLine{plainLine(fmt.Sprintf("%-7s %8s %8s %10s", "", "lifetime", "recent", "misses"+rateUnit(s.interval)))}
+	for _, c := range commandRatios {
+		lifetime, _ := ratioOf(s.current.Values, c)
+		if math.IsNaN(lifetime) {
+			continue
+		}
+		recent, missRate := ratioOf(s.rates, c)
+		line := plainLine(fmt.Sprintf("%-7s %8s %8s %10s", c.Command, nf.ratio(c.Command, lifetime), nf.ratio(c.Command, recent), nf.rate(c.Command, displayRate(missRate, s.interval))))
+		if recent < 50 {
+			line.Style = theme.warn
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 1 {
+		return []panelLine{plainLine("No hits or misses yet")}
+	}
+	return lines
+}
+
+// ratio prints a percentage followed by "%", or a dash for commands with
+// no traffic.
+func (f numberFormat) ratio(metric string, percent float64) string {
+	if math.IsNaN(percent) {
+		return "-"
+	}
+	return f.percent(metric, percent) + "%"
+}
+
+// defaultHitWindow is the sliding window of the windowed get hit ratio.
+const defaultHitWindow = 5 * time.Minute
+
+// hitSample is one reading of the get counters, kept for the sliding window.
+type hitSample struct {
+	At     time.Time
+	Hits   float64
+	Misses float64
+}
+
+// observeHits adds a sample to the sliding window, keeping the newest
+// sample at least a window old as the window's start. A counter going
+// backwards means the server restarted, which starts a new window.
+func (s *session) observeHits(stats *statsSnapshot) {
+	sample := hitSample{At: stats.Timestamp, Hits: stats.Values["get_hits"], Misses: stats.Values["get_misses"]}
+	if n := len(s.hitSamples); n > 0 && (sample.Hits < s.hitSamples[n-1].Hits || sample.Misses < s.hitSamples[n-1].Misses) {
+		s.hitSamples = nil
+	}
+	s.hitSamples = append(s.hitSamples, sample)
+	cutoff := sample.At.Add(-s.hitWindow)
+	for len(s.hitSamples) > 2 && !s.hitSamples[1].At.After(cutoff) {
+		s.hitSamples = s.hitSamples[1:]
+	}
+}
+
+// windowHitRatio is the get hit ratio over the sliding window, or NaN until
+// the window holds two samples with gets between them.
+func (s *session) windowHitRatio() float64 {
+	if len(s.hitSamples) < 2 {
+		return math.NaN()
+	}
+	first, last := s.hitSamples[0], s.hitSamples[len(s.hitSamples)-1]
+	hits, misses := last.Hits-first.Hits, last.Misses-first.Misses
+	if hits+misses <= 0 {
+		return math.NaN()
+	}
+	return hits / (hits + misses) * 100
+}
+
+// intervalHitRatio is the get hit ratio over the last interval.
+func (s *session) intervalHitRatio() float64 {
+	ratio, _ := ratioOf(s.rates, commandRatios[0])
+	return ratio
+}
+
+// shortDuration prints a duration without zero trailing units, as in "5m".
+func shortDuration(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// describeMisses breaks the get miss rate down by cause for the summary:
+// items that had expired (get_expired), items hidden by a flush_all
+// (get_flushed), and keys that were simply absent, the rest. Each calls for
+// different remediation. Servers too old to report the causes show only
+// the total.
+func describeMisses(s *session) string {
+	misses := rateValue(s.rates, "get_misses")
+	nf := numberFormat{"summary"}
+	shown := func(key string, rate float64) string { return nf.rate(key, displayRate(rate, s.interval)) }
+	text := fmt.Sprintf("Misses%s: %s", rateUnit(s.interval), shown("get_misses", misses))
+	absent, split := misses, false
+	for _, c := range []struct{ label, key string }{
+		{"expired", "get_expired"},
+		{"flushed", "get_flushed"},
+	} {
+		if _, ok := s.current.Values[c.key]; !ok {
+			continue
+		}
+		rate := rateValue(s.rates, c.key)
+		absent -= rate
+		split = true
+		text += fmt.Sprintf("  %s %s", c.label, shown(c.key, rate))
+	}
+	if split {
+		text += "  absent " + shown("get_misses", max(absent, 0))
+	}
+	return text
+}
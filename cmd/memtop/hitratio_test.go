@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandRatiosPanel(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if lines := renderCommandRatiosPanel(sess); lines != nil {
+		t.Fatalf("no panel before the first sample, got %+v", lines)
+	}
+	sess.current = &statsSnapshot{Values: map[string]float64{
+		"get_hits": 90, "get_misses": 10,
+		"touch_hits": 1, "touch_misses": 3,
+		"cas_hits": 6, "cas_misses": 1, "cas_badval": 1,
+	}}
+	sess.rates = map[string]float64{"get_hits": 9, "get_misses": 1, "touch_misses": 5}
+
+	var got []string
+	for _, l := range renderCommandRatiosPanel(sess)[1:] {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "get 90.0% 90.0% 1.0|touch 25.0% 0.0% 5.0|cas 75.0% - 0.0"; strings.Join(got, "|") != want {
+		t.Fatalf("rows = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines := renderCommandRatiosPanel(sess); lines[2].Style != theme.warn || lines[1].Style == theme.warn {
+		t.Fatalf("only the touch miss storm should be highlighted")
+	}
+}
+
+func TestWindowHitRatio(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.hitWindow = time.Minute
+	start := time.Unix(1000, 0)
+	feed := func(offset time.Duration, hits, misses float64) {
+		sess.record(&statsSnapshot{Timestamp: start.Add(offset), Values: map[string]float64{"get_hits": hits, "get_misses": misses}}, nil)
+	}
+
+	nf := numberFormat{"summary"}
+	feed(0, 1000000, 0)
+	if got := nf.ratio("", sess.windowHitRatio()); got != "-" {
+		t.Fatalf("one sample has no window, got %s", got)
+	}
+	feed(30*time.Second, 1000050, 50)
+	feed(60*time.Second, 1000100, 100)
+	if got := nf.ratio("", sess.windowHitRatio()); got != "50.0%" {
+		t.Fatalf("window ratio = %s, want 50.0%% despite the lifetime ratio near 100%%", got)
+	}
+	feed(90*time.Second, 1000190, 110)
+	if got := nf.ratio("", sess.windowHitRatio()); got != "70.0%" {
+		t.Fatalf("older samples should leave the window, got %s", got)
+	}
+	if got := nf.ratio("", sess.intervalHitRatio()); got != "90.0%" {
+		t.Fatalf("interval ratio = %s, want 90.0%%", got)
+	}
+
+	feed(120*time.Second, 10, 0)
+	if len(sess.hitSamples) != 1 {
+		t.Fatalf("a counter reset should restart the window, got %d samples", len(sess.hitSamples))
+	}
+	if got := shortDuration(5 * time.Minute); got != "5m" {
+		t.Fatalf("shortDuration = %q", got)
+	}
+}
+
+func TestDescribeMissesSplitsTheCauses(t *testing.T) {
+	s := newSession("a:11211", time.Second)
+	s.current = &statsSnapshot{Values: map[string]float64{"get_misses": 100}}
+	s.rates = map[string]float64{"get_misses": 10}
+	if got, want := describeMisses(s), "Misses/s: 10.00"; got != want {
+		t.Fatalf("describeMisses = %q, want %q", got, want)
+	}
+	s.current.Values["get_expired"] = 30
+	s.current.Values["get_flushed"] = 5
+	s.rates["get_expired"] = 6
+	s.rates["get_flushed"] = 1
+	if got, want := describeMisses(s), "Misses/s: 10.00  expired 6.00  flushed 1.00  absent 3.00"; got != want {
+		t.Fatalf("describeMisses = %q, want %q", got, want)
+	}
+}
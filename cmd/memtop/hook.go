@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHookTimeout bounds one alert hook run.
+const defaultHookTimeout = 30 * time.Second
+
+// alertHook runs a command whenever a script alert is raised or cleared,
+// for site-specific automation such as paging or scaling out. The alert is
+// passed in the environment: SERVER, STATE (firing or resolved), MESSAGE,
+// METRIC, VALUE, and THRESHOLD, the last three empty when the rule didn't
+// give them. A rule's own exec= command takes precedence over command;
+// alerts with neither are skipped. Commands run in the background, so a slow
+// hook doesn't hold up sampling, and failures go to the error log. Hooks
+// still running when memtop quits are stopped and waited for by close.
+type alertHook struct {
+	command []string
+	timeout time.Duration
+	start   func(u *ui, args, env []string)
+	seq     int
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func newAlertHook(command []string) *alertHook {
+	h := &alertHook{command: command, timeout: defaultHookTimeout}
+	h.ctx, h.cancel = context.WithCancel(context.Background())
+	h.start = h.runInBackground
+	return h
+}
+
+// close stops the hooks still running and waits for them to exit.
+func (h *alertHook) close() {
+	h.cancel()
+	h.running.Wait()
+}
+
+// hookEnv describes an alert event as environment variables.
+func hookEnv(e event) []string {
+	a := e.Alert
+	return []string{
+		"SERVER=" + e.Server,
+		"STATE=" + a.State,
+		"MESSAGE=" + a.Message,
+		"METRIC=" + a.Metric,
+		"VALUE=" + formatAlertNumber(a.Value),
+		"THRESHOLD=" + formatAlertNumber(a.Threshold),
+	}
+}
+
+func (h *alertHook) write(u *ui) error {
+	events, seq := u.events.since(h.seq)
+	h.seq = seq
+	for _, e := range events {
+		if e.Alert == nil {
+			continue
+		}
+		args := h.command
+		if len(e.Alert.Exec) > 0 {
+			args = e.Alert.Exec
+		}
+		if len(args) == 0 {
+			continue
+		}
+		h.start(u, args, hookEnv(e))
+	}
+	return nil
+}
+
+// runInBackground runs one hook with the alert added to memtop's own
+// environment and logs it if it fails or outlives the timeout.
+func (h *alertHook) runInBackground(u *ui, args, env []string) {
+	h.running.Add(1)
+	go func() {
+		defer h.running.Done()
+		defer u.panics.restore()
+		ctx, cancel := context.WithTimeout(h.ctx, h.timeout)
+		defer cancel()
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(), env...)
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if h.ctx.Err() != nil {
+			return
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("timed out after %s", h.timeout)
+		} else if msg := strings.TrimSpace(stderr.String()); err != nil && msg != "" {
+			err = fmt.Errorf("%v: %s", err, firstLine(msg))
+		}
+		if err != nil {
+			u.errors.add(logEntry{Time: time.Now(), Level: levelError, Source: "alert hook", Message: fmt.Sprintf("%s: %v", args[0], err)})
+		}
+	}()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAlertHookRunsOnTransitionsWithAlertEnvironment(t *testing.T) {
+	sc, err := loadScript("rules.star", `
+def on_sample(sample, state):
+    rate = sample.rates.get("evictions", 0)
+    if rate > 100:
+        alert("eviction storm", metric="evictions", value=rate, threshold=100)
+    if rate > 1000:
+        alert("page someone", exec=["/usr/local/bin/page", "--urgent"])
+`)
+	if err != nil {
+		t.Fatalf("loadScript: %v", err)
+	}
+	sess := newSession("10.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	type run struct{ args, env []string }
+	var runs []run
+	h := newAlertHook([]string{"scale-out"})
+	h.start = func(u *ui, args, env []string) { runs = append(runs, run{args, env}) }
+	feed := func(evictions float64) {
+		sess.current = &statsSnapshot{Timestamp: time.Now()}
+		sess.rates = map[string]float64{"evictions": evictions}
+		runScript(sess, sc, time.Now())
+		h.write(u)
+	}
+
+	feed(500)
+	feed(600)
+	feed(5000)
+	feed(0)
+	if len(runs) != 4 {
+		t.Fatalf("want runs for each raise and clear, got %+v", runs)
+	}
+	want := "SERVER=10.0.0.1:11211 STATE=firing MESSAGE=eviction storm METRIC=evictions VALUE=500 THRESHOLD=100"
+	if runs[0].args[0] != "scale-out" || strings.Join(runs[0].env, " ") != want {
+		t.Fatalf("first run = %+v, want env %q", runs[0], want)
+	}
+	if strings.Join(runs[1].args, " ") != "/usr/local/bin/page --urgent" {
+		t.Fatalf("the rule's own command should run, got %+v", runs[1])
+	}
+	for _, r := range runs[2:] {
+		if !strings.Contains(strings.Join(r.env, " "), "STATE=resolved") {
+			t.Fatalf("clearing should run with STATE=resolved, got %+v", r)
+		}
+	}
+}
+
+func TestAlertHookRunsCommandsAndLogsFailures(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	sess := newSession("10.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	h := newAlertHook([]string{"sh", "-c", `echo "$SERVER $STATE" > ` + out})
+	sess.logAlert(time.Now(), alertInfo{Message: "full", State: alertFiring})
+	sess.logAlert(time.Now(), alertInfo{Message: "broken", State: alertFiring, Exec: []string{"sh", "-c", "echo nope >&2; exit 3"}})
+	h.write(u)
+
+	deadline := time.Now().Add(5 * time.Second)
+	var data []byte
+	for time.Now().Before(deadline) {
+		data, _ = os.ReadFile(out)
+		if len(data) > 0 && len(u.errors.recent(1)) > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if strings.TrimSpace(string(data)) != "10.0.0.1:11211 firing" {
+		t.Fatalf("hook wrote %q", data)
+	}
+	logged := u.errors.recent(1)
+	if len(logged) != 1 || logged[0].Source != "alert hook" || !strings.Contains(logged[0].Message, "nope") {
+		t.Fatalf("failure should be logged with its stderr, got %+v", logged)
+	}
+}
+
+func TestAlertHookCloseStopsRunningHooks(t *testing.T) {
+	sess := newSession("10.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	h := newAlertHook([]string{"sleep", "30"})
+	sess.logAlert(time.Now(), alertInfo{Message: "full", State: alertFiring})
+	h.write(u)
+
+	start := time.Now()
+	h.close()
+	if took := time.Since(start); took > 5*time.Second {
+		t.Fatalf("close waited %s for a hook it should have stopped", took)
+	}
+	if logged := u.errors.recent(1); len(logged) != 0 {
+		t.Fatalf("a hook stopped on quit is not a failure, got %+v", logged)
+	}
+}
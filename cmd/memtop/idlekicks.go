@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// idleChurnPercent is the share of new connections replacing idle-kicked
+// ones from which the churn is highlighted: most clients then reconnect only
+// because the server timed them out.
+const idleChurnPercent = 50
+
+// renderIdleKicksPanel shows connection churn the summary hides: clients
+// closed by idle_timeout (idle_kicks), set against the connections opened,
+// and the share of time the server stopped accepting connections because it
+// hit its limit (time_in_listen_disabled_us). Both cost reconnects and
+// latency that look like network trouble from the client side.
+func renderIdleKicksPanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	v := s.current.Values
+	_, haveKicks := v["idle_kicks"]
+	_, havePaused := v["time_in_listen_disabled_us"]
+	if !haveKicks && !havePaused {
+		return []panelLine{plainLine(notSupported)}
+	}
+	nf := numberFormat{"idlekicks"}
+	unit := rateUnit(s.interval)
+	var lines []panelLine
+	if s.caps != nil {
+		timeout := "off"
+		if s.caps.IdleTimeout > 0 {
+			timeout = (time.Duration(s.caps.IdleTimeout) * time.Second).String()
+		}
+		lines = append(lines, plainLine(fmt.Sprintf("%-16s %s", "idle timeout", timeout)))
+	}
+	if haveKicks {
+		kicks := rateValue(s.rates, "idle_kicks")
+		line := plainLine(fmt.Sprintf("%-16s %s", "idle kicks"+unit, nf.rate("idle_kicks", displayRate(kicks, s.interval))))
+		if opened := rateValue(s.rates, "total_connections"); kicks > 0 && opened > 0 {
+			churn := min(kicks/opened*100, 100)
+			line.Text += fmt.Sprintf(" (%s%% of new connections)", nf.percent("idle_kicks", churn))
+			if churn >= idleChurnPercent {
+				line.Style = theme.warn
+			}
+		}
+		lines = append(lines, line)
+	}
+	if havePaused {
+		// The counter grows by microseconds per second while accepting is
+		// off, so its rate is the share of time spent paused.
+		paused := min(rateValue(s.rates, "time_in_listen_disabled_us")/1e4, 100)
+		line := plainLine(fmt.Sprintf("%-16s %s%% of the time", "accept paused", nf.percent("time_in_listen_disabled_us", paused)))
+		if paused > 0 {
+			line.Style = theme.bad.Bold(true)
+		}
+		total := time.Duration(v["time_in_listen_disabled_us"]) * time.Microsecond
+		lines = append(lines, line, plainLine(fmt.Sprintf("%-16s %s", "paused in total", total.Round(time.Millisecond))))
+	}
+	return lines
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdleKicksPanel(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if lines := renderIdleKicksPanel(sess); lines != nil {
+		t.Fatalf("no panel before the first sample, got %+v", lines)
+	}
+	sess.current = &statsSnapshot{Values: map[string]float64{"curr_connections": 10}}
+	if lines := renderIdleKicksPanel(sess); lines[0].Text != notSupported {
+		t.Fatalf("servers without the stats should say so, got %+v", lines)
+	}
+
+	sess.current = &statsSnapshot{Values: map[string]float64{"idle_kicks": 400, "time_in_listen_disabled_us": 2500000}}
+	sess.rates = map[string]float64{"idle_kicks": 6, "total_connections": 10, "time_in_listen_disabled_us": 125000}
+	sess.caps = detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21"}},
+		&statsSnapshot{Values: map[string]float64{"idle_timeout": 300}, Raw: map[string]string{}})
+	lines := renderIdleKicksPanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	want := "idle timeout 5m0s|idle kicks/s 6.00 (60.0% of new connections)|accept paused 12.5% of the time|paused in total 2.5s"
+	if strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[1].Style != theme.warn || lines[2].Style != theme.bad.Bold(true) || lines[3].Style == lines[2].Style {
+		t.Fatalf("churn and accept pauses should be highlighted: %+v", lines)
+	}
+
+	sess.rates = map[string]float64{"idle_kicks": 1, "total_connections": 10}
+	sess.caps.IdleTimeout = 0
+	lines = renderIdleKicksPanel(sess)
+	if lines[0].Text != "idle timeout     off" || lines[1].Style == theme.warn || lines[2].Style == theme.bad.Bold(true) {
+		t.Fatalf("quiet server should not be highlighted: %+v", lines)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// passTracker holds the cancel function of the sampling pass in flight, so
+// other goroutines (the key reader, the control endpoint) can abort a pass
+// that is stuck on a slow server instead of waiting out its timeout.
+type passTracker struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// inflight tracks the passes run by the sampling loop.
+var inflight passTracker
+
+// run calls fn with a context that abort cancels, and that is cancelled with
+// parent. It reports whether the pass ran to completion.
+func (p *passTracker) run(parent context.Context, fn func(ctx context.Context)) bool {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.cancel = nil
+		p.mu.Unlock()
+	}()
+	fn(ctx)
+	return ctx.Err() == nil
+}
+
+// abort cancels the pass in flight, if any.
+func (p *passTracker) abort() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestAbortCancelsStuckPass(t *testing.T) {
+	// A server that accepts connections but never answers.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	s := newSession(ln.Addr().String(), time.Second)
+	u := newUI(time.Second, nil, s)
+	time.AfterFunc(50*time.Millisecond, inflight.abort)
+
+	start := time.Now()
+	if inflight.run(context.Background(), func(ctx context.Context) { sample(ctx, u) }) {
+		t.Fatalf("aborted pass reported as completed")
+	}
+	if took := time.Since(start); took >= defaultTimeout {
+		t.Fatalf("pass took %s, want it cut short", took)
+	}
+	if s.lastErr != nil || len(u.events.recent(10)) != 0 {
+		t.Fatalf("an aborted fetch should not count as an outage: err %v, events %+v", s.lastErr, u.events.recent(10))
+	}
+
+	inflight.abort() // no pass in flight: nothing to do
+}
+
+func TestQuitKeysInDialogsKeepThePass(t *testing.T) {
+	u := newUI(time.Second, nil, newSession("127.0.0.1:11211", time.Second))
+	q := tcell.NewEventKey(tcell.KeyRune, 'q', tcell.ModNone)
+	if !u.abortsPass(q) {
+		t.Fatalf("q in the view should abort the pass")
+	}
+	u.prompt = &textPrompt{label: "Note"}
+	u.keysTaken.Store(u.takingKeys())
+	if u.abortsPass(q) || u.abortsPass(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)) {
+		t.Fatalf("q or Esc typed into the note prompt should not abort the pass")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// minInterval is the shortest refresh interval accepted. Below it a pass
+// rarely finishes before the next one is due, and rates turn into noise.
+const minInterval = 100 * time.Millisecond
+
+// minPollTimeout keeps short intervals from failing servers that are merely
+// a network hop away.
+const minPollTimeout = 500 * time.Millisecond
+
+// minFrameGap caps how often samples redraw the screen: at sub-second
+// intervals drawing every sample costs CPU without anyone seeing the
+// difference. Keys still redraw at once.
+const minFrameGap = 200 * time.Millisecond
+
+// validateInterval rejects refresh intervals memtop can't keep up with.
+func validateInterval(d time.Duration) error {
+	if d < minInterval {
+		return fmt.Errorf("refresh interval %s is below the %s minimum", d, minInterval)
+	}
+	return nil
+}
+
+// pollTimeoutFor is the per-server poll timeout used unless -poll-timeout
+// sets one: the interval, so a slow server doesn't hold up the next pass,
+// between minPollTimeout and defaultTimeout.
+func pollTimeoutFor(interval time.Duration) time.Duration {
+	return min(max(interval, minPollTimeout), defaultTimeout)
+}
+
+// redrawEvery is how often the screen is redrawn between samples, to keep
+// the header's "updated ... ago" current.
+func redrawEvery(interval time.Duration) time.Duration {
+	if interval < time.Second {
+		return minFrameGap
+	}
+	return time.Second
+}
+
+// agePrecision is how finely the header reports a sample's age: seconds,
+// or tenths of a second at sub-second intervals.
+func agePrecision(interval time.Duration) time.Duration {
+	if interval < time.Second {
+		return 100 * time.Millisecond
+	}
+	return time.Second
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSubSecondIntervals(t *testing.T) {
+	if err := validateInterval(250 * time.Millisecond); err != nil {
+		t.Fatalf("250ms rejected: %v", err)
+	}
+	if err := validateInterval(50 * time.Millisecond); err == nil {
+		t.Fatalf("50ms accepted")
+	}
+
+	for interval, want := range map[time.Duration]time.Duration{
+		250 * time.Millisecond: minPollTimeout,
+		time.Second:            time.Second,
+		time.Minute:            defaultTimeout,
+	} {
+		if got := pollTimeoutFor(interval); got != want {
+			t.Errorf("pollTimeoutFor(%s) = %s, want %s", interval, got, want)
+		}
+	}
+	if got := redrawEvery(250 * time.Millisecond); got != minFrameGap {
+		t.Errorf("redrawEvery(250ms) = %s, want %s", got, minFrameGap)
+	}
+
+	// Rates over a fractional elapsed time scale up to per-second figures.
+	start := time.Unix(1700000000, 0)
+	prev := &statsSnapshot{Timestamp: start, Values: map[string]float64{"cmd_get": 100}}
+	curr := &statsSnapshot{Timestamp: start.Add(250 * time.Millisecond), Values: map[string]float64{"cmd_get": 110}}
+	if got := calculateRates(curr, prev)["cmd_get"]; got != 40 {
+		t.Fatalf("rate over 250ms = %v, want 40", got)
+	}
+
+	s := newSession("a:11211", 250*time.Millisecond)
+	s.current = curr
+	if got, _ := describeFreshness(s, curr.Timestamp.Add(340*time.Millisecond)); got != "updated 300ms ago" {
+		t.Fatalf("freshness = %q, want tenths of a second", got)
+	}
+}
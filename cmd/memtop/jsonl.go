@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// jsonlWriter writes one JSON object per server and sample, for piping into
+// jq, vector, or fluent-bit.
+type jsonlWriter struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+// write emits the latest sample of every server, flushing writers that
+// buffer (such as compressed recordings) once the sample is complete.
+func (w *jsonlWriter) write(u *ui) error {
+	return w.writeRecords(sampleRecords(u))
+}
+
+// writeRecords emits records and flushes once they are all written.
+func (w *jsonlWriter) writeRecords(records []sampleRecord) error {
+	for _, rec := range records {
+		if err := w.enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	if f, ok := w.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// streamJSONLines samples every interval and writes JSON lines without the
+// TUI, until ctx is cancelled or writing fails.
+func streamJSONLines(ctx context.Context, u *ui, w io.Writer) error {
+	out := newJSONLWriter(w)
+	return runHeadless(ctx, u, func() error { return out.write(u) })
+}
+
+// runHeadless samples every interval and calls write, then the configured
+// sinks, after each pass, until ctx is cancelled or write fails. Remote
+// control requests are applied between passes, and nothing is sampled while
+// polling is paused.
+func runHeadless(ctx context.Context, u *ui, write func() error) error {
+	ticker := time.NewTicker(u.interval)
+	defer ticker.Stop()
+	for {
+		if !u.suspended {
+			completed := inflight.run(ctx, func(ctx context.Context) { sample(ctx, u) })
+			if ctx.Err() != nil {
+				return nil
+			}
+			if completed {
+				if err := write(); err != nil {
+					return err
+				}
+				u.sinks.write(u)
+			}
+		}
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+				break wait
+			case req := <-u.control:
+				u.handleControl(req, ticker)
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamJSONLinesWritesOneObjectPerServer(t *testing.T) {
+	ln := startSectionServer(t, map[string]string{"": "STAT curr_items 7\r\nSTAT version 1.6.21\r\n"})
+	defer ln.Close()
+	up := newSession(ln.Addr().String(), time.Second)
+	up.tags = map[string]string{"dc": "eu1"}
+	down := newSession("127.0.0.1:1", time.Second)
+	u := newUI(time.Second, nil, up, down)
+
+	// Stop after the first pass has been written.
+	ctx, cancel := context.WithCancel(context.Background())
+	var buf bytes.Buffer
+	if err := streamJSONLines(ctx, u, cancelWriter{&buf, cancel}); err != nil {
+		t.Fatalf("streamJSONLines: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per server, got %q", buf.String())
+	}
+	var first, second sampleRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("decode %q: %v", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("decode %q: %v", lines[1], err)
+	}
+	if !first.Up || first.Values["curr_items"] != 7 || first.Tags["dc"] != "eu1" || first.Timestamp.IsZero() {
+		t.Fatalf("unexpected record for the healthy server: %+v", first)
+	}
+	if second.Up || second.Error == "" || second.Values != nil {
+		t.Fatalf("a failed poll should carry the error and no values: %+v", second)
+	}
+}
+
+// cancelWriter cancels a context once something has been written.
+type cancelWriter struct {
+	io.Writer
+	cancel context.CancelFunc
+}
+
+func (w cancelWriter) Write(p []byte) (int, error) {
+	defer w.cancel()
+	return w.Writer.Write(p)
+}
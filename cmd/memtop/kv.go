@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRelativeExpiry is the longest TTL memcached accepts as relative seconds;
+// anything larger is read as an absolute unix time.
+const maxRelativeExpiry = 30 * 24 * time.Hour
+
+// expiration converts a TTL into memcached's exptime field.
+func expiration(ttl time.Duration) int64 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl <= maxRelativeExpiry:
+		return int64(ttl.Seconds())
+	default:
+		return time.Now().Add(ttl).Unix()
+	}
+}
+
+// getKey fetches one value over the configured protocol.
+func getKey(addr, key string) ([]byte, bool, error) {
+	if statsProtocol == protocolBinary {
+		resp, err := binaryKeyCommand(addr, opGet, nil, key, nil)
+		if err != nil || resp.Status == statusKeyNotFound {
+			return nil, false, err
+		}
+		return resp.Value, true, nil
+	}
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return nil, false, err
+	}
+	defer conn.Close()
+	value, _, found, err := textGets(conn, bufio.NewReader(conn), key)
+	return []byte(value), found, err
+}
+
+// setKey stores a value unconditionally.
+func setKey(addr, key string, value []byte, ttl time.Duration) error {
+	exp := expiration(ttl)
+	if statsProtocol == protocolBinary {
+		extras := make([]byte, 8)
+		binary.BigEndian.PutUint32(extras[4:], uint32(exp))
+		_, err := binaryKeyCommand(addr, opSet, extras, key, value)
+		return err
+	}
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	reply, err := textStore(conn, bufio.NewReader(conn), fmt.Sprintf("set %s 0 %d %d", key, exp, len(value)), string(value))
+	if err != nil {
+		return err
+	}
+	if reply != "STORED" {
+		return fmt.Errorf("set failed: %s", reply)
+	}
+	return nil
+}
+
+// deleteKey removes a key, reporting whether it existed.
+func deleteKey(addr, key string) (bool, error) {
+	if statsProtocol == protocolBinary {
+		resp, err := binaryKeyCommand(addr, opDelete, nil, key, nil)
+		if err != nil {
+			return false, err
+		}
+		return resp.Status != statusKeyNotFound, nil
+	}
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintf(conn, "delete %s\r\n", key); err != nil {
+		return false, err
+	}
+	reply, err := readLine(bufio.NewReader(conn))
+	if err != nil {
+		return false, err
+	}
+	switch reply {
+	case "DELETED":
+		return true, nil
+	case "NOT_FOUND":
+		return false, nil
+	}
+	return false, fmt.Errorf("delete failed: %s", reply)
+}
+
+// flushAll invalidates every item on the server after delay.
+func flushAll(addr string, delay time.Duration) error {
+	secs := int64(delay / time.Second)
+	if statsProtocol == protocolBinary {
+		extras := make([]byte, 4)
+		binary.BigEndian.PutUint32(extras, uint32(secs))
+		_, err := binaryKeyCommand(addr, opFlush, extras, "", nil)
+		return err
+	}
+	conn, err := dialServer(context.Background(), addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := fmt.Fprintf(conn, "flush_all %d\r\n", secs); err != nil {
+		return err
+	}
+	reply, err := readLine(bufio.NewReader(conn))
+	if err != nil {
+		return err
+	}
+	if reply != "OK" {
+		return fmt.Errorf("flush_all failed: %s", reply)
+	}
+	return nil
+}
+
+// binaryKeyCommand sends a single-key binary request. A missing key is
+// returned as a response rather than an error so callers can tell it apart.
+func binaryKeyCommand(addr string, opcode byte, extras []byte, key string, value []byte) (*binaryPacket, error) {
+	conn, err := dialBinary(context.Background(), addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if err := writeBinaryRequest(conn, opcode, extras, []byte(key), value); err != nil {
+		return nil, err
+	}
+	resp, err := readBinaryResponse(conn)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status != statusOK && resp.Status != statusKeyNotFound {
+		return nil, binaryError(resp)
+	}
+	return resp, nil
+}
+
+// textGets issues `gets key` and returns the value and CAS token.
+func textGets(w io.Writer, r *bufio.Reader, key string) (string, uint64, bool, error) {
+	if _, err := fmt.Fprintf(w, "gets %s\r\n", key); err != nil {
+		return "", 0, false, err
+	}
+	line, err := readLine(r)
+	if err != nil {
+		return "", 0, false, err
+	}
+	if line == "END" {
+		return "", 0, false, nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 5 || fields[0] != "VALUE" {
+		return "", 0, false, fmt.Errorf("unexpected gets reply %q", line)
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil || size < 0 || size > maxBodySize {
+		return "", 0, false, fmt.Errorf("unexpected gets reply %q", line)
+	}
+	cas, err := strconv.ParseUint(fields[4], 10, 64)
+	if err != nil {
+		return "", 0, false, fmt.Errorf("unexpected gets reply %q", line)
+	}
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", 0, false, err
+	}
+	if end, err := readLine(r); err != nil || end != "END" {
+		return "", 0, false, fmt.Errorf("unterminated gets reply")
+	}
+	return string(data[:size]), cas, true, nil
+}
+
+// textStore sends a storage command with its data block and returns the
+// reply line.
+func textStore(w io.Writer, r *bufio.Reader, command, data string) (string, error) {
+	if _, err := fmt.Fprintf(w, "%s\r\n%s\r\n", command, data); err != nil {
+		return "", err
+	}
+	return readLine(r)
+}
+
+// readLine reads one CRLF-terminated protocol line.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTextGetsRejectsBadValueSizes(t *testing.T) {
+	for _, reply := range []string{
+		"VALUE k 0 -5 1\r\n",
+		"VALUE k 0 4294967296 1\r\n",
+	} {
+		_, _, _, err := textGets(io.Discard, bufio.NewReader(strings.NewReader(reply)), "k")
+		if err == nil || !strings.Contains(err.Error(), "unexpected gets reply") {
+			t.Errorf("reply %q: err = %v, want unexpected gets reply", reply, err)
+		}
+	}
+}
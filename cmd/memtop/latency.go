@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// latencyWindow is how many recent stats fetches the average and maximum
+// cover.
+const latencyWindow = 60
+
+// timedFetch runs fetch and reports how long it took, so the cost of the
+// stats call itself can be watched alongside the counters it returns.
+func timedFetch(fetch func() (*statsSnapshot, error)) (*statsSnapshot, time.Duration, error) {
+	start := time.Now()
+	stats, err := fetch()
+	return stats, time.Since(start), err
+}
+
+// recordLatency keeps the duration of a successful stats fetch. Failed
+// fetches are left out; they usually end in a timeout that says nothing about
+// how busy the server is.
+func (s *session) recordLatency(d time.Duration) {
+	s.latencies = appendBounded(s.latencies, d, latencyWindow)
+}
+
+// latencySummary returns the last, average and maximum fetch time over the
+// window, or false before any fetch succeeded.
+func (s *session) latencySummary() (last, avg, peak time.Duration, ok bool) {
+	if len(s.latencies) == 0 {
+		return 0, 0, 0, false
+	}
+	var total time.Duration
+	for _, d := range s.latencies {
+		total += d
+		peak = max(peak, d)
+	}
+	return s.latencies[len(s.latencies)-1], total / time.Duration(len(s.latencies)), peak, true
+}
+
+// describeLatency renders the latency summary for the header.
+func describeLatency(s *session) string {
+	last, avg, peak, ok := s.latencySummary()
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("poll %s avg %s max %s", formatLatency(last), formatLatency(avg), formatLatency(peak))
+}
+
+// formatLatency prints a duration with a precision that suits its size.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return fmt.Sprintf("%dus", d.Microseconds())
+	case d < 100*time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return d.Round(time.Millisecond).String()
+	}
+}
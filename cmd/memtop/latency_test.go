@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLatencySummaryKeepsRecentWindow(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if _, _, _, ok := sess.latencySummary(); ok {
+		t.Fatalf("no summary expected before the first fetch")
+	}
+	sess.recordLatency(90 * time.Millisecond)
+	for i := 0; i < latencyWindow; i++ {
+		sess.recordLatency(time.Duration((latencyWindow-1-i)%3+1) * time.Millisecond)
+	}
+	last, avg, peak, ok := sess.latencySummary()
+	if !ok || last != time.Millisecond || peak != 3*time.Millisecond || avg != 2*time.Millisecond {
+		t.Fatalf("summary = %v %v %v, want 1ms 2ms 3ms (the 90ms sample should have aged out)", last, avg, peak)
+	}
+}
+
+func TestTimedFetchMeasuresDuration(t *testing.T) {
+	_, took, err := timedFetch(func() (*statsSnapshot, error) {
+		time.Sleep(5 * time.Millisecond)
+		return nil, errors.New("boom")
+	})
+	if err == nil || took < 5*time.Millisecond {
+		t.Fatalf("timedFetch = %v, %v", took, err)
+	}
+}
+
+func TestHeaderShowsPollLatency(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.recordLatency(1500 * time.Microsecond)
+	sess.recordLatency(250 * time.Microsecond)
+	u := newUI(time.Second, nil, sess)
+
+	header := strings.SplitN(screenText(t, u), "\n", 2)[0]
+	if !strings.Contains(header, "poll 250us avg 875us max 1.5ms") {
+		t.Fatalf("header missing latency, got %q", header)
+	}
+}
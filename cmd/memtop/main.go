@@ -2,24 +2,36 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"math"
 	"net"
 	"os"
+	"os/signal"
+	"regexp"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
+	"mymemcache-top/memstats"
 )
 
 // statsSnapshot captures a reading from Memcached so the UI can compare
-// successive snapshots and render both absolute numbers and rate data.
+// successive snapshots and render both absolute numbers and rate data. Stats
+// holds the well-known general stats with exact integer counters.
 type statsSnapshot struct {
 	Timestamp time.Time
 	Values    map[string]float64
-	Raw       map[string]string
+	// Counters holds every integer stat exactly; Values rounds those above
+	// 2^53, which long-running servers reach for bytes_read and friends.
+	Counters map[string]uint64
+	Raw      map[string]string
+	Stats    memstats.Stats
 }
 
 // defaultTimeout bounds network operations so the UI stays responsive even if
@@ -29,33 +41,479 @@ const defaultTimeout = 2 * time.Second
 // main wires together CLI parsing, screen setup, and the sampling loop so users
 // get a responsive view of their Memcached instance with minimal flags.
 func main() {
+	command, args := splitCommand(os.Args[1:])
+	if cmd, _ := lookupSubcommand(command); cmd.run != nil {
+		os.Exit(cmd.run(args, defaultStreams()))
+	}
+	agent, replay := command == "agent", command == "replay"
+
 	flag.Usage = func() {
-		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [host [port]]\n", os.Args[0])
-		fmt.Fprintln(flag.CommandLine.Output(), "\nOptions:")
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "Usage: %s [top] [options] [host [ports] | host:ports]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s agent [options] [host [ports] | host:ports]\n", os.Args[0])
+		fmt.Fprintf(out, "       %s replay [options] FILE\n", os.Args[0])
+		fmt.Fprintf(out, "       %s <command> [options] [args]\n", os.Args[0])
+		fmt.Fprintln(out, "\nPorts may be a list or range (host:11211,11212 or host 11211-11214) to monitor every instance on a host.")
+		fmt.Fprintln(out, "Connection options (-host, -port, -protocol, ...) may also come before the command.")
+		fmt.Fprintln(out, "\nCommands:")
+		printSubcommands(out)
+		fmt.Fprintln(out, "\nOptions:")
 		flag.PrintDefaults()
 	}
 
-	host := flag.String("host", "127.0.0.1", "memcached host (overridable by first positional arg)")
-	port := flag.Int("port", 11211, "memcached port (overridable by second positional arg)")
+	conn := addConnFlags(flag.CommandLine)
 	interval := flag.Duration("interval", 2*time.Second, "refresh interval")
-	flag.Parse()
+	pollWorkers := flag.Int("poll-workers", defaultPollWorkers, "maximum number of servers polled at the same time")
+	jitter := flag.Duration("jitter", 0, "put each poll off by a random delay of up to this long (at most half the interval), so many memtop or agent instances watching the same server don't all hit it on the same tick")
+	pollTimeout := flag.Duration("poll-timeout", 0, "how long one server's poll may take before it counts as failed (0 uses the interval, between 500ms and 2s)")
+	chartStyle := flag.String("chart", "auto", "history chart style: auto, braille or block")
+	moversCount := flag.Int("movers", defaultMoversCount, "number of metrics listed in the top movers panel")
+	moversExclude := flag.String("movers-exclude", defaultMoversExclude, "regexp of metrics ignored by the top movers panel")
+	linkSpeedText := flag.String("link-speed", "", "NIC `capacity` of the servers' hosts in bits per second, such as 1G or 10Gbit, to show bandwidth as a share of the link")
+	hitWindow := flag.Duration("hit-window", defaultHitWindow, "sliding window for the windowed hit ratio")
+	anomalyWindow := flag.Int("anomaly-window", defaultAnomalyWindow, "samples in the rolling window used for anomaly detection")
+	anomalySigma := flag.Float64("anomaly-sigma", defaultAnomalySigma, "standard deviations from the rolling mean before a rate is highlighted")
+	metadumpLimit := flag.Int("metadump-limit", defaultMetadumpLimit, "maximum keys read per metadump sampling pass (0 for no limit)")
+	flag.DurationVar(&certExpiryWarn, "tls-expiry-warn", defaultCertExpiryWarn, "with -tls, warn when a server certificate expires within this long")
+	metadumpInterval := flag.Duration("metadump-interval", defaultMetadumpInterval, "minimum time between metadump sampling passes")
+	viewRefreshList := flag.String("view-refresh", "", "refresh the expensive views on their own slower cadence, as comma-separated `name=duration` pairs for the slabs, ttl, plugins, and proxy collectors (for example slabs=10s,proxy=5s); ttl sets -metadump-interval")
+	eventLogPath := flag.String("event-log", "", "append detected events (restarts, flushes, connection changes) to this file")
+	errorLogPath := flag.String("log", "", "append errors and warnings (failed polls, odd replies, unsupported commands) to this `file`")
+	reportPath := flag.String("report", "", "on exit, write a report with each server's summary, charts of its key metrics, and the event log to this `file`: standalone HTML, or Markdown if the name ends in .md")
+	demo := flag.Bool("demo", false, "monitor three built-in synthetic servers instead of memcached, to explore the UI or take screenshots")
+	configPath := flag.String("config", "", "JSON config file listing servers and their tags")
+	groupByTag := flag.String("group-by", "", "tag used to group servers in the cluster view")
+	casProbe := flag.Bool("cas-probe", false, "run a gets/cas cycle on a canary key each interval to detect misrouted or foreign writes")
+	casProbeKey := flag.String("cas-probe-key", defaultProbeKey(), "canary key used by -cas-probe; keep it unique per memtop instance")
+	rollingRestart := flag.Bool("rolling-restart", false, "watch for server restarts and follow each server's cache re-warming (bytes and hit ratio back to 90% of before), with a cluster summary of the restart's cost")
+	timezone := flag.String("timezone", "Local", "time zone for displayed times: Local, UTC, or a name such as Europe/Berlin")
+	timeFormat := flag.String("time-format", defaultTimeFormat, "Go time layout for the snapshot timestamp")
+	precisionList := flag.String("precision", "", "decimals numbers are shown with, as comma-separated `key=decimals` pairs; a key names a kind (count, rate, percent), a kind in one panel (cluster.rate), a metric (evictions), or a metric in one panel (summary.hit_ratio)")
+	flag.BoolVar(&ratesPerInterval, "per-interval", false, "show rates as the change over one refresh interval instead of per second (u toggles)")
+	crashReport := flag.String("crash-report", "", "write a crash report to this file if memtop panics")
+	jsonlPath := flag.String("jsonl", "", "append one JSON object per server and sample to this `file` (- for stdout, which runs without the TUI)")
+	jsonlRotate := rotateOptions{}
+	flag.BoolVar(&jsonlRotate.Compress, "jsonl-gzip", false, "gzip-compress the -jsonl file (adds .gz to the name)")
+	flag.Int64Var(&jsonlRotate.MaxSize, "jsonl-max-size", 0, "rotate the -jsonl file once it reaches this many bytes (0 never)")
+	flag.DurationVar(&jsonlRotate.MaxAge, "jsonl-max-age", 0, "rotate the -jsonl file after this long (0 never)")
+	flag.IntVar(&jsonlRotate.Keep, "jsonl-keep", 0, "rotated -jsonl files to keep, deleting older ones (0 keeps all)")
+	jsonlTrigger := flag.String("jsonl-trigger", "", "record to the -jsonl file only around events of these comma-separated `kinds` (for example alert,restart)")
+	jsonlPre := flag.Duration("jsonl-pre", defaultTriggerPre, "with -jsonl-trigger, samples kept from before a trigger")
+	jsonlPost := flag.Duration("jsonl-post", defaultTriggerPost, "with -jsonl-trigger, how long recording continues after the last trigger")
+	scriptPath := flag.String("script", "", "Starlark `file` defining on_sample(sample, state), run for every server after each poll to compute metrics and raise alerts")
+	paletteName := flag.String("palette", "", "colour palette: default, colorblind (red-green safe), or mono (bold, underline, and reverse video only); mono is the default when NO_COLOR is set")
+	baselinePath := flag.String("baseline", "", "compare each stat against this saved snapshot `file` (from memtop stats -format json) in the stats view")
+	admin := flag.Bool("admin", false, "enable keys that change server state, such as a in the slab view to apply automove advice")
+	plain := flag.Bool("plain", false, "print labeled plain-text lines each refresh instead of the TUI, for screen readers and braille displays")
+	plainChanges := flag.Bool("plain-changes", false, "with -plain, print only values that changed since the last refresh")
+	metricNames := flag.String("metric-names", "memtop", "series names on /metrics: memtop (one family per stat) or exporter (the official memcached_exporter's names, for its dashboards)")
+	metricsExpr := flag.String("metrics", "", "only write the stats matching this `regexp` (for example 'cmd_.*|evictions|bytes') to -jsonl, -csv, /metrics, and the other outputs")
+	listenAddr := flag.String("listen", "", "serve OpenMetrics at /metrics and memtop's own counters at /debug/vars on this `address` (for example localhost:6060)")
+	remoteControl := flag.Bool("control", false, "also accept remote control requests at /control/ on the -listen address; they are not authenticated, so listen on localhost or a trusted network")
+	csvPath := flag.String("csv", "", "append one CSV row per server and sample to this `file`")
+	graphiteAddr := flag.String("graphite", "", "send every stat and rate to the Graphite plaintext listener at this `address` (for example graphite:2003)")
+	graphitePrefix := flag.String("graphite-prefix", "memtop", "with -graphite, the prefix of every metric path")
+	slackWebhook := flag.String("slack-webhook", "", "post script alerts, raised and cleared, to this Slack-compatible incoming webhook `URL` (a rule's alert(..., webhook=) overrides it)")
+	alertExec := flag.String("alert-exec", "", "run this `command` (split on spaces) whenever a script alert is raised or cleared, with SERVER, STATE, MESSAGE, METRIC, VALUE, and THRESHOLD in its environment (a rule's alert(..., exec=) overrides it)")
+	syslogTarget := flag.String("syslog", "", "send each server's key metrics and every event, with structured fields, to `target`: local (the syslog daemon), journald, udp://host:port, or tcp://host:port")
+	notify := flag.Bool("notify", false, "raise a desktop notification (notify-send, osascript, or a Windows toast) when a script alert fires while the terminal is in the background")
+	webhookURL := flag.String("webhook", "", "POST each sample as a JSON array to this `URL`")
+	var pluginConfigs []pluginConfig
+	flag.Func("plugin", "run this `command` (split on spaces) as an extra panel in the panels view; repeatable", func(v string) error {
+		if len(strings.Fields(v)) == 0 {
+			return fmt.Errorf("empty command")
+		}
+		pluginConfigs = append(pluginConfigs, pluginConfig{Command: strings.Fields(v)})
+		return nil
+	})
+	flag.CommandLine.Parse(args)
+
+	chart, err := parseChartMode(*chartStyle)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := setPalette(*paletteName, os.Getenv("NO_COLOR") != ""); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := setMetricNames(*metricNames); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := setTimeDisplay(*timezone, *timeFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if err := validateInterval(*interval); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *pollWorkers < 1 || *pollTimeout < 0 || *jitter < 0 {
+		fmt.Fprintln(os.Stderr, "-poll-workers must be positive and -poll-timeout and -jitter not negative")
+		os.Exit(2)
+	}
+	moversRe, err := regexp.Compile(*moversExclude)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid -movers-exclude: %v\n", err)
+		os.Exit(2)
+	}
+	metricFilter, err := parseMetricFilter(*metricsExpr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	args = flag.Args()
+	var replayPath string
+	if replay {
+		if len(args) != 1 || *demo {
+			fmt.Fprintln(os.Stderr, "replay takes the recording FILE and cannot be combined with -demo")
+			os.Exit(2)
+		}
+		replayPath, args = args[0], nil
+	}
+	addrs, err := serverArgs(conn, args)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	servers := make([]serverConfig, 0, len(addrs))
+	for _, addr := range addrs {
+		servers = append(servers, serverConfig{Addr: addr})
+	}
+	groupBy := *groupByTag
+	var watchKeys, columns []string
+	viewRefresh := make(map[string]time.Duration)
+	precision = make(map[string]int)
+	if *configPath != "" {
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		if len(cfg.Servers) > 0 {
+			servers = cfg.Servers
+		}
+		if groupBy == "" {
+			groupBy = cfg.GroupBy
+		}
+		watchKeys = cfg.WatchKeys
+		columns = cfg.Columns
+		for name, d := range cfg.viewRefresh {
+			viewRefresh[name] = d
+		}
+		for key, n := range cfg.Precision {
+			precision[key] = n
+		}
+		pluginConfigs = append(cfg.Plugins, pluginConfigs...)
+		if *linkSpeedText == "" {
+			*linkSpeedText = cfg.LinkSpeed
+		}
+	}
+	linkSpeed, err := parseLinkSpeed(*linkSpeedText)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	flagPrecision, err := parsePrecision(*precisionList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for key, n := range flagPrecision {
+		precision[key] = n
+	}
+	flagRefresh, err := parseViewRefresh(*viewRefreshList)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for name, d := range flagRefresh {
+		viewRefresh[name] = d
+	}
+	// The TTL sample already has its own cadence, so a ttl entry sets it
+	// unless -metadump-interval is given.
+	if d, ok := viewRefresh["ttl"]; ok {
+		metadumpSet := false
+		flag.Visit(func(f *flag.Flag) { metadumpSet = metadumpSet || f.Name == "metadump-interval" })
+		if !metadumpSet {
+			*metadumpInterval = d
+		}
+		delete(viewRefresh, "ttl")
+	}
+	// panics hands the terminal back when background goroutines panic; it
+	// is armed once the screen is up.
+	var panics panicGuard
+	if *demo {
+		demoServers, stopDemo, err := startDemoServers(&panics)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer stopDemo()
+		servers = demoServers
+		if groupBy == "" {
+			groupBy = "dc"
+		}
+	}
+	if replay {
+		replayServers, recorded, stopReplay, err := startReplayServers(replayPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load recording: %v\n", err)
+			os.Exit(1)
+		}
+		defer stopReplay()
+		servers = replayServers
+		intervalSet := false
+		flag.Visit(func(f *flag.Flag) { intervalSet = intervalSet || f.Name == "interval" })
+		if !intervalSet {
+			*interval = recorded
+		}
+	}
+	var sc *script
+	if *scriptPath != "" {
+		if sc, err = loadScript(*scriptPath, nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		panels = append(panels, sc.panel())
+	}
+	var plugins []*plugin
+	for _, pc := range pluginConfigs {
+		p, err := newPlugin(pc)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		plugins = append(plugins, p)
+		panels = append(panels, p.panel())
+	}
 
-	hostVal := *host
-	portVal := *port
-	args := flag.Args()
-	if len(args) > 0 {
-		hostVal = args[0]
+	// The demo and replay servers listen on loopback and speak only the
+	// ASCII protocol, so the connection flags don't apply to them.
+	if !*demo && !replay {
+		closeConn, err := conn.apply()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		defer closeConn()
+	}
+
+	events := newEventLog(defaultEventLimit, nil)
+	if agent {
+		events = newEventLog(defaultEventLimit, os.Stdout)
 	}
-	if len(args) > 1 {
-		p, err := strconv.Atoi(args[1])
+	if *eventLogPath != "" {
+		f, err := os.OpenFile(*eventLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "invalid port %q: %v\n", args[1], err)
+			fmt.Fprintf(os.Stderr, "failed to open event log: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		events = newEventLog(defaultEventLimit, f)
+	}
+
+	var errorLogFile io.Writer
+	if agent {
+		errorLogFile = os.Stderr
+	}
+	if *errorLogPath != "" {
+		f, err := os.OpenFile(*errorLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open log file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		errorLogFile = f
+	}
+
+	if *remoteControl && *listenAddr == "" {
+		fmt.Fprintln(os.Stderr, "-control needs -listen")
+		os.Exit(2)
+	}
+	var control chan controlRequest
+	if *listenAddr != "" {
+		if *remoteControl {
+			control = make(chan controlRequest)
+		}
+		ln, err := startHTTPServer(*listenAddr, control)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to listen: %v\n", err)
+			os.Exit(1)
+		}
+		defer ln.Close()
+	}
+
+	sessions := make([]*session, 0, len(servers))
+	for _, srv := range servers {
+		sess := newSession(srv.Addr, *interval)
+		sess.tags = srv.Tags
+		sess.moversCount = *moversCount
+		sess.moversExclude = moversRe
+		sess.anomalies = newRollingStats(*anomalyWindow)
+		sess.anomalySigma = *anomalySigma
+		sess.hitWindow = *hitWindow
+		sess.linkSpeed = linkSpeed
+		if srv.LinkSpeed != "" {
+			// Validated when the config was loaded.
+			sess.linkSpeed, _ = parseLinkSpeed(srv.LinkSpeed)
+		}
+		sess.metadumpLimit = *metadumpLimit
+		sess.metadumpInterval = *metadumpInterval
+		sess.watchKeys = watchKeys
+		if *casProbe {
+			sess.probe = newCASProbe(*casProbeKey, *interval)
+		}
+		if *rollingRestart {
+			sess.rewarm = newRewarmWatch()
+		}
+		sessions = append(sessions, sess)
+	}
+
+	u := newUI(*interval, events, sessions...)
+	u.panics = &panics
+	if *reportPath != "" {
+		started := time.Now()
+		defer func() {
+			if err := writeReportFile(*reportPath, newSessionReport(u, started, time.Now())); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+			}
+		}()
+	}
+	u.errors.out = errorLogFile
+	u.chartMode = chart
+	u.groupBy = groupBy
+	u.plugins = plugins
+	u.admin = *admin
+	u.configPath = *configPath
+	u.viewRefresh = viewRefresh
+	u.metrics = metricFilter
+	if len(columns) > 0 {
+		u.columns = columns
+	}
+	u.pollWorkers = *pollWorkers
+	u.jitter = *jitter
+	u.pollTimeout = *pollTimeout
+	if *baselinePath != "" {
+		if u.baseline, err = loadSnapshotFile(*baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load baseline: %v\n", err)
 			os.Exit(2)
 		}
-		portVal = p
+	}
+	u.script = sc
+	u.control = control
+	if *listenAddr != "" {
+		u.sinks.add("Prometheus", &published)
+	}
+	if *csvPath != "" {
+		f, err := os.OpenFile(*csvPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open CSV file: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open CSV file: %v\n", err)
+			os.Exit(1)
+		}
+		u.sinks.add("CSV", newCSVSink(f, info.Size() == 0))
+	}
+	if *graphiteAddr != "" {
+		u.sinks.add("Graphite", newGraphiteSink(*graphiteAddr, *graphitePrefix))
+	}
+	if *webhookURL != "" {
+		u.sinks.add("webhook", newWebhookSink(*webhookURL))
+	}
+	if *slackWebhook != "" || sc != nil {
+		u.sinks.add("chat webhook", newChatWebhook(*slackWebhook))
+	}
+	if *alertExec != "" || sc != nil {
+		hook := newAlertHook(strings.Fields(*alertExec))
+		defer hook.close()
+		u.sinks.add("alert hook", hook)
+	}
+	if *syslogTarget != "" {
+		sl, err := newSyslogSink(*syslogTarget)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open syslog: %v\n", err)
+			os.Exit(1)
+		}
+		u.sinks.add("syslog", sl)
+	}
+	if *notify {
+		n, err := newDesktopNotifier()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		u.sinks.add("desktop notification", n)
+	}
+
+	if agent && (*plain || *jsonlPath == "-") {
+		fmt.Fprintln(os.Stderr, "agent cannot be combined with -plain or -jsonl -")
+		os.Exit(2)
+	}
+	if *plainChanges && !*plain {
+		fmt.Fprintln(os.Stderr, "-plain-changes needs -plain")
+		os.Exit(2)
+	}
+	if *plain {
+		if *jsonlPath != "" {
+			fmt.Fprintln(os.Stderr, "-plain cannot be combined with -jsonl")
+			os.Exit(2)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := streamPlain(ctx, u, os.Stdout, *plainChanges); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write plain output: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	switch *jsonlPath {
+	case "":
+	case "-":
+		if jsonlRotate != (rotateOptions{}) || *jsonlTrigger != "" {
+			fmt.Fprintln(os.Stderr, "-jsonl-gzip, -jsonl-max-size, -jsonl-max-age, -jsonl-keep, and -jsonl-trigger need a -jsonl file")
+			os.Exit(2)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		if err := streamJSONLines(ctx, u, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write JSON lines: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	default:
+		rw, err := newRotatingWriter(*jsonlPath, jsonlRotate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to open JSON lines file: %v\n", err)
+			os.Exit(1)
+		}
+		defer rw.Close()
+		jw := newJSONLWriter(rw)
+		var out sink = jw
+		if kinds := parseTriggerKinds(*jsonlTrigger); len(kinds) > 0 {
+			out = newTriggeredRecorder(jw, kinds, *jsonlPre, *jsonlPost)
+		}
+		u.sinks.add("JSON lines", out)
 	}
 
-	addr := fmt.Sprintf("%s:%d", hostVal, portVal)
+	if agent {
+		if len(u.sinks.sinks) == 0 && *eventLogPath == "" && *errorLogPath == "" {
+			fmt.Fprintln(os.Stderr, "agent has no outputs: give -listen, -jsonl, -csv, -graphite, -webhook, -slack-webhook, -alert-exec, -syslog, -script, -event-log, or -log")
+			os.Exit(2)
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runAgent(ctx, u)
+		return
+	}
 
 	screen, err := tcell.NewScreen()
 	if err != nil {
@@ -67,134 +525,353 @@ func main() {
 		os.Exit(1)
 	}
 	defer screen.Fini()
+	defer restoreOnPanic(screen, *crashReport)
+	panics.arm(screen, *crashReport)
 
 	screen.Clear()
 	screen.HideCursor()
+	screen.EnableFocus()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
 
 	eventCh := make(chan tcell.Event, 8)
 	go func() {
+		defer restoreOnPanic(screen, *crashReport)
 		for {
 			event := screen.PollEvent()
 			if event == nil {
 				close(eventCh)
 				return
 			}
+			// The main loop only reads keys between passes; abort a pass
+			// stuck on a slow server so quitting doesn't wait for it. Keys
+			// typed into a dialog don't quit, so they leave the pass alone.
+			if key, ok := event.(*tcell.EventKey); ok && u.abortsPass(key) {
+				inflight.abort()
+			}
 			eventCh <- event
 		}
 	}()
 
 	ticker := time.NewTicker(*interval)
 	defer ticker.Stop()
+	// The header's "updated Ns ago" indicator needs redraws between samples.
+	redraw := time.NewTicker(redrawEvery(*interval))
+	defer redraw.Stop()
 
-	var (
-		currentStats *statsSnapshot
-		prevStats    *statsSnapshot
-		rates        map[string]float64
-		lastErr      error
-	)
-
-	drawScreen(screen, addr, *interval, currentStats, rates, lastErr)
+	drawScreen(screen, u)
 
 loop:
 	for {
+		u.keysTaken.Store(u.takingKeys())
 		select {
+		case <-ctx.Done():
+			break loop
 		case <-ticker.C:
-			stats, err := fetchStats(addr)
-			if err != nil {
-				lastErr = err
-			} else {
-				lastErr = nil
-				if prevStats != nil {
-					rates = calculateRates(stats, prevStats)
-				} else {
-					rates = make(map[string]float64)
+			if !u.suspended {
+				completed := inflight.run(ctx, func(ctx context.Context) { sample(ctx, u) })
+				if completed {
+					u.sinks.write(u)
 				}
-				prevStats = stats
-				currentStats = stats
 			}
-			drawScreen(screen, addr, *interval, currentStats, rates, lastErr)
+			if time.Since(u.frame.drawn) >= minFrameGap {
+				drawScreen(screen, u)
+			}
+		case <-redraw.C:
+			drawScreen(screen, u)
+		case req := <-u.control:
+			u.handleControl(req, ticker)
+			redraw.Reset(redrawEvery(u.interval))
+			if req.action == "server" && !u.suspended {
+				inflight.run(ctx, func(ctx context.Context) { sampleView(ctx, u) })
+			}
+			drawScreen(screen, u)
 		case ev, ok := <-eventCh:
 			if !ok {
 				break loop
 			}
 			switch evt := ev.(type) {
 			case *tcell.EventKey:
+				if u.prompt != nil {
+					if done, accepted := u.prompt.handleKey(evt); done {
+						if accepted {
+							u.addNote(time.Now(), u.prompt.value())
+						}
+						u.prompt = nil
+					}
+					drawScreen(screen, u)
+					continue
+				}
+				if u.chooser != nil {
+					if u.chooser.handleKey(evt, u) {
+						u.chooser = nil
+					}
+					drawScreen(screen, u)
+					continue
+				}
+				if u.detail != nil {
+					if u.detail.handleKey(evt) {
+						u.detail = nil
+					}
+					drawScreen(screen, u)
+					continue
+				}
 				switch {
-				case evt.Key() == tcell.KeyEscape, evt.Key() == tcell.KeyCtrlC, evt.Rune() == 'q', evt.Rune() == 'Q':
+				case isQuitKey(evt):
 					break loop
+				case evt.Rune() == 'n' || evt.Rune() == 'N':
+					u.prompt = &textPrompt{label: "Note"}
+					drawScreen(screen, u)
+				case evt.Rune() == 'p' || evt.Rune() == 'P':
+					u.toggleSuspend(time.Now())
+					drawScreen(screen, u)
+				case evt.Rune() == 'u' || evt.Rune() == 'U':
+					ratesPerInterval = !ratesPerInterval
+					drawScreen(screen, u)
 				case evt.Rune() == 'r' || evt.Rune() == 'R':
-					prevStats = nil
-					rates = make(map[string]float64)
-					drawScreen(screen, addr, *interval, currentStats, rates, lastErr)
+					for _, s := range u.servers {
+						s.resetRates()
+					}
+					drawScreen(screen, u)
+				case evt.Rune() >= '1' && evt.Rune() <= '9':
+					if v, ok := viewForKey(evt.Rune()); ok && v != u.view {
+						u.view = v
+						if !u.suspended {
+							inflight.run(ctx, func(ctx context.Context) { sampleView(ctx, u) })
+						}
+						drawScreen(screen, u)
+					}
+				case evt.Key() == tcell.KeyTab || evt.Key() == tcell.KeyBacktab:
+					if len(u.servers) > 1 {
+						if evt.Key() == tcell.KeyTab {
+							u.selectServer(1)
+						} else {
+							u.selectServer(-1)
+						}
+						if !u.suspended {
+							inflight.run(ctx, func(ctx context.Context) { sampleView(ctx, u) })
+						}
+						drawScreen(screen, u)
+					}
+				case evt.Rune() == '|' || evt.Rune() == '-':
+					if evt.Rune() == '|' {
+						u.splitPane(splitVertical)
+					} else {
+						u.splitPane(splitHorizontal)
+					}
+					drawScreen(screen, u)
+				case evt.Rune() == 'x' || evt.Rune() == 'X':
+					u.closePane()
+					drawScreen(screen, u)
+				case evt.Rune() == 'o' || evt.Rune() == 'O':
+					u.cycleFocus(1)
+					drawScreen(screen, u)
+				case evt.Rune() == 'm' && u.view == viewSlabs:
+					u.heatmap = (u.heatmap + 1) % heatmapMetricCount
+					drawScreen(screen, u)
+				case evt.Rune() == 'a' && u.view == viewSlabs && u.admin:
+					applyAdvice(u, time.Now())
+					drawScreen(screen, u)
+				case evt.Rune() == 'c' && u.view == viewStats:
+					u.chooser = &columnChooser{}
+					drawScreen(screen, u)
+				case evt.Rune() == 'g' && u.view == viewCluster:
+					u.cycleGroupBy()
+					drawScreen(screen, u)
+				case u.view == viewStats && evt.Key() == tcell.KeyEnter:
+					u.openStatDetail()
+					drawScreen(screen, u)
+				case u.view == viewStats && (evt.Key() == tcell.KeyLeft || evt.Key() == tcell.KeyRight):
+					u.setGroupCollapsed(evt.Key() == tcell.KeyLeft)
+					drawScreen(screen, u)
+				case u.view == viewStats && evt.Rune() == 'z':
+					u.toggleAllGroups()
+					drawScreen(screen, u)
+				case u.view == viewStats && scrollKey(evt, &u.statsCursor, screen):
+					drawScreen(screen, u)
+				case u.view == viewCluster && scrollKey(evt, &u.clusterOffset, screen):
+					drawScreen(screen, u)
+				case u.view == viewLog && scrollKey(evt, &u.logOffset, screen):
+					drawScreen(screen, u)
+				}
+			case *tcell.EventFocus:
+				if evt.Focused {
+					u.termFocus = focusIn
+				} else {
+					u.termFocus = focusOut
 				}
 			case *tcell.EventResize:
 				screen.Sync()
-				drawScreen(screen, addr, *interval, currentStats, rates, lastErr)
+				u.frame.invalidate()
+				drawScreen(screen, u)
 			}
 		}
 	}
 }
 
+// isQuitKey reports whether key quits memtop (outside a prompt).
+func isQuitKey(key *tcell.EventKey) bool {
+	return key.Key() == tcell.KeyEscape || key.Key() == tcell.KeyCtrlC || key.Rune() == 'q' || key.Rune() == 'Q'
+}
+
+// dialServer opens a connection to the server with the overall request
+// deadline already applied, so every command shares the same timeout policy.
+// With -tls the handshake runs within that deadline too.
+func dialServer(ctx context.Context, addr string) (net.Conn, error) {
+	conn, err := dialContext(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Now().Add(defaultTimeout)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn = watchContext(ctx, conn)
+	tconn, err := startTLS(ctx, conn, addr)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tconn, nil
+}
+
 // fetchStats requests the Memcached stats output and wraps it in a snapshot so
 // the caller can track both raw counters and the time they were observed.
-func fetchStats(addr string) (*statsSnapshot, error) {
-	conn, err := net.DialTimeout("tcp", addr, defaultTimeout)
+func fetchStats(ctx context.Context, addr string) (*statsSnapshot, error) {
+	return fetchStatsSection(ctx, addr, "")
+}
+
+// fetchStatsSection issues `stats <section>` (plain `stats` when section is
+// empty) so sub-reports such as slabs and items share the same parser.
+func fetchStatsSection(ctx context.Context, addr, section string) (*statsSnapshot, error) {
+	if statsProtocol == protocolBinary {
+		return fetchBinaryStats(ctx, addr, section)
+	}
+	conn, err := dialServer(ctx, addr)
 	if err != nil {
 		return nil, err
 	}
 	defer conn.Close()
 
-	if err := conn.SetDeadline(time.Now().Add(defaultTimeout)); err != nil {
+	command := "stats"
+	if section != "" {
+		command += " " + section
+	}
+	if _, err := fmt.Fprintf(conn, "%s\r\n", command); err != nil {
 		return nil, err
 	}
 
-	if _, err := fmt.Fprint(conn, "stats\r\n"); err != nil {
+	r := statsReaders.Get().(*bufio.Reader)
+	r.Reset(conn)
+	defer func() {
+		r.Reset(nil)
+		statsReaders.Put(r)
+	}()
+	raw, err := readStats(r, statsSizeHint(section))
+	if err != nil {
 		return nil, err
 	}
+	rememberStatsSize(section, len(raw))
 
-	scanner := bufio.NewScanner(conn)
-	values := make(map[string]float64)
-	raw := make(map[string]string)
+	return newStatsSnapshot(time.Now(), raw), nil
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "END" {
-			break
-		}
-		fields := strings.Fields(line)
-		if len(fields) < 3 || fields[0] != "STAT" {
-			continue
-		}
-		key := fields[1]
-		value := strings.Join(fields[2:], " ")
-		raw[key] = value
-		if number, err := strconv.ParseFloat(value, 64); err == nil {
+// newStatsSnapshot keeps every raw stat and parses the numeric ones, whatever
+// protocol they arrived over.
+func newStatsSnapshot(t time.Time, raw map[string]string) *statsSnapshot {
+	values := make(map[string]float64, len(raw))
+	counters := make(map[string]uint64, len(raw))
+	for key, value := range raw {
+		if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+			counters[key] = n
+			values[key] = float64(n)
+		} else if number, err := strconv.ParseFloat(value, 64); err == nil {
 			values[key] = number
 		}
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
-
 	return &statsSnapshot{
-		Timestamp: time.Now(),
+		Timestamp: t,
 		Values:    values,
+		Counters:  counters,
 		Raw:       raw,
-	}, nil
+		Stats:     memstats.Parse(raw),
+	}
+}
+
+// sample polls every server, then runs the extra queries the active view
+// needs for the selected one. Cancelling ctx stops the pass at once; servers
+// not yet polled keep their previous sample, and a fetch that was cut short
+// is not recorded as a failure.
+func sample(ctx context.Context, u *ui) {
+	start := time.Now()
+	defer func() { selfSampleMicros.Set(time.Since(start).Microseconds()) }()
+	u.detectSleep(start, start.Round(0))
+	pollServers(ctx, u)
+	if ctx.Err() != nil {
+		return
+	}
+	if u.script != nil {
+		for _, s := range u.servers {
+			runScript(s, u.script, time.Now())
+		}
+	}
+	sampleViews(ctx, u)
+}
+
+// sampleView runs the extra queries only the active view needs, against the
+// selected server. It is also called on view and server switches so the new
+// view has data immediately.
+func sampleView(ctx context.Context, u *ui) {
+	runCollectors(ctx, u, u.current(), collectForView)
+}
+
+// uptimeSlack is how far the local and server clocks may disagree over one
+// sample before the server's is trusted. uptime counts whole seconds, so two
+// readings can differ from the true interval by a second either way.
+const uptimeSlack = 2.0
+
+// rateElapsed is the seconds between two snapshots that rates divide by. It
+// is the local interval, unless the server's uptime advanced by clearly more
+// or less: then local ticks were delayed, by a stalled terminal or a laptop
+// that slept while the server kept counting, and the server's own clock is
+// the accurate one. A restart, which resets uptime, keeps the local interval.
+func rateElapsed(curr, prev *statsSnapshot) float64 {
+	local := curr.Timestamp.Sub(prev.Timestamp).Seconds()
+	now, okNow := curr.Values["uptime"]
+	then, okThen := prev.Values["uptime"]
+	if !okNow || !okThen || now <= then {
+		return local
+	}
+	if server := now - then; math.Abs(server-local) > uptimeSlack {
+		return server
+	}
+	return local
 }
 
 // calculateRates compares two snapshots and returns per-second deltas so the
 // interface can surface activity trends instead of raw monotonically increasing counters.
+// Integer counters are subtracted exactly, so a small change to a huge counter
+// isn't lost to float rounding.
 func calculateRates(curr, prev *statsSnapshot) map[string]float64 {
 	result := make(map[string]float64)
 	if curr == nil || prev == nil {
 		return result
 	}
-	elapsed := curr.Timestamp.Sub(prev.Timestamp).Seconds()
+	elapsed := rateElapsed(curr, prev)
 	if elapsed <= 0 {
 		return result
 	}
 	for key, currentVal := range curr.Values {
+		if c, ok := curr.Counters[key]; ok {
+			if p, ok := prev.Counters[key]; ok {
+				result[key] = 0
+				if c > p {
+					result[key] = float64(c-p) / elapsed
+				}
+				continue
+			}
+		}
 		if prevVal, ok := prev.Values[key]; ok {
 			diff := currentVal - prevVal
 			if diff < 0 {
@@ -207,30 +884,107 @@ func calculateRates(curr, prev *statsSnapshot) map[string]float64 {
 }
 
 // drawScreen paints the latest metrics on the terminal, keeping the layout
-// consistent so operators can notice anomalies quickly.
-func drawScreen(screen tcell.Screen, addr string, interval time.Duration, stats *statsSnapshot, rates map[string]float64, err error) {
-	screen.Clear()
+// consistent so operators can notice anomalies quickly. The frame is drawn
+// off screen and only the cells that changed reach the terminal.
+func drawScreen(screen tcell.Screen, u *ui) {
+	start := time.Now()
+	defer func() { selfRenderMicros.Set(time.Since(start).Microseconds()) }()
+	drawFrame(u.frame.begin(screen), u)
+	u.frame.flush(screen)
+}
+
+// drawFrame lays out one frame: header, the selected view or panes, and the
+// footer.
+func drawFrame(screen tcell.Screen, u *ui) {
 	width, height := screen.Size()
 	if height <= 0 || width <= 0 {
-		screen.Show()
+		return
+	}
+	if tooSmallForLayout(width, height) {
+		drawCompactCard(screen, u)
 		return
 	}
 
-	baseStyle := tcell.StyleDefault
-	highlightStyle := baseStyle.Bold(true)
+	highlightStyle := tcell.StyleDefault.Bold(true)
 
-	drawText(screen, 0, 0, highlightStyle, fmt.Sprintf("mymemcache-top  %s  (refresh %s)", addr, interval))
+	header := fmt.Sprintf("mymemcache-top  %s  (refresh %s)", u.serverLabel(), u.interval)
+	if ratesPerInterval {
+		header = fmt.Sprintf("mymemcache-top  %s  (refresh %s, rates %s)", u.serverLabel(), u.interval, rateWords(u.interval))
+	}
+	if latency := describeLatency(u.current()); latency != "" {
+		header += "  " + latency
+	}
+	drawText(screen, 0, 0, highlightStyle, header)
+	now := time.Now()
+	if fresh, stale := describeFreshness(u.current(), now); fresh != "" {
+		drawText(screen, utf8.RuneCountInString(header)+2, 0, freshnessStyle(stale && !u.suspended, now), fresh)
+	}
+	if u.suspended {
+		drawText(screen, 0, 1, suspendedStyle, suspendedBanner)
+	} else if warning := describeCertExpiry(u.current(), now); warning != "" {
+		drawText(screen, 0, 1, theme.warn, " "+warning+" ")
+	}
 
-	line := 2
+	if u.panes != nil {
+		drawPaneTree(screen, u, u.panes, 0, 2, width, height-3)
+	} else {
+		drawBody(screen, 2, u)
+	}
 
-	if err != nil {
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Error: %v", err))
+	if u.prompt != nil {
+		drawText(screen, 0, height-1, highlightStyle, u.prompt.footer())
+	} else if height > 2 {
+		controls := "Controls: q to quit | r to reset rate baseline | n note | "
+		if len(u.servers) > 1 {
+			controls += "Tab server | "
+		}
+		suspend := " | p suspend"
+		if u.suspended {
+			suspend = " | p resume"
+		}
+		drawText(screen, 0, height-1, highlightStyle, controls+viewHelp(u.view, u.current())+" | -/| split x close o pane"+suspend)
+	}
+}
+
+// drawBody renders the selected view from the given row, preceded by the
+// server's error if its last poll failed.
+func drawBody(screen tcell.Screen, line int, u *ui) {
+	if err := u.current().lastErr; err != nil {
+		drawText(screen, 0, line, tcell.StyleDefault, fmt.Sprintf("Error: %v", err))
 		line += 2
 	}
 
+	switch {
+	case !u.current().supports(viewRequires(u.view)):
+		drawText(screen, 0, line, tcell.StyleDefault, fmt.Sprintf("The %s view is %s: %s.", viewName(u.view), notSupported, u.current().unsupportedReason(viewRequires(u.view))))
+	case u.view == viewSlabs:
+		drawSlabsView(screen, line, u)
+	case u.view == viewPanels:
+		drawPanelsView(screen, line, u.current())
+	case u.view == viewStats:
+		drawStatsView(screen, line, u)
+	case u.view == viewCluster:
+		drawClusterView(screen, line, u)
+	case u.view == viewProxy:
+		drawProxyView(screen, line, u)
+	case u.view == viewLog:
+		drawLogView(screen, line, u)
+	default:
+		drawSummary(screen, line, u)
+	}
+}
+
+// drawSummary renders the overview of the server's headline metrics starting
+// at the given row, followed by gauges and history charts.
+func drawSummary(screen tcell.Screen, line int, u *ui) {
+	s := u.current()
+	stats, rates, err := s.current, s.rates, s.lastErr
+	width, height := screen.Size()
+	baseStyle := tcell.StyleDefault
+
 	if stats != nil {
 		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Time: %s    Uptime: %s    Version: %s",
-			stats.Timestamp.Format("2006-01-02 15:04:05"),
+			formatTimestamp(stats.Timestamp),
 			formatUptime(stats.Values["uptime"]),
 			stats.Raw["version"],
 		))
@@ -238,78 +992,95 @@ func drawScreen(screen tcell.Screen, addr string, interval time.Duration, stats
 
 		getHits := stats.Values["get_hits"]
 		getMisses := stats.Values["get_misses"]
-		totalGets := getHits + getMisses
-		hitRatio := 0.0
-		if totalGets > 0 {
-			hitRatio = (getHits / totalGets) * 100
+		ratio := hitRatio(stats)
+		nf := numberFormat{"summary"}
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Requests: hits %s  misses %s  hit ratio %s%% (interval %s, %s %s)  evictions %s  reclaimed %s",
+			nf.count("get_hits", getHits), nf.count("get_misses", getMisses), nf.percent("hit_ratio", ratio),
+			nf.ratio("", s.intervalHitRatio()), shortDuration(s.hitWindow), nf.ratio("", s.windowHitRatio()),
+			nf.count("evictions", stats.Values["evictions"]), nf.count("reclaimed", stats.Values["reclaimed"])))
+		line++
+		drawText(screen, 0, line, baseStyle, describeMisses(s))
+		line++
+		// Evictions and a warm-up share a row; an emptied cache rarely
+		// evicts, and evictions are the more urgent of the two.
+		if evictions := describeEvictions(s); evictions != "" {
+			drawText(screen, 0, line, theme.warn, evictions)
+		} else {
+			drawText(screen, 0, line, theme.warn, describeWarmup(s, time.Now()))
 		}
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Requests: hits %.0f  misses %.0f  hit ratio %.2f%%  evictions %.0f  reclaimed %.0f",
-			getHits, getMisses, hitRatio, stats.Values["evictions"], stats.Values["reclaimed"]))
-		line += 2
+		line++
 
 		bytesUsed := stats.Values["bytes"]
 		maxBytes := stats.Values["limit_maxbytes"]
-		memoryPercent := 0.0
-		if maxBytes > 0 {
-			memoryPercent = (bytesUsed / maxBytes) * 100
-		}
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Memory: %s / %s (%.1f%%)   Free: %s",
-			formatBytes(bytesUsed), formatBytes(maxBytes), memoryPercent, formatBytes(maxBytes-bytesUsed)))
+		memPercent := memoryPercent(stats)
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Memory: %s / %s (%s%%)   Free: %s   %s",
+			formatBytes(bytesUsed), formatBytes(maxBytes), nf.percent("memory", memPercent), formatBytes(maxBytes-bytesUsed),
+			describeMemoryETA(s.history, stats, rates)))
 		line++
 
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Connections: current %.0f  total %.0f  reserved %.0f  waiting %.0f  max simultaneous %.0f",
-			stats.Values["curr_connections"],
-			stats.Values["total_connections"],
-			stats.Values["reserved_fds"],
-			stats.Values["conn_yields"],
-			stats.Values["threads"],
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Connections: current %s  total %s  reserved %s  waiting %s  max simultaneous %s",
+			nf.count("curr_connections", stats.Values["curr_connections"]),
+			nf.count("total_connections", stats.Values["total_connections"]),
+			nf.count("reserved_fds", stats.Values["reserved_fds"]),
+			nf.count("conn_yields", stats.Values["conn_yields"]),
+			nf.count("threads", stats.Values["threads"]),
 		))
 		line++
 
-		cmdGetRate := rateValue(rates, "cmd_get")
-		cmdSetRate := rateValue(rates, "cmd_set")
-		cmdDeleteRate := rateValue(rates, "cmd_delete")
-		incrRate := rateValue(rates, "incr_hits") + rateValue(rates, "incr_misses")
-		decrRate := rateValue(rates, "decr_hits") + rateValue(rates, "decr_misses")
-		touchRate := rateValue(rates, "touch_hits") + rateValue(rates, "touch_misses")
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Commands/s: get %.2f  set %.2f  delete %.2f  incr %.2f  decr %.2f  touch %.2f",
-			cmdGetRate, cmdSetRate, cmdDeleteRate, incrRate, decrRate, touchRate))
+		shown := func(keys ...string) string {
+			total := 0.0
+			for _, key := range keys {
+				total += rateValue(rates, key)
+			}
+			return nf.rate(keys[0], displayRate(total, s.interval))
+		}
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Commands%s: get %s  set %s  delete %s  incr %s  decr %s  touch %s",
+			rateUnit(s.interval), shown("cmd_get"), shown("cmd_set"), shown("cmd_delete"),
+			shown("incr_hits", "incr_misses"), shown("decr_hits", "decr_misses"), shown("touch_hits", "touch_misses")))
 		line++
 
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Bandwidth/s: read %s  write %s",
-			formatBytesRate(rateValue(rates, "bytes_read")),
-			formatBytesRate(rateValue(rates, "bytes_written")),
-		))
+		bandwidth, bandwidthStyle := describeBandwidth(s)
+		drawText(screen, 0, line, bandwidthStyle, bandwidth)
 		line++
 
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Items: current %.0f  total %.0f  expired %.0f",
-			stats.Values["curr_items"],
-			stats.Values["total_items"],
-			stats.Values["expired_unfetched"],
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Items: current %s  total %s  expired %s",
+			nf.count("curr_items", stats.Values["curr_items"]),
+			nf.count("total_items", stats.Values["total_items"]),
+			nf.count("expired_unfetched", stats.Values["expired_unfetched"]),
 		))
 		line++
 
-		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Slabs: %.0f  Threads: %.0f  Accepting connections: %s",
-			stats.Values["slab_global_page_pool"],
-			stats.Values["threads"],
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Slabs: %s  Threads: %s  Accepting connections: %s",
+			nf.count("slab_global_page_pool", stats.Values["slab_global_page_pool"]),
+			nf.count("threads", stats.Values["threads"]),
 			boolToWord(stats.Values["accepting_conns"] == 1),
 		))
+		line += 2
+
+		connPercent := 0.0
+		if maxConns := stats.Values["max_connections"]; maxConns > 0 {
+			connPercent = (stats.Values["curr_connections"] / maxConns) * 100
+		}
+		drawGauge(screen, 0, line, width, "Memory", memPercent, false)
 		line++
-	} else if err == nil {
-		drawText(screen, 0, line, baseStyle, "Waiting for initial stats...")
+		drawGauge(screen, 0, line, width, "Connections", connPercent, false)
+		line++
+		drawGauge(screen, 0, line, width, "Hit ratio", ratio, true)
+		line++
+		if read, write, ok := linkUtilization(s); ok {
+			drawGauge(screen, 0, line, width, "Network", max(read, write), false)
+			line++
+		}
 		line++
-	}
 
-	if height > 2 {
-		drawText(screen, 0, height-1, highlightStyle,
-			"Controls: q to quit | r to reset rate baseline")
+		drawHistoryCharts(screen, 0, line, width, height-1-line, s.history, noteMarkers(u.events, s.history), u.chartMode)
+	} else if err == nil {
+		drawText(screen, 0, line, baseStyle, "Waiting for initial stats...")
 	}
-
-	screen.Show()
 }
 
-// drawText safely places text on the screen, clipping any overflow so drawing
-// never oversteps the terminal bounds.
+// drawText safely places text on the screen, one column per rune, clipping
+// any overflow so drawing never oversteps the terminal bounds.
 func drawText(screen tcell.Screen, x, y int, style tcell.Style, text string) {
 	if y < 0 {
 		return
@@ -318,12 +1089,13 @@ func drawText(screen tcell.Screen, x, y int, style tcell.Style, text string) {
 	if y >= height {
 		return
 	}
-	for i, r := range text {
-		pos := x + i
+	pos := x
+	for _, r := range text {
 		if pos >= width {
 			break
 		}
 		screen.SetContent(pos, y, r, nil, style)
+		pos++
 	}
 }
 
@@ -336,6 +1108,25 @@ func rateValue(rates map[string]float64, key string) float64 {
 	return rates[key]
 }
 
+// hitRatio returns the lifetime get hit ratio as a percentage.
+func hitRatio(stats *statsSnapshot) float64 {
+	hits := stats.Values["get_hits"]
+	total := hits + stats.Values["get_misses"]
+	if total <= 0 {
+		return 0
+	}
+	return (hits / total) * 100
+}
+
+// memoryPercent returns how much of limit_maxbytes is currently in use.
+func memoryPercent(stats *statsSnapshot) float64 {
+	maxBytes := stats.Values["limit_maxbytes"]
+	if maxBytes <= 0 {
+		return 0
+	}
+	return (stats.Values["bytes"] / maxBytes) * 100
+}
+
 // formatBytes renders byte counts using human-readable units, making memory
 // stats approachable without manual conversion.
 func formatBytes(b float64) string {
@@ -354,10 +1145,10 @@ func formatBytes(b float64) string {
 	return fmt.Sprintf("%.1f %s", b, units[idx])
 }
 
-// formatBytesRate formats bandwidth-style numbers and appends /s so users know
-// they are looking at a rate rather than a total.
-func formatBytesRate(bps float64) string {
-	return fmt.Sprintf("%s/s", formatBytes(bps))
+// formatBytesRate formats bandwidth-style numbers and appends the rate unit so
+// users know they are looking at a rate rather than a total.
+func formatBytesRate(bps float64, interval time.Duration) string {
+	return formatBytes(displayRate(bps, interval)) + rateUnit(interval)
 }
 
 // formatUptime emits a friendly uptime string because wall-clock durations are
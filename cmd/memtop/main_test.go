@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net"
@@ -10,6 +12,7 @@ import (
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"mymemcache-top/memstats/memstatstest"
 )
 
 func TestCalculateRates(t *testing.T) {
@@ -41,6 +44,57 @@ func TestCalculateRates(t *testing.T) {
 	}
 }
 
+func TestCalculateRatesUsesServerUptimeWhenLocalTicksSlip(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	prev := newStatsSnapshot(start, map[string]string{"uptime": "1000", "cmd_get": "0"})
+
+	// A laptop that slept for a minute saw 2s pass; the server saw 62s.
+	slept := newStatsSnapshot(start.Add(2*time.Second), map[string]string{"uptime": "1062", "cmd_get": "620"})
+	if got := calculateRates(slept, prev)["cmd_get"]; got != 10 {
+		t.Fatalf("rate after sleep = %.2f, want 10 from the uptime delta", got)
+	}
+
+	// Within uptime's whole-second granularity the local interval is kept.
+	normal := newStatsSnapshot(start.Add(2*time.Second), map[string]string{"uptime": "1003", "cmd_get": "20"})
+	if got := calculateRates(normal, prev)["cmd_get"]; got != 10 {
+		t.Fatalf("rate = %.2f, want 10 from the local interval", got)
+	}
+
+	// A restart resets uptime, so it says nothing about the interval.
+	restarted := newStatsSnapshot(start.Add(2*time.Second), map[string]string{"uptime": "5", "cmd_get": "20"})
+	if got := calculateRates(restarted, prev)["cmd_get"]; got != 10 {
+		t.Fatalf("rate after restart = %.2f, want 10 from the local interval", got)
+	}
+}
+
+func TestCountersAboveFloatPrecision(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	// 2^53 + 1 and + 3: as float64 both round to even neighbours and the
+	// difference of 2 comes out as 4.
+	prev := newStatsSnapshot(start, map[string]string{"bytes_read": "9007199254740993", "rusage_user": "1.5"})
+	curr := newStatsSnapshot(start.Add(time.Second), map[string]string{"bytes_read": "9007199254740995", "rusage_user": "2.0"})
+	if got := curr.Counters["bytes_read"]; got != 9007199254740995 {
+		t.Fatalf("counter = %d, want it exact", got)
+	}
+	if _, ok := curr.Counters["rusage_user"]; ok {
+		t.Fatalf("non-integer stat stored as a counter")
+	}
+	rates := calculateRates(curr, prev)
+	if rates["bytes_read"] != 2 || rates["rusage_user"] != 0.5 {
+		t.Fatalf("rates = %v, want bytes_read 2 and rusage_user 0.5", rates)
+	}
+
+	s := newSession("a:11211", time.Second)
+	s.current = curr
+	out, err := json.Marshal(newSampleRecord(s))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(out), `"bytes_read":9007199254740995`) || !strings.Contains(string(out), `"rusage_user":2`) {
+		t.Fatalf("record lost precision: %s", out)
+	}
+}
+
 func TestRateValueNilMap(t *testing.T) {
 	if got := rateValue(nil, "cmd_get"); got != 0 {
 		t.Fatalf("rateValue with nil map: got %.2f, want 0", got)
@@ -72,7 +126,7 @@ func TestFormatBytes(t *testing.T) {
 }
 
 func TestFormatBytesRate(t *testing.T) {
-	if got, want := formatBytesRate(2048), "2.0 KB/s"; got != want {
+	if got, want := formatBytesRate(2048, 2*time.Second), "2.0 KB/s"; got != want {
 		t.Fatalf("formatBytesRate mismatch: got %q, want %q", got, want)
 	}
 }
@@ -140,7 +194,7 @@ func TestFetchStatsParsesValues(t *testing.T) {
 		errCh <- nil
 	}()
 
-	snapshot, err := fetchStats(ln.Addr().String())
+	snapshot, err := fetchStats(context.Background(), ln.Addr().String())
 	if err != nil {
 		t.Fatalf("fetchStats returned error: %v", err)
 	}
@@ -162,6 +216,29 @@ func TestFetchStatsParsesValues(t *testing.T) {
 	}
 }
 
+func TestFetchStatsGivesUpOnASlowServer(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetStats("", map[string]string{"cmd_get": "1"})
+	srv.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := fetchStats(ctx, srv.Addr()); err == nil {
+		t.Fatalf("fetchStats should fail once the context expires")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("fetchStats took %s, want it to stop at the deadline", elapsed)
+	}
+
+	srv.SetLatency(0)
+	snapshot, err := fetchStats(context.Background(), srv.Addr())
+	if err != nil || snapshot.Values["cmd_get"] != 1 {
+		t.Fatalf("fetchStats without latency = %v, %v", snapshot, err)
+	}
+}
+
 func TestDrawScreenRendersKeySections(t *testing.T) {
 	screen := tcell.NewSimulationScreen("")
 	if err := screen.Init(); err != nil {
@@ -209,7 +286,10 @@ func TestDrawScreenRendersKeySections(t *testing.T) {
 		"bytes_written": 2048,
 	}
 
-	drawScreen(screen, "127.0.0.1:11211", 2*time.Second, stats, rates, nil)
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = stats
+	sess.rates = rates
+	drawScreen(screen, newUI(2*time.Second, nil, sess))
 
 	cells, width, height := screen.GetContents()
 	if height == 0 || width == 0 {
@@ -224,7 +304,11 @@ func TestDrawScreenRendersKeySections(t *testing.T) {
 	if !strings.Contains(timeLine, "Uptime: 01h 01m 01s") {
 		t.Fatalf("time line missing uptime, got %q", timeLine)
 	}
-	memoryLine := lineFromCells(cells, width, 5)
+	missesLine := lineFromCells(cells, width, 4)
+	if !strings.HasPrefix(missesLine, "Misses/s: 0.00") {
+		t.Fatalf("misses line unexpected, got %q", missesLine)
+	}
+	memoryLine := lineFromCells(cells, width, 6)
 	if !strings.Contains(memoryLine, "Memory: 2.0 KB / 8.0 KB (25.0%)   Free: 6.0 KB") {
 		t.Fatalf("memory line unexpected, got %q", memoryLine)
 	}
@@ -0,0 +1,57 @@
+package main
+
+import "fmt"
+
+// maintenanceRates are the background LRU work counters shown as rates in
+// the maintenance panel, in order.
+var maintenanceRates = []struct{ label, key string }{
+	{"crawler checked", "crawler_items_checked"},
+	{"crawler reclaimed", "crawler_reclaimed"},
+	{"maintainer juggles", "lru_maintainer_juggles"},
+	{"moves to cold", "moves_to_cold"},
+	{"moves to warm", "moves_to_warm"},
+	{"moves within LRU", "moves_within_lru"},
+	{"bumps dropped", "lru_bumps_dropped"},
+}
+
+// renderMaintenancePanel shows what memcached's background threads are
+// doing: whether the LRU crawler is running, and the rates of the crawler's
+// checks and reclaims, the LRU maintainer's juggles, and items moved between
+// the segmented LRU's queues. Bursts of this work line up with latency
+// changes that the request counters don't explain. Dropped bumps mean the
+// maintainer is falling behind and are highlighted.
+func renderMaintenancePanel(s *session) []panelLine {
+	if s.current == nil {
+		return nil
+	}
+	v := s.current.Values
+	_, crawler := v["lru_crawler_running"]
+	_, maintainer := v["lru_maintainer_juggles"]
+	if !crawler && !maintainer {
+		return []panelLine{plainLine(notSupported)}
+	}
+	var lines []panelLine
+	if crawler {
+		line := plainLine(fmt.Sprintf("%-18s idle", "crawler"))
+		if v["lru_crawler_running"] > 0 {
+			line = panelLine{Text: fmt.Sprintf("%-18s running", "crawler"), Style: theme.warn}
+		}
+		lines = append(lines, line)
+	}
+	if s.elapsed <= 0 {
+		return append(lines, plainLine("Waiting for two samples..."))
+	}
+	nf := numberFormat{"maintenance"}
+	for _, r := range maintenanceRates {
+		if _, ok := v[r.key]; !ok {
+			continue
+		}
+		rate := rateValue(s.rates, r.key)
+		line := plainLine(fmt.Sprintf("%-18s %s", r.label+rateUnit(s.interval), nf.rate(r.key, displayRate(rate, s.interval))))
+		if r.key == "lru_bumps_dropped" && rate > 0 {
+			line.Style = theme.bad
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
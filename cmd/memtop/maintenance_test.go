@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaintenancePanel(t *testing.T) {
+	start := time.Unix(1000, 0)
+	sess := newSession("127.0.0.1:11211", time.Second)
+	values := func(running, checked, juggles, dropped float64) map[string]float64 {
+		return map[string]float64{"lru_crawler_running": running, "crawler_items_checked": checked, "lru_maintainer_juggles": juggles, "lru_bumps_dropped": dropped}
+	}
+	sess.record(&statsSnapshot{Timestamp: start, Values: values(0, 100, 50, 0)}, nil)
+	lines := renderMaintenancePanel(sess)
+	if len(lines) != 2 || !strings.HasSuffix(lines[0].Text, "idle") || !strings.HasPrefix(lines[1].Text, "Waiting") {
+		t.Fatalf("one sample = %+v", lines)
+	}
+	sess.record(&statsSnapshot{Timestamp: start.Add(10 * time.Second), Values: values(1, 1100, 70, 5)}, nil)
+
+	lines = renderMaintenancePanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "crawler running|crawler checked/s 100.00|maintainer juggles/s 2.00|bumps dropped/s 0.50"; strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[0].Style != theme.warn || lines[3].Style != theme.bad {
+		t.Fatalf("a running crawler and dropped bumps should be highlighted")
+	}
+
+	sess.current = &statsSnapshot{Values: map[string]float64{}}
+	if lines := renderMaintenancePanel(sess); lines[0].Text != notSupported {
+		t.Fatalf("servers without maintenance stats should say so, got %+v", lines)
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultMetadumpLimit caps how many keys one sampling pass reads so large
+	// caches are sampled rather than dumped in full.
+	defaultMetadumpLimit = 10000
+	// defaultMetadumpInterval spaces sampling passes out; metadump walks the
+	// LRU on the server and is far more expensive than `stats`.
+	defaultMetadumpInterval = time.Minute
+)
+
+// metadumpEntry is one item reported by `lru_crawler metadump`.
+type metadumpEntry struct {
+	Key        string `json:"key"`
+	Exp        int64  `json:"exp"` // absolute unix expiry, -1 for items that never expire
+	LastAccess int64  `json:"la"`
+	Class      int    `json:"cls"`
+	Size       int64  `json:"size"`
+	Fetched    bool   `json:"fetch"`
+}
+
+// ttlBucket aggregates sampled items by remaining time to live.
+type ttlBucket struct {
+	Label string
+	Count int
+	Bytes int64
+}
+
+// ttlSample is the outcome of one metadump sampling pass.
+type ttlSample struct {
+	Taken     time.Time
+	Sampled   int
+	Truncated bool
+	Buckets   []ttlBucket
+}
+
+// ttlBucketLimits are the upper bounds, in seconds, of the finite TTL buckets
+// that follow the leading "no expiry" bucket.
+var ttlBucketLimits = []struct {
+	label string
+	limit int64
+}{
+	{"< 1m", 60},
+	{"< 1h", 3600},
+	{"< 1d", 86400},
+	{">= 1d", 0},
+}
+
+// parseMetadumpLine decodes a `key=... exp=... la=... cls=... size=...` line.
+// Keys are URL-encoded by the server.
+func parseMetadumpLine(line string) (metadumpEntry, bool) {
+	var entry metadumpEntry
+	seenKey := false
+	for _, field := range strings.Fields(line) {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "key":
+			key, err := url.QueryUnescape(value)
+			if err != nil {
+				key = value
+			}
+			entry.Key = key
+			seenKey = true
+		case "exp":
+			entry.Exp, _ = strconv.ParseInt(value, 10, 64)
+		case "la":
+			entry.LastAccess, _ = strconv.ParseInt(value, 10, 64)
+		case "cls":
+			entry.Class, _ = strconv.Atoi(value)
+		case "size":
+			entry.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "fetch":
+			entry.Fetched = value == "yes"
+		}
+	}
+	return entry, seenKey
+}
+
+// fetchMetadump streams `lru_crawler metadump all`, handing each entry to fn
+// until the dump ends or limit entries have been read. It reports whether the
+// dump was cut short by the limit.
+func fetchMetadump(ctx context.Context, addr string, limit int, fn func(metadumpEntry)) (bool, error) {
+	count := 0
+	truncated := false
+	err := streamMetadump(ctx, addr, func(entry metadumpEntry) bool {
+		fn(entry)
+		count++
+		truncated = limit > 0 && count >= limit
+		return !truncated
+	})
+	return truncated, err
+}
+
+// streamMetadump reads `lru_crawler metadump all` until the dump ends or fn
+// returns false. The deadline is renewed for every line, so a slow consumer
+// only fails when the server itself goes quiet.
+func streamMetadump(ctx context.Context, addr string, fn func(metadumpEntry) bool) error {
+	conn, err := dialServer(ctx, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := fmt.Fprint(conn, "lru_crawler metadump all\r\n"); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(nil, maxLineSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "END" {
+			return nil
+		}
+		if isProtocolError(line) {
+			return fmt.Errorf("metadump not available: %s", line)
+		}
+		entry, ok := parseMetadumpLine(line)
+		if !ok {
+			continue
+		}
+		if !fn(entry) {
+			return nil
+		}
+		if err := conn.SetDeadline(time.Now().Add(defaultTimeout)); err != nil {
+			return err
+		}
+	}
+	if errors.Is(scanner.Err(), bufio.ErrTooLong) {
+		return errLineTooLong()
+	}
+	return scanner.Err()
+}
+
+// isProtocolError reports whether a response line is one of memcached's error
+// replies, including BUSY from a crawler that is already running.
+func isProtocolError(line string) bool {
+	for _, prefix := range []string{"ERROR", "CLIENT_ERROR", "SERVER_ERROR", "BUSY"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newTTLBuckets returns empty buckets in display order.
+func newTTLBuckets() []ttlBucket {
+	buckets := []ttlBucket{{Label: "no expiry"}}
+	for _, b := range ttlBucketLimits {
+		buckets = append(buckets, ttlBucket{Label: b.label})
+	}
+	return buckets
+}
+
+// ttlBucketIndex returns the bucket for an item given the server's clock.
+// Items already past their expiry fall into the shortest bucket.
+func ttlBucketIndex(exp, now int64) int {
+	if exp <= 0 {
+		return 0
+	}
+	remaining := exp - now
+	for i, b := range ttlBucketLimits {
+		if b.limit == 0 || remaining < b.limit {
+			return i + 1
+		}
+	}
+	return len(ttlBucketLimits)
+}
+
+// fetchTTLSample runs one sampling pass and buckets the remaining TTLs. The
+// server's own clock is used so skew between hosts doesn't shift buckets.
+func fetchTTLSample(ctx context.Context, addr string, limit int, now int64) (*ttlSample, error) {
+	sample := &ttlSample{Buckets: newTTLBuckets()}
+	truncated, err := fetchMetadump(ctx, addr, limit, func(e metadumpEntry) {
+		b := &sample.Buckets[ttlBucketIndex(e.Exp, now)]
+		b.Count++
+		b.Bytes += e.Size
+		sample.Sampled++
+	})
+	if err != nil {
+		return nil, err
+	}
+	sample.Truncated = truncated
+	sample.Taken = time.Now()
+	return sample, nil
+}
+
+// serverTime returns the server's unix clock from the latest snapshot, falling
+// back to the local clock before the first sample arrives.
+func serverTime(stats *statsSnapshot) int64 {
+	if stats != nil {
+		if t, ok := stats.Values["time"]; ok && t > 0 {
+			return int64(t)
+		}
+	}
+	return time.Now().Unix()
+}
+
+// metadumpDue reports whether enough time has passed for another sampling pass.
+func (s *session) metadumpDue(now time.Time) bool {
+	return s.lastMetadump.IsZero() || now.Sub(s.lastMetadump) >= s.metadumpInterval
+}
+
+// recordTTLs stores the outcome of a sampling pass. The attempt time is kept
+// even on failure so an unsupported server isn't hammered every tick.
+func (s *session) recordTTLs(sample *ttlSample, err error) {
+	s.lastMetadump = time.Now()
+	if err != nil {
+		s.ttlErr = err
+		return
+	}
+	s.ttlErr = nil
+	s.ttls = sample
+}
+
+// renderTTLPanel shows how sampled items are spread across TTL buckets, so
+// keys stored without expiry stand out.
+func renderTTLPanel(s *session) []panelLine {
+	if s.ttlErr != nil {
+		return []panelLine{plainLine(fmt.Sprintf("Error: %v", s.ttlErr))}
+	}
+	if s.ttls == nil {
+		return []panelLine{plainLine("Waiting for metadump sample...")}
+	}
+	header := fmt.Sprintf("%d keys sampled %s ago", s.ttls.Sampled, time.Since(s.ttls.Taken).Truncate(time.Second))
+	if s.ttls.Truncated {
+		header += " (limit reached)"
+	}
+	lines := []panelLine{plainLine(header)}
+	for _, b := range s.ttls.Buckets {
+		percent := 0.0
+		if s.ttls.Sampled > 0 {
+			percent = float64(b.Count) / float64(s.ttls.Sampled) * 100
+		}
+		lines = append(lines, plainLine(fmt.Sprintf("%-10s %8d %5.1f%%  %10s", b.Label, b.Count, percent, formatBytes(float64(b.Bytes)))))
+	}
+	return lines
+}
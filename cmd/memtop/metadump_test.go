@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+func TestParseMetadumpLine(t *testing.T) {
+	entry, ok := parseMetadumpLine("key=user%3A42 exp=1700000600 la=1700000000 cas=7 fetch=yes cls=3 size=120")
+	if !ok {
+		t.Fatalf("expected line to parse")
+	}
+	if entry.Key != "user:42" || entry.Exp != 1700000600 || entry.Class != 3 || entry.Size != 120 || !entry.Fetched {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+	if _, ok := parseMetadumpLine("garbage"); ok {
+		t.Fatalf("lines without a key should be rejected")
+	}
+}
+
+func TestTTLBucketIndex(t *testing.T) {
+	const now = 1000000
+	tests := []struct {
+		name string
+		exp  int64
+		want string
+	}{
+		{name: "never", exp: -1, want: "no expiry"},
+		{name: "expired", exp: now - 5, want: "< 1m"},
+		{name: "seconds", exp: now + 30, want: "< 1m"},
+		{name: "minutes", exp: now + 600, want: "< 1h"},
+		{name: "hours", exp: now + 7200, want: "< 1d"},
+		{name: "days", exp: now + 3*86400, want: ">= 1d"},
+	}
+	buckets := newTTLBuckets()
+	for _, tc := range tests {
+		if got := buckets[ttlBucketIndex(tc.exp, now)].Label; got != tc.want {
+			t.Fatalf("%s: bucket %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFetchTTLSampleRespectsLimit(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetReply("lru_crawler metadump all", "key=a exp=-1 la=0 cas=1 fetch=no cls=1 size=100\r\n"+
+		"key=b exp=1030 la=0 cas=2 fetch=no cls=1 size=50\r\n"+
+		"key=c exp=-1 la=0 cas=3 fetch=no cls=1 size=10\r\n"+
+		"END\r\n")
+
+	sample, err := fetchTTLSample(context.Background(), srv.Addr(), 2, 1000)
+	if err != nil {
+		t.Fatalf("fetchTTLSample: %v", err)
+	}
+	if sample.Sampled != 2 || !sample.Truncated {
+		t.Fatalf("expected 2 truncated samples, got %d (truncated %v)", sample.Sampled, sample.Truncated)
+	}
+	if b := sample.Buckets[0]; b.Count != 1 || b.Bytes != 100 {
+		t.Fatalf("no-expiry bucket = %+v", b)
+	}
+	if b := sample.Buckets[1]; b.Count != 1 || b.Bytes != 50 {
+		t.Fatalf("< 1m bucket = %+v", b)
+	}
+}
+
+func TestFetchMetadumpSurfacesServerErrors(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetReply("lru_crawler metadump all", "BUSY currently processing crawler request\r\n")
+
+	_, err := fetchTTLSample(context.Background(), srv.Addr(), 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "BUSY") {
+		t.Fatalf("expected BUSY error, got %v", err)
+	}
+}
+
+func TestFetchMetadumpReportsLinesPastTheLimit(t *testing.T) {
+	prev := maxLineSize
+	t.Cleanup(func() { maxLineSize = prev })
+	maxLineSize = 1024
+
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetReply("lru_crawler metadump all", "key="+strings.Repeat("k", 2048)+" exp=-1 la=0 cas=1 fetch=no cls=1 size=100\r\nEND\r\n")
+
+	_, err := fetchTTLSample(context.Background(), srv.Addr(), 0, 0)
+	if err == nil || !strings.Contains(err.Error(), "-max-line-size") {
+		t.Fatalf("expected a line too long error, got %v", err)
+	}
+}
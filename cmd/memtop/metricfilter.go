@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// parseMetricFilter compiles -metrics, a regular expression that must match
+// a stat's whole name, so "bytes" keeps bytes but not bytes_read. An empty
+// expression keeps everything and returns nil.
+func parseMetricFilter(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid -metrics: %w", err)
+	}
+	return re, nil
+}
+
+// filterStats returns the entries of m whose names the filter matches, in a
+// new map so the session's own is left alone. A nil filter returns m.
+func filterStats[V any](m map[string]V, filter *regexp.Regexp) map[string]V {
+	if filter == nil || m == nil {
+		return m
+	}
+	out := make(map[string]V)
+	for key, v := range m {
+		if filter.MatchString(key) {
+			out[key] = v
+		}
+	}
+	return out
+}
+
+// filterStatNames keeps the names the filter matches, in order.
+func filterStatNames(names []string, filter *regexp.Regexp) []string {
+	if filter == nil {
+		return names
+	}
+	var out []string
+	for _, name := range names {
+		if filter.MatchString(name) {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// filtered returns a copy of the snapshot narrowed to the stats the filter
+// matches.
+func (s *statsSnapshot) filtered(filter *regexp.Regexp) *statsSnapshot {
+	out := *s
+	out.Raw = filterStats(s.Raw, filter)
+	out.Values = filterStats(s.Values, filter)
+	out.Counters = filterStats(s.Counters, filter)
+	return &out
+}
+
+// filtered narrows the record to the stats the filter matches.
+func (r sampleRecord) filtered(filter *regexp.Regexp) sampleRecord {
+	r.Values = filterStats(r.Values, filter)
+	r.Rates = filterStats(r.Rates, filter)
+	r.counters = filterStats(r.counters, filter)
+	return r
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+func TestMetricFilterMatchesWholeNames(t *testing.T) {
+	re, err := parseMetricFilter("cmd_.*|evictions|bytes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := filterStatNames([]string{"cmd_get", "cmd_set", "bytes", "bytes_read", "evictions", "total_evictions"}, re)
+	if want := []string{"cmd_get", "cmd_set", "bytes", "evictions"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("filtered = %q, want %q", got, want)
+	}
+	if re, err := parseMetricFilter(""); re != nil || err != nil {
+		t.Fatalf("empty -metrics = %v, %v; want no filter", re, err)
+	}
+	if _, err := parseMetricFilter("cmd_("); err == nil {
+		t.Fatalf("invalid -metrics accepted")
+	}
+}
+
+func TestSinksWriteOnlyFilteredMetrics(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"cmd_get": 10, "evictions": 1, "uptime": 5},
+		Counters:  map[string]uint64{"cmd_get": 10, "evictions": 1, "uptime": 5},
+	}
+	sess.rates = map[string]float64{"cmd_get": 2, "evictions": 0}
+	u := newUI(2*time.Second, nil, sess)
+	u.metrics, _ = parseMetricFilter("cmd_.*")
+
+	rec := sampleRecords(u)[0]
+	if len(rec.Values) != 1 || len(rec.Rates) != 1 || rec.formatValue("cmd_get") != "10" {
+		t.Fatalf("record = %+v", rec)
+	}
+	if len(sess.current.Values) != 3 || len(sess.rates) != 2 {
+		t.Fatalf("filtering changed the session's maps")
+	}
+
+	var out bytes.Buffer
+	if err := newCSVSink(&out, true).write(u); err != nil {
+		t.Fatal(err)
+	}
+	if header := strings.SplitN(out.String(), "\n", 2)[0]; header != "timestamp,server,up,cmd_get,cmd_set,cmd_get_per_sec,cmd_set_per_sec" {
+		t.Fatalf("csv header = %q", header)
+	}
+}
+
+func TestStatsAndExportMetricsFlag(t *testing.T) {
+	srv := memstatstest.NewServer()
+	defer srv.Close()
+	srv.SetStats("", map[string]string{"cmd_get": "10", "bytes": "100", "bytes_read": "7"})
+	srv.SetStats("settings", map[string]string{"maxconns": "1024"})
+
+	code, out, errOut := runCommand(t, srv.Addr(), "", "stats", "-metrics", "cmd_.*|bytes")
+	if want := "STAT bytes 100\nSTAT cmd_get 10\n"; code != 0 || out != want {
+		t.Fatalf("stats -metrics = %d %q %s", code, out, errOut)
+	}
+	code, out, errOut = runCommand(t, srv.Addr(), "", "export", "-metrics", "bytes")
+	if code != 0 || !strings.Contains(out, `"values":{"bytes":100}`) {
+		t.Fatalf("export -metrics = %d %q %s", code, out, errOut)
+	}
+	if code, _, _ := runCommand(t, srv.Addr(), "", "export", "-metrics", "("); code != 2 {
+		t.Fatalf("invalid -metrics exited %d, want 2", code)
+	}
+}
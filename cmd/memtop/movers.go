@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+)
+
+// defaultMoversExclude skips counters that change every tick by construction
+// or jitter constantly, which would otherwise crowd out interesting movers.
+const defaultMoversExclude = `^(uptime|time|rusage_user|rusage_system)$`
+
+// defaultMoversCount is how many movers the panel lists by default.
+const defaultMoversCount = 8
+
+// mover describes how much a metric's rate changed between two intervals.
+type mover struct {
+	Key    string
+	Prev   float64
+	Curr   float64
+	Change float64
+}
+
+// topMovers ranks metrics by the absolute change in their per-second rate
+// between the previous and current interval, skipping keys that match exclude.
+func topMovers(curr, prev map[string]float64, exclude *regexp.Regexp, n int) []mover {
+	var movers []mover
+	for key, c := range curr {
+		p, ok := prev[key]
+		if !ok {
+			continue
+		}
+		if exclude != nil && exclude.MatchString(key) {
+			continue
+		}
+		change := c - p
+		if change == 0 {
+			continue
+		}
+		movers = append(movers, mover{Key: key, Prev: p, Curr: c, Change: change})
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		ai, aj := math.Abs(movers[i].Change), math.Abs(movers[j].Change)
+		if ai != aj {
+			return ai > aj
+		}
+		return movers[i].Key < movers[j].Key
+	})
+	if n >= 0 && len(movers) > n {
+		movers = movers[:n]
+	}
+	return movers
+}
+
+// renderMoversPanel lists the metrics whose rates moved most since the
+// previous interval, so unusual counters surface without being watched.
+func renderMoversPanel(s *session) []panelLine {
+	if len(s.prevRates) == 0 || len(s.rates) == 0 {
+		return []panelLine{plainLine("Waiting for two intervals of rates...")}
+	}
+	movers := topMovers(s.rates, s.prevRates, s.moversExclude, s.moversCount)
+	if len(movers) == 0 {
+		return []panelLine{plainLine("No rate changes this interval.")}
+	}
+	nf := numberFormat{"movers"}
+	lines := make([]panelLine, 0, len(movers))
+	for _, m := range movers {
+		lines = append(lines, plainLine(fmt.Sprintf("%-22s %11s%s  (%s -> %s)", m.Key,
+			nf.signed(numberRate, m.Key, displayRate(m.Change, s.interval)), rateUnit(s.interval),
+			nf.rate(m.Key, displayRate(m.Prev, s.interval)), nf.rate(m.Key, displayRate(m.Curr, s.interval)))))
+	}
+	return lines
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestTopMoversRanksByAbsoluteChange(t *testing.T) {
+	prev := map[string]float64{"cmd_get": 100, "cmd_set": 50, "evictions": 0, "uptime": 1, "steady": 5}
+	curr := map[string]float64{"cmd_get": 110, "cmd_set": 5, "evictions": 30, "uptime": 90, "steady": 5, "new_key": 7}
+
+	movers := topMovers(curr, prev, regexp.MustCompile(defaultMoversExclude), 2)
+	if len(movers) != 2 {
+		t.Fatalf("expected 2 movers, got %d: %+v", len(movers), movers)
+	}
+	if movers[0].Key != "cmd_set" || movers[0].Change != -45 {
+		t.Fatalf("largest mover should be cmd_set (-45), got %+v", movers[0])
+	}
+	if movers[1].Key != "evictions" {
+		t.Fatalf("second mover should be evictions, got %+v", movers[1])
+	}
+	for _, m := range topMovers(curr, prev, regexp.MustCompile(defaultMoversExclude), -1) {
+		if m.Key == "uptime" || m.Key == "steady" || m.Key == "new_key" {
+			t.Fatalf("unexpected mover %q", m.Key)
+		}
+	}
+}
+
+func TestRenderMoversPanelWaitsForTwoIntervals(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 0)
+	lines := renderMoversPanel(sess)
+	if len(lines) != 1 || !strings.Contains(lines[0].Text, "Waiting") {
+		t.Fatalf("expected waiting message, got %+v", lines)
+	}
+
+	sess.prevRates = map[string]float64{"cmd_get": 1}
+	sess.rates = map[string]float64{"cmd_get": 3}
+	lines = renderMoversPanel(sess)
+	if len(lines) != 1 || !strings.HasPrefix(lines[0].Text, "cmd_get") || !strings.Contains(lines[0].Text, "+2.00/s") {
+		t.Fatalf("unexpected movers panel %+v", lines)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// eventNote marks user-supplied timeline annotations in the event log.
+const eventNote = "note"
+
+// noteMarkerStyle highlights chart columns that carry a note.
+var noteMarkerStyle = theme.warn.Bold(true)
+
+// textPrompt collects a single line of input in the footer, for example a
+// timeline note, while the rest of the UI keeps refreshing.
+type textPrompt struct {
+	label string
+	text  []rune
+}
+
+// handleKey applies a key press to the prompt. It reports whether the prompt
+// is finished and, if so, whether the input was accepted.
+func (p *textPrompt) handleKey(evt *tcell.EventKey) (done, accepted bool) {
+	switch evt.Key() {
+	case tcell.KeyEnter:
+		return true, true
+	case tcell.KeyEscape, tcell.KeyCtrlC:
+		return true, false
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.text) > 0 {
+			p.text = p.text[:len(p.text)-1]
+		}
+	case tcell.KeyRune:
+		p.text = append(p.text, evt.Rune())
+	}
+	return false, false
+}
+
+// value returns the entered text without surrounding whitespace.
+func (p *textPrompt) value() string {
+	return strings.TrimSpace(string(p.text))
+}
+
+// footer renders the prompt line shown in place of the controls.
+func (p *textPrompt) footer() string {
+	return p.label + ": " + string(p.text) + "_  (Enter to save, Esc to cancel)"
+}
+
+// noteMarkers maps every note onto the index of the first history sample
+// taken at or after it, so charts can mark the moment it was written.
+func noteMarkers(events *eventLog, h *history) []int {
+	if events == nil || h == nil || len(h.times) == 0 {
+		return nil
+	}
+	var markers []int
+	for _, e := range events.events {
+		if e.Kind != eventNote {
+			continue
+		}
+		for i, ts := range h.times {
+			if !ts.Before(e.Time) {
+				markers = append(markers, i)
+				break
+			}
+		}
+	}
+	return markers
+}
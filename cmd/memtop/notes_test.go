@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestTextPromptEditing(t *testing.T) {
+	p := &textPrompt{label: "Note"}
+	for _, r := range "deployed v2.3x" {
+		if done, _ := p.handleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone)); done {
+			t.Fatalf("typing should not finish the prompt")
+		}
+	}
+	p.handleKey(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+	if got := p.value(); got != "deployed v2.3" {
+		t.Fatalf("prompt value = %q", got)
+	}
+	done, accepted := p.handleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	if !done || !accepted {
+		t.Fatalf("Enter should accept the prompt")
+	}
+	done, accepted = (&textPrompt{}).handleKey(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+	if !done || accepted {
+		t.Fatalf("Escape should cancel the prompt")
+	}
+}
+
+func TestNoteMarkersAlignWithHistory(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		sess.history.add(&statsSnapshot{Timestamp: start.Add(time.Duration(i) * time.Second), Values: map[string]float64{}}, nil)
+	}
+	u := newUI(time.Second, nil, sess)
+	u.addNote(start.Add(1500*time.Millisecond), "deployed v2.3")
+	u.addNote(start.Add(time.Minute), "after the last sample")
+	u.addNote(start, "   ")
+
+	markers := noteMarkers(u.events, sess.history)
+	if len(markers) != 1 || markers[0] != 2 {
+		t.Fatalf("noteMarkers = %v, want [2]", markers)
+	}
+	if got := len(sess.events.events); got != 2 {
+		t.Fatalf("blank notes should be ignored, got %d events", got)
+	}
+}
+
+func TestDrawChartHighlightsMarkers(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(4, 3)
+
+	drawChart(screen, 0, 0, 4, 3, "x", []float64{1, 1, 1, 1}, []int{1}, 2, chartBlock)
+	screen.Show()
+
+	r, _, style, _ := screen.GetContent(1, 1)
+	if r != '┆' || style != noteMarkerStyle {
+		t.Fatalf("empty marker cell should show a dashed line, got %q", r)
+	}
+	r, _, style, _ = screen.GetContent(1, 2)
+	if r != '█' || style != noteMarkerStyle {
+		t.Fatalf("filled marker cell should keep its glyph and be recoloured, got %q", r)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// focusState is whether the terminal running memtop has the keyboard focus,
+// as far as the terminal reports it.
+type focusState int
+
+const (
+	focusUnknown focusState = iota // the terminal never reported focus
+	focusIn
+	focusOut
+)
+
+// desktopNotifier raises a desktop notification for every alert logged
+// since the previous pass, so an alert still gets noticed while memtop runs
+// in a background terminal. Terminals that report focus changes only get
+// notifications while they are in the background; others always do.
+// Cleared alerts are not announced.
+type desktopNotifier struct {
+	command func(title, body string) []string
+	run     func(args []string) error
+	seq     int
+}
+
+// newDesktopNotifier returns a notifier using the platform's notification
+// command, or an error if there is none or it isn't installed.
+func newDesktopNotifier() (*desktopNotifier, error) {
+	command := func(title, body string) []string { return notifyCommand(runtime.GOOS, title, body) }
+	args := command("", "")
+	if args == nil {
+		return nil, fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("desktop notifications need %s: %w", args[0], err)
+	}
+	return &desktopNotifier{command: command, run: runNotifyCommand}, nil
+}
+
+// notifyCommand returns the command line showing a notification on goos:
+// notify-send on Linux and the BSDs, osascript on macOS, and a PowerShell
+// toast on Windows. It returns nil for other platforms.
+func notifyCommand(goos, title, body string) []string {
+	switch goos {
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		return []string{"notify-send", "--app-name=memtop", title, body}
+	case "darwin":
+		return []string{"osascript", "-e", fmt.Sprintf("display notification %s with title %s", appleScriptString(body), appleScriptString(title))}
+	case "windows":
+		script := `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null;` +
+			`$x = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02);` +
+			`$t = $x.GetElementsByTagName('text');` +
+			`$t.Item(0).AppendChild($x.CreateTextNode(` + powerShellString(title) + `)) > $null;` +
+			`$t.Item(1).AppendChild($x.CreateTextNode(` + powerShellString(body) + `)) > $null;` +
+			`[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('memtop').Show([Windows.UI.Notifications.ToastNotification]::new($x))`
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", script}
+	}
+	return nil
+}
+
+// appleScriptString quotes s as an AppleScript string literal.
+func appleScriptString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// powerShellString quotes s as a single-quoted PowerShell string literal.
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// runNotifyCommand runs a notification command, giving up after
+// defaultTimeout so a hung notification daemon can't stall sampling.
+func runNotifyCommand(args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			err = fmt.Errorf("%v: %s", err, firstLine(msg))
+		}
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	return nil
+}
+
+func (n *desktopNotifier) write(u *ui) error {
+	events, seq := u.events.since(n.seq)
+	n.seq = seq
+	if u.termFocus == focusIn {
+		return nil
+	}
+	for _, e := range events {
+		if e.Kind != eventAlert || strings.HasPrefix(e.Message, "cleared: ") {
+			continue
+		}
+		title := "memtop alert"
+		if e.Server != "" {
+			title += ": " + e.Server
+		}
+		if err := n.run(n.command(title, e.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
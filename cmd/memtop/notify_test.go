@@ -0,0 +1,58 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDesktopNotifierAnnouncesNewAlertsInTheBackground(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	var sent [][]string
+	n := &desktopNotifier{
+		command: func(title, body string) []string { return []string{title, body} },
+		run:     func(args []string) error { sent = append(sent, args); return nil },
+	}
+
+	sess.logEvent(time.Now(), eventFlush, "not an alert")
+	sess.logEvent(time.Now(), eventAlert, "evictions spiking")
+	if err := n.write(u); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if len(sent) != 1 || sent[0][0] != "memtop alert: 127.0.0.1:11211" || sent[0][1] != "evictions spiking" {
+		t.Fatalf("sent %v, want one alert", sent)
+	}
+
+	sess.logEvent(time.Now(), eventAlert, "cleared: evictions spiking")
+	n.write(u)
+	if len(sent) != 1 {
+		t.Fatalf("cleared alerts should not notify, sent %v", sent)
+	}
+
+	u.termFocus = focusIn
+	sess.logEvent(time.Now(), eventAlert, "hit ratio low")
+	n.write(u)
+	u.termFocus = focusOut
+	n.write(u)
+	if len(sent) != 1 {
+		t.Fatalf("alerts while focused should not notify later either, sent %v", sent)
+	}
+}
+
+func TestNotifyCommandQuotesPerPlatform(t *testing.T) {
+	mac := notifyCommand("darwin", "memtop", `say "hi" \ bye`)
+	if want := `display notification "say \"hi\" \\ bye" with title "memtop"`; mac[0] != "osascript" || mac[2] != want {
+		t.Fatalf("darwin command = %q, want script %q", mac, want)
+	}
+	win := notifyCommand("windows", "memtop", "it's full")
+	if win[0] != "powershell" || !strings.Contains(win[len(win)-1], "'it''s full'") {
+		t.Fatalf("windows command = %q", win)
+	}
+	if linux := notifyCommand("linux", "memtop", "full"); linux[0] != "notify-send" || linux[len(linux)-1] != "full" {
+		t.Fatalf("linux command = %q", linux)
+	}
+	if notifyCommand("plan9", "memtop", "full") != nil {
+		t.Fatalf("unsupported platforms should have no command")
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// openMetricsContentType is the media type scrapers negotiate for.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// counterStats lists the monotonic counters among memcached's numeric stats,
+// by the last segment of their name so slab and item counters such as
+// `items:1:evicted` match too. Everything else is exposed as a gauge, which
+// stays valid OpenMetrics even for a counter missing from this list.
+var counterStats = map[string]bool{
+	"total_connections": true, "rejected_connections": true,
+	"cmd_get": true, "cmd_set": true, "cmd_flush": true, "cmd_touch": true, "cmd_meta": true,
+	"get_hits": true, "get_misses": true, "get_expired": true, "get_flushed": true,
+	"delete_hits": true, "delete_misses": true, "incr_hits": true, "incr_misses": true,
+	"decr_hits": true, "decr_misses": true, "cas_hits": true, "cas_misses": true, "cas_badval": true,
+	"touch_hits": true, "touch_misses": true, "store_too_large": true, "store_no_memory": true,
+	"auth_cmds": true, "auth_errors": true, "idle_kicks": true, "evictions": true, "reclaimed": true,
+	"bytes_read": true, "bytes_written": true, "listen_disabled_num": true, "conn_yields": true,
+	"total_items": true, "expired_unfetched": true, "evicted_unfetched": true, "evicted_active": true,
+	"slab_reassign_rescues": true, "slab_reassign_chunk_rescues": true, "slab_reassign_evictions_nomem": true,
+	"slab_reassign_inline_reclaim": true, "slab_reassign_busy_items": true, "slab_reassign_busy_deletes": true,
+	"slabs_moved": true, "lru_crawler_starts": true, "lru_maintainer_juggles": true,
+	"crawler_reclaimed": true, "crawler_items_checked": true, "lrutail_reflocked": true,
+	"moves_to_cold": true, "moves_to_warm": true, "moves_within_lru": true, "direct_reclaims": true,
+	"lru_bumps_dropped": true, "malloc_fails": true, "log_worker_dropped": true, "log_worker_written": true,
+	"log_watcher_skipped": true, "log_watcher_sent": true, "rusage_user": true, "rusage_system": true,
+	"time_in_listen_disabled_us": true, "response_obj_oom": true, "read_buf_oom": true,
+	"round_robin_fallback": true, "evicted": true, "evicted_nonzero": true, "outofmemory": true,
+	"tailrepairs": true, "hits_to_hot": true, "hits_to_warm": true, "hits_to_cold": true, "hits_to_temp": true,
+}
+
+// metricFamily maps a stat name to its OpenMetrics family and the slab class
+// it belongs to, if any: `items:3:evicted` becomes memcached_items_evicted
+// with slab 3.
+func metricFamily(key string) (name, slab, help string) {
+	segments := strings.Split(key, ":")
+	var words, helpParts []string
+	for _, seg := range segments {
+		if _, err := strconv.Atoi(seg); err == nil {
+			if slab == "" {
+				slab = seg
+			}
+			helpParts = append(helpParts, "N")
+			continue
+		}
+		words = append(words, seg)
+		helpParts = append(helpParts, seg)
+	}
+	return "memcached_" + sanitizeMetricName(strings.Join(words, "_")), slab, strings.Join(helpParts, ":")
+}
+
+// sanitizeMetricName replaces characters OpenMetrics does not allow in names.
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// escapeLabelValue escapes a label value for the text format.
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// recordLabels renders the labels shared by every sample of a record.
+func recordLabels(rec sampleRecord) string {
+	parts := []string{`server="` + escapeLabelValue(rec.Server) + `"`}
+	keys := make([]string, 0, len(rec.Tags))
+	for k := range rec.Tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		name := sanitizeMetricName(k)
+		if name == "" || name == "server" || name == "slab" || name[0] >= '0' && name[0] <= '9' {
+			name = "tag_" + name
+		}
+		parts = append(parts, name+`="`+escapeLabelValue(rec.Tags[k])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+// openMetricsFamily collects the samples of one metric family across servers,
+// since the format requires each family to appear exactly once.
+type openMetricsFamily struct {
+	counter bool
+	help    string
+	samples []string
+}
+
+// writeOpenMetrics writes the records' numeric stats in the OpenMetrics text
+// format: counters get the _total suffix, every family carries # TYPE and
+// # HELP, no exemplars or timestamps are emitted, and the output ends with
+// # EOF. memcached_up reports which servers answered.
+func writeOpenMetrics(w io.Writer, records []sampleRecord) error {
+	families := map[string]*openMetricsFamily{
+		"memcached_up": {help: "Whether the last stats poll of the server succeeded."},
+	}
+	for _, rec := range records {
+		labels := recordLabels(rec)
+		up := 0
+		if rec.Up {
+			up = 1
+		}
+		families["memcached_up"].samples = append(families["memcached_up"].samples, fmt.Sprintf("memcached_up{%s} %d", labels, up))
+		if !rec.Up {
+			continue
+		}
+		keys := make([]string, 0, len(rec.Values))
+		for key := range rec.Values {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return statsKeyLess(keys[i], keys[j]) })
+		for _, key := range keys {
+			name, slab, help := metricFamily(key)
+			segments := strings.Split(key, ":")
+			fam := families[name]
+			if fam == nil {
+				fam = &openMetricsFamily{counter: counterStats[segments[len(segments)-1]], help: "memcached stat " + help}
+				families[name] = fam
+			}
+			sampleName, sampleLabels := name, labels
+			if fam.counter {
+				sampleName += "_total"
+			}
+			if slab != "" {
+				sampleLabels += `,slab="` + slab + `"`
+			}
+			fam.samples = append(fam.samples, fmt.Sprintf("%s{%s} %s", sampleName, sampleLabels, rec.formatValue(key)))
+		}
+	}
+
+	return writeFamilies(w, families)
+}
+
+// writeFamilies writes metric families in name order, each with its # TYPE
+// and # HELP, and ends the exposition with # EOF.
+func writeFamilies(w io.Writer, families map[string]*openMetricsFamily) error {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fam := families[name]
+		typ := "gauge"
+		if fam.counter {
+			typ = "counter"
+		}
+		fmt.Fprintf(bw, "# TYPE %s %s\n# HELP %s %s\n", name, typ, name, fam.help)
+		for _, sample := range fam.samples {
+			fmt.Fprintln(bw, sample)
+		}
+	}
+	fmt.Fprintln(bw, "# EOF")
+	return bw.Flush()
+}
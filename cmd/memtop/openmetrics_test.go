@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	records := []sampleRecord{
+		{
+			Server: "cache-1:11211",
+			Tags:   map[string]string{"dc": "eu1", "server": "x"},
+			Up:     true,
+			Values: map[string]float64{"cmd_get": 10, "curr_items": 3, "items:2:evicted": 4, "items:10:evicted": 1},
+		},
+		{Server: "cache-2:11211"},
+	}
+	var buf bytes.Buffer
+	if err := writeOpenMetrics(&buf, records); err != nil {
+		t.Fatalf("writeOpenMetrics: %v", err)
+	}
+	want := `# TYPE memcached_cmd_get counter
+# HELP memcached_cmd_get memcached stat cmd_get
+memcached_cmd_get_total{server="cache-1:11211",dc="eu1",tag_server="x"} 10
+# TYPE memcached_curr_items gauge
+# HELP memcached_curr_items memcached stat curr_items
+memcached_curr_items{server="cache-1:11211",dc="eu1",tag_server="x"} 3
+# TYPE memcached_items_evicted counter
+# HELP memcached_items_evicted memcached stat items:N:evicted
+memcached_items_evicted_total{server="cache-1:11211",dc="eu1",tag_server="x",slab="2"} 4
+memcached_items_evicted_total{server="cache-1:11211",dc="eu1",tag_server="x",slab="10"} 1
+# TYPE memcached_up gauge
+# HELP memcached_up Whether the last stats poll of the server succeeded.
+memcached_up{server="cache-1:11211",dc="eu1",tag_server="x"} 1
+memcached_up{server="cache-2:11211"} 0
+# EOF
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected exposition:\n%s", got)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if got := escapeLabelValue("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Fatalf("escapeLabelValue = %q", got)
+	}
+}
+
+func TestMetricsEndpointServesPublishedSamples(t *testing.T) {
+	sess := newSession("cache-1:11211", time.Second)
+	sess.current = &statsSnapshot{Timestamp: time.Now(), Values: map[string]float64{"curr_items": 7}}
+	published.publish(newUI(time.Second, nil, sess))
+	defer published.publish(&ui{})
+
+	ln, err := startHTTPServer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatalf("startHTTPServer: %v", err)
+	}
+	defer ln.Close()
+	resp, err := http.Get("http://" + ln.Addr().String() + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(string(body), `memcached_curr_items{server="cache-1:11211"} 7`) || !strings.HasSuffix(string(body), "# EOF\n") {
+		t.Fatalf("unexpected /metrics body:\n%s", body)
+	}
+}
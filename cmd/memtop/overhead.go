@@ -0,0 +1,53 @@
+package main
+
+import "fmt"
+
+// memoryOverhead compares what the items need with what memcached allocated
+// for them. `bytes` only counts item sizes, so it understates real usage by
+// the slack inside each chunk and by chunks allocated but not in use.
+type memoryOverhead struct {
+	Data       float64 // the server's `bytes`: items including their headers
+	InChunks   float64 // used chunks times their chunk size
+	FreeChunks float64 // allocated chunks holding no item
+	Malloced   float64 // total_malloced from stats slabs
+}
+
+// chunkWaste is the slack between item sizes and the chunks holding them.
+func (m memoryOverhead) chunkWaste() float64 {
+	return max(m.InChunks-m.Data, 0)
+}
+
+// overheadPercent is how much of the allocated memory holds no item data.
+func (m memoryOverhead) overheadPercent() float64 {
+	if m.Malloced <= 0 {
+		return 0
+	}
+	return max(m.Malloced-m.Data, 0) / m.Malloced * 100
+}
+
+// estimateOverhead combines the general stats with the slab classes. It
+// reports false until both are available.
+func estimateOverhead(stats *statsSnapshot, sample *slabSample, classes []slabClass) (memoryOverhead, bool) {
+	if stats == nil || sample == nil || sample.Slabs == nil {
+		return memoryOverhead{}, false
+	}
+	m := memoryOverhead{Data: stats.Values["bytes"], Malloced: sample.Slabs.Values["total_malloced"]}
+	if m.Malloced <= 0 {
+		return memoryOverhead{}, false
+	}
+	for _, c := range classes {
+		m.InChunks += c.UsedChunks * c.ChunkSize
+		m.FreeChunks += max(c.TotalChunks-c.UsedChunks, 0) * c.ChunkSize
+	}
+	return m, true
+}
+
+// describeOverhead summarises the estimate on one line.
+func describeOverhead(m memoryOverhead) string {
+	waste := 0.0
+	if m.InChunks > 0 {
+		waste = m.chunkWaste() / m.InChunks * 100
+	}
+	return fmt.Sprintf("Memory: %s of data in %s of chunks (%.1f%% slack), %s in free chunks, %s malloced: %.1f%% overhead",
+		formatBytes(m.Data), formatBytes(m.InChunks), waste, formatBytes(m.FreeChunks), formatBytes(m.Malloced), m.overheadPercent())
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestEstimateOverhead(t *testing.T) {
+	sample := testSlabSample()
+	classes := parseSlabClasses(sample, nil)
+	if _, ok := estimateOverhead(nil, sample, classes); ok {
+		t.Fatalf("no estimate without general stats")
+	}
+
+	stats := &statsSnapshot{Values: map[string]float64{"bytes": 40320}}
+	m, ok := estimateOverhead(stats, sample, classes)
+	if !ok {
+		t.Fatalf("expected an estimate")
+	}
+	if m.InChunks != 50400 || m.FreeChunks != 7200 || m.chunkWaste() != 10080 {
+		t.Fatalf("estimate = %+v (waste %.0f)", m, m.chunkWaste())
+	}
+	if got := m.overheadPercent(); math.Abs(got-98.72) > 0.01 {
+		t.Fatalf("overhead = %.2f%%, want 98.72%%", got)
+	}
+	if text := describeOverhead(m); !strings.Contains(text, "(20.0% slack)") || !strings.HasSuffix(text, "98.7% overhead") {
+		t.Fatalf("description = %q", text)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// palette maps the meanings memtop highlights onto terminal styles, so the
+// colours can be swapped for users who can't tell them apart or terminals
+// that have none.
+type palette struct {
+	name   string
+	bad    tcell.Style // errors, outages, and critical thresholds
+	warn   tcell.Style // flushes, alerts, and thresholds worth a look
+	good   tcell.Style // recoveries and healthy gauges
+	accent tcell.Style // chart plots
+	banner tcell.Style // the SUSPENDED banner
+	heat   []heatCell  // heatmap steps, cold to hot
+}
+
+// heatCell is how one heatmap step is drawn: a fill rune in a style.
+type heatCell struct {
+	Fill  rune
+	Style tcell.Style
+}
+
+// colorHeat builds heatmap steps that fill with a background colour.
+func colorHeat(colors ...tcell.Color) []heatCell {
+	cells := make([]heatCell, len(colors))
+	for i, c := range colors {
+		cells[i] = heatCell{Fill: ' ', Style: tcell.StyleDefault.Background(c)}
+	}
+	return cells
+}
+
+// palettes lists the palettes -palette accepts; the first is the default.
+var palettes = []palette{
+	{
+		name:   "default",
+		bad:    tcell.StyleDefault.Foreground(tcell.ColorRed),
+		warn:   tcell.StyleDefault.Foreground(tcell.ColorYellow),
+		good:   tcell.StyleDefault.Foreground(tcell.ColorGreen),
+		accent: tcell.StyleDefault.Foreground(tcell.ColorTeal),
+		banner: tcell.StyleDefault.Background(tcell.ColorYellow).Foreground(tcell.ColorBlack).Bold(true),
+		heat: colorHeat(tcell.ColorNavy, tcell.ColorBlue, tcell.ColorTeal, tcell.ColorGreen,
+			tcell.ColorYellow, tcell.ColorOrange, tcell.ColorRed),
+	},
+	{
+		// Okabe-Ito colours, which stay distinct with red-green colour
+		// blindness, and the viridis ramp for the heatmap.
+		name:   "colorblind",
+		bad:    tcell.StyleDefault.Foreground(tcell.NewHexColor(0xd55e00)),
+		warn:   tcell.StyleDefault.Foreground(tcell.NewHexColor(0xf0e442)),
+		good:   tcell.StyleDefault.Foreground(tcell.NewHexColor(0x56b4e9)),
+		accent: tcell.StyleDefault.Foreground(tcell.NewHexColor(0x56b4e9)),
+		banner: tcell.StyleDefault.Background(tcell.NewHexColor(0xf0e442)).Foreground(tcell.ColorBlack).Bold(true),
+		heat: colorHeat(tcell.NewHexColor(0x440154), tcell.NewHexColor(0x443983), tcell.NewHexColor(0x31688e),
+			tcell.NewHexColor(0x21918c), tcell.NewHexColor(0x35b779), tcell.NewHexColor(0x90d743), tcell.NewHexColor(0xfde725)),
+	},
+	{
+		// No colour at all: severity is carried by bold and underline,
+		// leaving reverse video to the selection, the stale flash, and the
+		// banner, and the heatmap by shading characters.
+		name:   "mono",
+		bad:    tcell.StyleDefault.Bold(true).Underline(true),
+		warn:   tcell.StyleDefault.Underline(true),
+		good:   tcell.StyleDefault,
+		accent: tcell.StyleDefault,
+		banner: tcell.StyleDefault.Reverse(true).Bold(true),
+		heat: []heatCell{
+			{' ', tcell.StyleDefault}, {'░', tcell.StyleDefault}, {'▒', tcell.StyleDefault},
+			{'▓', tcell.StyleDefault}, {'█', tcell.StyleDefault},
+		},
+	},
+}
+
+// theme is the palette in use.
+var theme = palettes[0]
+
+// setPalette selects a palette by name. An empty name picks mono when the
+// NO_COLOR convention asks for it and the default palette otherwise.
+func setPalette(name string, noColor bool) error {
+	if name == "" {
+		name = "default"
+		if noColor {
+			name = "mono"
+		}
+	}
+	names := make([]string, 0, len(palettes))
+	for _, p := range palettes {
+		if p.name == name {
+			theme = p
+			anomalyStyle = p.bad.Bold(true)
+			noteMarkerStyle = p.warn.Bold(true)
+			suspendedStyle = p.banner
+			return nil
+		}
+		names = append(names, p.name)
+	}
+	return fmt.Errorf("unknown palette %q (want %s)", name, strings.Join(names, ", "))
+}
+
+// heatStep maps a 0-1 intensity onto the palette's heatmap steps.
+func heatStep(intensity float64) heatCell {
+	steps := theme.heat
+	if intensity <= 0 {
+		return steps[0]
+	}
+	if intensity >= 1 {
+		return steps[len(steps)-1]
+	}
+	return steps[int(intensity*float64(len(steps)-1)+0.5)]
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSetPalette(t *testing.T) {
+	defer setPalette("default", false)
+
+	if err := setPalette("", true); err != nil || theme.name != "mono" {
+		t.Fatalf("NO_COLOR should select mono, got %q (%v)", theme.name, err)
+	}
+	for _, style := range []tcell.Style{gaugeStyle(95, false), gaugeStyle(75, false), eventStyle(eventConnLost), anomalyStyle, suspendedStyle} {
+		if fg, bg, _ := style.Decompose(); fg != tcell.ColorDefault || bg != tcell.ColorDefault {
+			t.Fatalf("mono styles must not use colour, got fg %v bg %v", fg, bg)
+		}
+	}
+	if _, _, attrs := gaugeStyle(95, false).Decompose(); attrs&tcell.AttrBold == 0 || attrs&tcell.AttrUnderline == 0 {
+		t.Fatalf("a critical gauge should stand out in bold underline")
+	}
+	if theme.bad == gaugeStyle(75, false) {
+		t.Fatalf("mono bad and warn styles should differ")
+	}
+	if freshnessStyle(true, time.Unix(0, 0)) == freshnessStyle(true, time.Unix(1, 0)) {
+		t.Fatalf("the stale flash should toggle in mono")
+	}
+	if heatStep(0).Fill == heatStep(1).Fill {
+		t.Fatalf("mono heatmap steps should differ by fill character")
+	}
+
+	if err := setPalette("colorblind", false); err != nil || gaugeStyle(95, false) == gaugeStyle(10, false) {
+		t.Fatalf("colorblind palette should still separate critical from healthy (%v)", err)
+	}
+	if err := setPalette("", false); err != nil || theme.name != "default" {
+		t.Fatalf("no palette and no NO_COLOR should keep the default, got %q", theme.name)
+	}
+	if err := setPalette("neon", false); err == nil {
+		t.Fatalf("an unknown palette should be rejected")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// panelColumnWidth is the preferred width of one column in the panels view.
+const panelColumnWidth = 40
+
+// panelLine is a single row of panel output with its own style, so panels can
+// highlight individual entries.
+type panelLine struct {
+	Text  string
+	Style tcell.Style
+}
+
+// panelSpec registers a titled block for the panels view. Render returns nil
+// when the panel has nothing to show yet, in which case it is skipped.
+// Panels whose Requires feature the server lacks show a note instead.
+type panelSpec struct {
+	Title    string
+	Requires feature
+	Render   func(s *session) []panelLine
+}
+
+// panels lists the panels shown in the panels view, in display order.
+var panels = []panelSpec{
+	{Title: "Events", Render: renderEventsPanel},
+	{Title: "Top movers", Render: renderMoversPanel},
+	{Title: "Errors", Render: renderErrorsPanel},
+	{Title: "Anomalies", Render: renderAnomaliesPanel},
+	{Title: "Item removals", Render: renderRemovalsPanel},
+	{Title: "Hit ratio by command", Render: renderCommandRatiosPanel},
+	{Title: "Connections", Render: renderConnectionsPanel},
+	{Title: "Idle kicks and accept pauses", Render: renderIdleKicksPanel},
+	{Title: "UDP", Render: renderUDPPanel},
+	{Title: "CPU", Render: renderCPUPanel},
+	{Title: "Hash table", Render: renderHashTablePanel},
+	{Title: "LRU maintenance", Render: renderMaintenancePanel},
+	{Title: "Watched keys", Render: renderWatchedKeysPanel},
+	{Title: "Consistency probe (CAS)", Render: renderProbePanel},
+	{Title: "Re-warm after restart", Render: renderRewarmPanel},
+	{Title: "TTL distribution (metadump sample)", Requires: featureLRUCrawler, Render: renderTTLPanel},
+	{Title: "TLS", Render: renderTLSPanel},
+	{Title: "Server capabilities", Render: renderCapabilitiesPanel},
+	{Title: "memtop (self)", Render: renderSelfPanel},
+}
+
+// notSupported replaces panels and views the server can't provide.
+const notSupported = "not supported by this server"
+
+// plainLine wraps text in the default style.
+func plainLine(text string) panelLine {
+	return panelLine{Text: text, Style: tcell.StyleDefault}
+}
+
+// drawPanelsView flows every panel with content into as many columns as the
+// terminal width allows, always placing the next panel in the shortest column.
+func drawPanelsView(screen tcell.Screen, line int, s *session) {
+	width, height := screen.Size()
+	columns := width / panelColumnWidth
+	if columns < 1 {
+		columns = 1
+	}
+	colWidth := width / columns
+	bottom := height - 1
+	next := make([]int, columns)
+	for i := range next {
+		next[i] = line
+	}
+
+	for _, p := range panels {
+		var lines []panelLine
+		if s.supports(p.Requires) {
+			lines = p.Render(s)
+		} else {
+			lines = []panelLine{plainLine(notSupported)}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		col := 0
+		for i := range next {
+			if next[i] < next[col] {
+				col = i
+			}
+		}
+		x, y := col*colWidth, next[col]
+		if y >= bottom {
+			continue
+		}
+		drawText(screen, x, y, tcell.StyleDefault.Bold(true).Underline(true), clipText(p.Title, colWidth-1))
+		y++
+		for _, l := range lines {
+			if y >= bottom {
+				break
+			}
+			drawText(screen, x, y, l.Style, clipText(l.Text, colWidth-1))
+			y++
+		}
+		next[col] = y + 1
+	}
+}
+
+// clipText truncates text to at most width runes so neighbouring panel
+// columns don't overwrite each other.
+func clipText(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
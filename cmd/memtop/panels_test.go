@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestClipText(t *testing.T) {
+	if got := clipText("hello", 10); got != "hello" {
+		t.Fatalf("short text should be unchanged, got %q", got)
+	}
+	if got := clipText("hello world", 5); got != "hello" {
+		t.Fatalf("clipText = %q, want %q", got, "hello")
+	}
+	if got := clipText("hello", 0); got != "" {
+		t.Fatalf("zero width should clip everything, got %q", got)
+	}
+}
+
+func TestDrawPanelsViewShowsPanelTitles(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 20)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewPanels
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	line := lineFromCells(cells, width, 2)
+	if !strings.HasPrefix(line, panels[0].Title) {
+		t.Fatalf("first panel title missing, got %q", line)
+	}
+	if !strings.Contains(line, panels[1].Title) {
+		t.Fatalf("second panel should start the next column, got %q", line)
+	}
+}
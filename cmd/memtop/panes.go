@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// splitDir says how a pane is divided.
+type splitDir int
+
+const (
+	splitNone       splitDir = iota // a leaf showing one view
+	splitVertical                   // side by side
+	splitHorizontal                 // stacked
+)
+
+// paneNode is a node of the pane layout tree. Leaves remember the view and
+// server they show; the focused leaf's live state is u.view and u.selected,
+// so every key that changes the view or server acts on the focused pane.
+type paneNode struct {
+	split         splitDir
+	first, second *paneNode
+	parent        *paneNode
+
+	view   view
+	server int
+}
+
+// leaves lists the panes in display order.
+func (n *paneNode) leaves() []*paneNode {
+	if n.split == splitNone {
+		return []*paneNode{n}
+	}
+	return append(n.first.leaves(), n.second.leaves()...)
+}
+
+// saveFocus stores the live view and server in the focused pane.
+func (u *ui) saveFocus() {
+	if u.focus != nil {
+		u.focus.view, u.focus.server = u.view, u.selected
+	}
+}
+
+// setFocus makes leaf the focused pane and loads its state.
+func (u *ui) setFocus(leaf *paneNode) {
+	u.saveFocus()
+	u.focus = leaf
+	u.view, u.selected = leaf.view, leaf.server
+}
+
+// splitPane divides the focused pane in two, both showing its view, and
+// focuses the new half.
+func (u *ui) splitPane(dir splitDir) {
+	if u.panes == nil {
+		u.panes = &paneNode{view: u.view, server: u.selected}
+		u.focus = u.panes
+	}
+	u.saveFocus()
+	n := u.focus
+	n.first = &paneNode{parent: n, view: n.view, server: n.server}
+	n.second = &paneNode{parent: n, view: n.view, server: n.server}
+	n.split = dir
+	u.focus = nil
+	u.setFocus(n.second)
+}
+
+// closePane removes the focused pane, giving its space to its sibling. When
+// one pane is left memtop returns to the ordinary full-screen layout.
+func (u *ui) closePane() {
+	if u.panes == nil || u.focus.parent == nil {
+		return
+	}
+	u.saveFocus()
+	parent := u.focus.parent
+	sibling := parent.first
+	if sibling == u.focus {
+		sibling = parent.second
+	}
+	*parent = paneNode{split: sibling.split, first: sibling.first, second: sibling.second,
+		parent: parent.parent, view: sibling.view, server: sibling.server}
+	if parent.split != splitNone {
+		parent.first.parent, parent.second.parent = parent, parent
+	}
+	u.focus = nil
+	u.setFocus(parent.leaves()[0])
+	if u.panes.split == splitNone {
+		u.panes, u.focus = nil, nil
+	}
+}
+
+// cycleFocus moves the focus to the next or previous pane.
+func (u *ui) cycleFocus(delta int) {
+	if u.panes == nil {
+		return
+	}
+	leaves := u.panes.leaves()
+	for i, leaf := range leaves {
+		if leaf == u.focus {
+			u.setFocus(leaves[((i+delta)%len(leaves)+len(leaves))%len(leaves)])
+			return
+		}
+	}
+}
+
+// withPane runs fn with the pane's view and server as the live state.
+func (u *ui) withPane(leaf *paneNode, fn func()) {
+	if leaf == u.focus {
+		fn()
+		return
+	}
+	view, selected := u.view, u.selected
+	u.view, u.selected = leaf.view, leaf.server
+	defer func() { u.view, u.selected = view, selected }()
+	fn()
+}
+
+// sampleViews runs the extra queries of every visible pane.
+func sampleViews(ctx context.Context, u *ui) {
+	if u.panes == nil {
+		sampleView(ctx, u)
+		return
+	}
+	for _, leaf := range u.panes.leaves() {
+		u.withPane(leaf, func() { sampleView(ctx, u) })
+	}
+}
+
+// drawPaneTree lays the panes out in the given rectangle.
+func drawPaneTree(screen tcell.Screen, u *ui, n *paneNode, x, y, w, h int) {
+	switch n.split {
+	case splitVertical:
+		left := (w - 1) / 2
+		drawPaneTree(screen, u, n.first, x, y, left, h)
+		for row := y; row < y+h; row++ {
+			screen.SetContent(x+left, row, '│', nil, tcell.StyleDefault)
+		}
+		drawPaneTree(screen, u, n.second, x+left+1, y, w-left-1, h)
+	case splitHorizontal:
+		top := h / 2
+		drawPaneTree(screen, u, n.first, x, y, w, top)
+		drawPaneTree(screen, u, n.second, x, y+top, w, h-top)
+	default:
+		drawPane(screen, u, n, x, y, w, h)
+	}
+}
+
+// drawPane draws one pane: a title naming its view and server, highlighted
+// when focused, above the view itself.
+func drawPane(screen tcell.Screen, u *ui, leaf *paneNode, x, y, w, h int) {
+	if w <= 0 || h <= 0 {
+		return
+	}
+	u.withPane(leaf, func() {
+		style := tcell.StyleDefault.Bold(true)
+		if leaf == u.focus {
+			style = style.Reverse(true)
+		}
+		title := fmt.Sprintf(" %s  %s ", viewName(u.view), u.current().addr)
+		for col := 0; col < w; col++ {
+			screen.SetContent(x+col, y, ' ', nil, style)
+		}
+		drawText(&paneScreen{Screen: screen, x: x, y: y, w: w, h: 1}, 0, 0, style, title)
+		drawBody(&paneScreen{Screen: screen, x: x, y: y + 1, w: w, h: h - 1}, 0, u)
+	})
+}
+
+// paneScreen confines drawing to a rectangle of the real screen so views can
+// render into a pane unchanged.
+type paneScreen struct {
+	tcell.Screen
+	x, y, w, h int
+}
+
+// Size reports one row more than the pane has: views leave the terminal's
+// last row free for the footer, and that row is clipped here.
+func (p *paneScreen) Size() (int, int) {
+	return p.w, p.h + 1
+}
+
+func (p *paneScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if x < 0 || y < 0 || x >= p.w || y >= p.h {
+		return
+	}
+	p.Screen.SetContent(p.x+x, p.y+y, primary, combining, style)
+}
+
+func (p *paneScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	if x < 0 || y < 0 || x >= p.w || y >= p.h {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	return p.Screen.GetContent(p.x+x, p.y+y)
+}
+
+// Clear blanks the pane only.
+func (p *paneScreen) Clear() {
+	for row := 0; row < p.h; row++ {
+		for col := 0; col < p.w; col++ {
+			p.SetContent(col, row, ' ', nil, tcell.StyleDefault)
+		}
+	}
+}
+
+// Show and Sync are left to drawScreen, which owns the real screen.
+func (p *paneScreen) Show() {}
+func (p *paneScreen) Sync() {}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSplitAndClosePanes(t *testing.T) {
+	u := newUI(time.Second, nil,
+		newSession("10.0.0.1:11211", time.Second), newSession("10.0.0.2:11211", time.Second))
+
+	u.splitPane(splitVertical)
+	u.view, u.selected = viewStats, 1
+	u.splitPane(splitHorizontal)
+	if n := len(u.panes.leaves()); n != 3 {
+		t.Fatalf("two splits should give three panes, got %d", n)
+	}
+
+	u.cycleFocus(1)
+	if u.focus != u.panes.leaves()[0] || u.view != viewSummary || u.selected != 0 {
+		t.Fatalf("focus should wrap to the first pane and load its view and server")
+	}
+	u.cycleFocus(-1)
+	if u.view != viewStats || u.selected != 1 {
+		t.Fatalf("moving back should restore the last pane's view %v and server %d", u.view, u.selected)
+	}
+
+	u.closePane()
+	if n := len(u.panes.leaves()); n != 2 {
+		t.Fatalf("closing a pane should leave two, got %d", n)
+	}
+	u.closePane()
+	if u.panes != nil || u.focus != nil {
+		t.Fatalf("closing down to one pane should return to the single layout")
+	}
+	u.closePane()
+}
+
+func TestPanesDrawEachViewInItsRectangle(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(120, 30)
+
+	u := newUI(time.Second, nil,
+		newSession("10.0.0.1:11211", time.Second), newSession("10.0.0.2:11211", time.Second))
+	u.splitPane(splitVertical)
+	u.view, u.selected = viewStats, 1
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	title := lineFromCells(cells, width, 2)
+	left, right := title[:59], title[60:]
+	if !strings.Contains(left, "summary  10.0.0.1:11211") || !strings.Contains(right, "stats  10.0.0.2:11211") {
+		t.Fatalf("pane titles = %q", title)
+	}
+	if r, _, _, _ := screen.GetContent(59, 10); r != '│' {
+		t.Fatalf("side-by-side panes should be separated, got %q", r)
+	}
+	_, _, style, _ := screen.GetContent(60, 2)
+	if _, _, attrs := style.Decompose(); attrs&tcell.AttrReverse == 0 {
+		t.Fatalf("the focused pane's title should be highlighted")
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// plainItem is one labeled value in the plain output.
+type plainItem struct {
+	Label string
+	Value string
+}
+
+// plainItems lists a server's essentials as spelled-out label and value
+// pairs, avoiding symbols and abbreviations that screen readers mangle.
+// Rates appear from the second sample on.
+func plainItems(s *session) []plainItem {
+	switch {
+	case s.lastErr != nil:
+		return []plainItem{{"Status", "down, " + s.lastErr.Error()}}
+	case s.current == nil:
+		return []plainItem{{"Status", "waiting for stats"}}
+	}
+	v := s.current.Values
+	nf := numberFormat{"plain"}
+	items := []plainItem{
+		{"Status", "up"},
+		{"Uptime", formatUptime(v["uptime"])},
+		{"Hit ratio", nf.percent("hit_ratio", hitRatio(s.current)) + " percent"},
+		{"Memory used", fmt.Sprintf("%s percent of %s", nf.percent("memory", memoryPercent(s.current)), formatBytes(v["limit_maxbytes"]))},
+		{"Items", nf.count("curr_items", v["curr_items"])},
+		{"Connections", nf.count("curr_connections", v["curr_connections"])},
+	}
+	if s.rates != nil {
+		rate := func(key string) string { return nf.rate(key, displayRate(s.rates[key], s.interval)) }
+		items = append(items,
+			plainItem{"Gets " + rateWords(s.interval), rate("cmd_get")},
+			plainItem{"Sets " + rateWords(s.interval), rate("cmd_set")},
+			plainItem{"Evictions " + rateWords(s.interval), rate("evictions")},
+		)
+	}
+	return items
+}
+
+// plainWriter prints each refresh as a timestamp line followed by one
+// "Label: value" line per item under a heading per server, without cursor
+// movement, so the output reads well in a screen reader or on a braille
+// display. With changesOnly, values that did not change since the last
+// refresh are left out, as are servers and refreshes with no changes.
+type plainWriter struct {
+	w           io.Writer
+	changesOnly bool
+	last        map[string]map[string]string
+}
+
+func newPlainWriter(w io.Writer, changesOnly bool) *plainWriter {
+	return &plainWriter{w: w, changesOnly: changesOnly, last: make(map[string]map[string]string)}
+}
+
+// write prints the latest sample of every server.
+func (p *plainWriter) write(u *ui, now time.Time) error {
+	var out []string
+	for _, s := range u.servers {
+		prev := p.last[s.addr]
+		seen := make(map[string]string)
+		var lines []string
+		for _, item := range plainItems(s) {
+			seen[item.Label] = item.Value
+			if p.changesOnly && prev != nil && prev[item.Label] == item.Value {
+				continue
+			}
+			lines = append(lines, item.Label+": "+item.Value)
+		}
+		p.last[s.addr] = seen
+		if len(lines) > 0 {
+			heading := "Server " + s.addr
+			if len(s.tags) > 0 {
+				heading += ", tags " + formatTags(s.tags)
+			}
+			out = append(out, heading)
+			out = append(out, lines...)
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(p.w, "Refresh at %s\n", clockTime(now)); err != nil {
+		return err
+	}
+	for _, line := range out {
+		if _, err := fmt.Fprintln(p.w, line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(p.w)
+	return err
+}
+
+// streamPlain samples every interval and prints plain output without the
+// TUI, until ctx is cancelled or writing fails.
+func streamPlain(ctx context.Context, u *ui, w io.Writer, changesOnly bool) error {
+	out := newPlainWriter(w, changesOnly)
+	return runHeadless(ctx, u, func() error { return out.write(u, time.Now()) })
+}
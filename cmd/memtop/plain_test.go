@@ -0,0 +1,41 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlainWriterLabelsValuesAndSkipsUnchanged(t *testing.T) {
+	sess := newSession("10.0.0.1:11211", time.Second)
+	sess.tags = map[string]string{"dc": "eu1"}
+	sess.current = &statsSnapshot{Values: map[string]float64{
+		"get_hits": 90, "get_misses": 10, "bytes": 16 << 20, "limit_maxbytes": 64 << 20, "uptime": 3600, "curr_items": 5, "curr_connections": 3,
+	}}
+	u := newUI(time.Second, nil, sess)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.Local)
+
+	var buf bytes.Buffer
+	w := newPlainWriter(&buf, true)
+	if err := w.write(u, now); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	for _, want := range []string{"Refresh at 12:00:00\n", "Server 10.0.0.1:11211, tags dc=eu1\n", "Hit ratio: 90.0 percent\n", "Memory used: 25.0 percent of 64.0 MB\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("plain output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := w.write(u, now.Add(time.Second)); err != nil || buf.Len() != 0 {
+		t.Fatalf("an unchanged refresh should print nothing, got %q (%v)", buf.String(), err)
+	}
+
+	sess.lastErr = errors.New("connection refused")
+	w.write(u, now.Add(2*time.Second))
+	if want := "Refresh at 12:00:02\nServer 10.0.0.1:11211, tags dc=eu1\nStatus: down, connection refused\n\n"; buf.String() != want {
+		t.Fatalf("changes-only output = %q, want %q", buf.String(), want)
+	}
+}
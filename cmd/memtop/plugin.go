@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultPluginTimeout bounds a plugin run so a hung plugin can't stall
+// sampling.
+const defaultPluginTimeout = time.Second
+
+// maxPluginLines caps how much of a plugin's output is kept.
+const maxPluginLines = 50
+
+// pluginConfig describes an external panel. The command is executed directly,
+// not through a shell.
+type pluginConfig struct {
+	Title   string   `json:"title"`
+	Command []string `json:"command"`
+	// Timeout is a Go duration such as "500ms"; empty means the default.
+	Timeout string `json:"timeout"`
+}
+
+// plugin is a configured external panel ready to run.
+type plugin struct {
+	title   string
+	command []string
+	timeout time.Duration
+}
+
+// newPlugin validates a plugin config.
+func newPlugin(cfg pluginConfig) (*plugin, error) {
+	if len(cfg.Command) == 0 || cfg.Command[0] == "" {
+		return nil, fmt.Errorf("plugin %q has no command", cfg.Title)
+	}
+	p := &plugin{title: cfg.Title, command: cfg.Command, timeout: defaultPluginTimeout}
+	if p.title == "" {
+		p.title = filepath.Base(cfg.Command[0])
+	}
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("plugin %q: invalid timeout %q", p.title, cfg.Timeout)
+		}
+		p.timeout = d
+	}
+	return p, nil
+}
+
+// pluginOutput is the JSON form of a plugin's answer. Plugins may instead
+// print plain text, which is shown line by line.
+type pluginOutput struct {
+	Lines   []string           `json:"lines"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// pluginResult is the last run of one plugin against one server.
+type pluginResult struct {
+	lines []panelLine
+	err   error
+}
+
+// run executes the plugin with the server's sample as a single JSON object
+// (the same record the -jsonl output writes) on stdin.
+func (p *plugin) run(ctx context.Context, s *session) pluginResult {
+	input, err := json.Marshal(newSampleRecord(s))
+	if err != nil {
+		return pluginResult{err: err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.command[0], p.command[1:]...)
+	cmd.Stdin = bytes.NewReader(append(input, '\n'))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return pluginResult{err: fmt.Errorf("timed out after %s", p.timeout)}
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, firstLine(msg))
+		}
+		return pluginResult{err: err}
+	}
+	lines, err := parsePluginOutput(out)
+	return pluginResult{lines: lines, err: err}
+}
+
+// parsePluginOutput turns a plugin's stdout into panel lines. Output starting
+// with '{' is decoded as a pluginOutput, whose metrics follow its lines sorted
+// by name; anything else is plain text.
+func parsePluginOutput(out []byte) ([]panelLine, error) {
+	var lines []panelLine
+	trimmed := bytes.TrimSpace(out)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		var po pluginOutput
+		if err := json.Unmarshal(trimmed, &po); err != nil {
+			return nil, fmt.Errorf("invalid plugin output: %w", err)
+		}
+		for _, text := range po.Lines {
+			lines = append(lines, plainLine(text))
+		}
+		names := make([]string, 0, len(po.Metrics))
+		for name := range po.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lines = append(lines, plainLine(fmt.Sprintf("%-24s %s", name, strconv.FormatFloat(po.Metrics[name], 'f', -1, 64))))
+		}
+	} else if len(trimmed) > 0 {
+		for _, text := range strings.Split(string(trimmed), "\n") {
+			lines = append(lines, plainLine(strings.TrimRight(text, "\r")))
+		}
+	}
+	if len(lines) > maxPluginLines {
+		lines = lines[:maxPluginLines]
+	}
+	return lines, nil
+}
+
+// panel registers the plugin in the panels view. Results are kept per
+// session since the plugin sees one server at a time.
+func (p *plugin) panel() panelSpec {
+	return panelSpec{Title: p.title, Render: func(s *session) []panelLine {
+		res, ok := s.plugins[p]
+		switch {
+		case !ok:
+			return []panelLine{plainLine("Waiting for first run...")}
+		case res.err != nil:
+			return []panelLine{plainLine(fmt.Sprintf("Error: %v", res.err))}
+		case len(res.lines) == 0:
+			return []panelLine{plainLine("(no output)")}
+		}
+		return res.lines
+	}}
+}
+
+// runPlugins refreshes every plugin's output for the session. A plugin cut
+// short because ctx was cancelled keeps its previous output.
+func runPlugins(ctx context.Context, s *session, plugins []*plugin) {
+	if s.plugins == nil {
+		s.plugins = make(map[*plugin]pluginResult, len(plugins))
+	}
+	for _, p := range plugins {
+		res := p.run(ctx, s)
+		if ctx.Err() != nil {
+			return
+		}
+		s.plugins[p] = res
+	}
+}
+
+// firstLine returns text up to its first newline.
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(text, "\n")
+	return line
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePluginOutput(t *testing.T) {
+	lines, err := parsePluginOutput([]byte(`{"lines": ["orders cache"], "metrics": {"miss_cost_ms": 12.5, "app_hits": 3}}`))
+	if err != nil {
+		t.Fatalf("parsePluginOutput: %v", err)
+	}
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "orders cache|app_hits 3|miss_cost_ms 12.5"; strings.Join(got, "|") != want {
+		t.Fatalf("JSON output = %q, want %q", strings.Join(got, "|"), want)
+	}
+
+	lines, _ = parsePluginOutput([]byte("first\r\nsecond\n"))
+	if len(lines) != 2 || lines[0].Text != "first" || lines[1].Text != "second" {
+		t.Fatalf("plain output = %+v", lines)
+	}
+	if _, err := parsePluginOutput([]byte("{broken")); err == nil {
+		t.Fatalf("malformed JSON should be reported")
+	}
+}
+
+func TestPluginPanelRunsCommand(t *testing.T) {
+	p, err := newPlugin(pluginConfig{Title: "App", Command: []string{"sh", "-c", `read rec; case "$rec" in *'"server":"10.0.0.1:11211"'*) echo seen;; *) echo missing;; esac`}})
+	if err != nil {
+		t.Fatalf("newPlugin: %v", err)
+	}
+	sess := newSession("10.0.0.1:11211", time.Second)
+	panel := p.panel()
+	if lines := panel.Render(sess); len(lines) != 1 || !strings.HasPrefix(lines[0].Text, "Waiting") {
+		t.Fatalf("before the first run = %+v", lines)
+	}
+	runPlugins(context.Background(), sess, []*plugin{p})
+	if lines := panel.Render(sess); len(lines) != 1 || lines[0].Text != "seen" {
+		t.Fatalf("plugin should receive the sample on stdin, got %+v", lines)
+	}
+
+	slow, _ := newPlugin(pluginConfig{Command: []string{"sleep", "5"}, Timeout: "50ms"})
+	runPlugins(context.Background(), sess, []*plugin{slow})
+	if lines := slow.panel().Render(sess); !strings.Contains(lines[0].Text, "timed out") {
+		t.Fatalf("a hung plugin should time out, got %+v", lines)
+	}
+	if slow.title != "sleep" {
+		t.Fatalf("an untitled plugin should be named after its command, got %q", slow.title)
+	}
+
+	if _, err := newPlugin(pluginConfig{Title: "x"}); err == nil {
+		t.Fatalf("a plugin without a command should be rejected")
+	}
+	if _, err := newPlugin(pluginConfig{Command: []string{"true"}, Timeout: "soon"}); err == nil {
+		t.Fatalf("an invalid timeout should be rejected")
+	}
+}
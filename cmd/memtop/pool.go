@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// defaultPollWorkers bounds how many servers are polled at once, so a large
+// fleet doesn't open hundreds of sockets in the same instant.
+const defaultPollWorkers = 16
+
+// maxPollJitter caps the random delay before each server's poll. The delay is
+// a tenth of the interval below that, so fast refreshes stay fast.
+const maxPollJitter = 250 * time.Millisecond
+
+// pollJitter is the longest random delay a server's poll is put off by. A
+// single server has nothing to be spread out from, unless -jitter asks to
+// spread memtop instances apart; that is capped at half the interval so
+// polls still land within their tick.
+func pollJitter(u *ui) time.Duration {
+	auto := min(u.interval/10, maxPollJitter)
+	if len(u.servers) <= 1 {
+		auto = 0
+	}
+	return max(auto, min(u.jitter, u.interval/2))
+}
+
+// pollPanic carries a panic out of a poll worker to the goroutine that
+// started the pass, whose deferred restoreOnPanic hands the terminal back.
+type pollPanic struct {
+	value any
+	stack []byte
+}
+
+// String keeps the worker's stack, which the re-panic would otherwise lose,
+// in the trace and the crash report.
+func (p *pollPanic) String() string {
+	return fmt.Sprintf("%v [in poll worker]\n\n%s", p.value, p.stack)
+}
+
+// pollServers polls every server through a bounded pool of workers. Each
+// server gets its own deadline, so one slow node costs at most its timeout
+// and the others are polled in the meantime. A panic while polling is
+// re-raised here once the other workers are done, so it reaches the
+// restoreOnPanic of the goroutine that runs the pass.
+func pollServers(ctx context.Context, u *ui) {
+	jobs := make(chan *session)
+	jitter := pollJitter(u)
+	timeout := u.pollTimeout
+	if timeout == 0 {
+		timeout = pollTimeoutFor(u.interval)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var crashed *pollPanic
+	poll := func(s *session) {
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				if crashed == nil {
+					crashed = &pollPanic{value: r, stack: debug.Stack()}
+				}
+				mu.Unlock()
+			}
+		}()
+		pollServer(ctx, u, s, timeout)
+	}
+	for range min(max(u.pollWorkers, 1), len(u.servers)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				if jitter > 0 {
+					select {
+					case <-time.After(rand.N(jitter)):
+					case <-ctx.Done():
+						continue
+					}
+				}
+				poll(s)
+			}
+		}()
+	}
+feed:
+	for _, s := range u.servers {
+		select {
+		case jobs <- s:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if crashed != nil {
+		panic(crashed)
+	}
+}
+
+// pollServer fetches and records one server's stats, then refreshes what
+// depends on them: capabilities and the every-poll collectors. A fetch
+// cut short because ctx was cancelled is not recorded; one that ran out of
+// its own deadline is recorded as a failure.
+func pollServer(ctx context.Context, u *ui, s *session, timeout time.Duration) {
+	sctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stats, took, err := timedFetch(func() (*statsSnapshot, error) { return fetchStats(sctx, s.addr) })
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil && sctx.Err() != nil {
+		err = fmt.Errorf("no reply within %s", timeout)
+	}
+	recordPoll(err)
+	if err == nil {
+		s.recordLatency(took)
+		if len(stats.Raw) == 0 {
+			s.logWarning("stats", "server returned no stats")
+		}
+	}
+	s.record(stats, err)
+	refreshCapabilities(sctx, s)
+	if s.lastErr == nil {
+		runCollectors(sctx, u, s, collectEveryPoll)
+	}
+}
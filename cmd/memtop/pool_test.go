@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// startSlowStatsServer answers `stats` after delay, tracking how many
+// connections it is serving at once.
+func startSlowStatsServer(t *testing.T, delay time.Duration, active, peak *atomic.Int32) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				n := active.Add(1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				bufio.NewReader(conn).ReadString('\n')
+				time.Sleep(delay)
+				// Count the connection as finished before replying, so the
+				// client's next dial can't overlap it here.
+				active.Add(-1)
+				fmt.Fprint(conn, "STAT uptime 10\r\nEND\r\n")
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestSlowServerDoesNotHoldUpOthers(t *testing.T) {
+	var active, peak atomic.Int32
+	stuck := startSlowStatsServer(t, time.Minute, &active, &peak)
+	defer stuck.Close()
+	fast := startSlowStatsServer(t, 0, &active, &peak)
+	defer fast.Close()
+
+	u := newUI(time.Second, nil, newSession(stuck.Addr().String(), time.Second), newSession(fast.Addr().String(), time.Second))
+	u.pollTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	sample(context.Background(), u)
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("pass took %s with a 100ms poll timeout", took)
+	}
+	if err := u.servers[0].lastErr; err == nil || !strings.Contains(err.Error(), "no reply within 100ms") {
+		t.Fatalf("stuck server error = %v", err)
+	}
+	if u.servers[1].lastErr != nil || u.servers[1].current == nil {
+		t.Fatalf("fast server was not recorded: %v", u.servers[1].lastErr)
+	}
+}
+
+func TestPollWorkersBoundConcurrentConnections(t *testing.T) {
+	var active, peak atomic.Int32
+	ln := startSlowStatsServer(t, 20*time.Millisecond, &active, &peak)
+	defer ln.Close()
+
+	var servers []*session
+	for range 6 {
+		servers = append(servers, newSession(ln.Addr().String(), time.Second))
+	}
+	u := newUI(time.Second, nil, servers...)
+	u.pollWorkers = 2
+	sample(context.Background(), u)
+
+	if got := peak.Load(); got > 2 {
+		t.Fatalf("%d connections open at once with 2 workers", got)
+	}
+	for _, s := range servers {
+		if s.lastErr != nil || s.current == nil {
+			t.Fatalf("server not polled: %v", s.lastErr)
+		}
+	}
+}
+
+func TestPollJitterScalesWithInterval(t *testing.T) {
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	if got := pollJitter(u); got != 0 {
+		t.Fatalf("single server jitter = %s, want 0", got)
+	}
+	u.servers = append(u.servers, newSession("b:11211", time.Second))
+	if got := pollJitter(u); got != 100*time.Millisecond {
+		t.Fatalf("jitter at 1s = %s, want 100ms", got)
+	}
+	u.interval = time.Minute
+	if got := pollJitter(u); got != maxPollJitter {
+		t.Fatalf("jitter at 1m = %s, want the %s cap", got, maxPollJitter)
+	}
+}
+
+func TestPollJitterHonoursJitterFlag(t *testing.T) {
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	u.jitter = 300 * time.Millisecond
+	if got := pollJitter(u); got != 300*time.Millisecond {
+		t.Fatalf("single server jitter = %s, want -jitter's 300ms", got)
+	}
+	u.jitter = 10 * time.Second
+	if got := pollJitter(u); got != 500*time.Millisecond {
+		t.Fatalf("jitter = %s, want half the interval", got)
+	}
+	u.servers = append(u.servers, newSession("b:11211", time.Second))
+	u.jitter = 10 * time.Millisecond
+	if got := pollJitter(u); got != 100*time.Millisecond {
+		t.Fatalf("jitter = %s, want the automatic 100ms", got)
+	}
+}
+
+func TestPollWorkerPanicReachesTheCaller(t *testing.T) {
+	var active, peak atomic.Int32
+	ln := startSlowStatsServer(t, 0, &active, &peak)
+	defer ln.Close()
+	saved := collectors
+	defer func() { collectors = saved }()
+	collectors = append(slices.Clone(collectors), collector{
+		Name:    "boom",
+		Scope:   collectEveryPoll,
+		Collect: func(context.Context, *ui, *session) { panic("boom") },
+	})
+
+	u := newUI(time.Second, nil, newSession(ln.Addr().String(), time.Second), newSession(ln.Addr().String(), time.Second))
+	screen := &fakeScreen{}
+	var recovered any
+	func() {
+		defer func() { recovered = recover() }()
+		defer restoreOnPanic(screen, "")
+		pollServers(context.Background(), u)
+	}()
+
+	p, ok := recovered.(*pollPanic)
+	if !ok || p.value != "boom" {
+		t.Fatalf("worker panic should be re-raised by pollServers, recovered %v", recovered)
+	}
+	if !screen.finished {
+		t.Fatalf("the caller's restoreOnPanic should restore the screen")
+	}
+	if !strings.Contains(p.String(), "pollServer") {
+		t.Fatalf("panic should carry the worker's stack:\n%s", p)
+	}
+}
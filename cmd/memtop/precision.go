@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Number kinds, each shown with its own precision.
+const (
+	numberCount   = "count"
+	numberRate    = "rate"
+	numberPercent = "percent"
+)
+
+// maxPrecision bounds the decimals a number may be shown with.
+const maxPrecision = 9
+
+// defaultPrecision is the decimals of each number kind, with the few places
+// that have always shown more.
+var defaultPrecision = map[string]int{
+	numberCount:         0,
+	numberRate:          2,
+	numberPercent:       1,
+	"summary.hit_ratio": 2,
+	"report.hit_ratio":  2,
+	"compact.rate":      0,
+	"hitratio.rate":     1,
+	"plain.rate":        0,
+}
+
+// precision overrides defaultPrecision from -precision and the config file's
+// "precision". Keys name a number kind ("rate"), a kind in one panel
+// ("cluster.rate"), a metric ("evictions"), or a metric in one panel
+// ("summary.hit_ratio").
+var precision map[string]int
+
+// parsePrecision reads -precision, comma-separated key=decimals pairs such
+// as "rate=1,cluster.percent=2,evictions=0".
+func parsePrecision(list string) (map[string]int, error) {
+	out := make(map[string]int)
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("precision %q: want key=decimals", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("precision %q: %w", entry, err)
+		}
+		out[strings.TrimSpace(key)] = n
+	}
+	return out, validatePrecision(out)
+}
+
+// validatePrecision rejects empty keys and decimals out of range.
+func validatePrecision(p map[string]int) error {
+	for key, n := range p {
+		if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") {
+			return fmt.Errorf("precision: invalid key %q", key)
+		}
+		if n < 0 || n > maxPrecision {
+			return fmt.Errorf("precision: %s has %d decimals, want 0 to %d", key, n, maxPrecision)
+		}
+	}
+	return nil
+}
+
+// precisionFor is the decimals of a number of the given kind in a panel,
+// the most specific setting winning: the metric in the panel, the metric,
+// the kind in the panel, then the kind.
+func precisionFor(kind, panel, metric string) int {
+	keys := []string{panel + "." + metric, metric, panel + "." + kind, kind}
+	if metric == "" {
+		keys = keys[2:]
+	}
+	for _, key := range keys {
+		if n, ok := precision[key]; ok {
+			return n
+		}
+	}
+	for _, key := range keys {
+		if n, ok := defaultPrecision[key]; ok {
+			return n
+		}
+	}
+	return 0
+}
+
+// numberFormat renders the numbers of one panel at their configured
+// precision. metric may be empty for numbers that aren't a single stat.
+type numberFormat struct {
+	panel string
+}
+
+func (f numberFormat) format(kind, metric string, v float64) string {
+	return strconv.FormatFloat(v, 'f', precisionFor(kind, f.panel, metric), 64)
+}
+
+// count renders a count, such as items or connections.
+func (f numberFormat) count(metric string, v float64) string {
+	return f.format(numberCount, metric, v)
+}
+
+// rate renders a rate already converted with displayRate.
+func (f numberFormat) rate(metric string, v float64) string {
+	return f.format(numberRate, metric, v)
+}
+
+// percent renders a percentage without its sign.
+func (f numberFormat) percent(metric string, v float64) string {
+	return f.format(numberPercent, metric, v)
+}
+
+// signed renders a number of the given kind with its sign, as changes are.
+func (f numberFormat) signed(kind, metric string, v float64) string {
+	s := f.format(kind, metric, v)
+	if !strings.HasPrefix(s, "-") && !math.IsNaN(v) {
+		s = "+" + s
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestParsePrecision(t *testing.T) {
+	p, err := parsePrecision("rate=1, cluster.percent = 2,evictions=0,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p["rate"] != 1 || p["cluster.percent"] != 2 || p["evictions"] != 0 || len(p) != 3 {
+		t.Fatalf("parsed %v", p)
+	}
+	for _, bad := range []string{"rate", "rate=two", "rate=-1", "rate=12", "=1", "cluster.=1"} {
+		if _, err := parsePrecision(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestNumberFormatPicksTheMostSpecificPrecision(t *testing.T) {
+	saved := precision
+	defer func() { precision = saved }()
+
+	precision = nil
+	nf := numberFormat{"cluster"}
+	if got := nf.rate("cmd_get", 12.345); got != "12.35" {
+		t.Fatalf("default rate = %s, want two decimals", got)
+	}
+	if got := nf.count("curr_items", 41.6); got != "42" {
+		t.Fatalf("default count = %s, want a whole number", got)
+	}
+	if got := (numberFormat{"summary"}).percent("hit_ratio", 97.5); got != "97.50" {
+		t.Fatalf("summary hit ratio = %s, want its two decimals", got)
+	}
+
+	precision = map[string]int{"rate": 0, "cluster.rate": 1, "evictions": 3, "cluster.evictions": 2}
+	for _, tc := range []struct {
+		nf     numberFormat
+		metric string
+		want   string
+	}{
+		{numberFormat{"summary"}, "cmd_get", "12"},
+		{numberFormat{"cluster"}, "cmd_get", "12.3"},
+		{numberFormat{"summary"}, "evictions", "12.345"},
+		{numberFormat{"cluster"}, "evictions", "12.35"},
+	} {
+		if got := tc.nf.rate(tc.metric, 12.345); got != tc.want {
+			t.Errorf("%s %s = %s, want %s", tc.nf.panel, tc.metric, got, tc.want)
+		}
+	}
+	if got := nf.signed(numberRate, "cmd_get", 2); got != "+2.0" {
+		t.Fatalf("signed = %s, want +2.0", got)
+	}
+}
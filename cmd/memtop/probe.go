@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// casProbe runs a gets/cas cycle against a canary key each interval. The key
+// is unique to this memtop process, so nobody else should touch it: a miss
+// means the key vanished or was routed to another backend, and a CAS
+// mismatch means it was written by someone else.
+type casProbe struct {
+	key string
+	ttl time.Duration
+
+	seq    uint64
+	want   string // value of our last successful store
+	cas    uint64 // CAS token read back after that store
+	stored bool
+
+	cycles     int
+	misses     int
+	mismatches int
+	lastAt     time.Time
+	last       string
+	lastErr    error
+}
+
+// probeOutcome classifies one probe cycle.
+type probeOutcome int
+
+const (
+	probeOK probeOutcome = iota
+	probeMiss
+	probeMismatch
+)
+
+// defaultProbeKey names the canary after the host and process so concurrent
+// memtop instances never share one.
+func defaultProbeKey() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("memtop:canary:%s:%d", host, os.Getpid())
+}
+
+// newCASProbe prepares a probe whose canary outlives several intervals, so an
+// expiry never looks like a miss.
+func newCASProbe(key string, interval time.Duration) *casProbe {
+	return &casProbe{key: key, ttl: max(time.Minute, 10*interval)}
+}
+
+// run performs one cycle: read the canary, compare it with what we last
+// stored, then replace it with a new value using cas (or add when missing).
+func (p *casProbe) run(ctx context.Context, addr string) (probeOutcome, string, error) {
+	conn, err := dialServer(ctx, addr)
+	if err != nil {
+		return probeOK, "", err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	value, cas, found, err := textGets(conn, r, p.key)
+	if err != nil {
+		return probeOK, "", err
+	}
+	outcome, detail := probeOK, "ok"
+	switch {
+	case p.stored && !found:
+		outcome, detail = probeMiss, "canary key missing"
+	case p.stored && value != p.want:
+		outcome, detail = probeMismatch, fmt.Sprintf("canary value %q, expected %q", value, p.want)
+	case p.stored && cas != p.cas:
+		outcome, detail = probeMismatch, fmt.Sprintf("canary CAS %d, expected %d", cas, p.cas)
+	}
+
+	p.seq++
+	next := strconv.FormatUint(p.seq, 10)
+	ttl := int(p.ttl.Seconds())
+	var reply string
+	if found {
+		reply, err = textStore(conn, r, fmt.Sprintf("cas %s 0 %d %d %d", p.key, ttl, len(next), cas), next)
+	} else {
+		reply, err = textStore(conn, r, fmt.Sprintf("add %s 0 %d %d", p.key, ttl, len(next)), next)
+	}
+	if err != nil {
+		return outcome, detail, err
+	}
+	p.stored = false
+	switch reply {
+	case "STORED":
+	case "EXISTS", "NOT_STORED":
+		return probeMismatch, "canary changed between gets and " + strings.ToLower(reply), nil
+	case "NOT_FOUND":
+		return probeMiss, "canary vanished before cas", nil
+	default:
+		return outcome, detail, fmt.Errorf("unexpected reply %q", reply)
+	}
+
+	value, cas, found, err = textGets(conn, r, p.key)
+	if err != nil {
+		return outcome, detail, err
+	}
+	if found && value == next {
+		p.want, p.cas, p.stored = next, cas, true
+	}
+	return outcome, detail, nil
+}
+
+// runProbe performs a probe cycle for the session, counting anomalies and
+// logging them as events.
+func (s *session) runProbe(ctx context.Context, now time.Time) {
+	p := s.probe
+	outcome, detail, err := p.run(ctx, s.addr)
+	if ctx.Err() != nil {
+		return
+	}
+	p.lastAt = now
+	p.lastErr = err
+	if err != nil {
+		return
+	}
+	p.cycles++
+	p.last = detail
+	switch outcome {
+	case probeMiss:
+		p.misses++
+		s.logEvent(now, eventProbe, "CAS probe miss: "+detail)
+	case probeMismatch:
+		p.mismatches++
+		s.logEvent(now, eventProbe, "CAS probe mismatch: "+detail)
+	}
+}
+
+// renderProbePanel summarises the consistency probe; it is hidden when the
+// probe is disabled.
+func renderProbePanel(s *session) []panelLine {
+	p := s.probe
+	if p == nil {
+		return nil
+	}
+	lines := []panelLine{plainLine("key " + p.key)}
+	if p.lastAt.IsZero() {
+		return append(lines, plainLine("Waiting for first cycle..."))
+	}
+	lines = append(lines, plainLine(fmt.Sprintf("cycles %d   misses %d   mismatches %d", p.cycles, p.misses, p.mismatches)))
+	if p.lastErr != nil {
+		return append(lines, plainLine(fmt.Sprintf("Error: %v", p.lastErr)))
+	}
+	style := eventStyle(eventConnOK)
+	if p.last != "ok" {
+		style = eventStyle(eventProbe)
+	}
+	return append(lines, panelLine{Text: fmt.Sprintf("last %s: %s", clockTime(p.lastAt), p.last), Style: style})
+}
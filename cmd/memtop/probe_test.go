@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStore is a tiny memcached speaking just enough of gets/set/add/cas,
+// delete and flush_all for the probe and the subcommands. Tests poke items directly to
+// simulate other writers and evictions.
+type fakeStore struct {
+	mu      sync.Mutex
+	items   map[string]string
+	cas     map[string]uint64
+	nextCAS uint64
+	flushes []string
+}
+
+func (f *fakeStore) set(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextCAS++
+	f.items[key], f.cas[key] = value, f.nextCAS
+}
+
+func (f *fakeStore) remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.items, key)
+}
+
+func startFakeStore(t *testing.T) (*fakeStore, net.Listener) {
+	t.Helper()
+	store := &fakeStore{items: map[string]string{}, cas: map[string]uint64{}}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go store.serve(conn)
+		}
+	}()
+	return store, ln
+}
+
+func (f *fakeStore) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "gets":
+			f.mu.Lock()
+			if v, ok := f.items[fields[1]]; ok {
+				fmt.Fprintf(conn, "VALUE %s 0 %d %d\r\n%s\r\n", fields[1], len(v), f.cas[fields[1]], v)
+			}
+			f.mu.Unlock()
+			fmt.Fprint(conn, "END\r\n")
+		case "set", "add", "cas":
+			data, _ := readLine(r)
+			key := fields[1]
+			f.mu.Lock()
+			_, exists := f.items[key]
+			reply := "STORED"
+			switch {
+			case fields[0] == "add" && exists:
+				reply = "NOT_STORED"
+			case fields[0] == "cas" && !exists:
+				reply = "NOT_FOUND"
+			case fields[0] == "cas" && strconv.FormatUint(f.cas[key], 10) != fields[5]:
+				reply = "EXISTS"
+			}
+			f.mu.Unlock()
+			if reply == "STORED" {
+				f.set(key, data)
+			}
+			fmt.Fprintf(conn, "%s\r\n", reply)
+		case "delete":
+			f.mu.Lock()
+			_, exists := f.items[fields[1]]
+			delete(f.items, fields[1])
+			f.mu.Unlock()
+			if exists {
+				fmt.Fprint(conn, "DELETED\r\n")
+			} else {
+				fmt.Fprint(conn, "NOT_FOUND\r\n")
+			}
+		case "flush_all":
+			f.mu.Lock()
+			f.flushes = append(f.flushes, line)
+			if len(fields) == 1 || fields[1] == "0" {
+				clear(f.items)
+			}
+			f.mu.Unlock()
+			fmt.Fprint(conn, "OK\r\n")
+		default:
+			fmt.Fprint(conn, "ERROR\r\n")
+		}
+	}
+}
+
+func TestCASProbeDetectsMissesAndForeignWrites(t *testing.T) {
+	store, ln := startFakeStore(t)
+	defer ln.Close()
+	sess := newSession(ln.Addr().String(), time.Second)
+	sess.probe = newCASProbe("memtop:canary:test", time.Second)
+	now := time.Now()
+
+	sess.runProbe(context.Background(), now)
+	sess.runProbe(context.Background(), now)
+	if sess.probe.lastErr != nil || sess.probe.cycles != 2 || sess.probe.misses+sess.probe.mismatches != 0 {
+		t.Fatalf("clean cycles should not report anomalies: %+v", sess.probe)
+	}
+
+	store.set("memtop:canary:test", "written elsewhere")
+	sess.runProbe(context.Background(), now)
+	if sess.probe.mismatches != 1 {
+		t.Fatalf("foreign write should count as a mismatch: %+v", sess.probe)
+	}
+
+	store.remove("memtop:canary:test")
+	sess.runProbe(context.Background(), now)
+	if sess.probe.misses != 1 {
+		t.Fatalf("vanished key should count as a miss: %+v", sess.probe)
+	}
+
+	sess.runProbe(context.Background(), now)
+	if sess.probe.cycles != 5 || sess.probe.last != "ok" {
+		t.Fatalf("probe should recover after re-adding the key: %+v", sess.probe)
+	}
+
+	var kinds []string
+	for _, e := range sess.events.events {
+		kinds = append(kinds, e.Kind)
+	}
+	if strings.Join(kinds, ",") != "probe,probe" {
+		t.Fatalf("probe anomalies should be logged, got %v", kinds)
+	}
+	lines := renderProbePanel(sess)
+	if len(lines) != 3 || !strings.Contains(lines[1].Text, "misses 1   mismatches 1") {
+		t.Fatalf("unexpected panel %+v", lines)
+	}
+}
+
+func TestProbePanelHiddenWhenDisabled(t *testing.T) {
+	if lines := renderProbePanel(newSession("127.0.0.1:11211", time.Second)); lines != nil {
+		t.Fatalf("disabled probe should not render, got %+v", lines)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+// startStatsServer answers a single `stats` request with one counter.
+func startStatsServer(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		bufio.NewReader(conn).ReadString('\n')
+		fmt.Fprint(conn, "STAT curr_items 7\r\nEND\r\n")
+	}()
+	return ln
+}
+
+// pipe copies both directions until either side closes.
+func pipe(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+// startHTTPProxy accepts one CONNECT request, recording the target and the
+// Proxy-Authorization header it was sent.
+func startHTTPProxy(t *testing.T, seen chan<- *http.Request) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			conn.Close()
+			return
+		}
+		seen <- req
+		if req.Method != http.MethodConnect {
+			fmt.Fprint(conn, "HTTP/1.1 405 Method Not Allowed\r\n\r\n")
+			conn.Close()
+			return
+		}
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			fmt.Fprint(conn, "HTTP/1.1 502 Bad Gateway\r\n\r\n")
+			conn.Close()
+			return
+		}
+		fmt.Fprint(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		pipe(conn, upstream)
+	}()
+	return ln
+}
+
+// startSOCKS5Proxy accepts one unauthenticated SOCKS5 CONNECT to an IPv4
+// address, which is all the test needs.
+func startSOCKS5Proxy(t *testing.T) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		greeting := make([]byte, 2)
+		io.ReadFull(conn, greeting)
+		io.ReadFull(conn, make([]byte, greeting[1]))
+		conn.Write([]byte{5, 0})
+
+		request := make([]byte, 10)
+		if _, err := io.ReadFull(conn, request); err != nil || request[3] != 1 {
+			conn.Close()
+			return
+		}
+		target := net.JoinHostPort(net.IP(request[4:8]).String(), strconv.Itoa(int(binary.BigEndian.Uint16(request[8:]))))
+		upstream, err := net.Dial("tcp", target)
+		if err != nil {
+			conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+			conn.Close()
+			return
+		}
+		conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+		pipe(conn, upstream)
+	}()
+	return ln
+}
+
+func fetchThrough(t *testing.T, d dialer, addr string) *statsSnapshot {
+	t.Helper()
+	previous := serverDialer
+	serverDialer = d
+	defer func() { serverDialer = previous }()
+	snapshot, err := fetchStats(context.Background(), addr)
+	if err != nil {
+		t.Fatalf("fetchStats through proxy: %v", err)
+	}
+	return snapshot
+}
+
+func TestFetchStatsThroughHTTPProxy(t *testing.T) {
+	server := startStatsServer(t)
+	defer server.Close()
+	seen := make(chan *http.Request, 1)
+	proxyLn := startHTTPProxy(t, seen)
+	defer proxyLn.Close()
+
+	d, err := proxyDialer("http://ops:secret@"+proxyLn.Addr().String(), serverDialer)
+	if err != nil {
+		t.Fatalf("proxyDialer: %v", err)
+	}
+	snapshot := fetchThrough(t, d, server.Addr().String())
+	if got := snapshot.Values["curr_items"]; got != 7 {
+		t.Fatalf("curr_items = %.0f, want 7", got)
+	}
+	req := <-seen
+	if req.Host != server.Addr().String() {
+		t.Fatalf("CONNECT target = %q, want %q", req.Host, server.Addr())
+	}
+	if got := req.Header.Get("Proxy-Authorization"); got != "Basic b3BzOnNlY3JldA==" {
+		t.Fatalf("Proxy-Authorization = %q", got)
+	}
+}
+
+func TestHTTPProxyRefusalIsReported(t *testing.T) {
+	seen := make(chan *http.Request, 1)
+	proxyLn := startHTTPProxy(t, seen)
+	defer proxyLn.Close()
+
+	d, err := proxyDialer("http://"+proxyLn.Addr().String(), serverDialer)
+	if err != nil {
+		t.Fatalf("proxyDialer: %v", err)
+	}
+	// Nothing listens on port 1, so the proxy answers 502.
+	if _, err := d.Dial("tcp", "127.0.0.1:1"); err == nil {
+		t.Fatalf("expected an error when the proxy refuses the tunnel")
+	}
+}
+
+func TestFetchStatsThroughSOCKS5FromEnvironment(t *testing.T) {
+	server := startStatsServer(t)
+	defer server.Close()
+	proxyLn := startSOCKS5Proxy(t)
+	defer proxyLn.Close()
+
+	t.Setenv("ALL_PROXY", "socks5://"+proxyLn.Addr().String())
+	t.Setenv("NO_PROXY", "")
+	d, err := proxyDialer("", serverDialer)
+	if err != nil {
+		t.Fatalf("proxyDialer: %v", err)
+	}
+	if d == serverDialer {
+		t.Fatalf("ALL_PROXY should select a proxy dialer")
+	}
+	snapshot := fetchThrough(t, d, server.Addr().String())
+	if got := snapshot.Values["curr_items"]; got != 7 {
+		t.Fatalf("curr_items = %.0f, want 7", got)
+	}
+}
+
+func TestProxyDialerDefaultsAndErrors(t *testing.T) {
+	t.Setenv("ALL_PROXY", "")
+	t.Setenv("all_proxy", "")
+	if d, err := proxyDialer("", serverDialer); err != nil || d != serverDialer {
+		t.Fatalf("without a proxy the forward dialer should be used, got %v, %v", d, err)
+	}
+	if _, err := proxyDialer("ftp://proxy:21", serverDialer); err == nil {
+		t.Fatalf("unsupported proxy schemes should be rejected")
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// recordProxy stores the latest `stats proxy` reading and its rates.
+func (s *session) recordProxy(stats *statsSnapshot, err error) {
+	if err != nil {
+		s.proxyErr = err
+		return
+	}
+	s.proxyErr = nil
+	s.proxyRates = calculateRates(stats, s.proxy)
+	s.proxy = stats
+}
+
+// proxyCounters splits `stats proxy` keys into per-command counters (cmd_*)
+// and everything else, which covers route and user-defined stats.
+func proxyCounters(stats *statsSnapshot) ([]string, []string) {
+	if stats == nil {
+		return nil, nil
+	}
+	var commands, routes []string
+	for key := range stats.Raw {
+		if strings.HasPrefix(key, "cmd_") {
+			commands = append(commands, key)
+		} else {
+			routes = append(routes, key)
+		}
+	}
+	sort.Strings(commands)
+	sort.Strings(routes)
+	return commands, routes
+}
+
+// drawProxyView shows the built-in proxy's health counters from plain `stats`
+// followed by the command and route counters from `stats proxy`.
+func drawProxyView(screen tcell.Screen, line int, u *ui) {
+	s := u.current()
+	_, height := screen.Size()
+	baseStyle := tcell.StyleDefault
+	bold := baseStyle.Bold(true)
+	if s.current == nil {
+		return
+	}
+
+	v := s.current.Values
+	unit := rateUnit(s.interval)
+	nf := numberFormat{"proxy"}
+	rate := func(key string) string { return nf.rate(key, displayRate(rateValue(s.rates, key), s.interval)) }
+	count := func(key string) string { return nf.count(key, v[key]) }
+	drawText(screen, 0, line, baseStyle, fmt.Sprintf("Requests: %s%s   Errors: %s%s   Active: %s   Awaiting: %s",
+		rate("proxy_conn_requests"), unit,
+		rate("proxy_conn_errors"), unit,
+		count("proxy_req_active"),
+		count("proxy_await_active"),
+	))
+	line++
+	drawText(screen, 0, line, baseStyle, fmt.Sprintf("Backends: %s total   %s marked bad   %s failed   Config reloads: %s (%s failed)",
+		count("proxy_backend_total"),
+		count("proxy_backend_marked_bad"),
+		count("proxy_backend_failed"),
+		count("proxy_config_reloads"),
+		count("proxy_config_reload_fails"),
+	))
+	line += 2
+
+	if s.proxyErr != nil {
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Proxy stats error: %v", s.proxyErr))
+		return
+	}
+	if s.proxy == nil {
+		drawText(screen, 0, line, baseStyle, "Waiting for proxy stats...")
+		return
+	}
+
+	commands, routes := proxyCounters(s.proxy)
+	for _, section := range []struct {
+		title string
+		keys  []string
+	}{
+		{"Commands", commands},
+		{"Routes and user stats", routes},
+	} {
+		if len(section.keys) == 0 || line >= height-1 {
+			continue
+		}
+		drawText(screen, 0, line, bold, fmt.Sprintf("%-32s %20s %14s", section.title, "Total", "Rate"+unit))
+		line++
+		for _, key := range section.keys {
+			if line >= height-1 {
+				break
+			}
+			drawText(screen, 0, line, baseStyle, fmt.Sprintf("%-32s %20s %14s", key, s.proxy.Raw[key], nf.rate(key, displayRate(rateValue(s.proxyRates, key), s.interval))))
+			line++
+		}
+		line++
+	}
+}
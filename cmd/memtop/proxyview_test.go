@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func proxyTestSession() *session {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{
+		Values: map[string]float64{"proxy_backend_total": 3, "proxy_backend_marked_bad": 1},
+		Raw:    map[string]string{"version": "1.6.23", "proxy_backend_total": "3", "proxy_backend_marked_bad": "1"},
+	}
+	sess.caps = detectCapabilities(sess.current, nil)
+	start := time.Now()
+	sess.recordProxy(newStatsSnapshot(start, map[string]string{"cmd_mg": "100", "cmd_ms": "10", "route_sessions": "50"}), nil)
+	sess.recordProxy(newStatsSnapshot(start.Add(time.Second), map[string]string{"cmd_mg": "140", "cmd_ms": "12", "route_sessions": "70"}), nil)
+	return sess
+}
+
+func screenText(t *testing.T, u *ui) string {
+	t.Helper()
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(100, 24)
+	drawScreen(screen, u)
+
+	cells, width, height := screen.GetContents()
+	var all []string
+	for row := 0; row < height; row++ {
+		all = append(all, lineFromCells(cells, width, row))
+	}
+	return strings.Join(all, "\n")
+}
+
+func TestProxyCountersSplitCommandsFromRoutes(t *testing.T) {
+	sess := proxyTestSession()
+	commands, routes := proxyCounters(sess.proxy)
+	if strings.Join(commands, ",") != "cmd_mg,cmd_ms" || strings.Join(routes, ",") != "route_sessions" {
+		t.Fatalf("commands %v routes %v", commands, routes)
+	}
+	if got := sess.proxyRates["cmd_mg"]; got != 40 {
+		t.Fatalf("cmd_mg rate = %.2f, want 40", got)
+	}
+}
+
+func TestDrawProxyView(t *testing.T) {
+	u := newUI(time.Second, nil, proxyTestSession())
+	u.view = viewProxy
+	text := screenText(t, u)
+	for _, want := range []string{"Backends: 3 total   1 marked bad", "cmd_mg", "40.00", "Routes and user stats", "route_sessions"} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("proxy view missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestProxyViewNotSupportedWithoutProxyMode(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{Values: map[string]float64{}, Raw: map[string]string{"version": "1.6.21"}}
+	sess.caps = detectCapabilities(sess.current, nil)
+	u := newUI(time.Second, nil, sess)
+	u.view = viewProxy
+	if text := screenText(t, u); !strings.Contains(text, "The proxy view is "+notSupported) {
+		t.Fatalf("unsupported proxy view should say so:\n%s", text)
+	}
+}
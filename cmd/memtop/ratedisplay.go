@@ -0,0 +1,36 @@
+package main
+
+import "time"
+
+// ratesPerInterval shows rates as the change over one refresh interval
+// instead of per second, for operators who compare against tools printing
+// per-tick deltas. -per-interval sets it at start and u toggles it. Only the
+// screen and -plain output follow it; charts, recordings, and exports stay
+// per second.
+var ratesPerInterval bool
+
+// displayRate converts a per-second rate of a server polled every interval
+// into the unit rates are shown in.
+func displayRate(perSecond float64, interval time.Duration) float64 {
+	if ratesPerInterval {
+		return perSecond * interval.Seconds()
+	}
+	return perSecond
+}
+
+// rateUnit is the suffix of rate labels: "/s", or "/2s" for the change over
+// a 2s interval.
+func rateUnit(interval time.Duration) string {
+	if ratesPerInterval {
+		return "/" + shortDuration(interval)
+	}
+	return "/s"
+}
+
+// rateWords spells out rateUnit for the header and -plain output.
+func rateWords(interval time.Duration) string {
+	if ratesPerInterval {
+		return "per " + shortDuration(interval)
+	}
+	return "per second"
+}
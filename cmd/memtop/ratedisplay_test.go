@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRatesPerInterval(t *testing.T) {
+	defer func() { ratesPerInterval = false }()
+	if got := displayRate(10, 2*time.Second); got != 10 {
+		t.Fatalf("per second rate = %v, want 10", got)
+	}
+	if unit, words := rateUnit(2*time.Second), rateWords(2*time.Second); unit != "/s" || words != "per second" {
+		t.Fatalf("per second labels = %q, %q", unit, words)
+	}
+
+	ratesPerInterval = true
+	if got := displayRate(10, 2*time.Second); got != 20 {
+		t.Fatalf("per interval rate = %v, want 20", got)
+	}
+	if got := displayRate(10, 500*time.Millisecond); got != 5 {
+		t.Fatalf("per 500ms rate = %v, want 5", got)
+	}
+	if unit, words := rateUnit(2*time.Second), rateWords(time.Minute); unit != "/2s" || words != "per 1m" {
+		t.Fatalf("per interval labels = %q, %q", unit, words)
+	}
+	if got := formatBytesRate(2048, 2*time.Second); got != "4.0 KB/2s" {
+		t.Fatalf("bandwidth per interval = %q", got)
+	}
+}
+
+func TestCompactItemsPerInterval(t *testing.T) {
+	defer func() { ratesPerInterval = false }()
+	ratesPerInterval = true
+	s := newSession("127.0.0.1:11211", 2*time.Second)
+	s.current = &statsSnapshot{Values: map[string]float64{}, Raw: map[string]string{}}
+	s.rates = map[string]float64{"cmd_get": 50}
+	if got := compactItems(s)[2]; got != "gets/2s 100" {
+		t.Fatalf("compact rate = %q, want gets/2s 100", got)
+	}
+}
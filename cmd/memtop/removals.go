@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// removalCause is one reason an item left the cache, derived from a counter
+// delta over the last interval.
+type removalCause struct {
+	Label string
+	Note  string
+	Count float64
+	Live  bool // true when the removed items still held valid data
+}
+
+// removalBreakdown splits the items removed during the last interval by
+// cause. Evictions are divided into never-fetched and fetched items, since
+// evicting data that was being read is what actually hurts hit ratio.
+func removalBreakdown(s *session) []removalCause {
+	evicted := s.intervalDelta("evictions")
+	unfetched := s.intervalDelta("evicted_unfetched")
+	fetched := evicted - unfetched
+	if fetched < 0 {
+		fetched = 0
+	}
+	return []removalCause{
+		{Label: "reclaimed", Note: "expired slots reused", Count: s.intervalDelta("reclaimed")},
+		{Label: "expired unfetched", Note: "dead, never read", Count: s.intervalDelta("expired_unfetched")},
+		{Label: "evicted unfetched", Note: "live, never read", Count: unfetched, Live: true},
+		{Label: "evicted fetched", Note: "live, were read", Count: fetched, Live: true},
+		{Label: "evicted active", Note: "live, recently hit", Count: s.intervalDelta("evicted_active"), Live: true},
+	}
+}
+
+// renderRemovalsPanel answers "are we evicting live data or just reclaiming
+// dead keys?" for the most recent interval.
+func renderRemovalsPanel(s *session) []panelLine {
+	if s.current == nil || s.elapsed <= 0 {
+		return []panelLine{plainLine("Waiting for two samples...")}
+	}
+	causes := removalBreakdown(s)
+	lines := []panelLine{plainLine(fmt.Sprintf("Last %s:", s.elapsed.Round(100*time.Millisecond)))}
+	live, dead := 0.0, 0.0
+	for _, c := range causes {
+		style := tcell.StyleDefault
+		if c.Live && c.Count > 0 {
+			style = theme.bad
+			live += c.Count
+		} else if !c.Live {
+			dead += c.Count
+		}
+		lines = append(lines, panelLine{Text: fmt.Sprintf("%-18s %8.0f  %s", c.Label, c.Count, c.Note), Style: style})
+	}
+	if age, ok := lastEvictedAge(s); ok {
+		lines = append(lines, plainLine(fmt.Sprintf("last evicted item idle %s", formatUptime(age))))
+	}
+
+	switch {
+	case live > 0:
+		lines = append(lines, panelLine{Text: "Verdict: evicting live data", Style: theme.bad.Bold(true)})
+	case dead > 0:
+		lines = append(lines, panelLine{Text: "Verdict: only reclaiming dead keys", Style: theme.good})
+	default:
+		lines = append(lines, plainLine("Verdict: no removals"))
+	}
+	return lines
+}
+
+// lastEvictedAge reports the smallest per-class evicted_time from the latest
+// `stats items` reading: how long the most recently evicted item had sat idle.
+// It is only available while slab data is being collected.
+func lastEvictedAge(s *session) (float64, bool) {
+	if s.slabs == nil || s.slabs.Items == nil {
+		return 0, false
+	}
+	found := false
+	youngest := 0.0
+	for key, value := range s.slabs.Items.Values {
+		_, field, ok := parseItemsKey(key)
+		if !ok || field != "evicted_time" || value <= 0 {
+			continue
+		}
+		if !found || value < youngest {
+			youngest = value
+			found = true
+		}
+	}
+	return youngest, found
+}
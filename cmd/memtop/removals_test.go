@@ -0,0 +1,66 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRemovalBreakdownSplitsEvictions(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	start := time.Now()
+	sess.record(&statsSnapshot{Timestamp: start, Values: map[string]float64{
+		"evictions": 100, "evicted_unfetched": 40, "reclaimed": 10, "expired_unfetched": 5, "evicted_active": 0,
+	}}, nil)
+	sess.record(&statsSnapshot{Timestamp: start.Add(2 * time.Second), Values: map[string]float64{
+		"evictions": 110, "evicted_unfetched": 44, "reclaimed": 30, "expired_unfetched": 7, "evicted_active": 1,
+	}}, nil)
+
+	got := map[string]float64{}
+	for _, c := range removalBreakdown(sess) {
+		got[c.Label] = c.Count
+	}
+	want := map[string]float64{
+		"reclaimed": 20, "expired unfetched": 2, "evicted unfetched": 4, "evicted fetched": 6, "evicted active": 1,
+	}
+	for label, count := range want {
+		if got[label] != count {
+			t.Fatalf("%s = %.0f, want %.0f (all: %v)", label, got[label], count, got)
+		}
+	}
+
+	lines := renderRemovalsPanel(sess)
+	if last := lines[len(lines)-1].Text; last != "Verdict: evicting live data" {
+		t.Fatalf("unexpected verdict %q", last)
+	}
+	if !strings.HasPrefix(lines[0].Text, "Last 2s") {
+		t.Fatalf("panel should label the interval, got %q", lines[0].Text)
+	}
+}
+
+func TestRemovalsPanelReclaimOnlyVerdict(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	start := time.Now()
+	sess.record(&statsSnapshot{Timestamp: start, Values: map[string]float64{"reclaimed": 1}}, nil)
+	sess.record(&statsSnapshot{Timestamp: start.Add(time.Second), Values: map[string]float64{"reclaimed": 3}}, nil)
+
+	lines := renderRemovalsPanel(sess)
+	if last := lines[len(lines)-1].Text; last != "Verdict: only reclaiming dead keys" {
+		t.Fatalf("unexpected verdict %q", last)
+	}
+}
+
+func TestLastEvictedAgeUsesYoungestClass(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if _, ok := lastEvictedAge(sess); ok {
+		t.Fatalf("no slab data should mean no age")
+	}
+	sess.slabs = &slabSample{Items: &statsSnapshot{Values: map[string]float64{
+		"items:1:evicted_time": 300,
+		"items:2:evicted_time": 45,
+		"items:3:evicted_time": 0,
+	}}}
+	if age, ok := lastEvictedAge(sess); !ok || age != 45 {
+		t.Fatalf("lastEvictedAge = %.0f, %v; want 45, true", age, ok)
+	}
+}
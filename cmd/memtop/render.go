@@ -0,0 +1,108 @@
+package main
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// frameCell is one cell of a rendered frame.
+type frameCell struct {
+	main      rune
+	combining []rune
+	style     tcell.Style
+}
+
+var blankCell = frameCell{main: ' ', style: tcell.StyleDefault}
+
+// frameScreen collects a frame off screen. Views draw into it exactly as they
+// would into the terminal, and the renderer then copies only the cells that
+// differ from the previous frame.
+type frameScreen struct {
+	tcell.Screen
+	w, h  int
+	cells []frameCell
+}
+
+func (f *frameScreen) Size() (int, int) {
+	return f.w, f.h
+}
+
+func (f *frameScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if x < 0 || y < 0 || x >= f.w || y >= f.h {
+		return
+	}
+	f.cells[y*f.w+x] = frameCell{main: primary, combining: combining, style: style}
+}
+
+func (f *frameScreen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	if x < 0 || y < 0 || x >= f.w || y >= f.h {
+		return ' ', nil, tcell.StyleDefault, 1
+	}
+	c := f.cells[y*f.w+x]
+	return c.main, c.combining, c.style, 1
+}
+
+// Clear blanks the frame.
+func (f *frameScreen) Clear() {
+	for i := range f.cells {
+		f.cells[i] = blankCell
+	}
+}
+
+// Show and Sync are left to the renderer, which owns the real screen.
+func (f *frameScreen) Show() {}
+func (f *frameScreen) Sync() {}
+
+// renderer remembers the last frame put on a screen so the next one only
+// touches the cells that changed, and the terminal isn't written to at all
+// when nothing did. At sub-second intervals most of a frame stays the same.
+type renderer struct {
+	screen tcell.Screen
+	prev   *frameScreen
+	next   *frameScreen
+	drawn  time.Time // when the last frame was flushed
+}
+
+// begin returns a blank frame the size of screen.
+func (r *renderer) begin(screen tcell.Screen) *frameScreen {
+	w, h := screen.Size()
+	w, h = max(w, 0), max(h, 0)
+	if r.next == nil || r.next.w != w || r.next.h != h {
+		r.next = &frameScreen{w: w, h: h, cells: make([]frameCell, w*h)}
+	}
+	r.next.Screen = screen
+	r.next.Clear()
+	return r.next
+}
+
+// flush copies the frame's changed cells to the screen and shows them. A new
+// screen or size, or a call to invalidate, repaints every cell.
+func (r *renderer) flush(screen tcell.Screen) {
+	f := r.next
+	full := r.screen != screen || r.prev == nil || r.prev.w != f.w || r.prev.h != f.h
+	changed := full
+	for i, c := range f.cells {
+		if !full {
+			p := r.prev.cells[i]
+			if p.main == c.main && p.style == c.style && slices.Equal(p.combining, c.combining) {
+				continue
+			}
+		}
+		screen.SetContent(i%f.w, i/f.w, c.main, c.combining, c.style)
+		changed = true
+	}
+	if changed {
+		screen.Show()
+	}
+	r.screen = screen
+	r.prev, r.next = f, r.prev
+	r.drawn = time.Now()
+}
+
+// invalidate makes the next frame repaint everything, for when the screen's
+// contents can no longer be trusted, such as after a resize.
+func (r *renderer) invalidate() {
+	r.prev = nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// countingScreen counts the cells written to and the frames shown on a
+// simulation screen.
+type countingScreen struct {
+	tcell.SimulationScreen
+	cells, shows int
+}
+
+func (c *countingScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	c.cells++
+	c.SimulationScreen.SetContent(x, y, primary, combining, style)
+}
+
+func (c *countingScreen) Show() {
+	c.shows++
+	c.SimulationScreen.Show()
+}
+
+func TestDrawScreenOnlyWritesChangedCells(t *testing.T) {
+	sim := tcell.NewSimulationScreen("")
+	if err := sim.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer sim.Fini()
+	sim.SetSize(80, 20)
+	screen := &countingScreen{SimulationScreen: sim}
+
+	u := newUI(time.Second, nil, newSession("127.0.0.1:11211", time.Second))
+	drawScreen(screen, u)
+	if screen.cells != 80*20 || screen.shows != 1 {
+		t.Fatalf("first frame wrote %d cells and showed %d times, want a full repaint", screen.cells, screen.shows)
+	}
+
+	screen.cells, screen.shows = 0, 0
+	drawScreen(screen, u)
+	if screen.cells != 0 || screen.shows != 0 {
+		t.Fatalf("unchanged frame wrote %d cells and showed %d times", screen.cells, screen.shows)
+	}
+
+	u.toggleSuspend(time.Now())
+	drawScreen(screen, u)
+	if screen.cells == 0 || screen.cells >= 80*20/2 || screen.shows != 1 {
+		t.Fatalf("suspending wrote %d cells and showed %d times", screen.cells, screen.shows)
+	}
+	cells, width, _ := sim.GetContents()
+	if banner := lineFromCells(cells, width, 1); !strings.Contains(banner, "SUSPENDED") {
+		t.Fatalf("banner missing after partial redraw: %q", banner)
+	}
+
+	screen.cells = 0
+	u.frame.invalidate()
+	drawScreen(screen, u)
+	if screen.cells != 80*20 {
+		t.Fatalf("invalidated frame wrote %d cells, want a full repaint", screen.cells)
+	}
+}
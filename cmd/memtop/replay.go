@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"mymemcache-top/memstats/memstatstest"
+)
+
+// replayRecord is the part of a -jsonl record that replay needs. Values are
+// kept as JSON numbers so counters past 2^53 replay exactly.
+type replayRecord struct {
+	Server    string                 `json:"server"`
+	Tags      map[string]string      `json:"tags"`
+	Up        bool                   `json:"up"`
+	Timestamp time.Time              `json:"timestamp"`
+	Values    map[string]json.Number `json:"values"`
+}
+
+// replayedServer is one server's samples from a recording, oldest first.
+type replayedServer struct {
+	tags    map[string]string
+	replies []map[string]string
+	times   []time.Time
+}
+
+// readRecording groups a -jsonl recording, plain or gzip-compressed, by
+// server in the order servers first appear. A sample from a server that was
+// down repeats the previous one, so every server stays on the same tick.
+func readRecording(r io.Reader) ([]string, map[string]*replayedServer, error) {
+	var order []string
+	servers := make(map[string]*replayedServer)
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+	for {
+		var rec replayRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		srv := servers[rec.Server]
+		if srv == nil {
+			srv = &replayedServer{tags: rec.Tags}
+			servers[rec.Server] = srv
+			order = append(order, rec.Server)
+		}
+		reply := make(map[string]string, len(rec.Values))
+		for key, value := range rec.Values {
+			reply[key] = value.String()
+		}
+		switch {
+		case rec.Up && len(reply) > 0:
+		case len(srv.replies) > 0:
+			reply = srv.replies[len(srv.replies)-1]
+		default:
+			continue
+		}
+		srv.replies = append(srv.replies, reply)
+		srv.times = append(srv.times, rec.Timestamp)
+	}
+	if len(order) == 0 {
+		return nil, nil, errors.New("no samples in the recording")
+	}
+	return order, servers, nil
+}
+
+// recordedInterval is the median gap between a server's samples, the
+// interval the recording was taken at.
+func recordedInterval(times []time.Time) time.Duration {
+	var gaps []time.Duration
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap > 0 {
+			gaps = append(gaps, gap)
+		}
+	}
+	if len(gaps) == 0 {
+		return 2 * time.Second
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	return max(gaps[len(gaps)/2].Round(time.Millisecond), minInterval)
+}
+
+// startReplayServers loads a recording and starts a fake server per
+// recorded server that answers each `stats` with the server's next sample
+// and then keeps repeating the last one. Polled at the recorded interval,
+// the monitor shows the recording again, rates and charts included. It
+// returns the servers' configs, the interval, and a function stopping them.
+func startReplayServers(path string) ([]serverConfig, time.Duration, func(), error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	order, recorded, err := readRecording(r)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var servers []serverConfig
+	var running []*memstatstest.Server
+	interval := time.Duration(0)
+	for _, name := range order {
+		rec := recorded[name]
+		if len(rec.replies) == 0 {
+			continue
+		}
+		srv := memstatstest.NewServer()
+		srv.SetStats("", rec.replies...)
+		for _, section := range []string{"settings", "slabs", "items"} {
+			srv.SetStats(section, map[string]string{})
+		}
+		running = append(running, srv)
+		tags := map[string]string{"recorded": name}
+		for k, v := range rec.tags {
+			tags[k] = v
+		}
+		servers = append(servers, serverConfig{Addr: srv.Addr(), Tags: tags})
+		if interval == 0 {
+			interval = recordedInterval(rec.times)
+		}
+	}
+	stop := func() {
+		for _, srv := range running {
+			srv.Close()
+		}
+	}
+	if len(servers) == 0 {
+		return nil, 0, nil, fmt.Errorf("%s: every recorded server was down", path)
+	}
+	return servers, interval, stop, nil
+}
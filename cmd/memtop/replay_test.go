@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayServesTheRecordingInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rec.jsonl")
+	recording := strings.Join([]string{
+		`{"server":"a:11211","tags":{"dc":"east"},"up":true,"timestamp":"2024-03-01T12:00:00Z","values":{"cmd_get":18446744073709551000}}`,
+		`{"server":"b:11211","up":false,"error":"refused","timestamp":"2024-03-01T12:00:00Z"}`,
+		`{"server":"a:11211","up":false,"error":"timeout","timestamp":"2024-03-01T12:00:02Z"}`,
+		`{"server":"b:11211","up":true,"timestamp":"2024-03-01T12:00:02Z","values":{"cmd_get":5}}`,
+		`{"server":"a:11211","up":true,"timestamp":"2024-03-01T12:00:04Z","values":{"cmd_get":18446744073709551001}}`,
+	}, "\n")
+	if err := os.WriteFile(path, []byte(recording), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	servers, interval, stop, err := startReplayServers(path)
+	if err != nil {
+		t.Fatalf("startReplayServers: %v", err)
+	}
+	defer stop()
+	if interval != 2*time.Second || len(servers) != 2 {
+		t.Fatalf("interval %s, servers %+v", interval, servers)
+	}
+	if servers[0].Tags["recorded"] != "a:11211" || servers[0].Tags["dc"] != "east" {
+		t.Fatalf("tags = %v", servers[0].Tags)
+	}
+	var got []string
+	for i := 0; i < 4; i++ {
+		stats, err := fetchStats(context.Background(), servers[0].Addr)
+		if err != nil {
+			t.Fatalf("fetchStats: %v", err)
+		}
+		got = append(got, stats.Raw["cmd_get"])
+	}
+	// The outage repeats the previous sample and the last one sticks.
+	want := "18446744073709551000 18446744073709551000 18446744073709551001 18446744073709551001"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("replayed cmd_get %v, want %s", got, want)
+	}
+}
+
+func TestReplayRejectsAnEmptyRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.jsonl")
+	os.WriteFile(path, nil, 0o644)
+	if _, _, _, err := startReplayServers(path); err == nil {
+		t.Fatalf("an empty recording should be rejected")
+	}
+}
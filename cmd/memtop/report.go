@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// sessionReport is what memtop saw during one run, written on exit with
+// -report for incident reviews.
+type sessionReport struct {
+	Started time.Time
+	Ended   time.Time
+	Servers []serverReport
+	Events  []event // oldest first
+}
+
+// serverReport is one server's summary at the end of the run and the
+// history of its charted series.
+type serverReport struct {
+	Addr    string
+	Tags    string
+	Error   string
+	Summary []reportRow
+	Charts  []reportChart
+}
+
+// reportRow is one labelled summary value.
+type reportRow struct {
+	Label, Value string
+}
+
+// reportChart is one charted series over the run.
+type reportChart struct {
+	Label  string
+	Values []float64
+	Max    float64 // fixed ceiling, zero to scale to the data
+}
+
+// newSessionReport gathers the report from the sessions and the event log.
+func newSessionReport(u *ui, started, ended time.Time) sessionReport {
+	rep := sessionReport{Started: started, Ended: ended}
+	for _, s := range u.servers {
+		sr := serverReport{Addr: s.addr, Tags: formatTags(s.tags), Summary: reportSummary(s)}
+		if s.lastErr != nil {
+			sr.Error = s.lastErr.Error()
+		}
+		for _, series := range chartedSeries {
+			sr.Charts = append(sr.Charts, reportChart{Label: series.Label, Values: s.history.values(series.Name), Max: series.Max})
+		}
+		rep.Servers = append(rep.Servers, sr)
+	}
+	recent := u.events.recent(u.events.limit)
+	for i := len(recent) - 1; i >= 0; i-- {
+		rep.Events = append(rep.Events, recent[i])
+	}
+	return rep
+}
+
+// reportSummary is the server's last sample, in the summary view's terms.
+func reportSummary(s *session) []reportRow {
+	stats, rates := s.current, s.rates
+	if stats == nil {
+		return nil
+	}
+	nf := numberFormat{"report"}
+	return []reportRow{
+		{"Version", stats.Raw["version"]},
+		{"Uptime", formatUptime(stats.Values["uptime"])},
+		{"Hit ratio", nf.percent("hit_ratio", hitRatio(stats)) + "%"},
+		{"Memory", fmt.Sprintf("%s / %s (%s%%)", formatBytes(stats.Values["bytes"]), formatBytes(stats.Values["limit_maxbytes"]), nf.percent("memory", memoryPercent(stats)))},
+		{"Items", nf.count("curr_items", stats.Values["curr_items"])},
+		{"Evictions", nf.count("evictions", stats.Values["evictions"])},
+		{"Connections", nf.count("curr_connections", stats.Values["curr_connections"])},
+		{"Gets/s", nf.rate("cmd_get", rateValue(rates, "cmd_get"))},
+		{"Sets/s", nf.rate("cmd_set", rateValue(rates, "cmd_set"))},
+	}
+}
+
+// writeReportFile writes the report to path, as Markdown if the name ends
+// in .md or .markdown and as HTML otherwise.
+func writeReportFile(path string, rep sessionReport) error {
+	write := writeHTMLReport
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		write = writeMarkdownReport
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f, rep); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Size of the report's SVG charts.
+const (
+	reportChartWidth  = 720
+	reportChartHeight = 120
+)
+
+// svgChart draws values as a line scaled to max (or the largest value),
+// broken where samples are missing. It only emits numbers, so the result is
+// safe to embed.
+func svgChart(values []float64, max float64) template.HTML {
+	if max <= 0 {
+		max = maxFinite(values)
+	}
+	if max <= 0 {
+		max = 1
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg viewBox="0 0 %d %d" width="%d" height="%d">`, reportChartWidth, reportChartHeight, reportChartWidth, reportChartHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" class="bg"/>`, reportChartWidth, reportChartHeight)
+	step := float64(reportChartWidth)
+	if len(values) > 1 {
+		step /= float64(len(values) - 1)
+	}
+	var points []string
+	flush := func() {
+		if len(points) > 0 {
+			fmt.Fprintf(&b, `<polyline points="%s"/>`, strings.Join(points, " "))
+			points = points[:0]
+		}
+	}
+	for i, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			flush()
+			continue
+		}
+		y := float64(reportChartHeight) * (1 - math.Min(math.Max(v/max, 0), 1))
+		points = append(points, fmt.Sprintf("%.1f,%.1f", float64(i)*step, y))
+	}
+	flush()
+	b.WriteString(`</svg>`)
+	return template.HTML(b.String())
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"stamp":      formatTimestamp,
+	"subject":    func(e event) string { return e.subject() },
+	"duration":   func(a, b time.Time) string { return b.Sub(a).Round(time.Second).String() },
+	"chart":      func(c reportChart) template.HTML { return svgChart(c.Values, c.Max) },
+	"peak":       func(c reportChart) string { return formatChartValue(maxFinite(c.Values)) },
+	"eventClass": func(kind string) string { return strings.ReplaceAll(kind, "-", "") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>memtop report {{stamp .Started}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; } h2 { font-size: 1.2em; margin-top: 2em; } h3 { font-size: 1em; margin-bottom: .3em; }
+table { border-collapse: collapse; } td, th { padding: .2em .8em; text-align: left; border-bottom: 1px solid #ddd; }
+.error { color: #b00; }
+svg .bg { fill: #f6f6f6; } svg polyline { fill: none; stroke: #2a6fb0; stroke-width: 1.5; }
+.restart, .connlost, .logerror { color: #b00; } .alert, .flush, .suspend { color: #a60; } .connok { color: #080; }
+</style>
+</head>
+<body>
+<h1>memtop report</h1>
+<p>{{stamp .Started}} to {{stamp .Ended}} ({{duration .Started .Ended}})</p>
+{{range .Servers}}
+<h2>{{.Addr}}{{if .Tags}} <small>{{.Tags}}</small>{{end}}</h2>
+{{if .Error}}<p class="error">Last poll failed: {{.Error}}</p>{{end}}
+{{if .Summary}}<table>{{range .Summary}}<tr><th>{{.Label}}</th><td>{{.Value}}</td></tr>{{end}}</table>{{end}}
+{{range .Charts}}
+<h3>{{.Label}} <small>peak {{peak .}}</small></h3>
+{{chart .}}
+{{end}}
+{{end}}
+<h2>Events</h2>
+{{if .Events}}<table>
+<tr><th>Time</th><th>Kind</th><th>Message</th></tr>
+{{range .Events}}<tr class="{{eventClass .Kind}}"><td>{{stamp .Time}}</td><td>{{.Kind}}</td><td>{{subject .}}</td></tr>
+{{end}}</table>{{else}}<p>No events.</p>{{end}}
+</body>
+</html>
+`))
+
+// writeHTMLReport renders the report as a standalone HTML page with inline
+// styles and SVG charts, so it can be attached to an incident review as is.
+func writeHTMLReport(w io.Writer, rep sessionReport) error {
+	return reportTemplate.Execute(w, rep)
+}
+
+// markdownSparklineWidth is how many samples a Markdown chart shows.
+const markdownSparklineWidth = 60
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", " ").Replace(s)
+}
+
+// writeMarkdownReport renders the report as GitHub-flavoured Markdown for
+// pasting into an issue or incident document: a summary table per server,
+// its charts as text sparklines, and the event log as a table.
+func writeMarkdownReport(w io.Writer, rep sessionReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# memtop report\n\n%s to %s (%s)\n", formatTimestamp(rep.Started), formatTimestamp(rep.Ended), rep.Ended.Sub(rep.Started).Round(time.Second))
+	for _, sr := range rep.Servers {
+		fmt.Fprintf(&b, "\n## %s\n\n", sr.Addr)
+		if sr.Tags != "" {
+			fmt.Fprintf(&b, "Tags: %s\n\n", sr.Tags)
+		}
+		if sr.Error != "" {
+			fmt.Fprintf(&b, "**Last poll failed:** %s\n\n", sr.Error)
+		}
+		if len(sr.Summary) > 0 {
+			b.WriteString("| Metric | Value |\n| --- | --- |\n")
+			for _, row := range sr.Summary {
+				fmt.Fprintf(&b, "| %s | %s |\n", row.Label, markdownCell(row.Value))
+			}
+			b.WriteString("\n")
+		}
+		for _, c := range sr.Charts {
+			if line := sparkline(c.Values, markdownSparklineWidth); strings.TrimSpace(line) != "" {
+				fmt.Fprintf(&b, "- %s (peak %s): `%s`\n", c.Label, formatChartValue(maxFinite(c.Values)), line)
+			}
+		}
+	}
+	b.WriteString("\n## Events\n\n")
+	if len(rep.Events) == 0 {
+		b.WriteString("No events.\n")
+	} else {
+		b.WriteString("| Time | Kind | Message |\n| --- | --- | --- |\n")
+		for _, e := range rep.Events {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", formatTimestamp(e.Time), e.Kind, markdownCell(e.subject()))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionReportRendersSummaryChartsAndEvents(t *testing.T) {
+	u := sinkTestUI()
+	s := u.servers[0]
+	s.tags = map[string]string{"dc": "eu1"}
+	s.current.Raw = map[string]string{"version": "1.6.21"}
+	s.history.add(&statsSnapshot{Timestamp: time.Unix(1, 0), Values: map[string]float64{}}, map[string]float64{"cmd_get": 10})
+	s.history.add(&statsSnapshot{Timestamp: time.Unix(2, 0), Values: map[string]float64{}}, map[string]float64{"cmd_get": 20})
+	s.logEvent(time.Unix(3, 0), eventRestart, "server restarted <oops>")
+
+	path := filepath.Join(t.TempDir(), "report.html")
+	start := time.Unix(0, 0)
+	if err := writeReportFile(path, newSessionReport(u, start, start.Add(90*time.Second))); err != nil {
+		t.Fatalf("writeReportFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	html := string(data)
+	for _, want := range []string{
+		"<h2>10.0.0.1:11211 <small>dc=eu1</small></h2>",
+		"<th>Version</th><td>1.6.21</td>",
+		"(1m30s)",
+		"Gets/s <small>peak 20.00</small>",
+		`<polyline points="0.0,60.0 720.0,0.0"/>`,
+		`<tr class="restart">`,
+		"server restarted &lt;oops&gt;",
+	} {
+		if !strings.Contains(html, want) {
+			t.Fatalf("report lacks %q:\n%s", want, html)
+		}
+	}
+}
+
+func TestSVGChartBreaksAtGaps(t *testing.T) {
+	svg := string(svgChart([]float64{1, math.NaN(), 1, 0}, 2))
+	if strings.Count(svg, "<polyline") != 2 || !strings.Contains(svg, `points="0.0,60.0"`) || !strings.Contains(svg, `points="480.0,60.0 720.0,120.0"`) {
+		t.Fatalf("svg = %s", svg)
+	}
+}
+
+func TestMarkdownReportForIssues(t *testing.T) {
+	u := sinkTestUI()
+	s := u.servers[0]
+	s.history.add(&statsSnapshot{Timestamp: time.Unix(1, 0), Values: map[string]float64{}}, map[string]float64{"cmd_get": 10})
+	s.history.add(&statsSnapshot{Timestamp: time.Unix(2, 0), Values: map[string]float64{}}, map[string]float64{"cmd_get": 20})
+	s.logAlert(time.Unix(3, 0), alertInfo{Message: "a|b", State: alertFiring})
+
+	path := filepath.Join(t.TempDir(), "incident.md")
+	start := time.Unix(0, 0)
+	if err := writeReportFile(path, newSessionReport(u, start, start.Add(time.Minute))); err != nil {
+		t.Fatalf("writeReportFile: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	md := string(data)
+	for _, want := range []string{
+		"# memtop report\n",
+		"## 10.0.0.1:11211\n",
+		"| Items | 7 |\n",
+		"- Gets/s (peak 20.00): `▄█`\n",
+		"| 1970-01-01 00:00:03 | alert | 10.0.0.1:11211: a\\|b |\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Fatalf("report lacks %q:\n%s", want, md)
+		}
+	}
+	if strings.Contains(md, "- Sets/s") {
+		t.Fatalf("series without samples should be left out:\n%s", md)
+	}
+}
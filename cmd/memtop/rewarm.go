@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// rewarmTarget is the share of its pre-restart bytes and hit ratio a server
+// must get back before it counts as warm again.
+const rewarmTarget = 0.9
+
+// rewarmWatch follows a server through restarts for -rolling-restart: what
+// it held before the last restart, how far it has refilled since, and the
+// misses the cold cache cost on the way.
+type rewarmWatch struct {
+	restarts    int
+	restartedAt time.Time
+	warming     bool
+	warmedAt    time.Time
+
+	// beforeBytes and beforeRatio are the bytes and windowed hit ratio of
+	// the process that went away; beforeRatio is NaN when it was unknown.
+	beforeBytes float64
+	beforeRatio float64
+
+	bytes float64
+	ratio float64 // windowed hit ratio since the restart, NaN until known
+	// extraMisses counts misses beyond those the pre-restart hit ratio
+	// would have given for the same gets.
+	extraMisses float64
+}
+
+// newRewarmWatch starts a watch that has seen no restart yet.
+func newRewarmWatch() *rewarmWatch {
+	return &rewarmWatch{beforeRatio: math.NaN(), ratio: math.NaN()}
+}
+
+// bytesProgress is the share of the pre-restart bytes held again, 1 when the
+// server held nothing before.
+func (w *rewarmWatch) bytesProgress() float64 {
+	if w.beforeBytes <= 0 {
+		return 1
+	}
+	return min(w.bytes/w.beforeBytes, 1)
+}
+
+// warm reports whether bytes and hit ratio are both back within
+// rewarmTarget of what they were. A hit ratio that was unknown before the
+// restart is not waited for.
+func (w *rewarmWatch) warm() bool {
+	if w.bytesProgress() < rewarmTarget {
+		return false
+	}
+	return math.IsNaN(w.beforeRatio) || (!math.IsNaN(w.ratio) && w.ratio >= rewarmTarget*w.beforeRatio)
+}
+
+// observeRewarm updates the watch with a new sample. prev is the sample
+// before it and ratioBefore the windowed hit ratio up to prev, taken before
+// a restart clears the window. A restart starts a new re-warm; the end of
+// one is logged with what it cost.
+func (s *session) observeRewarm(prev, curr *statsSnapshot, ratioBefore float64) {
+	w := s.rewarm
+	if w == nil || prev == nil {
+		return
+	}
+	if curr.Values["uptime"] < prev.Values["uptime"] {
+		w.restarts++
+		w.restartedAt = curr.Timestamp.Add(-time.Duration(curr.Values["uptime"]) * time.Second)
+		w.warming, w.warmedAt = true, time.Time{}
+		w.beforeBytes, w.beforeRatio = prev.Values["bytes"], ratioBefore
+		w.bytes, w.ratio, w.extraMisses = curr.Values["bytes"], math.NaN(), 0
+		return
+	}
+	if !w.warming {
+		return
+	}
+	w.bytes, w.ratio = curr.Values["bytes"], s.windowHitRatio()
+	hits := curr.Values["get_hits"] - prev.Values["get_hits"]
+	misses := curr.Values["get_misses"] - prev.Values["get_misses"]
+	if !math.IsNaN(w.beforeRatio) && hits+misses > 0 {
+		expected := (hits + misses) * (100 - w.beforeRatio) / 100
+		w.extraMisses += max(misses-expected, 0)
+	}
+	if w.warm() {
+		w.warming, w.warmedAt = false, curr.Timestamp
+		nf := numberFormat{"rewarm"}
+		s.logEvent(curr.Timestamp, eventRewarm, fmt.Sprintf("re-warmed %s after restart: %.0f%% of bytes back, hit ratio %s (was %s), about %s extra misses",
+			shortDuration(w.warmedAt.Sub(w.restartedAt).Round(time.Second)), w.bytesProgress()*100,
+			nf.ratio("hit_ratio", w.ratio), nf.ratio("hit_ratio", w.beforeRatio), nf.count("extra_misses", w.extraMisses)))
+	}
+}
+
+// describeRewarm summarizes a server's last re-warm in one line, or returns
+// "" before its first restart.
+func describeRewarm(w *rewarmWatch, now time.Time) string {
+	if w == nil || w.restarts == 0 {
+		return ""
+	}
+	nf := numberFormat{"rewarm"}
+	if w.warming {
+		return fmt.Sprintf("warming %s  bytes %.0f%%  hit %s (was %s)  %s extra misses",
+			shortDuration(now.Sub(w.restartedAt).Round(time.Second)), w.bytesProgress()*100,
+			nf.ratio("hit_ratio", w.ratio), nf.ratio("hit_ratio", w.beforeRatio), nf.count("extra_misses", w.extraMisses))
+	}
+	return fmt.Sprintf("warm after %s  %s extra misses",
+		shortDuration(w.warmedAt.Sub(w.restartedAt).Round(time.Second)), nf.count("extra_misses", w.extraMisses))
+}
+
+// renderRewarmPanel shows the selected server's re-warm; it is hidden
+// without -rolling-restart.
+func renderRewarmPanel(s *session) []panelLine {
+	w := s.rewarm
+	if w == nil {
+		return nil
+	}
+	if w.restarts == 0 {
+		return []panelLine{plainLine("No restart seen yet")}
+	}
+	lines := []panelLine{plainLine(fmt.Sprintf("restarts %d   last at %s", w.restarts, clockTime(w.restartedAt)))}
+	line := plainLine(describeRewarm(w, time.Now()))
+	if w.warming {
+		line.Style = theme.warn
+	}
+	return append(lines, line)
+}
+
+// rollingRestartLines summarizes a rolling restart across the fleet for the
+// cluster view: how many servers restarted since the first restart seen, how
+// many are still warming, the misses their cold caches cost, and a line per
+// restarted server. It is empty until a watched server restarts.
+func rollingRestartLines(u *ui, now time.Time) []panelLine {
+	var first time.Time
+	var restarted, warming int
+	var extra float64
+	var rows []panelLine
+	for _, s := range u.servers {
+		w := s.rewarm
+		if w == nil || w.restarts == 0 {
+			continue
+		}
+		restarted++
+		extra += w.extraMisses
+		if first.IsZero() || w.restartedAt.Before(first) {
+			first = w.restartedAt
+		}
+		row := plainLine(fmt.Sprintf("  %-26s %s", s.addr, describeRewarm(w, now)))
+		if w.warming {
+			warming++
+			row.Style = theme.warn
+		}
+		rows = append(rows, row)
+	}
+	if restarted == 0 {
+		return nil
+	}
+	head := panelLine{
+		Text: fmt.Sprintf("Rolling restart since %s (%s): %d/%d restarted, %d warming, about %s extra misses",
+			clockTime(first), shortDuration(now.Sub(first).Round(time.Second)), restarted, len(u.servers), warming,
+			numberFormat{"rewarm"}.count("extra_misses", extra)),
+		Style: theme.warn.Bold(true),
+	}
+	if warming == 0 {
+		head.Style = theme.good.Bold(true)
+	}
+	return append([]panelLine{head}, rows...)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRewarmFollowsRestartUntilWarm(t *testing.T) {
+	s := newSession("a:11211", 10*time.Second)
+	s.rewarm = newRewarmWatch()
+	other := newSession("b:11211", 10*time.Second)
+	other.rewarm = newRewarmWatch()
+	u := newUI(10*time.Second, nil, s, other)
+
+	start := time.Now()
+	sample := func(step int, uptime, bytes, hits, misses int) {
+		s.record(newStatsSnapshot(start.Add(time.Duration(step)*10*time.Second), map[string]string{
+			"uptime":     strconv.Itoa(uptime),
+			"bytes":      strconv.Itoa(bytes),
+			"get_hits":   strconv.Itoa(hits),
+			"get_misses": strconv.Itoa(misses),
+		}), nil)
+	}
+	sample(0, 1000, 1000, 900, 100)
+	sample(1, 1010, 1000, 1800, 200)
+	if rollingRestartLines(u, start) != nil {
+		t.Fatal("no summary expected before a restart")
+	}
+
+	sample(2, 5, 0, 0, 0)
+	sample(3, 15, 500, 50, 50)
+	w := s.rewarm
+	if w.restarts != 1 || !w.warming || w.beforeBytes != 1000 || w.beforeRatio != 90 {
+		t.Fatalf("restart not picked up: %+v", w)
+	}
+	// 100 gets at the old 90% would have missed 10 times, not 50.
+	if w.extraMisses != 40 {
+		t.Fatalf("extra misses = %v, want 40", w.extraMisses)
+	}
+	now := start.Add(30 * time.Second)
+	lines := rollingRestartLines(u, now)
+	if len(lines) != 2 || !strings.Contains(lines[0].Text, "1/2 restarted, 1 warming, about 40 extra misses") ||
+		!strings.Contains(lines[1].Text, "warming 15s  bytes 50%  hit 50.0% (was 90.0%)") {
+		t.Fatalf("unexpected summary %+v", lines)
+	}
+
+	sample(4, 25, 950, 950, 150)
+	if w.warming || w.extraMisses != 40 {
+		t.Fatalf("server should be warm again: %+v", w)
+	}
+	events := u.events.recent(1)
+	if len(events) != 1 || events[0].Kind != eventRewarm || !strings.Contains(events[0].Message, "re-warmed 25s after restart: 95% of bytes back") {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if got := describeRewarm(w, now); got != "warm after 25s  40 extra misses" {
+		t.Fatalf("describeRewarm = %q", got)
+	}
+}
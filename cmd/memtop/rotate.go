@@ -0,0 +1,183 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// rotateOptions control how a recording file is compressed and rotated. Zero
+// values disable the corresponding limit.
+type rotateOptions struct {
+	Compress bool
+	MaxSize  int64         // rotate once the file reaches this many bytes
+	MaxAge   time.Duration // rotate once the file has been open this long
+	Keep     int           // rotated files to keep; older ones are deleted
+}
+
+// rotatingWriter appends to a recording file, optionally gzip-compressed,
+// and moves it aside with a timestamp suffix when it grows too large or too
+// old, so long-running recordings don't fill the disk.
+type rotatingWriter struct {
+	path string
+	opts rotateOptions
+	now  func() time.Time
+
+	f      *os.File
+	gz     *gzip.Writer
+	w      io.Writer
+	size   int64
+	opened time.Time
+}
+
+// newRotatingWriter opens path for appending. With compression a ".gz"
+// suffix is added if missing; appending starts a new gzip member, which
+// gzip readers treat as a continuation of the same stream.
+func newRotatingWriter(path string, opts rotateOptions) (*rotatingWriter, error) {
+	if opts.Compress && !strings.HasSuffix(path, ".gz") {
+		path += ".gz"
+	}
+	r := &rotatingWriter{path: path, opts: opts, now: time.Now}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingWriter) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.f, r.size, r.opened = f, info.Size(), r.now()
+	r.w = &countingWriter{w: f, n: &r.size}
+	if r.opts.Compress {
+		r.gz = gzip.NewWriter(r.w)
+		r.w = r.gz
+	}
+	return nil
+}
+
+// Write rotates first if a limit has been reached, so a record is never
+// split across files.
+func (r *rotatingWriter) Write(p []byte) (int, error) {
+	if r.due() {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	return r.w.Write(p)
+}
+
+// Flush pushes buffered compressed data to the file so a crash loses at most
+// the current sample.
+func (r *rotatingWriter) Flush() error {
+	if r.gz == nil {
+		return nil
+	}
+	return r.gz.Flush()
+}
+
+func (r *rotatingWriter) due() bool {
+	if r.size == 0 {
+		return false
+	}
+	return r.opts.MaxSize > 0 && r.size >= r.opts.MaxSize ||
+		r.opts.MaxAge > 0 && r.now().Sub(r.opened) >= r.opts.MaxAge
+}
+
+// Close finishes the gzip stream and closes the file.
+func (r *rotatingWriter) Close() error {
+	var err error
+	if r.gz != nil {
+		err = r.gz.Close()
+	}
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// rotate moves the current file to a timestamped name, opens a fresh one,
+// and prunes old rotations beyond Keep.
+func (r *rotatingWriter) rotate() error {
+	if err := r.Close(); err != nil {
+		return err
+	}
+	base, ext := r.splitExt()
+	rotated := fmt.Sprintf("%s.%s%s", base, r.now().UTC().Format("20060102T150405.000"), ext)
+	if err := os.Rename(r.path, rotated); err != nil {
+		return err
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	return r.prune()
+}
+
+// splitExt separates the ".gz" suffix, which rotated names keep at the end.
+func (r *rotatingWriter) splitExt() (string, string) {
+	if r.opts.Compress {
+		return strings.TrimSuffix(r.path, ".gz"), ".gz"
+	}
+	return r.path, ""
+}
+
+// rotatedFiles lists earlier rotations, oldest first; the timestamp suffix
+// sorts chronologically.
+func (r *rotatingWriter) rotatedFiles() ([]string, error) {
+	base, ext := r.splitExt()
+	matches, err := filepath.Glob(base + ".[0-9]*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, m := range matches {
+		// Without compression the pattern also matches compressed rotations.
+		if ext == "" && strings.HasSuffix(m, ".gz") {
+			continue
+		}
+		rotated = append(rotated, m)
+	}
+	sort.Strings(rotated)
+	return rotated, nil
+}
+
+func (r *rotatingWriter) prune() error {
+	if r.opts.Keep <= 0 {
+		return nil
+	}
+	rotated, err := r.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	for len(rotated) > r.opts.Keep {
+		if err := os.Remove(rotated[0]); err != nil {
+			return err
+		}
+		rotated = rotated[1:]
+	}
+	return nil
+}
+
+// countingWriter tracks how many bytes reached the file.
+type countingWriter struct {
+	w io.Writer
+	n *int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	*c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingWriterRotatesBySizeAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "samples.jsonl")
+	w, err := newRotatingWriter(path, rotateOptions{MaxSize: 10, Keep: 2})
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { clock = clock.Add(time.Second); return clock }
+
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		if _, err := w.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	rotated, err := w.rotatedFiles()
+	if err != nil {
+		t.Fatalf("rotatedFiles: %v", err)
+	}
+	if len(rotated) != 2 {
+		t.Fatalf("expected 2 rotated files after pruning, got %v", rotated)
+	}
+	if data, _ := os.ReadFile(rotated[0]); string(data) != "second line\n" {
+		t.Fatalf("oldest kept rotation = %q, want the second line", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "fourth line\n" {
+		t.Fatalf("current file = %q, want the newest line", data)
+	}
+}
+
+func TestRotatingWriterCompressesAndRotatesByAge(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newRotatingWriter(filepath.Join(dir, "samples.jsonl"), rotateOptions{Compress: true, MaxAge: time.Minute})
+	if err != nil {
+		t.Fatalf("newRotatingWriter: %v", err)
+	}
+	clock := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	w.now = func() time.Time { return clock }
+	w.opened = clock
+
+	w.Write([]byte("a\n"))
+	w.Flush()
+	clock = clock.Add(30 * time.Second)
+	w.Write([]byte("b\n"))
+	w.Flush()
+	clock = clock.Add(time.Minute)
+	w.Write([]byte("c\n"))
+	w.Close()
+
+	rotated, _ := w.rotatedFiles()
+	if len(rotated) != 1 || !strings.HasSuffix(rotated[0], ".gz") {
+		t.Fatalf("expected one compressed rotation, got %v", rotated)
+	}
+	if got := readGzip(t, rotated[0]); got != "a\nb\n" {
+		t.Fatalf("rotated file holds %q", got)
+	}
+	if got := readGzip(t, filepath.Join(dir, "samples.jsonl.gz")); got != "c\n" {
+		t.Fatalf("current file holds %q", got)
+	}
+}
+
+func readGzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open %s: %v", path, err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip %s: %v", path, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	return string(data)
+}
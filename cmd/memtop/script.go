@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// maxScriptSteps stops a runaway script instead of freezing the UI.
+const maxScriptSteps = 1_000_000
+
+// script is a user's Starlark file. It must define
+//
+//	def on_sample(sample, state): ...
+//
+// which memtop calls for every server after each poll. sample is a struct
+// with server, tags, up, error, values, and rates; state is a dict kept per
+// server between calls, for rules that need memory. The builtins metric(name,
+// value) and alert(message, metric=, value=, threshold=, webhook=, exec=)
+// report derived values and raise alerts; an alert's optional details travel
+// with it to the chat webhook and the alert hook.
+type script struct {
+	name     string
+	onSample starlark.Callable
+}
+
+// scriptRun collects what one on_sample call reported.
+type scriptRun struct {
+	metrics []scriptMetric
+	alerts  []alertInfo
+}
+
+// scriptMetric is a derived value reported with metric().
+type scriptMetric struct {
+	Name  string
+	Value float64
+}
+
+// scriptOptions allow the constructs stateful rules need.
+var scriptOptions = &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true, Recursion: true}
+
+// loadScript executes the file once to define on_sample.
+func loadScript(path string, src any) (*script, error) {
+	thread := &starlark.Thread{Name: "load", Print: func(*starlark.Thread, string) {}}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	globals, err := starlark.ExecFileOptions(scriptOptions, thread, path, src, scriptBuiltins)
+	if err != nil {
+		return nil, fmt.Errorf("script %s: %w", path, err)
+	}
+	fn, ok := globals["on_sample"].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("script %s: no on_sample(sample, state) function", path)
+	}
+	return &script{name: filepath.Base(path), onSample: fn}, nil
+}
+
+// scriptBuiltins are available to every script.
+var scriptBuiltins = starlark.StringDict{
+	"metric": starlark.NewBuiltin("metric", scriptMetricBuiltin),
+	"alert":  starlark.NewBuiltin("alert", scriptAlertBuiltin),
+}
+
+func scriptMetricBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name string
+	var value starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 2, &name, &value); err != nil {
+		return nil, err
+	}
+	v, ok := starlark.AsFloat(value)
+	if !ok {
+		return nil, fmt.Errorf("%s: value for %q is %s, want a number", b.Name(), name, value.Type())
+	}
+	run, ok := thread.Local("run").(*scriptRun)
+	if !ok {
+		return nil, fmt.Errorf("%s: only callable from on_sample", b.Name())
+	}
+	run.metrics = append(run.metrics, scriptMetric{Name: name, Value: v})
+	return starlark.None, nil
+}
+
+func scriptAlertBuiltin(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var a alertInfo
+	var value, threshold, command starlark.Value = starlark.None, starlark.None, starlark.None
+	if err := starlark.UnpackArgs(b.Name(), args, kwargs, "message", &a.Message, "metric?", &a.Metric, "value?", &value, "threshold?", &threshold, "webhook?", &a.Webhook, "exec?", &command); err != nil {
+		return nil, err
+	}
+	var err error
+	if a.Exec, err = commandArg(b.Name(), command); err != nil {
+		return nil, err
+	}
+	if a.Value, err = optionalFloat(b.Name(), "value", value); err != nil {
+		return nil, err
+	}
+	if a.Threshold, err = optionalFloat(b.Name(), "threshold", threshold); err != nil {
+		return nil, err
+	}
+	run, ok := thread.Local("run").(*scriptRun)
+	if !ok {
+		return nil, fmt.Errorf("%s: only callable from on_sample", b.Name())
+	}
+	run.alerts = append(run.alerts, a)
+	return starlark.None, nil
+}
+
+// commandArg converts an optional command, given as a string split on
+// spaces (like -plugin) or a list of strings, nil when omitted.
+func commandArg(fn string, v starlark.Value) ([]string, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.String:
+		return strings.Fields(string(v)), nil
+	case *starlark.List:
+		args := make([]string, v.Len())
+		for i := range args {
+			s, ok := starlark.AsString(v.Index(i))
+			if !ok {
+				return nil, fmt.Errorf("%s: exec[%d] is %s, want a string", fn, i, v.Index(i).Type())
+			}
+			args[i] = s
+		}
+		return args, nil
+	}
+	return nil, fmt.Errorf("%s: exec is %s, want a string or a list", fn, v.Type())
+}
+
+// optionalFloat converts an optional numeric argument, nil when omitted.
+func optionalFloat(fn, name string, v starlark.Value) (*float64, error) {
+	if v == starlark.None {
+		return nil, nil
+	}
+	f, ok := starlark.AsFloat(v)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is %s, want a number", fn, name, v.Type())
+	}
+	return &f, nil
+}
+
+// run calls on_sample for the session's latest sample.
+func (sc *script) run(s *session) (*scriptRun, error) {
+	if s.scriptState == nil {
+		s.scriptState = starlark.NewDict(0)
+	}
+	run := &scriptRun{}
+	thread := &starlark.Thread{Name: s.addr, Print: func(*starlark.Thread, string) {}}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	thread.SetLocal("run", run)
+	if _, err := starlark.Call(thread, sc.onSample, starlark.Tuple{scriptSample(newSampleRecord(s)), s.scriptState}, nil); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// scriptSample exposes a sample record to Starlark.
+func scriptSample(rec sampleRecord) starlark.Value {
+	tags := starlark.NewDict(len(rec.Tags))
+	for k, v := range rec.Tags {
+		tags.SetKey(starlark.String(k), starlark.String(v))
+	}
+	return starlarkstruct.FromStringDict(starlark.String("sample"), starlark.StringDict{
+		"server": starlark.String(rec.Server),
+		"tags":   tags,
+		"up":     starlark.Bool(rec.Up),
+		"error":  starlark.String(rec.Error),
+		"values": floatDict(rec.Values),
+		"rates":  floatDict(rec.Rates),
+	})
+}
+
+func floatDict(m map[string]float64) *starlark.Dict {
+	d := starlark.NewDict(len(m))
+	for k, v := range m {
+		d.SetKey(starlark.String(k), starlark.Float(v))
+	}
+	return d
+}
+
+// runScript runs the script for one server and logs alerts as they are
+// raised and cleared, so a condition that holds for many samples produces
+// one event rather than one per poll.
+func runScript(s *session, sc *script, now time.Time) {
+	run, err := sc.run(s)
+	s.scriptErr = err
+	if err != nil {
+		s.logError("script", err)
+		return
+	}
+	s.scriptMetrics = run.metrics
+	s.recordScriptMetrics(run.metrics)
+	active := make(map[string]alertInfo, len(run.alerts))
+	for _, a := range run.alerts {
+		if _, seen := active[a.Message]; seen {
+			continue
+		}
+		if _, firing := s.scriptAlerts[a.Message]; !firing {
+			a.State = alertFiring
+			a.Sparkline = sparkline(s.alertSeries(a.Metric), sparklineWidth)
+			s.logAlert(now, a)
+		}
+		active[a.Message] = a
+	}
+	var cleared []string
+	for msg := range s.scriptAlerts {
+		if _, ok := active[msg]; !ok {
+			cleared = append(cleared, msg)
+		}
+	}
+	sort.Strings(cleared)
+	for _, msg := range cleared {
+		a := s.scriptAlerts[msg]
+		a.State = alertResolved
+		a.Sparkline = sparkline(s.alertSeries(a.Metric), sparklineWidth)
+		s.logAlert(now, a)
+	}
+	s.scriptAlerts = active
+}
+
+// recordScriptMetrics keeps a short history of each derived metric, for
+// the sparklines sent with alerts.
+func (s *session) recordScriptMetrics(metrics []scriptMetric) {
+	if s.scriptHistory == nil {
+		s.scriptHistory = make(map[string][]float64)
+	}
+	for _, m := range metrics {
+		s.scriptHistory[m.Name] = appendBounded(s.scriptHistory[m.Name], m.Value, sparklineWidth)
+	}
+}
+
+// alertSeries returns the recent values of an alert's metric: a metric()
+// the script reported, or one of the charted series.
+func (s *session) alertSeries(metric string) []float64 {
+	if metric == "" {
+		return nil
+	}
+	if values, ok := s.scriptHistory[metric]; ok {
+		return values
+	}
+	return s.history.values(metric)
+}
+
+// scriptPanel shows the script's derived metrics and active alerts.
+func (sc *script) panel() panelSpec {
+	return panelSpec{Title: "Script (" + sc.name + ")", Render: renderScriptPanel}
+}
+
+func renderScriptPanel(s *session) []panelLine {
+	if s.scriptErr != nil {
+		return []panelLine{{Text: fmt.Sprintf("Error: %v", s.scriptErr), Style: eventStyle(eventConnLost)}}
+	}
+	alerts := make([]string, 0, len(s.scriptAlerts))
+	for msg := range s.scriptAlerts {
+		alerts = append(alerts, msg)
+	}
+	sort.Strings(alerts)
+	lines := make([]panelLine, 0, len(alerts)+len(s.scriptMetrics))
+	for _, msg := range alerts {
+		lines = append(lines, panelLine{Text: "ALERT " + msg, Style: eventStyle(eventAlert).Bold(true)})
+	}
+	for _, m := range s.scriptMetrics {
+		lines = append(lines, panelLine{Text: fmt.Sprintf("%-24s %.4g", m.Name, m.Value), Style: tcell.StyleDefault})
+	}
+	if len(lines) == 0 {
+		return []panelLine{plainLine("No metrics or alerts")}
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testScript = `
+def on_sample(sample, state):
+    hits = sample.values.get("get_hits", 0)
+    total = hits + sample.values.get("get_misses", 0)
+    if total:
+        metric("hit_ratio", hits / total)
+    # Alert only after two low samples in a row.
+    state["low"] = state.get("low", 0) + 1 if total and hits / total < 0.5 else 0
+    if state["low"] >= 2:
+        alert("hit ratio below 50% on " + sample.tags["role"])
+`
+
+func TestScriptDerivesMetricsAndAlerts(t *testing.T) {
+	sc, err := loadScript("rules.star", testScript)
+	if err != nil {
+		t.Fatalf("loadScript: %v", err)
+	}
+	sess := newSession("10.0.0.1:11211", time.Second)
+	sess.tags = map[string]string{"role": "sessions"}
+	now := time.Unix(1000, 0)
+	feed := func(hits, misses float64) {
+		sess.current = &statsSnapshot{Timestamp: now, Values: map[string]float64{"get_hits": hits, "get_misses": misses}}
+		runScript(sess, sc, now)
+		if sess.scriptErr != nil {
+			t.Fatalf("script failed: %v", sess.scriptErr)
+		}
+	}
+
+	feed(1, 3)
+	if len(sess.scriptMetrics) != 1 || sess.scriptMetrics[0].Value != 0.25 || len(sess.scriptAlerts) != 0 {
+		t.Fatalf("first low sample = %+v %v", sess.scriptMetrics, sess.scriptAlerts)
+	}
+	feed(1, 3)
+	feed(1, 3)
+	feed(9, 1)
+	var got []string
+	for _, e := range sess.events.recent(10) {
+		got = append(got, e.Message)
+	}
+	if want := "cleared: hit ratio below 50% on sessions|hit ratio below 50% on sessions"; strings.Join(got, "|") != want {
+		t.Fatalf("alert events = %q, want %q", strings.Join(got, "|"), want)
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	if _, err := loadScript("empty.star", "x = 1\n"); err == nil || !strings.Contains(err.Error(), "on_sample") {
+		t.Fatalf("a script without on_sample should be rejected, got %v", err)
+	}
+	sc, err := loadScript("loop.star", "def on_sample(sample, state):\n    while True:\n        pass\n")
+	if err != nil {
+		t.Fatalf("loadScript: %v", err)
+	}
+	sess := newSession("10.0.0.1:11211", time.Second)
+	runScript(sess, sc, time.Now())
+	if sess.scriptErr == nil {
+		t.Fatalf("a runaway script should be stopped")
+	}
+	if lines := renderScriptPanel(sess); !strings.HasPrefix(lines[0].Text, "Error:") {
+		t.Fatalf("the panel should show the error, got %+v", lines)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// memtop's own counters, published under "memtop" in /debug/vars so a
+// deployment watching hundreds of servers can tell when memtop itself is the
+// bottleneck.
+var (
+	selfPolls         = new(expvar.Int)
+	selfPollFailures  = new(expvar.Int)
+	selfDroppedEvents = new(expvar.Int)
+	selfSampleMicros  = new(expvar.Int) // duration of the last sampling pass
+	selfRenderMicros  = new(expvar.Int) // duration of the last frame
+)
+
+func init() {
+	m := expvar.NewMap("memtop")
+	m.Set("polls", selfPolls)
+	m.Set("poll_failures", selfPollFailures)
+	m.Set("dropped_events", selfDroppedEvents)
+	m.Set("sample_us", selfSampleMicros)
+	m.Set("render_us", selfRenderMicros)
+	m.Set("goroutines", expvar.Func(func() any { return runtime.NumGoroutine() }))
+}
+
+// recordPoll counts one stats poll for the self-monitoring counters.
+func recordPoll(err error) {
+	selfPolls.Add(1)
+	if err != nil {
+		selfPollFailures.Add(1)
+	}
+}
+
+// startHTTPServer serves /metrics and /debug/vars on addr until the listener
+// is closed. With a non-nil control channel it also accepts /control/
+// requests for the sampling loop.
+func startHTTPServer(addr string, control chan<- controlRequest) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/metrics", serveMetrics)
+	if control != nil {
+		mux.Handle("/control/", serveControl(control))
+	}
+	go http.Serve(ln, mux)
+	return ln, nil
+}
+
+// renderSelfPanel shows memtop's own resource use and polling health.
+func renderSelfPanel(*session) []panelLine {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	lines := []panelLine{
+		plainLine(fmt.Sprintf("goroutines %d   heap %s", runtime.NumGoroutine(), formatBytes(float64(mem.HeapAlloc)))),
+		plainLine(fmt.Sprintf("polls %d   failed %d", selfPolls.Value(), selfPollFailures.Value())),
+		plainLine(fmt.Sprintf("last sample %s   render %s",
+			formatLatency(time.Duration(selfSampleMicros.Value())*time.Microsecond),
+			formatLatency(time.Duration(selfRenderMicros.Value())*time.Microsecond))),
+	}
+	dropped := plainLine(fmt.Sprintf("dropped events %d", selfDroppedEvents.Value()))
+	if selfDroppedEvents.Value() > 0 {
+		dropped.Style = eventStyle(eventLogFailure)
+	}
+	return append(lines, dropped)
+}
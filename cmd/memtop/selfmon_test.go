@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSelfCountersTrackPollsAndDroppedEvents(t *testing.T) {
+	polls, failures, dropped := selfPolls.Value(), selfPollFailures.Value(), selfDroppedEvents.Value()
+
+	recordPoll(nil)
+	recordPoll(errors.New("connection refused"))
+	log := newEventLog(10, failingWriter{})
+	log.add(event{Time: time.Now(), Message: "one"})
+	log.add(event{Time: time.Now(), Message: "two"})
+
+	if got := selfPolls.Value() - polls; got != 2 {
+		t.Fatalf("polls grew by %d, want 2", got)
+	}
+	if got := selfPollFailures.Value() - failures; got != 1 {
+		t.Fatalf("poll failures grew by %d, want 1", got)
+	}
+	if got := selfDroppedEvents.Value() - dropped; got != 2 {
+		t.Fatalf("dropped events grew by %d, want 2 (the failed write and the next event)", got)
+	}
+
+	lines := renderSelfPanel(nil)
+	if len(lines) != 4 || !strings.HasPrefix(lines[0].Text, "goroutines ") || !strings.HasPrefix(lines[3].Text, "dropped events ") {
+		t.Fatalf("unexpected panel %+v", lines)
+	}
+}
+
+func TestDebugServerPublishesVars(t *testing.T) {
+	ln, err := startHTTPServer("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatalf("startHTTPServer: %v", err)
+	}
+	defer ln.Close()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/debug/vars")
+	if err != nil {
+		t.Fatalf("GET /debug/vars: %v", err)
+	}
+	defer resp.Body.Close()
+	var vars struct {
+		Memtop map[string]float64 `json:"memtop"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		t.Fatalf("decode /debug/vars: %v", err)
+	}
+	for _, name := range []string{"polls", "poll_failures", "dropped_events", "sample_us", "render_us", "goroutines"} {
+		if _, ok := vars.Memtop[name]; !ok {
+			t.Errorf("memtop.%s missing from /debug/vars: %v", name, vars.Memtop)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"regexp"
+	"time"
+
+	"go.starlark.net/starlark"
+)
+
+// session holds what memtop knows about the monitored server between ticks,
+// so sampling and rendering share one source of truth.
+type session struct {
+	addr      string
+	tags      map[string]string
+	interval  time.Duration
+	current   *statsSnapshot
+	prev      *statsSnapshot
+	rates     map[string]float64
+	prevRates map[string]float64
+	elapsed   time.Duration
+	lastErr   error
+	caps      *capabilities
+	latencies []time.Duration
+	probe     *casProbe
+	rewarm    *rewarmWatch
+	history   *history
+	events    *eventLog
+	errors    *errorLog
+
+	watchKeys []string
+	watched   []watchedKey
+	watchErr  error
+
+	linkSpeed  float64 // NIC capacity in bits per second, 0 if unknown
+	hitWindow  time.Duration
+	hitSamples []hitSample
+	warmup     *warmup
+
+	moversCount   int
+	moversExclude *regexp.Regexp
+
+	anomalies    *rollingStats
+	zscores      map[string]float64
+	anomalySigma float64
+
+	// ranges and recent hold each stat's session extremes and latest
+	// values for the detail popup.
+	ranges map[string]statRange
+	recent map[string][]float64
+
+	// lastCollected is when each view collector last ran, for
+	// -view-refresh.
+	lastCollected map[string]time.Time
+
+	ttls             *ttlSample
+	ttlErr           error
+	lastMetadump     time.Time
+	metadumpLimit    int
+	metadumpInterval time.Duration
+
+	slabs     *slabSample
+	prevSlabs *slabSample
+	itemRates map[string]float64
+	slabErr   error
+	// evictStreak counts consecutive slab samples each class evicted in.
+	evictStreak map[int]int
+
+	proxy      *statsSnapshot
+	proxyRates map[string]float64
+	proxyErr   error
+
+	plugins map[*plugin]pluginResult
+
+	scriptState   *starlark.Dict
+	scriptMetrics []scriptMetric
+	scriptHistory map[string][]float64
+	scriptAlerts  map[string]alertInfo
+	scriptErr     error
+}
+
+// newSession prepares an empty session for the given server.
+func newSession(addr string, interval time.Duration) *session {
+	return &session{
+		addr:     addr,
+		interval: interval,
+		history:  newHistory(defaultHistoryLimit),
+		events:   newEventLog(defaultEventLimit, nil),
+		errors:   newErrorLog(defaultErrorLimit, nil),
+
+		hitWindow: defaultHitWindow,
+
+		moversCount:   defaultMoversCount,
+		moversExclude: regexp.MustCompile(defaultMoversExclude),
+
+		anomalies:    newRollingStats(defaultAnomalyWindow),
+		anomalySigma: defaultAnomalySigma,
+
+		metadumpLimit:    defaultMetadumpLimit,
+		metadumpInterval: defaultMetadumpInterval,
+	}
+}
+
+// record folds the result of a fetch into the session. Errors keep the last
+// good snapshot on screen so a transient failure doesn't blank the display.
+func (s *session) record(stats *statsSnapshot, err error) {
+	now := time.Now()
+	if err != nil {
+		if s.lastErr == nil {
+			s.logEvent(now, eventConnLost, err.Error())
+		}
+		s.logError("stats", err)
+		s.lastErr = err
+		return
+	}
+	if s.lastErr != nil {
+		s.logEvent(now, eventConnOK, "stats fetch succeeded again")
+	}
+	s.lastErr = nil
+	last, ratioBefore := s.current, s.windowHitRatio()
+	s.detectEvents(last, stats)
+	s.prevRates = s.rates
+	if s.prev != nil {
+		s.rates = calculateRates(stats, s.prev)
+		s.elapsed = stats.Timestamp.Sub(s.prev.Timestamp)
+	} else {
+		s.rates = make(map[string]float64)
+		s.elapsed = 0
+	}
+	s.prev = stats
+	s.current = stats
+	s.history.add(stats, s.rates)
+	s.observeHits(stats)
+	s.observeRewarm(last, stats, ratioBefore)
+	s.observeWarmup(last, stats, ratioBefore)
+	s.zscores = s.anomalies.observe(s.rates)
+	s.observeValues(stats)
+}
+
+// resetRates discards the rate baseline so the next sample starts fresh.
+func (s *session) resetRates() {
+	s.prev = nil
+	s.rates = make(map[string]float64)
+	s.prevRates = nil
+	s.elapsed = 0
+	s.prevSlabs = nil
+	s.itemRates = make(map[string]float64)
+	s.hitSamples = nil
+}
+
+// recordSlabs stores a slab/item reading and derives per-class rates from the
+// previous one, mirroring record for the main stats.
+func (s *session) recordSlabs(sample *slabSample, err error) {
+	if err != nil {
+		s.slabErr = err
+		return
+	}
+	s.slabErr = nil
+	if s.prevSlabs != nil {
+		s.itemRates = calculateRates(sample.Items, s.prevSlabs.Items)
+	} else {
+		s.itemRates = make(map[string]float64)
+	}
+	s.prevSlabs = sample
+	s.slabs = sample
+	s.trackEvictions(parseSlabClasses(sample, s.itemRates))
+}
+
+// intervalDelta converts a metric's rate back into how much the counter grew
+// over the last sampling interval.
+func (s *session) intervalDelta(key string) float64 {
+	return rateValue(s.rates, key) * s.elapsed.Seconds()
+}
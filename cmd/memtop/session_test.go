@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSessionRecordComputesRatesAndKeepsLastGoodSnapshot(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	start := time.Now()
+
+	first := &statsSnapshot{Timestamp: start, Values: map[string]float64{"cmd_get": 10}}
+	sess.record(first, nil)
+	if len(sess.rates) != 0 {
+		t.Fatalf("first sample should not produce rates, got %v", sess.rates)
+	}
+
+	second := &statsSnapshot{Timestamp: start.Add(time.Second), Values: map[string]float64{"cmd_get": 30}}
+	sess.record(second, nil)
+	if got := sess.rates["cmd_get"]; got != 20 {
+		t.Fatalf("cmd_get rate = %.2f, want 20", got)
+	}
+
+	sess.record(nil, errors.New("connection refused"))
+	if sess.lastErr == nil || sess.current != second {
+		t.Fatalf("error should be recorded while keeping the last snapshot")
+	}
+	if got := sess.history.len(); got != 2 {
+		t.Fatalf("failed fetches should not be added to history, got %d samples", got)
+	}
+
+	sess.resetRates()
+	if sess.prev != nil || len(sess.rates) != 0 {
+		t.Fatalf("resetRates should clear the baseline")
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sink is an output fed after every completed sampling pass: JSON lines,
+// CSV, the Prometheus endpoint, Graphite, or a webhook. Any number run at
+// once, next to the TUI or a headless stream.
+type sink interface {
+	write(u *ui) error
+}
+
+// namedSink is a configured sink and whether its last write failed.
+type namedSink struct {
+	name    string
+	out     sink
+	failing bool
+}
+
+// sinkSet fans each sample out to every configured sink. One sink failing
+// doesn't stop the others: the failure is logged once as an event (every
+// occurrence goes to the error log), the sink is retried every pass, and its
+// recovery is logged too. The zero value has no sinks.
+type sinkSet struct {
+	sinks []*namedSink
+}
+
+// add registers out under name, which appears in failure events.
+func (s *sinkSet) add(name string, out sink) {
+	s.sinks = append(s.sinks, &namedSink{name: name, out: out})
+}
+
+// write hands the latest samples to every sink.
+func (s *sinkSet) write(u *ui) {
+	for _, ns := range s.sinks {
+		err := ns.out.write(u)
+		if err != nil {
+			u.errors.add(logEntry{Time: time.Now(), Level: levelError, Source: ns.name + " output", Message: err.Error()})
+		}
+		switch {
+		case err != nil && !ns.failing:
+			u.events.add(event{Time: time.Now(), Kind: eventLogFailure, Message: fmt.Sprintf("%s output failing: %v", ns.name, err)})
+		case err == nil && ns.failing:
+			u.events.add(event{Time: time.Now(), Kind: eventLogFailure, Message: fmt.Sprintf("%s output recovered", ns.name)})
+		}
+		ns.failing = err != nil
+	}
+}
+
+// write makes the board a sink: /metrics serves what it was last handed.
+func (b *sampleBoard) write(u *ui) error {
+	b.publish(u)
+	return nil
+}
+
+// csvStats are the stats written as CSV columns, after the timestamp,
+// server, and up columns; csvRates are the counters whose per-second rates
+// follow them. A spreadsheet wants a fixed set of columns, unlike JSON
+// lines, which carry every stat.
+var (
+	csvStats = []string{"curr_connections", "curr_items", "bytes", "limit_maxbytes", "cmd_get", "cmd_set", "get_hits", "get_misses", "evictions"}
+	csvRates = []string{"cmd_get", "cmd_set", "get_hits", "get_misses", "evictions", "bytes_read", "bytes_written"}
+)
+
+// csvSink writes one CSV row per server and sample.
+type csvSink struct {
+	w      io.Writer
+	enc    *csv.Writer
+	header bool // whether the header row is still to be written
+}
+
+// newCSVSink writes rows to w, starting with a header unless w already holds
+// rows (appending to an existing file).
+func newCSVSink(w io.Writer, header bool) *csvSink {
+	return &csvSink{w: w, enc: csv.NewWriter(w), header: header}
+}
+
+// csvHeader names the columns of every row, leaving out the stats filter
+// does not match.
+func csvHeader(filter *regexp.Regexp) []string {
+	row := []string{"timestamp", "server", "up"}
+	row = append(row, filterStatNames(csvStats, filter)...)
+	for _, key := range filterStatNames(csvRates, filter) {
+		row = append(row, key+"_per_sec")
+	}
+	return row
+}
+
+func (c *csvSink) write(u *ui) error {
+	if c.header {
+		if err := c.enc.Write(csvHeader(u.metrics)); err != nil {
+			return err
+		}
+		c.header = false
+	}
+	for _, rec := range sampleRecords(u) {
+		row := []string{rec.Timestamp.UTC().Format(time.RFC3339Nano), rec.Server, strconv.FormatBool(rec.Up)}
+		for _, key := range filterStatNames(csvStats, u.metrics) {
+			if _, ok := rec.Values[key]; ok {
+				row = append(row, rec.formatValue(key))
+			} else {
+				row = append(row, "")
+			}
+		}
+		for _, key := range filterStatNames(csvRates, u.metrics) {
+			if rate, ok := rec.Rates[key]; ok {
+				row = append(row, strconv.FormatFloat(rate, 'f', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if err := c.enc.Write(row); err != nil {
+			return err
+		}
+	}
+	c.enc.Flush()
+	if err := c.enc.Error(); err != nil {
+		return err
+	}
+	if f, ok := c.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// graphiteSink sends every stat and rate to Graphite's plaintext protocol,
+// as <prefix>.<server>.<stat> and <prefix>.<server>.rate.<stat>. The
+// connection is kept between passes and redialed after a failure.
+type graphiteSink struct {
+	addr   string
+	prefix string
+	conn   net.Conn
+}
+
+func newGraphiteSink(addr, prefix string) *graphiteSink {
+	return &graphiteSink{addr: addr, prefix: prefix}
+}
+
+// graphiteName makes s safe as one dot-separated path element.
+func graphiteName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', ' ', '/':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// graphiteLines renders records in the plaintext protocol, sorted so each
+// pass sends the same series in the same order.
+func graphiteLines(prefix string, records []sampleRecord) []byte {
+	var buf bytes.Buffer
+	for _, rec := range records {
+		base := prefix + "." + graphiteName(rec.Server)
+		ts := rec.Timestamp.Unix()
+		up := 0
+		if rec.Up {
+			up = 1
+		}
+		fmt.Fprintf(&buf, "%s.up %d %d\n", base, up, ts)
+		for _, key := range slices.Sorted(maps.Keys(rec.Values)) {
+			fmt.Fprintf(&buf, "%s.%s %s %d\n", base, graphiteName(key), rec.formatValue(key), ts)
+		}
+		for _, key := range slices.Sorted(maps.Keys(rec.Rates)) {
+			fmt.Fprintf(&buf, "%s.rate.%s %s %d\n", base, graphiteName(key), strconv.FormatFloat(rec.Rates[key], 'f', -1, 64), ts)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (g *graphiteSink) write(u *ui) error {
+	if g.conn == nil {
+		conn, err := net.DialTimeout("tcp", g.addr, defaultTimeout)
+		if err != nil {
+			return err
+		}
+		g.conn = conn
+	}
+	g.conn.SetWriteDeadline(time.Now().Add(defaultTimeout))
+	if _, err := g.conn.Write(graphiteLines(g.prefix, sampleRecords(u))); err != nil {
+		g.conn.Close()
+		g.conn = nil
+		return err
+	}
+	return nil
+}
+
+// webhookSink POSTs every pass to a URL as a JSON array with one record per
+// server, the same records -jsonl writes.
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{url: url, client: &http.Client{Timeout: defaultTimeout}}
+}
+
+func (h *webhookSink) write(u *ui) error {
+	body, err := json.Marshal(sampleRecords(u))
+	if err != nil {
+		return err
+	}
+	resp, err := h.client.Post(h.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s answered %s", h.url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSink counts writes and fails while err is set.
+type fakeSink struct {
+	writes int
+	err    error
+}
+
+func (f *fakeSink) write(u *ui) error {
+	f.writes++
+	return f.err
+}
+
+func TestSinkSetKeepsFeedingOtherSinks(t *testing.T) {
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	broken := &fakeSink{err: errors.New("disk full")}
+	healthy := &fakeSink{}
+	u.sinks.add("CSV", broken)
+	u.sinks.add("webhook", healthy)
+
+	u.sinks.write(u)
+	u.sinks.write(u)
+	broken.err = nil
+	u.sinks.write(u)
+
+	if broken.writes != 3 || healthy.writes != 3 {
+		t.Fatalf("writes = %d and %d, want every sink fed every pass", broken.writes, healthy.writes)
+	}
+	var messages []string
+	for _, e := range u.events.recent(10) {
+		messages = append(messages, e.Message)
+	}
+	if len(messages) != 2 || messages[0] != "CSV output recovered" || !strings.Contains(messages[1], "CSV output failing: disk full") {
+		t.Fatalf("events = %q, want one failure and one recovery", messages)
+	}
+}
+
+func sinkTestUI() *ui {
+	s := newSession("10.0.0.1:11211", time.Second)
+	s.current = &statsSnapshot{
+		Timestamp: time.Unix(1700000000, 0),
+		Values:    map[string]float64{"curr_items": 7, "cmd_get": 42},
+		Counters:  map[string]uint64{"curr_items": 7, "cmd_get": 42},
+	}
+	s.rates = map[string]float64{"cmd_get": 2.5}
+	return newUI(time.Second, nil, s)
+}
+
+func TestCSVSinkWritesHeaderOnce(t *testing.T) {
+	u := sinkTestUI()
+	var buf bytes.Buffer
+	c := newCSVSink(&buf, true)
+	c.write(u)
+	c.write(u)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "timestamp,server,up,curr_connections") {
+		t.Fatalf("output = %q, want a header and two rows", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "2023-11-14T22:13:20Z,10.0.0.1:11211,true,,7,") || !strings.Contains(lines[1], ",2.5,") {
+		t.Fatalf("row = %q", lines[1])
+	}
+}
+
+func TestGraphiteLines(t *testing.T) {
+	got := string(graphiteLines("memtop", sampleRecords(sinkTestUI())))
+	want := "memtop.10_0_0_1_11211.up 1 1700000000\n" +
+		"memtop.10_0_0_1_11211.cmd_get 42 1700000000\n" +
+		"memtop.10_0_0_1_11211.curr_items 7 1700000000\n" +
+		"memtop.10_0_0_1_11211.rate.cmd_get 2.5 1700000000\n"
+	if got != want {
+		t.Fatalf("lines =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWebhookSinkPostsRecords(t *testing.T) {
+	var got []sampleRecord
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	if err := newWebhookSink(srv.URL).write(sinkTestUI()); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if len(got) != 1 || got[0].Server != "10.0.0.1:11211" || got[0].Values["cmd_get"] != 42 {
+		t.Fatalf("posted %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	if err := newWebhookSink(failing.URL).write(sinkTestUI()); err == nil {
+		t.Fatalf("a 502 answer was not reported")
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// slabSample pairs `stats slabs` and `stats items` readings taken together so
+// per-class memory layout and eviction counters line up.
+type slabSample struct {
+	Slabs *statsSnapshot
+	Items *statsSnapshot
+}
+
+// slabClass summarises one slab class for the heatmap and table.
+type slabClass struct {
+	ID          int
+	ChunkSize   float64
+	TotalPages  float64
+	TotalChunks float64
+	UsedChunks  float64
+	Evicted     float64
+	EvictRate   float64
+}
+
+// usedPercent reports how many of the class's chunks hold items.
+func (c slabClass) usedPercent() float64 {
+	if c.TotalChunks <= 0 {
+		return 0
+	}
+	return c.UsedChunks / c.TotalChunks * 100
+}
+
+// heatmapMetric selects what the slab heatmap colours by.
+type heatmapMetric int
+
+const (
+	heatmapUsed heatmapMetric = iota
+	heatmapEvictions
+	heatmapMetricCount
+)
+
+// fetchSlabs collects the slab and item sub-reports in one pass.
+func fetchSlabs(ctx context.Context, addr string) (*slabSample, error) {
+	slabs, err := fetchStatsSection(ctx, addr, "slabs")
+	if err != nil {
+		return nil, err
+	}
+	items, err := fetchStatsSection(ctx, addr, "items")
+	if err != nil {
+		return nil, err
+	}
+	return &slabSample{Slabs: slabs, Items: items}, nil
+}
+
+// parseSlabClasses extracts per-class figures from the `N:field` keys of
+// `stats slabs` and the `items:N:field` keys of `stats items`, ordered by
+// class id.
+func parseSlabClasses(sample *slabSample, itemRates map[string]float64) []slabClass {
+	if sample == nil || sample.Slabs == nil {
+		return nil
+	}
+	byID := make(map[int]*slabClass)
+	class := func(id int) *slabClass {
+		c, ok := byID[id]
+		if !ok {
+			c = &slabClass{ID: id}
+			byID[id] = c
+		}
+		return c
+	}
+
+	for key, value := range sample.Slabs.Values {
+		idPart, field, ok := strings.Cut(key, ":")
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(idPart)
+		if err != nil {
+			continue
+		}
+		c := class(id)
+		switch field {
+		case "chunk_size":
+			c.ChunkSize = value
+		case "total_pages":
+			c.TotalPages = value
+		case "total_chunks":
+			c.TotalChunks = value
+		case "used_chunks":
+			c.UsedChunks = value
+		}
+	}
+
+	if sample.Items != nil {
+		for key, value := range sample.Items.Values {
+			id, field, ok := parseItemsKey(key)
+			if !ok || field != "evicted" {
+				continue
+			}
+			c, known := byID[id]
+			if !known {
+				continue
+			}
+			c.Evicted = value
+			c.EvictRate = rateValue(itemRates, key)
+		}
+	}
+
+	classes := make([]slabClass, 0, len(byID))
+	for _, c := range byID {
+		classes = append(classes, *c)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i].ID < classes[j].ID })
+	return classes
+}
+
+// parseItemsKey splits an `items:N:field` key into its class id and field.
+func parseItemsKey(key string) (int, string, bool) {
+	rest, ok := strings.CutPrefix(key, "items:")
+	if !ok {
+		return 0, "", false
+	}
+	idPart, field, ok := strings.Cut(rest, ":")
+	if !ok {
+		return 0, "", false
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil {
+		return 0, "", false
+	}
+	return id, field, true
+}
+
+// heatIntensities normalises each class's heatmap metric to 0-1. Used chunks
+// are already a percentage; eviction rates are scaled against the busiest
+// class so the hottest one always stands out.
+func heatIntensities(classes []slabClass, metric heatmapMetric) ([]float64, float64) {
+	out := make([]float64, len(classes))
+	if metric == heatmapEvictions {
+		peak := 0.0
+		for _, c := range classes {
+			if c.EvictRate > peak {
+				peak = c.EvictRate
+			}
+		}
+		if peak > 0 {
+			for i, c := range classes {
+				out[i] = c.EvictRate / peak
+			}
+		}
+		return out, peak
+	}
+	for i, c := range classes {
+		out[i] = c.usedPercent() / 100
+	}
+	return out, 100
+}
+
+// drawSlabsView renders the slab heatmap followed by a per-class table.
+func drawSlabsView(screen tcell.Screen, line int, u *ui) {
+	s := u.current()
+	width, height := screen.Size()
+	baseStyle := tcell.StyleDefault
+	bold := baseStyle.Bold(true)
+
+	if s.slabErr != nil {
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("Slab stats error: %v", s.slabErr))
+		line += 2
+	}
+	if s.slabs == nil {
+		if s.slabErr == nil {
+			drawText(screen, 0, line, baseStyle, "Waiting for slab stats...")
+		}
+		return
+	}
+
+	classes := parseSlabClasses(s.slabs, s.itemRates)
+	nf := numberFormat{"slabs"}
+	metricName := "chunks used %"
+	if u.heatmap == heatmapEvictions {
+		metricName = "evictions" + rateUnit(s.interval)
+	}
+	drawText(screen, 0, line, baseStyle, fmt.Sprintf("Slab classes: %d  active %s  total malloced %s   heatmap: %s (m to toggle)",
+		len(classes),
+		nf.count("active_slabs", s.slabs.Slabs.Values["active_slabs"]),
+		formatBytes(s.slabs.Slabs.Values["total_malloced"]),
+		metricName,
+	))
+	line++
+	if m, ok := estimateOverhead(s.current, s.slabs, classes); ok {
+		drawText(screen, 0, line, baseStyle, describeOverhead(m))
+	}
+	line += 2
+	if len(classes) == 0 {
+		drawText(screen, 0, line, baseStyle, "No slab classes allocated yet.")
+		return
+	}
+
+	intensities, peak := heatIntensities(classes, u.heatmap)
+	cellWidth := width / len(classes)
+	if cellWidth < 1 {
+		cellWidth = 1
+	}
+	if cellWidth > 4 {
+		cellWidth = 4
+	}
+	const heatRows = 2
+	nextLabel := 0
+	for i, c := range classes {
+		x := i * cellWidth
+		if x+cellWidth > width {
+			break
+		}
+		cell := heatStep(intensities[i])
+		for row := 0; row < heatRows; row++ {
+			drawText(screen, x, line+row, cell.Style, strings.Repeat(string(cell.Fill), cellWidth))
+		}
+		if label := strconv.Itoa(c.ID); x >= nextLabel {
+			drawText(screen, x, line+heatRows, baseStyle, label)
+			nextLabel = x + len(label) + 1
+		}
+	}
+	line += heatRows + 1
+
+	legendLow, legendHigh := "0%", "100%"
+	if u.heatmap == heatmapEvictions {
+		legendLow, legendHigh = "0"+rateUnit(s.interval), nf.rate("evictions", displayRate(peak, s.interval))+rateUnit(s.interval)
+	}
+	drawText(screen, 0, line, baseStyle, legendLow+" ")
+	x := len(legendLow) + 1
+	for _, cell := range theme.heat {
+		drawText(screen, x, line, cell.Style, strings.Repeat(string(cell.Fill), 2))
+		x += 2
+	}
+	drawText(screen, x+1, line, baseStyle, legendHigh)
+	line += 2
+
+	if moves := adviseMoves(classes, s.evictStreak); len(moves) > 0 {
+		for i, m := range moves {
+			text := "Automove advice: " + m.String()
+			if i == 0 && u.admin {
+				text += " (a to apply)"
+			}
+			drawText(screen, 0, line, theme.warn, text)
+			line++
+		}
+		line++
+	}
+
+	drawText(screen, 0, line, bold, fmt.Sprintf("%5s %10s %6s %21s %7s %10s", "Class", "Chunk", "Pages", "Used/Total chunks", "Used%", "Evict"+rateUnit(s.interval)))
+	line++
+	for _, c := range classes {
+		if line >= height-1 {
+			break
+		}
+		drawText(screen, 0, line, baseStyle, fmt.Sprintf("%5d %10s %6s %21s %6s%% %10s",
+			c.ID,
+			formatBytes(c.ChunkSize),
+			nf.count("total_pages", c.TotalPages),
+			nf.count("used_chunks", c.UsedChunks)+"/"+nf.count("total_chunks", c.TotalChunks),
+			nf.percent("used_chunks", c.usedPercent()),
+			nf.rate("evictions", displayRate(c.EvictRate, s.interval)),
+		))
+		line++
+	}
+}
+
+// maxEvictingClasses bounds the slab classes named on the summary's
+// eviction line.
+const maxEvictingClasses = 3
+
+// topEvictingClasses returns the classes evicting this interval, busiest
+// first, at most n of them.
+func topEvictingClasses(classes []slabClass, n int) []slabClass {
+	var out []slabClass
+	for _, c := range classes {
+		if c.EvictRate > 0 {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].EvictRate > out[j].EvictRate })
+	return out[:min(n, len(out))]
+}
+
+// describeEvictions names the slab classes behind the server's evictions,
+// for the summary view, or returns "" while the server isn't evicting.
+// The per-class rates need two slab samples, which the slabs collector
+// takes while the summary shows evictions.
+func describeEvictions(s *session) string {
+	if s.current == nil || rateValue(s.rates, "evictions") <= 0 {
+		return ""
+	}
+	top := topEvictingClasses(parseSlabClasses(s.slabs, s.itemRates), maxEvictingClasses)
+	if len(top) == 0 {
+		return "Evicting from: waiting for slab stats..."
+	}
+	nf := numberFormat{"summary"}
+	parts := make([]string, len(top))
+	for i, c := range top {
+		parts[i] = fmt.Sprintf("class %d (%s chunks) %s%s", c.ID, formatBytes(c.ChunkSize),
+			nf.rate("evictions", displayRate(c.EvictRate, s.interval)), rateUnit(s.interval))
+	}
+	return "Evicting from: " + strings.Join(parts, ", ")
+}
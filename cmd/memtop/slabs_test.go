@@ -0,0 +1,168 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func testSlabSample() *slabSample {
+	return &slabSample{
+		Slabs: &statsSnapshot{
+			Timestamp: time.Now(),
+			Values: map[string]float64{
+				"1:chunk_size":   96,
+				"1:total_pages":  1,
+				"1:total_chunks": 100,
+				"1:used_chunks":  25,
+				"5:chunk_size":   240,
+				"5:total_pages":  2,
+				"5:total_chunks": 200,
+				"5:used_chunks":  200,
+				"active_slabs":   2,
+				"total_malloced": 3 * 1024 * 1024,
+			},
+		},
+		Items: &statsSnapshot{
+			Timestamp: time.Now(),
+			Values: map[string]float64{
+				"items:1:number":  25,
+				"items:1:evicted": 0,
+				"items:5:number":  200,
+				"items:5:evicted": 40,
+				"items:9:evicted": 3, // class without slab data is ignored
+			},
+		},
+	}
+}
+
+func TestParseSlabClasses(t *testing.T) {
+	classes := parseSlabClasses(testSlabSample(), map[string]float64{"items:5:evicted": 4})
+	if len(classes) != 2 {
+		t.Fatalf("expected 2 classes, got %d: %+v", len(classes), classes)
+	}
+	if classes[0].ID != 1 || classes[1].ID != 5 {
+		t.Fatalf("classes should be sorted by id, got %d and %d", classes[0].ID, classes[1].ID)
+	}
+	if got := classes[0].usedPercent(); got != 25 {
+		t.Fatalf("class 1 used%% = %.1f, want 25", got)
+	}
+	if classes[1].Evicted != 40 || classes[1].EvictRate != 4 {
+		t.Fatalf("class 5 evictions = %.0f (%.1f/s), want 40 (4/s)", classes[1].Evicted, classes[1].EvictRate)
+	}
+}
+
+func TestDescribeEvictionsNamesTheEvictingClasses(t *testing.T) {
+	s := newSession("a:11211", time.Second)
+	s.current = &statsSnapshot{Values: map[string]float64{"evictions": 40}}
+	if got := describeEvictions(s); got != "" {
+		t.Fatalf("no eviction rate, got %q", got)
+	}
+	s.rates = map[string]float64{"evictions": 5}
+	if got := describeEvictions(s); !strings.Contains(got, "waiting") {
+		t.Fatalf("no slab sample yet, got %q", got)
+	}
+	s.slabs = testSlabSample()
+	s.itemRates = map[string]float64{"items:5:evicted": 4, "items:1:evicted": 1}
+	want := "Evicting from: class 5 (240 B chunks) 4.00/s, class 1 (96 B chunks) 1.00/s"
+	if got := describeEvictions(s); got != want {
+		t.Fatalf("describeEvictions = %q, want %q", got, want)
+	}
+}
+
+func TestHeatIntensities(t *testing.T) {
+	classes := []slabClass{
+		{ID: 1, TotalChunks: 10, UsedChunks: 5, EvictRate: 1},
+		{ID: 2, TotalChunks: 10, UsedChunks: 10, EvictRate: 4},
+	}
+	used, _ := heatIntensities(classes, heatmapUsed)
+	if used[0] != 0.5 || used[1] != 1 {
+		t.Fatalf("used intensities = %v, want [0.5 1]", used)
+	}
+	evictions, peak := heatIntensities(classes, heatmapEvictions)
+	if peak != 4 || evictions[0] != 0.25 || evictions[1] != 1 {
+		t.Fatalf("eviction intensities = %v (peak %.1f), want [0.25 1] (peak 4)", evictions, peak)
+	}
+	if heatStep(0) != theme.heat[0] || heatStep(1) != theme.heat[len(theme.heat)-1] {
+		t.Fatalf("heatStep should span the palette")
+	}
+}
+
+func TestDrawSlabsViewRendersTable(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 20)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.recordSlabs(testSlabSample(), nil)
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewSlabs
+	drawScreen(screen, u)
+
+	cells, width, height := screen.GetContents()
+	var all []string
+	for row := 0; row < height; row++ {
+		all = append(all, lineFromCells(cells, width, row))
+	}
+	text := strings.Join(all, "\n")
+	if !strings.Contains(text, "Slab classes: 2") {
+		t.Fatalf("slab header missing:\n%s", text)
+	}
+	if !strings.Contains(text, "25/100") || !strings.Contains(text, "200/200") {
+		t.Fatalf("slab table rows missing:\n%s", text)
+	}
+	if !strings.Contains(all[height-1], "2 [slabs]") {
+		t.Fatalf("footer should mark the active view, got %q", all[height-1])
+	}
+}
+
+func TestDrawSlabsViewMonoHeatmapFillsEachCell(t *testing.T) {
+	if err := setPalette("mono", false); err != nil {
+		t.Fatalf("setPalette: %v", err)
+	}
+	defer setPalette("default", false)
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 20)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.recordSlabs(testSlabSample(), nil)
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewSlabs
+	drawScreen(screen, u)
+
+	// Two classes at 25% and 100% used get four columns each.
+	used, _ := heatIntensities(parseSlabClasses(sess.slabs, sess.itemRates), heatmapUsed)
+	heat := strings.Repeat(string(heatStep(used[0]).Fill), 4) + strings.Repeat(string(heatStep(used[1]).Fill), 4)
+	legend := "0% "
+	for _, cell := range theme.heat {
+		legend += strings.Repeat(string(cell.Fill), 2)
+	}
+	legend += " 100%"
+
+	cells, width, height := screen.GetContents()
+	var all []string
+	heatRows := 0
+	for row := 0; row < height; row++ {
+		line := lineFromCells(cells, width, row)
+		if strings.TrimRight(line, " ") == heat {
+			heatRows++
+		}
+		all = append(all, line)
+	}
+	text := strings.Join(all, "\n")
+	if heatRows != 2 {
+		t.Fatalf("heatmap rows should be exactly %q:\n%s", heat, text)
+	}
+	if !strings.Contains(text, legend) {
+		t.Fatalf("legend swatches should sit side by side %q:\n%s", legend, text)
+	}
+}
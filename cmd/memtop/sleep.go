@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// minSleepGap is the shortest system sleep worth re-baselining for; shorter
+// gaps are left to the uptime-based rate correction.
+const minSleepGap = 5 * time.Second
+
+// detectSleep notices the system having slept since the last sampling pass,
+// given the current monotonic and wall clock readings. The monotonic clock
+// stops while a laptop is suspended but the wall clock does not, so the
+// difference is the time slept. Counters kept moving on the server in the
+// meantime, so rates are re-baselined on every server and the gap is
+// recorded in the event log, instead of showing one huge or zero rate after
+// wakeup.
+func (u *ui) detectSleep(mono, wall time.Time) {
+	lastMono, lastWall := u.lastPass, u.lastPassWall
+	u.lastPass, u.lastPassWall = mono, wall
+	if lastMono.IsZero() {
+		return
+	}
+	slept := wall.Sub(lastWall) - mono.Sub(lastMono)
+	if slept <= max(u.interval, minSleepGap) {
+		return
+	}
+	for _, s := range u.servers {
+		s.resetRates()
+	}
+	u.events.add(event{Time: wall, Kind: eventSleep, Message: fmt.Sprintf("system slept for about %s; rates re-baselined", slept.Round(time.Second))})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetectSleepRebaselinesRates(t *testing.T) {
+	s := newSession("a:11211", 2*time.Second)
+	s.prev = &statsSnapshot{}
+	s.rates = map[string]float64{"cmd_get": 10}
+	u := newUI(2*time.Second, nil, s)
+
+	mono := time.Now()
+	wall := mono.Round(0)
+	u.detectSleep(mono, wall)
+	// An ordinary tick, and a slow pass that moved both clocks alike.
+	u.detectSleep(mono.Add(2*time.Second), wall.Add(2*time.Second))
+	u.detectSleep(mono.Add(time.Minute), wall.Add(time.Minute))
+	if s.prev == nil || len(u.events.recent(10)) != 0 {
+		t.Fatalf("rates reset without a sleep: events %+v", u.events.recent(10))
+	}
+
+	// Ten minutes asleep: the wall clock moved on, the monotonic one didn't.
+	u.detectSleep(mono.Add(time.Minute+2*time.Second), wall.Add(11*time.Minute+2*time.Second))
+	if s.prev != nil || len(s.rates) != 0 {
+		t.Fatalf("rates kept across the sleep: prev %v, rates %v", s.prev, s.rates)
+	}
+	events := u.events.recent(1)
+	if len(events) != 1 || events[0].Kind != eventSleep || !strings.Contains(events[0].Message, "10m0s") {
+		t.Fatalf("events = %+v, want one sleep of 10m0s", events)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHKeys are tried, in order, when -ssh-key is not given.
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sshTunnel dials servers through an SSH connection to a bastion host. The
+// SSH connection is opened on first use and re-opened after it drops, so a
+// bastion restart shows up as a few failed samples rather than a dead session.
+type sshTunnel struct {
+	addr    string
+	config  *ssh.ClientConfig
+	forward dialer
+
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+// parseSSHTarget splits `[user@]host[:port]`, defaulting to the local user
+// and port 22.
+func parseSSHTarget(target string) (string, string, error) {
+	login, hostPort, ok := strings.Cut(target, "@")
+	if !ok {
+		hostPort = login
+		login = ""
+	}
+	if login == "" {
+		if current, err := user.Current(); err == nil {
+			login = current.Username
+		}
+	}
+	if hostPort == "" {
+		return "", "", fmt.Errorf("ssh target %q has no host", target)
+	}
+	if _, _, err := net.SplitHostPort(hostPort); err != nil {
+		hostPort = net.JoinHostPort(strings.Trim(hostPort, "[]"), "22")
+	}
+	return login, hostPort, nil
+}
+
+// newSSHTunnel prepares a tunnel through target, authenticating with the SSH
+// agent and/or private keys and verifying the bastion against known_hosts.
+// The bastion itself is reached with forward, so a proxy can sit in front.
+func newSSHTunnel(target, keyPath, knownHostsPath string, forward dialer) (*sshTunnel, error) {
+	login, addr, err := parseSSHTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := sshAuthMethods(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	if knownHostsPath == "" {
+		knownHostsPath = sshHomePath("known_hosts")
+	}
+	hostKeys, err := knownhosts.New(knownHostsPath)
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts: %w", err)
+	}
+	return &sshTunnel{
+		addr: addr,
+		config: &ssh.ClientConfig{
+			User:            login,
+			Auth:            auth,
+			HostKeyCallback: hostKeys,
+		},
+		forward: forward,
+	}, nil
+}
+
+// Dial opens a connection to addr from the bastion's side of the tunnel,
+// giving up after defaultTimeout.
+func (t *sshTunnel) Dial(network, addr string) (net.Conn, error) {
+	return t.DialContext(context.Background(), network, addr)
+}
+
+// DialContext opens a connection to addr from the bastion's side of the
+// tunnel. The lock is only held to get the SSH connection; the channel is
+// opened outside it, so one slow server doesn't queue every other poll
+// behind it. The attempt is bounded by ctx, or by defaultTimeout when ctx
+// has no deadline. A channel the bastion never answers can't be abandoned
+// on its own, so the SSH connection is closed and the next attempt
+// reconnects.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+	client, err := t.clientFor(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	conn, err := client.DialContext(ctx, network, addr)
+	if err != nil {
+		// A refused channel means the bastion is fine; anything else may
+		// mean it has gone away or stalled.
+		var refused *ssh.OpenChannelError
+		if !errors.As(err, &refused) {
+			t.drop(client)
+		}
+		return nil, fmt.Errorf("ssh %s: %w", t.addr, err)
+	}
+	return &deadlineConn{Conn: conn}, nil
+}
+
+// clientFor returns the SSH connection, opening it first if needed.
+func (t *sshTunnel) clientFor(ctx context.Context) (*ssh.Client, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == nil {
+		client, err := t.connect(ctx)
+		if err != nil {
+			return nil, err
+		}
+		t.client = client
+	}
+	return t.client, nil
+}
+
+// drop closes client and forgets it, unless another dial has already
+// replaced it.
+func (t *sshTunnel) drop(client *ssh.Client) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == client {
+		t.client = nil
+	}
+	client.Close()
+}
+
+// connect opens the SSH connection, bounding the handshake by ctx.
+func (t *sshTunnel) connect(ctx context.Context) (*ssh.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var conn net.Conn
+	var err error
+	if d, ok := t.forward.(contextDialer); ok {
+		conn, err = d.DialContext(ctx, "tcp", t.addr)
+	} else {
+		conn, err = t.forward.Dial("tcp", t.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(conn, t.addr, t.config)
+	if !stop() {
+		err = ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// Close shuts down the SSH connection, if open.
+func (t *sshTunnel) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.client == nil {
+		return nil
+	}
+	err := t.client.Close()
+	t.client = nil
+	return err
+}
+
+// sshAuthMethods offers the running SSH agent first, then private keys. An
+// explicit keyPath must be readable; the default keys are skipped when missing
+// or passphrase-protected, since the agent usually holds those.
+func sshAuthMethods(keyPath string) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+
+	paths := []string{keyPath}
+	if keyPath == "" {
+		paths = paths[:0]
+		for _, name := range defaultSSHKeys {
+			paths = append(paths, sshHomePath(name))
+		}
+	}
+	var signers []ssh.Signer
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var signer ssh.Signer
+			if signer, err = ssh.ParsePrivateKey(data); err == nil {
+				signers = append(signers, signer)
+				continue
+			}
+		}
+		if keyPath != "" {
+			var missing *ssh.PassphraseMissingError
+			if errors.As(err, &missing) {
+				return nil, fmt.Errorf("ssh key %s is passphrase-protected; add it to ssh-agent instead", path)
+			}
+			return nil, fmt.Errorf("ssh key %s: %w", path, err)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no SSH agent or usable private key found")
+	}
+	return methods, nil
+}
+
+// sshHomePath returns a file inside ~/.ssh.
+func sshHomePath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".ssh", name)
+	}
+	return filepath.Join(home, ".ssh", name)
+}
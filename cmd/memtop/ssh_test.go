@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestParseSSHTarget(t *testing.T) {
+	tests := map[string][2]string{
+		"ops@bastion":        {"ops", "bastion:22"},
+		"ops@bastion:2222":   {"ops", "bastion:2222"},
+		"ops@[2001:db8::1]":  {"ops", "[2001:db8::1]:22"},
+		"deploy@10.0.0.1:22": {"deploy", "10.0.0.1:22"},
+	}
+	for input, want := range tests {
+		login, addr, err := parseSSHTarget(input)
+		if err != nil || login != want[0] || addr != want[1] {
+			t.Errorf("parseSSHTarget(%q) = %q, %q, %v; want %q, %q", input, login, addr, err, want[0], want[1])
+		}
+	}
+	if _, _, err := parseSSHTarget("ops@"); err == nil {
+		t.Errorf("expected an error for a target without host")
+	}
+}
+
+func TestDeadlineConnClosesWhenExpired(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	conn := &deadlineConn{Conn: client}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(20 * time.Millisecond))
+	done := make(chan error, 1)
+	go func() {
+		_, err := conn.Read(make([]byte, 1))
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("read should fail once the deadline passes")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("deadline did not interrupt the read")
+	}
+}
+
+// startSSHServer runs a minimal SSH server that accepts clientKey and forwards
+// direct-tcpip channels, like a bastion with port forwarding enabled.
+// Channels to stall.invalid are never answered, like a bastion that hangs.
+func startSSHServer(t *testing.T, hostKey ssh.Signer, clientKey ssh.PublicKey) net.Listener {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientKey.Marshal()) {
+				return nil, fmt.Errorf("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		for {
+			raw, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, channels, requests, err := ssh.NewServerConn(raw, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for ch := range channels {
+					var target struct {
+						Host     string
+						Port     uint32
+						OrigHost string
+						OrigPort uint32
+					}
+					if ch.ChannelType() != "direct-tcpip" || ssh.Unmarshal(ch.ExtraData(), &target) != nil {
+						ch.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					if target.Host == "stall.invalid" {
+						continue
+					}
+					upstream, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+					if err != nil {
+						ch.Reject(ssh.ConnectionFailed, err.Error())
+						continue
+					}
+					channel, reqs, err := ch.Accept()
+					if err != nil {
+						upstream.Close()
+						continue
+					}
+					go ssh.DiscardRequests(reqs)
+					go func() {
+						io.Copy(channel, upstream)
+						channel.Close()
+					}()
+					go func() {
+						io.Copy(upstream, channel)
+						upstream.Close()
+					}()
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestFetchStatsThroughSSHTunnel(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	dir := t.TempDir()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, _ := ssh.NewSignerFromKey(hostPriv)
+	_, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	clientKey, _ := ssh.NewSignerFromKey(clientPriv)
+
+	bastion := startSSHServer(t, hostKey, clientKey.PublicKey())
+	defer bastion.Close()
+
+	block, err := ssh.MarshalPrivateKey(clientPriv, "")
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	keyPath := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600)
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	os.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{bastion.Addr().String()}, hostKey.PublicKey())+"\n"), 0o600)
+
+	memcached, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer memcached.Close()
+	go func() {
+		conn, err := memcached.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Read(make([]byte, 64))
+		fmt.Fprint(conn, "STAT curr_items 7\r\nEND\r\n")
+	}()
+
+	tunnel, err := newSSHTunnel("tester@"+bastion.Addr().String(), keyPath, knownHostsPath, serverDialer)
+	if err != nil {
+		t.Fatalf("newSSHTunnel: %v", err)
+	}
+	defer tunnel.Close()
+	previous := serverDialer
+	serverDialer = tunnel
+	defer func() { serverDialer = previous }()
+
+	snapshot, err := fetchStats(context.Background(), memcached.Addr().String())
+	if err != nil {
+		t.Fatalf("fetchStats through tunnel: %v", err)
+	}
+	if got := snapshot.Values["curr_items"]; got != 7 {
+		t.Fatalf("curr_items = %.0f, want 7", got)
+	}
+}
+
+func TestSSHTunnelRejectsUnknownHostKey(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	dir := t.TempDir()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, _ := ssh.NewSignerFromKey(hostPriv)
+	_, otherPriv, _ := ed25519.GenerateKey(rand.Reader)
+	otherKey, _ := ssh.NewSignerFromKey(otherPriv)
+	_, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	clientKey, _ := ssh.NewSignerFromKey(clientPriv)
+
+	bastion := startSSHServer(t, hostKey, clientKey.PublicKey())
+	defer bastion.Close()
+
+	block, _ := ssh.MarshalPrivateKey(clientPriv, "")
+	keyPath := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600)
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	os.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{bastion.Addr().String()}, otherKey.PublicKey())+"\n"), 0o600)
+
+	tunnel, err := newSSHTunnel("tester@"+bastion.Addr().String(), keyPath, knownHostsPath, serverDialer)
+	if err != nil {
+		t.Fatalf("newSSHTunnel: %v", err)
+	}
+	defer tunnel.Close()
+	if _, err := tunnel.Dial("tcp", "127.0.0.1:11211"); err == nil {
+		t.Fatalf("dial should fail when the bastion's host key does not match known_hosts")
+	}
+}
+
+func TestSSHTunnelStalledChannelDoesNotBlockOtherDials(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	dir := t.TempDir()
+
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostKey, _ := ssh.NewSignerFromKey(hostPriv)
+	_, clientPriv, _ := ed25519.GenerateKey(rand.Reader)
+	clientKey, _ := ssh.NewSignerFromKey(clientPriv)
+
+	bastion := startSSHServer(t, hostKey, clientKey.PublicKey())
+	defer bastion.Close()
+
+	block, _ := ssh.MarshalPrivateKey(clientPriv, "")
+	keyPath := filepath.Join(dir, "id_ed25519")
+	os.WriteFile(keyPath, pem.EncodeToMemory(block), 0o600)
+	knownHostsPath := filepath.Join(dir, "known_hosts")
+	os.WriteFile(knownHostsPath, []byte(knownhosts.Line([]string{bastion.Addr().String()}, hostKey.PublicKey())+"\n"), 0o600)
+
+	memcached, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer memcached.Close()
+
+	tunnel, err := newSSHTunnel("tester@"+bastion.Addr().String(), keyPath, knownHostsPath, serverDialer)
+	if err != nil {
+		t.Fatalf("newSSHTunnel: %v", err)
+	}
+	defer tunnel.Close()
+	// Open the SSH connection up front so both dials below share it.
+	if _, err := tunnel.clientFor(context.Background()); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stalled := make(chan error, 1)
+	go func() {
+		_, err := tunnel.DialContext(ctx, "tcp", "stall.invalid:11211")
+		stalled <- err
+	}()
+	// Give the stalled channel open time to be sent first.
+	time.Sleep(50 * time.Millisecond)
+
+	dialCtx, dialCancel := context.WithTimeout(context.Background(), time.Second)
+	defer dialCancel()
+	conn, err := tunnel.DialContext(dialCtx, "tcp", memcached.Addr().String())
+	if err != nil {
+		t.Fatalf("dial should not wait for the stalled channel: %v", err)
+	}
+	conn.Close()
+
+	cancel()
+	select {
+	case err := <-stalled:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("stalled dial error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("cancelling ctx did not abandon the stalled dial")
+	}
+	tunnel.mu.Lock()
+	defer tunnel.mu.Unlock()
+	if tunnel.client != nil {
+		t.Fatalf("the stalled SSH connection should be dropped")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxRelatedStats bounds the related stats listed in the detail popup.
+const maxRelatedStats = 6
+
+// statRange is the lowest and highest value a stat had this session.
+type statRange struct {
+	min, max float64
+}
+
+// relatedStats names stats worth reading together that share no prefix.
+var relatedStats = map[string][]string{
+	"cmd_get":          {"get_hits", "get_misses", "get_expired"},
+	"get_hits":         {"cmd_get"},
+	"get_misses":       {"cmd_get"},
+	"evictions":        {"bytes", "limit_maxbytes", "reclaimed", "expired_unfetched"},
+	"bytes":            {"limit_maxbytes", "curr_items", "evictions"},
+	"limit_maxbytes":   {"bytes"},
+	"curr_connections": {"max_connections", "rejected_connections", "listen_disabled_num"},
+	"max_connections":  {"curr_connections"},
+	"curr_items":       {"total_items", "bytes"},
+}
+
+// observeValues extends each numeric stat's session range and recent values,
+// for the detail popup.
+func (s *session) observeValues(stats *statsSnapshot) {
+	if s.ranges == nil {
+		s.ranges = make(map[string]statRange)
+		s.recent = make(map[string][]float64)
+	}
+	for key, v := range stats.Values {
+		r, ok := s.ranges[key]
+		if !ok {
+			r = statRange{v, v}
+		}
+		s.ranges[key] = statRange{min(r.min, v), max(r.max, v)}
+		s.recent[key] = appendBounded(s.recent[key], v, defaultAnomalyWindow)
+	}
+}
+
+// related lists the stats shown next to key: the curated ones first, then
+// those sharing its first word, as get_hits does with get_misses.
+func (s *session) related(key string) []string {
+	var out []string
+	seen := map[string]bool{key: true}
+	add := func(k string) {
+		if _, ok := s.current.Raw[k]; ok && !seen[k] && len(out) < maxRelatedStats {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	for _, k := range relatedStats[key] {
+		add(k)
+	}
+	if prefix, _, ok := strings.Cut(key, "_"); ok {
+		var siblings []string
+		for k := range s.current.Raw {
+			if strings.HasPrefix(k, prefix+"_") {
+				siblings = append(siblings, k)
+			}
+		}
+		sort.Strings(siblings)
+		for _, k := range siblings {
+			add(k)
+		}
+	}
+	return out
+}
+
+// statDetail is the popup opened with Enter on a stats table row.
+type statDetail struct {
+	key string
+}
+
+// handleKey reports whether the popup is finished.
+func (d *statDetail) handleKey(evt *tcell.EventKey) bool {
+	switch evt.Key() {
+	case tcell.KeyEnter, tcell.KeyEscape, tcell.KeyCtrlC:
+		return true
+	case tcell.KeyRune:
+		return evt.Rune() == 'q'
+	}
+	return false
+}
+
+// lines renders the stat's description and kind from the glossary, its
+// value with its session range, its rate, sparklines of both, and related
+// stats.
+func (d *statDetail) lines(s *session) []panelLine {
+	lines := []panelLine{{Text: d.key, Style: tcell.StyleDefault.Bold(true)}}
+	if info, ok := describeStat("", d.key); ok {
+		lines = append(lines, plainLine(info.Description), plainLine(fmt.Sprintf("%-7s %s", "Kind", info)))
+	} else {
+		lines = append(lines, plainLine("No description available."))
+	}
+	lines = append(lines, plainLine(""))
+	value := s.current.Raw[d.key]
+	if r, ok := s.ranges[d.key]; ok {
+		value += fmt.Sprintf("  (session min %s, max %s)", formatStatValue(r.min), formatStatValue(r.max))
+	}
+	lines = append(lines, plainLine(fmt.Sprintf("%-7s %s", "Value", value)))
+	if r, ok := s.rates[d.key]; ok {
+		lines = append(lines, plainLine(fmt.Sprintf("%-7s %s%s", "Rate", numberFormat{"detail"}.rate(d.key, displayRate(r, s.interval)), rateUnit(s.interval))))
+	}
+	if values := s.recent[d.key]; len(values) > 1 {
+		lines = append(lines, plainLine(fmt.Sprintf("%-7s %s", "History", sparkline(values, defaultAnomalyWindow))))
+	}
+	if s.anomalies != nil && len(s.anomalies.samples[d.key]) > 1 {
+		lines = append(lines, plainLine(fmt.Sprintf("%-7s %s", "Rates", sparkline(s.anomalies.samples[d.key], defaultAnomalyWindow))))
+	}
+	if related := s.related(d.key); len(related) > 0 {
+		lines = append(lines, plainLine(""), plainLine("Related:"))
+		for _, k := range related {
+			lines = append(lines, plainLine(fmt.Sprintf("  %-28s %s", k, s.current.Raw[k])))
+		}
+	}
+	return append(lines, plainLine(""), plainLine("Enter or Esc closes"))
+}
+
+// formatStatValue prints whole numbers without decimals.
+func formatStatValue(v float64) string {
+	if v == float64(int64(v)) {
+		return fmt.Sprintf("%d", int64(v))
+	}
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestStatDetailShowsRangeHistoryAndRelated(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	start := time.Now()
+	for i, gets := range []float64{100, 140, 200} {
+		sess.record(&statsSnapshot{
+			Timestamp: start.Add(time.Duration(i) * 2 * time.Second),
+			Values:    map[string]float64{"cmd_get": gets, "get_hits": gets - 10, "get_misses": 10, "get_flushed": 0, "curr_items": 50 - gets/10},
+			Raw: map[string]string{
+				"cmd_get": formatStatValue(gets), "get_hits": formatStatValue(gets - 10), "get_misses": "10",
+				"get_flushed": "0", "curr_items": formatStatValue(50 - gets/10),
+			},
+		}, nil)
+	}
+	if r := sess.ranges["curr_items"]; r != (statRange{30, 40}) {
+		t.Fatalf("curr_items range = %+v", r)
+	}
+	if got, want := sess.related("get_hits"), []string{"cmd_get", "get_flushed", "get_misses"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("related(get_hits) = %q, want %q", got, want)
+	}
+
+	text := ""
+	for _, l := range (&statDetail{key: "cmd_get"}).lines(sess) {
+		text += l.Text + "\n"
+	}
+	for _, want := range []string{
+		glossary["cmd_get"].Description,
+		"Kind    counter, requests",
+		"Value   200  (session min 100, max 200)",
+		"Rate    30.00/s",
+		"History ",
+		"  get_hits",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("detail lacks %q:\n%s", want, text)
+		}
+	}
+	sess.current.Raw["x_custom"] = "1"
+	if lines := (&statDetail{key: "x_custom"}).lines(sess); lines[1].Text != "No description available." {
+		t.Errorf("undescribed stat = %q", lines[1].Text)
+	}
+}
+
+func TestStatsViewCursorOpensDetail(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 24)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.record(&statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"cmd_get": 5, "evictions": 2},
+		Raw:       map[string]string{"cmd_get": "5", "evictions": "2", "version": "1.6.21"},
+	}, nil)
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	// Rows: Server heading, version, Commands heading, cmd_get, Memory
+	// heading, evictions.
+	for i := 0; i < 5; i++ {
+		if !scrollKey(tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone), &u.statsCursor, screen) {
+			t.Fatalf("Down should move the cursor")
+		}
+	}
+	drawScreen(screen, u)
+	cells, width, _ := screen.GetContents()
+	if _, _, attrs := cells[8*width].Style.Decompose(); attrs&tcell.AttrReverse == 0 {
+		t.Fatalf("cursor row not highlighted")
+	}
+
+	u.openStatDetail()
+	if u.detail == nil || u.detail.key != "evictions" {
+		t.Fatalf("detail = %+v, want evictions", u.detail)
+	}
+	drawScreen(screen, u)
+	cells, width, _ = screen.GetContents()
+	if line := lineFromCells(cells, width, 4); !strings.Contains(line, "| evictions") {
+		t.Fatalf("popup not drawn, got %q", line)
+	}
+	if !u.detail.handleKey(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)) {
+		t.Fatalf("Esc should close the popup")
+	}
+}
+
+func TestStatDetailDrawsSparklineOneColumnPerBar(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(100, 30)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	start := time.Now()
+	for i, gets := range []float64{100, 140, 200, 260, 300} {
+		sess.record(&statsSnapshot{
+			Timestamp: start.Add(time.Duration(i) * 2 * time.Second),
+			Values:    map[string]float64{"cmd_get": gets},
+			Raw:       map[string]string{"cmd_get": formatStatValue(gets)},
+		}, nil)
+	}
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	u.detail = &statDetail{key: "cmd_get"}
+	drawScreen(screen, u)
+
+	spark := sparkline(sess.recent["cmd_get"], defaultAnomalyWindow)
+	cells, width, height := screen.GetContents()
+	for row := 0; row < height; row++ {
+		line := lineFromCells(cells, width, row)
+		if !strings.Contains(line, "History") {
+			continue
+		}
+		if !strings.Contains(line, "History "+spark) {
+			t.Fatalf("sparkline %q not drawn in adjacent columns, got %q", spark, line)
+		}
+		if !strings.HasSuffix(strings.TrimRight(line, " "), " |") {
+			t.Fatalf("sparkline row overflows the popup border, got %q", line)
+		}
+		return
+	}
+	t.Fatalf("popup has no History row")
+}
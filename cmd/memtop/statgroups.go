@@ -0,0 +1,143 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// statGroups are the sections of the stats table, in order. A stat belongs
+// to the first group with a matching rule; what none claims is listed under
+// Server.
+var statGroups = []struct {
+	name  string
+	match func(key string) bool
+}{
+	{"Commands", func(k string) bool {
+		return hasAnyPrefix(k, "cmd_", "get_", "delete_", "incr_", "decr_", "cas_", "touch_", "store_", "auth_")
+	}},
+	{"Connections", func(k string) bool {
+		return strings.HasSuffix(k, "_connections") || hasAnyPrefix(k, "conn_", "connection_", "listen_disabled", "time_in_listen", "accepting_conns", "reserved_fds", "idle_kicks", "bytes_read", "bytes_written", "read_buf_", "response_obj_")
+	}},
+	{"Crawler", func(k string) bool {
+		return hasAnyPrefix(k, "lru_crawler_", "crawler_")
+	}},
+	{"LRU", func(k string) bool {
+		return hasAnyPrefix(k, "lru_", "moves_", "lrutail_", "direct_reclaims", "evictions_tail")
+	}},
+	{"Memory", func(k string) bool {
+		return hasAnyPrefix(k, "bytes", "limit_maxbytes", "curr_items", "total_items", "evict", "reclaimed", "expired_", "slab", "hash_", "malloc_", "total_malloced", "active_slabs")
+	}},
+	{"Extstore", func(k string) bool {
+		return hasAnyPrefix(k, "extstore_")
+	}},
+	{"Proxy", func(k string) bool {
+		return hasAnyPrefix(k, "proxy_")
+	}},
+}
+
+// otherStatGroup collects the stats no group claims: pid, uptime, version,
+// rusage, threads, and logging.
+const otherStatGroup = "Server"
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// statGroup names the section a stat is listed under.
+func statGroup(key string) string {
+	for _, g := range statGroups {
+		if g.match(key) {
+			return g.name
+		}
+	}
+	return otherStatGroup
+}
+
+// statRow is one line of the stats table: a group heading when key is
+// empty, otherwise a stat.
+type statRow struct {
+	group string
+	key   string
+	// count is the number of stats under a heading.
+	count int
+}
+
+// statRows lays the session's stats out under their group headings, server
+// information first, leaving out the stats of collapsed groups.
+func statRows(s *session, collapsed map[string]bool) []statRow {
+	members := make(map[string][]string)
+	for key := range s.current.Raw {
+		g := statGroup(key)
+		members[g] = append(members[g], key)
+	}
+	var rows []statRow
+	for _, g := range allStatGroups() {
+		keys := members[g]
+		if len(keys) == 0 {
+			continue
+		}
+		sort.Strings(keys)
+		rows = append(rows, statRow{group: g, count: len(keys)})
+		if collapsed[g] {
+			continue
+		}
+		for _, key := range keys {
+			rows = append(rows, statRow{group: g, key: key})
+		}
+	}
+	return rows
+}
+
+// setGroupCollapsed collapses or expands the group under the stats cursor,
+// leaving the cursor on its heading.
+func (u *ui) setGroupCollapsed(collapse bool) {
+	s := u.current()
+	if s.current == nil {
+		return
+	}
+	rows := statRows(s, u.collapsed)
+	if u.statsCursor >= len(rows) {
+		return
+	}
+	group := rows[u.statsCursor].group
+	if u.collapsed == nil {
+		u.collapsed = make(map[string]bool)
+	}
+	u.collapsed[group] = collapse
+	for i, r := range statRows(s, u.collapsed) {
+		if r.group == group && r.key == "" {
+			u.statsCursor = i
+			break
+		}
+	}
+}
+
+// toggleAllGroups collapses every group, or expands them all when every
+// one already is.
+func (u *ui) toggleAllGroups() {
+	all := true
+	for _, g := range allStatGroups() {
+		all = all && u.collapsed[g]
+	}
+	u.collapsed = make(map[string]bool)
+	if !all {
+		for _, g := range allStatGroups() {
+			u.collapsed[g] = true
+		}
+	}
+	u.statsCursor = 0
+}
+
+// allStatGroups names every group in table order.
+func allStatGroups() []string {
+	names := []string{otherStatGroup}
+	for _, g := range statGroups {
+		names = append(names, g.name)
+	}
+	return names
+}
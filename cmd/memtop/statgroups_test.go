@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatGroup(t *testing.T) {
+	for key, want := range map[string]string{
+		"cmd_get":                "Commands",
+		"get_hits":               "Commands",
+		"curr_connections":       "Connections",
+		"bytes_read":             "Connections",
+		"bytes":                  "Memory",
+		"evicted_unfetched":      "Memory",
+		"slab_reassign_running":  "Memory",
+		"lru_crawler_starts":     "Crawler",
+		"crawler_reclaimed":      "Crawler",
+		"lru_maintainer_juggles": "LRU",
+		"moves_to_cold":          "LRU",
+		"extstore_objects_read":  "Extstore",
+		"proxy_conn_requests":    "Proxy",
+		"uptime":                 "Server",
+		"version":                "Server",
+	} {
+		if got := statGroup(key); got != want {
+			t.Errorf("statGroup(%s) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestStatGroupsCollapse(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{Raw: map[string]string{"uptime": "5", "cmd_get": "1", "cmd_set": "2", "bytes": "3"}}
+	u := newUI(2*time.Second, nil, sess)
+
+	describe := func() string {
+		var out []string
+		for _, r := range statRows(sess, u.collapsed) {
+			if r.key == "" {
+				out = append(out, "["+r.group+"]")
+			} else {
+				out = append(out, r.key)
+			}
+		}
+		return strings.Join(out, " ")
+	}
+	if got := describe(); got != "[Server] uptime [Commands] cmd_get cmd_set [Memory] bytes" {
+		t.Fatalf("rows = %s", got)
+	}
+
+	u.statsCursor = 4 // cmd_set
+	u.setGroupCollapsed(true)
+	if got := describe(); got != "[Server] uptime [Commands] [Memory] bytes" || u.statsCursor != 2 {
+		t.Fatalf("after collapsing Commands: rows %s, cursor %d", got, u.statsCursor)
+	}
+	u.openStatDetail() // Enter on a heading unfolds it
+	if got := describe(); got != "[Server] uptime [Commands] cmd_get cmd_set [Memory] bytes" || u.detail != nil {
+		t.Fatalf("Enter on the heading: rows %s, detail %+v", got, u.detail)
+	}
+
+	u.toggleAllGroups()
+	if got := describe(); got != "[Server] [Commands] [Memory]" {
+		t.Fatalf("all collapsed: %s", got)
+	}
+	u.toggleAllGroups()
+	if got := describe(); !strings.Contains(got, "cmd_set") {
+		t.Fatalf("all expanded: %s", got)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// statsSections are the sections `memtop stats` accepts; the empty section is
+// plain `stats`.
+var statsSections = []string{"", "slabs", "items", "settings"}
+
+// runStats implements `memtop stats [section]`, printing one stats report in
+// the server's `STAT name value` form, as a JSON object, as OpenMetrics, or
+// through a Go template.
+func runStats(args []string, std streams) int {
+	fs, conn := newCommandFlags("stats", std)
+	format := fs.String("format", "text", "output format: text (STAT lines), json, openmetrics, or go-template=TEMPLATE")
+	ratesOver := fs.Duration("rates", 0, "sample twice this far apart and include per-second rates (.Rates in templates)")
+	metrics := fs.String("metrics", "", "only print the stats matching this `regexp`, such as 'cmd_.*|evictions|bytes'")
+	describe := fs.Bool("describe", false, "follow each stat with its description, kind, and unit from the built-in glossary (text format only)")
+	rest, ok := parseCommandArgs(fs, args, -1)
+	if !ok {
+		return 2
+	}
+	section := ""
+	switch len(rest) {
+	case 0:
+	case 1:
+		section = rest[0]
+	default:
+		fs.Usage()
+		return 2
+	}
+	var tmpl *template.Template
+	switch {
+	case *format == "text", *format == "json", *format == "openmetrics":
+	case strings.HasPrefix(*format, goTemplatePrefix):
+		var err error
+		if tmpl, err = parseOutputTemplate(strings.TrimPrefix(*format, goTemplatePrefix)); err != nil {
+			fmt.Fprintf(std.Err, "invalid template: %v\n", err)
+			return 2
+		}
+	default:
+		fmt.Fprintf(std.Err, "unknown format %q (want text, json, openmetrics, or go-template=TEMPLATE)\n", *format)
+		return 2
+	}
+	filter, err := parseMetricFilter(*metrics)
+	if err != nil {
+		fmt.Fprintln(std.Err, err)
+		return 2
+	}
+	if *describe && *format != "text" {
+		fmt.Fprintln(std.Err, "-describe only applies to -format text")
+		return 2
+	}
+	if *ratesOver < 0 {
+		fmt.Fprintln(std.Err, "-rates must not be negative")
+		return 2
+	}
+	known := false
+	for _, s := range statsSections {
+		known = known || s == section
+	}
+	if !known {
+		fmt.Fprintf(std.Err, "unknown stats section %q (want slabs, items, or settings)\n", section)
+		return 2
+	}
+	closeConn, ok := connectCommand(conn, std)
+	if !ok {
+		return 2
+	}
+	defer closeConn()
+
+	snapshot, err := fetchStatsSection(context.Background(), conn.addr(), section)
+	var rates map[string]float64
+	if err == nil && *ratesOver > 0 {
+		time.Sleep(*ratesOver)
+		var next *statsSnapshot
+		if next, err = fetchStatsSection(context.Background(), conn.addr(), section); err == nil {
+			rates, snapshot = calculateRates(next, snapshot), next
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(std.Err, "stats %s: %v\n", section, err)
+		return 1
+	}
+	if len(snapshot.Raw) == 0 {
+		// An unknown section answers ERROR, which fails the read above; a
+		// bare END is the only way to get here.
+		fmt.Fprintf(std.Err, "stats %s: server returned no stats\n", section)
+		return 1
+	}
+	snapshot, rates = snapshot.filtered(filter), filterStats(rates, filter)
+	rec := sampleRecord{Server: conn.addr(), Up: true, Timestamp: snapshot.Timestamp, Values: snapshot.Values, Rates: rates}
+	switch {
+	case tmpl != nil:
+		err = writeTemplate(std.Out, tmpl, rec)
+	case *format == "json":
+		err = writeStatsJSON(std.Out, snapshot)
+	case *format == "openmetrics":
+		err = writeOpenMetrics(std.Out, []sampleRecord{rec})
+	case *describe:
+		err = writeDescribedStats(std.Out, snapshot, section)
+	default:
+		err = writeStatsLines(std.Out, snapshot)
+	}
+	if err != nil {
+		fmt.Fprintf(std.Err, "stats %s: %v\n", section, err)
+		return 1
+	}
+	return 0
+}
+
+// writeStatsLines prints a snapshot the way the server sends it.
+func writeStatsLines(w io.Writer, snapshot *statsSnapshot) error {
+	keys := make([]string, 0, len(snapshot.Raw))
+	for key := range snapshot.Raw {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return statsKeyLess(keys[i], keys[j]) })
+	for _, key := range keys {
+		if _, err := fmt.Fprintf(w, "STAT %s %s\n", key, snapshot.Raw[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDescribedStats prints the STAT lines aligned, each followed by the
+// stat's glossary entry as a comment, for reading a report without
+// protocol.txt at hand.
+func writeDescribedStats(w io.Writer, snapshot *statsSnapshot, section string) error {
+	keys := make([]string, 0, len(snapshot.Raw))
+	for key := range snapshot.Raw {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return statsKeyLess(keys[i], keys[j]) })
+	for _, key := range keys {
+		line := fmt.Sprintf("STAT %-32s %-20s", key, snapshot.Raw[key])
+		if info, ok := describeStat(section, key); ok {
+			line += fmt.Sprintf(" # %s (%s)", info.Description, info)
+		}
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStatsJSON prints a snapshot as one JSON object, with numeric stats as
+// numbers and everything else as strings.
+func writeStatsJSON(w io.Writer, snapshot *statsSnapshot) error {
+	out := make(map[string]any, len(snapshot.Raw))
+	for key, value := range snapshot.Raw {
+		out[key] = value
+		if number, ok := snapshot.Values[key]; ok {
+			out[key] = number
+		}
+		if counter, ok := snapshot.Counters[key]; ok {
+			out[key] = counter
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// statsKeyLess orders stat names segment by segment, comparing numeric
+// segments as numbers so that `items:2:number` sorts before `items:10:number`.
+func statsKeyLess(a, b string) bool {
+	as, bs := strings.Split(a, ":"), strings.Split(b, ":")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		if aErr == nil && bErr == nil {
+			return an < bn
+		}
+		return as[i] < bs[i]
+	}
+	return len(as) < len(bs)
+}
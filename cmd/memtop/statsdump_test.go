@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// startSectionServer answers `stats <section>` from sections, and ERROR for
+// anything else.
+func startSectionServer(t *testing.T, sections map[string]string) net.Listener {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			line, _ := bufio.NewReader(conn).ReadString('\n')
+			section := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "stats"))
+			if body, ok := sections[section]; ok {
+				fmt.Fprint(conn, body+"END\r\n")
+			} else {
+				fmt.Fprint(conn, "ERROR\r\n")
+			}
+			conn.Close()
+		}
+	}()
+	return ln
+}
+
+func TestStatsSubcommand(t *testing.T) {
+	ln := startSectionServer(t, map[string]string{
+		"":      "STAT pid 42\r\nSTAT version 1.6.21\r\n",
+		"items": "STAT items:10:number 1\r\nSTAT items:2:number 5\r\nSTAT items:2:age 30\r\n",
+	})
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	code, out, errOut := runCommand(t, addr, "", "stats", "items")
+	if code != 0 {
+		t.Fatalf("stats items exited %d: %s", code, errOut)
+	}
+	if want := "STAT items:2:age 30\nSTAT items:2:number 5\nSTAT items:10:number 1\n"; out != want {
+		t.Fatalf("stats items printed:\n%s", out)
+	}
+
+	code, out, _ = runCommand(t, addr, "", "stats", "-format", "json")
+	if want := "{\n  \"pid\": 42,\n  \"version\": \"1.6.21\"\n}\n"; code != 0 || out != want {
+		t.Fatalf("stats -format json = %d %q", code, out)
+	}
+
+	if code, _, errOut := runCommand(t, addr, "", "stats", "settings"); code != 1 || !strings.Contains(errOut, `replied "ERROR"`) {
+		t.Fatalf("unsupported section = %d %q, want 1", code, errOut)
+	}
+	if code, _, _ := runCommand(t, addr, "", "stats", "detail"); code != 2 {
+		t.Fatalf("unknown section exited %d, want 2", code)
+	}
+}
+
+func TestStatsTemplateFormat(t *testing.T) {
+	ln := startSectionServer(t, map[string]string{"": "STAT pid 42\r\nSTAT bytes 2048\r\nSTAT cmd_get 7\r\n"})
+	defer ln.Close()
+	addr := ln.Addr().String()
+
+	tmpl := `go-template=pid {{.Values.pid}} uses {{bytes .Values.bytes}}, {{.Rates.cmd_get | printf "%.0f"}} gets/s`
+	code, out, errOut := runCommand(t, addr, "", "stats", "-format", tmpl, "-rates", "10ms")
+	if code != 0 {
+		t.Fatalf("template format exited %d: %s", code, errOut)
+	}
+	if want := "pid 42 uses 2.0 KB, 0 gets/s\n"; out != want {
+		t.Fatalf("template output = %q, want %q", out, want)
+	}
+
+	if code, _, _ := runCommand(t, addr, "", "stats", "-format", "go-template={{.Values"); code != 2 {
+		t.Fatalf("a broken template exited %d, want 2", code)
+	}
+}
+
+func TestStatsKeyLess(t *testing.T) {
+	keys := []string{"items:10:age", "items:2:number", "items:2:age", "active_slabs", "1:chunk_size", "total_malloced"}
+	sort.Slice(keys, func(i, j int) bool { return statsKeyLess(keys[i], keys[j]) })
+	want := "1:chunk_size,active_slabs,items:2:age,items:2:number,items:10:age,total_malloced"
+	if got := strings.Join(keys, ","); got != want {
+		t.Fatalf("sorted %s, want %s", got, want)
+	}
+}
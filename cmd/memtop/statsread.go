@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// statsReaders recycles the buffered readers used for stats replies, so a
+// poll of many servers at a short interval doesn't allocate a fresh buffer
+// per request.
+var statsReaders = sync.Pool{New: func() any { return bufio.NewReaderSize(nil, 16<<10) }}
+
+// statsSizes remembers how many stats each section's last reply held, to
+// size the next reply's maps up front instead of growing them stat by stat.
+var statsSizes = struct {
+	sync.Mutex
+	bySection map[string]int
+}{bySection: make(map[string]int)}
+
+// statsSizeHint returns the size of the last reply for section.
+func statsSizeHint(section string) int {
+	statsSizes.Lock()
+	defer statsSizes.Unlock()
+	return statsSizes.bySection[section]
+}
+
+// rememberStatsSize keeps the largest reply seen for section; servers of
+// different versions report different numbers of stats.
+func rememberStatsSize(section string, n int) {
+	statsSizes.Lock()
+	defer statsSizes.Unlock()
+	statsSizes.bySection[section] = max(statsSizes.bySection[section], n)
+}
+
+// maxInternedKeys bounds the key cache. Stat names come from a small fixed
+// set per server version (slab sections add a few per class), so the bound
+// only matters if a server invents names.
+const maxInternedKeys = 8192
+
+// statKeys interns stat names: the same names arrive every poll, and
+// sharing their strings saves an allocation per stat and per map.
+var statKeys = struct {
+	sync.Mutex
+	names map[string]string
+}{names: make(map[string]string)}
+
+// internKey returns the shared string for name.
+func internKey(name []byte) string {
+	statKeys.Lock()
+	defer statKeys.Unlock()
+	if s, ok := statKeys.names[string(name)]; ok {
+		return s
+	}
+	s := string(name)
+	if len(statKeys.names) < maxInternedKeys {
+		statKeys.names[s] = s
+	}
+	return s
+}
+
+var statPrefix = []byte("STAT ")
+
+// defaultMaxLineSize bounds one line of a server reply unless
+// -max-line-size says otherwise.
+const defaultMaxLineSize = 1 << 20
+
+// maxLineSize is the longest reply line memtop reads. Longer lines fail the
+// request with errLineTooLong rather than being cut short or dropped.
+var maxLineSize = defaultMaxLineSize
+
+// errLineTooLong reports a reply line past maxLineSize.
+func errLineTooLong() error {
+	return fmt.Errorf("server sent a reply line longer than %d bytes; raise -max-line-size", maxLineSize)
+}
+
+// maxBodySize bounds a length a server announces before sending the data:
+// a binary response body or a gets value. Stats bodies are small and
+// memcached items default to at most 1 MB, so anything larger is a broken or
+// hostile reply, not data worth allocating for.
+const maxBodySize = 16 << 20
+
+// errorReplies are the lines memcached answers with instead of stats, each
+// with a note on what usually causes it.
+var errorReplies = []struct {
+	prefix, hint string
+}{
+	{"ERROR", "unknown command or stats section"},
+	{"CLIENT_ERROR", "the server rejected the request"},
+	{"SERVER_ERROR", "the server failed to answer"},
+}
+
+// replyError returns the error for a memcached error reply, or nil when line
+// is not one. CLIENT_ERROR unauthenticated gets its own hint since it is the
+// usual reply from a server that requires SASL or an auth file.
+func replyError(line []byte) error {
+	for _, r := range errorReplies {
+		rest, ok := bytes.CutPrefix(line, []byte(r.prefix))
+		if !ok || (len(rest) > 0 && rest[0] != ' ') {
+			continue
+		}
+		hint := r.hint
+		if bytes.Contains(rest, []byte("unauthenticated")) {
+			hint = "the server requires authentication"
+		}
+		return fmt.Errorf("server replied %q (%s)", line, hint)
+	}
+	return nil
+}
+
+// readStats reads `STAT <name> <value>` lines until END. A connection that
+// closes early yields the stats read so far; an error reply (ERROR,
+// CLIENT_ERROR, SERVER_ERROR) fails the read so the cause is shown instead
+// of an empty or partial result.
+func readStats(r *bufio.Reader, hint int) (map[string]string, error) {
+	raw := make(map[string]string, hint)
+	for {
+		line, err := r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			// Longer than the buffer; the rest is read into new memory.
+			line = append([]byte(nil), line...)
+			for errors.Is(err, bufio.ErrBufferFull) && len(line) <= maxLineSize {
+				var more []byte
+				more, err = r.ReadSlice('\n')
+				line = append(line, more...)
+			}
+		}
+		if len(line) > maxLineSize {
+			return nil, errLineTooLong()
+		}
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF {
+				return raw, nil
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+		if string(line) == "END" {
+			return raw, nil
+		}
+		if rest, ok := bytes.CutPrefix(line, statPrefix); ok {
+			name, value, _ := bytes.Cut(rest, []byte(" "))
+			if value = bytes.TrimSpace(value); len(name) > 0 && len(value) > 0 {
+				raw[internKey(name)] = string(value)
+			}
+		} else if err := replyError(line); err != nil {
+			return nil, err
+		}
+		if err == io.EOF {
+			return raw, nil
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadStatsParsesReply(t *testing.T) {
+	long := strings.Repeat("x", 40<<10)
+	reply := "STAT pid 7\r\nSTAT version 1.6.21\r\nSTAT long " + long + "\r\nNOT A STAT\r\nSTAT empty\r\nEND\r\nSTAT after 1\r\n"
+	raw, err := readStats(bufio.NewReaderSize(strings.NewReader(reply), 16<<10), 0)
+	if err != nil {
+		t.Fatalf("readStats: %v", err)
+	}
+	if len(raw) != 3 || raw["pid"] != "7" || raw["version"] != "1.6.21" || raw["long"] != long {
+		t.Fatalf("unexpected stats %v", raw)
+	}
+
+	// A reply cut off before END keeps what arrived, unterminated last line
+	// included.
+	raw, err = readStats(bufio.NewReader(strings.NewReader("STAT pid 7\r\nSTAT uptime 9")), 0)
+	if err != nil || raw["pid"] != "7" || raw["uptime"] != "9" {
+		t.Fatalf("truncated reply gave %v, %v", raw, err)
+	}
+}
+
+func TestReadStatsRejectsLinesPastTheLimit(t *testing.T) {
+	prev := maxLineSize
+	t.Cleanup(func() { maxLineSize = prev })
+	maxLineSize = 32 << 10
+
+	reply := "STAT pid 7\r\nSTAT long " + strings.Repeat("x", 40<<10) + "\r\nEND\r\n"
+	_, err := readStats(bufio.NewReaderSize(strings.NewReader(reply), 16<<10), 0)
+	if err == nil || !strings.Contains(err.Error(), "-max-line-size") {
+		t.Fatalf("readStats = %v, want a line too long error", err)
+	}
+}
+
+func TestReadStatsReportsErrorReplies(t *testing.T) {
+	for _, tc := range []struct {
+		reply, want string
+	}{
+		{"ERROR\r\n", "unknown command or stats section"},
+		{"STAT pid 7\r\nSERVER_ERROR out of memory\r\n", `"SERVER_ERROR out of memory"`},
+		{"CLIENT_ERROR unauthenticated\r\n", "requires authentication"},
+	} {
+		_, err := readStats(bufio.NewReader(strings.NewReader(tc.reply)), 0)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("readStats(%q) = %v, want an error mentioning %q", tc.reply, err, tc.want)
+		}
+	}
+
+	// Only whole words count; a stat line never starts this way, but other
+	// noise is still skipped.
+	raw, err := readStats(bufio.NewReader(strings.NewReader("ERRORS 3\r\nSTAT pid 7\r\nEND\r\n")), 0)
+	if err != nil || raw["pid"] != "7" {
+		t.Fatalf("noise line gave %v, %v", raw, err)
+	}
+}
+
+func TestReadStatsReusesKeysAndBuffers(t *testing.T) {
+	var reply strings.Builder
+	for _, name := range []string{"cmd_get", "cmd_set", "get_hits", "get_misses", "bytes", "curr_items"} {
+		reply.WriteString("STAT " + name + " 12345\r\n")
+	}
+	reply.WriteString("END\r\n")
+	text := reply.String()
+	src := strings.NewReader(text)
+	r := bufio.NewReader(src)
+	parse := func() {
+		src.Reset(text)
+		r.Reset(src)
+		if _, err := readStats(r, 6); err != nil {
+			t.Fatalf("readStats: %v", err)
+		}
+	}
+	parse()
+	// One allocation per value plus the map itself; names are interned and
+	// the reader's buffer is reused.
+	if allocs := testing.AllocsPerRun(100, parse); allocs > 8 {
+		t.Fatalf("%.0f allocations per reply of 6 stats", allocs)
+	}
+}
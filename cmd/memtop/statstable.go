@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// drawStatsView renders every stat the server reported as a scrollable table
+// of the columns chosen with c: by default value, change this interval,
+// rate, and rolling z-score, highlighting anomalous rates. With a baseline
+// loaded a last column shows each stat's change against it. Stats are listed
+// under collapsible group headings; the arrow keys move a cursor through the
+// rows, and Enter opens the detail popup or folds the group.
+func drawStatsView(screen tcell.Screen, line int, u *ui) {
+	s := u.current()
+	_, height := screen.Size()
+	if s.current == nil {
+		if s.lastErr == nil {
+			drawText(screen, 0, line, tcell.StyleDefault, "Waiting for initial stats...")
+		}
+		return
+	}
+
+	statsRows := statRows(s, u.collapsed)
+	columns := u.shownColumns()
+	header := fmt.Sprintf("%-30s", "Metric")
+	for _, name := range columns {
+		header += fmt.Sprintf(" %*s", statsColumnWidth(name), statsColumnTitle(name, s))
+	}
+	drawText(screen, 0, line, tcell.StyleDefault.Bold(true), header)
+	switch {
+	case u.chooser != nil:
+		defer u.chooser.draw(screen, 2, line+1, u)
+	case u.detail != nil:
+		defer drawBox(screen, 2, line+1, u.detail.lines(s))
+	}
+	line++
+
+	rows := height - 1 - line
+	u.statsCursor = max(0, min(u.statsCursor, len(statsRows)-1))
+	u.statsOffset = min(u.statsOffset, u.statsCursor)
+	u.statsOffset = max(u.statsOffset, u.statsCursor-rows+1)
+	u.statsOffset = clampOffset(u.statsOffset, len(statsRows), rows)
+	for i, r := range statsRows[u.statsOffset:] {
+		if line >= height-1 {
+			break
+		}
+		key := r.key
+		if key == "" {
+			style := tcell.StyleDefault.Bold(true)
+			if u.statsOffset+i == u.statsCursor {
+				style = style.Reverse(true)
+			}
+			fold := "[-]"
+			if u.collapsed[r.group] {
+				fold = "[+]"
+			}
+			drawText(screen, 0, line, style, fmt.Sprintf("%s %s (%d)", fold, r.group, r.count))
+			line++
+			continue
+		}
+		style := tcell.StyleDefault
+		if s.isAnomalous(key) {
+			style = anomalyStyle
+		}
+		row := fmt.Sprintf("  %-28s", key)
+		for _, name := range columns {
+			row += fmt.Sprintf(" %*s", statsColumnWidth(name), statsColumnCell(name, u, s, key))
+			if name == "baseline" && style == tcell.StyleDefault && math.Abs(u.baselineChange(s, key)) >= baselineNotable {
+				style = theme.warn
+			}
+		}
+		if u.statsOffset+i == u.statsCursor {
+			style = style.Reverse(true)
+		}
+		drawText(screen, 0, line, style, row)
+		line++
+	}
+}
+
+// openStatDetail opens the detail popup for the stat under the cursor, or
+// folds or unfolds the group whose heading it is on.
+func (u *ui) openStatDetail() {
+	s := u.current()
+	if s.current == nil {
+		return
+	}
+	rows := statRows(s, u.collapsed)
+	if u.statsCursor >= len(rows) {
+		return
+	}
+	if r := rows[u.statsCursor]; r.key != "" {
+		u.detail = &statDetail{key: r.key}
+	} else {
+		u.setGroupCollapsed(!u.collapsed[r.group])
+	}
+}
+
+// statDelta is a stat's change since the previous sample, signed so gauges
+// that shrank show it, or empty for non-numeric stats and the first sample.
+// Integer stats are subtracted exactly.
+func statDelta(curr, prev *statsSnapshot, key string) string {
+	if curr == nil || prev == nil {
+		return ""
+	}
+	if c, ok := curr.Counters[key]; ok {
+		if p, ok := prev.Counters[key]; ok {
+			switch {
+			case c > p:
+				return fmt.Sprintf("+%d", c-p)
+			case c < p:
+				return fmt.Sprintf("-%d", p-c)
+			}
+			return "0"
+		}
+	}
+	c, okC := curr.Values[key]
+	p, okP := prev.Values[key]
+	switch {
+	case !okC || !okP:
+		return ""
+	case c == p:
+		return "0"
+	case c-p == math.Trunc(c-p):
+		return fmt.Sprintf("%+.0f", c-p)
+	}
+	return fmt.Sprintf("%+.2f", c-p)
+}
+
+// clampOffset keeps a scroll offset within the rows that exist so the table
+// never scrolls past its last page.
+func clampOffset(offset, total, visible int) int {
+	return max(0, min(offset, total-visible))
+}
+
+// scrollKey applies arrow and paging keys to a table offset, reporting whether
+// the key was a scroll key. Clamping happens at draw time.
+func scrollKey(evt *tcell.EventKey, offset *int, screen tcell.Screen) bool {
+	_, height := screen.Size()
+	page := max(1, height-4)
+	switch evt.Key() {
+	case tcell.KeyUp:
+		*offset--
+	case tcell.KeyDown:
+		*offset++
+	case tcell.KeyPgUp:
+		*offset -= page
+	case tcell.KeyPgDn:
+		*offset += page
+	case tcell.KeyHome:
+		*offset = 0
+	default:
+		return false
+	}
+	*offset = max(0, *offset)
+	return true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestClampOffset(t *testing.T) {
+	tests := []struct{ offset, total, visible, want int }{
+		{offset: -3, total: 10, visible: 5, want: 0},
+		{offset: 3, total: 10, visible: 5, want: 3},
+		{offset: 8, total: 10, visible: 5, want: 5},
+		{offset: 2, total: 3, visible: 5, want: 0},
+	}
+	for _, tc := range tests {
+		if got := clampOffset(tc.offset, tc.total, tc.visible); got != tc.want {
+			t.Fatalf("clampOffset(%d, %d, %d) = %d, want %d", tc.offset, tc.total, tc.visible, got, tc.want)
+		}
+	}
+}
+
+func TestDrawStatsViewHighlightsAnomalies(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 10)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.current = &statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"cmd_get": 100, "evictions": 9},
+		Raw:       map[string]string{"cmd_get": "100", "evictions": "9", "version": "1.6.21"},
+	}
+	sess.rates = map[string]float64{"cmd_get": 5, "evictions": 3}
+	sess.zscores = map[string]float64{"evictions": 6}
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	if header := lineFromCells(cells, width, 2); !strings.HasPrefix(header, "Metric") {
+		t.Fatalf("table header missing, got %q", header)
+	}
+	// Rows: Server heading, version, Commands heading, cmd_get, Memory
+	// heading, evictions.
+	row := lineFromCells(cells, width, 8)
+	if !strings.HasPrefix(row, "  evictions") || !strings.Contains(row, "+6.0") {
+		t.Fatalf("evictions row unexpected, got %q", row)
+	}
+	if style := cells[8*width].Style; style != anomalyStyle {
+		t.Fatalf("anomalous row should be highlighted")
+	}
+	if row := lineFromCells(cells, width, 4); !strings.Contains(row, "1.6.21") {
+		t.Fatalf("non-numeric stats should still be listed, got %q", row)
+	}
+}
+
+func TestStatDelta(t *testing.T) {
+	prev := &statsSnapshot{
+		Values:   map[string]float64{"cmd_get": 100, "curr_items": 50, "rusage_user": 1.5, "version": 0},
+		Counters: map[string]uint64{"cmd_get": 1<<63 + 1, "curr_items": 50},
+	}
+	curr := &statsSnapshot{
+		Values:   map[string]float64{"cmd_get": 110, "curr_items": 45, "rusage_user": 1.75, "evictions": 3},
+		Counters: map[string]uint64{"cmd_get": 1<<63 + 11, "curr_items": 45, "evictions": 3},
+	}
+	for key, want := range map[string]string{
+		"cmd_get":     "+10",
+		"curr_items":  "-5",
+		"rusage_user": "+0.25",
+		"evictions":   "",
+	} {
+		if got := statDelta(curr, prev, key); got != want {
+			t.Errorf("statDelta(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if got := statDelta(curr, nil, "cmd_get"); got != "" {
+		t.Errorf("delta without a previous sample = %q", got)
+	}
+}
+
+func TestDrawStatsViewShowsValueDeltaAndRate(t *testing.T) {
+	screen := tcell.NewSimulationScreen("")
+	if err := screen.Init(); err != nil {
+		t.Fatalf("simulation screen init failed: %v", err)
+	}
+	defer screen.Fini()
+	screen.SetSize(80, 10)
+
+	sess := newSession("127.0.0.1:11211", 2*time.Second)
+	sess.prev = &statsSnapshot{Values: map[string]float64{"cmd_get": 100}, Counters: map[string]uint64{"cmd_get": 100}}
+	sess.current = &statsSnapshot{
+		Timestamp: time.Now(),
+		Values:    map[string]float64{"cmd_get": 120},
+		Counters:  map[string]uint64{"cmd_get": 120},
+		Raw:       map[string]string{"cmd_get": "120"},
+	}
+	sess.rates = map[string]float64{"cmd_get": 10}
+	u := newUI(2*time.Second, nil, sess)
+	u.view = viewStats
+	drawScreen(screen, u)
+
+	cells, width, _ := screen.GetContents()
+	if header := strings.Fields(lineFromCells(cells, width, 2)); strings.Join(header, " ") != "Metric Value Delta Rate/s Sigma" {
+		t.Fatalf("table header = %q", header)
+	}
+	if row := strings.Fields(lineFromCells(cells, width, 4)); strings.Join(row, " ") != "cmd_get 120 +20 10.00" {
+		t.Fatalf("cmd_get row = %q", row)
+	}
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Syslog severities used for samples and events.
+const (
+	severityWarning = 4
+	severityNotice  = 5
+	severityInfo    = 6
+)
+
+// syslogFacility is "daemon", the facility memtop logs under.
+const syslogFacility = 3
+
+// logField is one structured field of a log message.
+type logField struct {
+	Name, Value string
+}
+
+// structuredLog is a destination for messages with structured fields:
+// syslog or the systemd journal.
+type structuredLog interface {
+	send(severity int, t time.Time, message string, fields []logField) error
+}
+
+// syslogSink sends each server's key metrics after every pass, and every
+// event since the previous pass (alerts with their metric, value, and
+// threshold), to syslog or the journal, for sites whose log pipeline is
+// their only way in. The metrics are the ones -csv writes.
+type syslogSink struct {
+	out structuredLog
+	seq int
+}
+
+// newSyslogSink connects to target: "local" for the local syslog daemon,
+// "journald" for the systemd journal, or udp://host:port or tcp://host:port
+// for a remote syslog server.
+func newSyslogSink(target string) (*syslogSink, error) {
+	var out structuredLog
+	var err error
+	switch {
+	case target == "journald":
+		out, err = dialJournal(journalSocket)
+	case target == "local":
+		out, err = dialLocalSyslog()
+	case strings.HasPrefix(target, "udp://"), strings.HasPrefix(target, "tcp://"):
+		network, addr, _ := strings.Cut(target, "://")
+		out, err = dialSyslog(network, addr)
+	default:
+		return nil, fmt.Errorf("invalid -syslog %q: want local, journald, udp://host:port, or tcp://host:port", target)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{out: out}, nil
+}
+
+// sampleFields describes a record as structured fields.
+func sampleFields(rec sampleRecord) []logField {
+	fields := []logField{{"server", rec.Server}, {"up", strconv.FormatBool(rec.Up)}}
+	if rec.Error != "" {
+		fields = append(fields, logField{"error", rec.Error})
+	}
+	for _, key := range csvStats {
+		if _, ok := rec.Values[key]; ok {
+			fields = append(fields, logField{key, rec.formatValue(key)})
+		}
+	}
+	for _, key := range csvRates {
+		if rate, ok := rec.Rates[key]; ok {
+			fields = append(fields, logField{key + "_per_sec", strconv.FormatFloat(rate, 'f', 2, 64)})
+		}
+	}
+	return fields
+}
+
+// eventFields describes an event, and an alert's details, as structured
+// fields.
+func eventFields(e event) []logField {
+	fields := []logField{{"event", e.Kind}}
+	if e.Server != "" {
+		fields = append(fields, logField{"server", e.Server})
+	}
+	if a := e.Alert; a != nil {
+		fields = append(fields, logField{"alert_state", a.State})
+		for _, f := range []logField{{"metric", a.Metric}, {"value", formatAlertNumber(a.Value)}, {"threshold", formatAlertNumber(a.Threshold)}} {
+			if f.Value != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
+
+// eventSeverity ranks raised alerts and outages above other events.
+func eventSeverity(e event) int {
+	switch {
+	case e.Kind == eventAlert && e.Alert != nil && e.Alert.State == alertResolved:
+		return severityNotice
+	case e.Kind == eventAlert, e.Kind == eventRestart, e.Kind == eventConnLost:
+		return severityWarning
+	}
+	return severityNotice
+}
+
+func (s *syslogSink) write(u *ui) error {
+	events, seq := u.events.since(s.seq)
+	s.seq = seq
+	for _, rec := range sampleRecords(u) {
+		message := "sample " + rec.Server
+		if !rec.Up {
+			message += " down: " + rec.Error
+		}
+		if err := s.out.send(severityInfo, rec.Timestamp, message, sampleFields(rec)); err != nil {
+			return err
+		}
+	}
+	for _, e := range events {
+		if err := s.out.send(eventSeverity(e), e.Time, e.subject(), eventFields(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syslogWriter sends messages to a syslog daemon: RFC 5424 with the fields
+// as structured data to a remote server, and the traditional RFC 3164
+// format with the fields appended as key=value pairs to the local daemon,
+// which is what /dev/log readers expect. The connection is redialed after a
+// failure.
+type syslogWriter struct {
+	network, addr string
+	local         bool
+	hostname      string
+	conn          net.Conn
+}
+
+// localSyslogPaths are where the local syslog daemon listens.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+func dialLocalSyslog() (*syslogWriter, error) {
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			w := &syslogWriter{network: network, addr: path, local: true}
+			if err := w.connect(); err == nil {
+				return w, nil
+			}
+		}
+	}
+	return nil, errors.New("no local syslog daemon found")
+}
+
+func dialSyslog(network, addr string) (*syslogWriter, error) {
+	w := &syslogWriter{network: network, addr: addr}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.addr, defaultTimeout)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	if w.hostname, _ = os.Hostname(); w.hostname == "" {
+		w.hostname = "-"
+	}
+	return nil
+}
+
+// format renders one syslog message.
+func (w *syslogWriter) format(severity int, t time.Time, message string, fields []logField) []byte {
+	var buf bytes.Buffer
+	pri := syslogFacility*8 + severity
+	if w.local {
+		fmt.Fprintf(&buf, "<%d>%s memtop[%d]: %s", pri, t.Format(time.Stamp), os.Getpid(), message)
+		for _, f := range fields {
+			fmt.Fprintf(&buf, " %s=%s", f.Name, strconv.Quote(f.Value))
+		}
+	} else {
+		fmt.Fprintf(&buf, "<%d>1 %s %s memtop %d - [memtop@32473", pri, t.UTC().Format(time.RFC3339Nano), w.hostname, os.Getpid())
+		escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+		for _, f := range fields {
+			fmt.Fprintf(&buf, ` %s="%s"`, f.Name, escape.Replace(f.Value))
+		}
+		fmt.Fprintf(&buf, "] %s", message)
+	}
+	if w.network == "tcp" || w.network == "unix" {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func (w *syslogWriter) send(severity int, t time.Time, message string, fields []logField) error {
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return err
+		}
+	}
+	w.conn.SetWriteDeadline(time.Now().Add(defaultTimeout))
+	if _, err := w.conn.Write(w.format(severity, t, message, fields)); err != nil {
+		w.conn.Close()
+		w.conn = nil
+		return err
+	}
+	return nil
+}
+
+// journalSocket is where systemd-journald accepts native messages.
+const journalSocket = "/run/systemd/journal/socket"
+
+// journalWriter sends messages to the systemd journal in its native
+// protocol, with every field as a MEMTOP_* journal field.
+type journalWriter struct {
+	conn *net.UnixConn
+}
+
+func dialJournal(path string) (*journalWriter, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("systemd journal not available: %w", err)
+	}
+	return &journalWriter{conn: conn}, nil
+}
+
+// journalFieldName turns a field name into a valid journal field name.
+func journalFieldName(name string) string {
+	return "MEMTOP_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// writeJournalField appends one field, switching to the length-prefixed
+// form for values spanning lines.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+// journalMessage renders one native journal datagram.
+func journalMessage(severity int, t time.Time, message string, fields []logField) []byte {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", message)
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(severity))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", "memtop")
+	writeJournalField(&buf, "SYSLOG_FACILITY", strconv.Itoa(syslogFacility))
+	writeJournalField(&buf, "MEMTOP_TIMESTAMP", t.UTC().Format(time.RFC3339Nano))
+	for _, f := range fields {
+		writeJournalField(&buf, journalFieldName(f.Name), f.Value)
+	}
+	return buf.Bytes()
+}
+
+func (w *journalWriter) send(severity int, t time.Time, message string, fields []logField) error {
+	_, err := w.conn.Write(journalMessage(severity, t, message, fields))
+	return err
+}
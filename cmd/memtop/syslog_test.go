@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSinkSendsSamplesAndAlertsAsStructuredData(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+	sl, err := newSyslogSink("udp://" + pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("newSyslogSink: %v", err)
+	}
+
+	u := sinkTestUI()
+	value := 500.0
+	u.servers[0].logAlert(time.Now(), alertInfo{Message: `storm "now"`, State: alertFiring, Metric: "evictions", Value: &value})
+	if err := sl.write(u); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	read := func() string {
+		buf := make([]byte, 4096)
+		pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(buf[:n])
+	}
+	sample := read()
+	if !strings.HasPrefix(sample, "<30>1 ") || !strings.Contains(sample, `[memtop@32473 server="10.0.0.1:11211" up="true"`) || !strings.Contains(sample, `cmd_get="42"`) {
+		t.Fatalf("sample message = %q", sample)
+	}
+	alert := read()
+	if !strings.HasPrefix(alert, "<28>1 ") || !strings.Contains(alert, `event="alert"`) || !strings.Contains(alert, `alert_state="firing" metric="evictions" value="500"]`) || !strings.HasSuffix(alert, `storm "now"`) {
+		t.Fatalf("alert message = %q", alert)
+	}
+}
+
+func TestJournalWriterUsesNativeFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	ln, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unix datagram sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	w, err := dialJournal(path)
+	if err != nil {
+		t.Fatalf("dialJournal: %v", err)
+	}
+	if err := w.send(severityWarning, time.Unix(0, 0), "two\nlines", []logField{{"curr_items", "7"}, {"get-rate", "1.5"}}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	buf := make([]byte, 4096)
+	ln.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, err := ln.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	got := string(buf[:n])
+	for _, want := range []string{"MESSAGE\n\x09\x00\x00\x00\x00\x00\x00\x00two\nlines\n", "PRIORITY=4\n", "SYSLOG_IDENTIFIER=memtop\n", "MEMTOP_CURR_ITEMS=7\n", "MEMTOP_GET_RATE=1.5\n"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("journal datagram %q lacks %q", got, want)
+		}
+	}
+}
+
+func TestSyslogLocalFormat(t *testing.T) {
+	w := &syslogWriter{network: "unixgram", local: true}
+	got := string(w.format(severityInfo, time.Date(2024, 3, 5, 7, 8, 9, 0, time.Local), "sample a", []logField{{"up", "true"}}))
+	if !strings.HasPrefix(got, "<30>Mar  5 07:08:09 memtop[") || !strings.HasSuffix(got, `]: sample a up="true"`) {
+		t.Fatalf("local message = %q", got)
+	}
+}
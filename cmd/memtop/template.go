@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+)
+
+// goTemplatePrefix selects template output in -format, as in
+// `-format 'go-template={{.Rates.cmd_get | printf "%.0f"}} gets/s'`.
+const goTemplatePrefix = "go-template="
+
+// templateFuncs are available to output templates alongside Go's builtins.
+var templateFuncs = template.FuncMap{
+	"bytes":  formatBytes,
+	"uptime": formatUptime,
+}
+
+// parseOutputTemplate compiles a user template. Missing map keys render as
+// zero so `.Rates.x` works even when a stat is absent.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	return template.New("format").Funcs(templateFuncs).Option("missingkey=zero").Parse(text)
+}
+
+// writeTemplate renders rec, ending the output with a newline if the
+// template didn't, so single-line templates behave in shell pipelines.
+func writeTemplate(w io.Writer, tmpl *template.Template, rec sampleRecord) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, rec); err != nil {
+		return err
+	}
+	if !strings.HasSuffix(buf.String(), "\n") {
+		buf.WriteByte('\n')
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
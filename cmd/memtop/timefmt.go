@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultTimeFormat is the layout of full timestamps unless -time-format says
+// otherwise.
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// displayLocation and displayTimeFormat control how times are shown; main
+// sets them from -timezone and -time-format. Teams correlating with server
+// logs usually want UTC rather than the local zone.
+var (
+	displayLocation   = time.Local
+	displayTimeFormat = defaultTimeFormat
+)
+
+// setTimeDisplay applies -timezone (Local, UTC, or an IANA name such as
+// Europe/Berlin) and -time-format (a Go reference-time layout).
+func setTimeDisplay(zone, format string) error {
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return fmt.Errorf("invalid -timezone %q: %w", zone, err)
+	}
+	if format == "" {
+		return errors.New("-time-format must not be empty")
+	}
+	displayLocation, displayTimeFormat = loc, format
+	return nil
+}
+
+// formatTimestamp renders a snapshot time in the configured zone and layout.
+func formatTimestamp(t time.Time) string {
+	return t.In(displayLocation).Format(displayTimeFormat)
+}
+
+// clockTime renders the time of day in the configured zone, for compact
+// panel entries.
+func clockTime(t time.Time) string {
+	return t.In(displayLocation).Format("15:04:05")
+}
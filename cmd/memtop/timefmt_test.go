@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func withTimeDisplay(t *testing.T, zone, format string) {
+	t.Helper()
+	prevLoc, prevFormat := displayLocation, displayTimeFormat
+	t.Cleanup(func() { displayLocation, displayTimeFormat = prevLoc, prevFormat })
+	if err := setTimeDisplay(zone, format); err != nil {
+		t.Fatalf("setTimeDisplay(%q, %q): %v", zone, format, err)
+	}
+}
+
+func TestFormatTimestampUsesZoneAndLayout(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	withTimeDisplay(t, "UTC", time.RFC3339)
+	if got := formatTimestamp(ts); got != "2024-03-01T11:30:00Z" {
+		t.Fatalf("formatTimestamp in UTC = %q", got)
+	}
+	if got := clockTime(ts); got != "11:30:00" {
+		t.Fatalf("clockTime in UTC = %q", got)
+	}
+	e := event{Time: ts, Kind: eventFlush, Message: "x"}
+	if got := e.String(); got[:19] != "2024-03-01 11:30:00" {
+		t.Fatalf("events should use the display zone, got %q", got)
+	}
+
+	withTimeDisplay(t, "America/New_York", "15:04 MST")
+	if got := formatTimestamp(ts); got != "06:30 EST" {
+		t.Fatalf("formatTimestamp in New York = %q", got)
+	}
+}
+
+func TestSetTimeDisplayRejectsBadInput(t *testing.T) {
+	prevLoc, prevFormat := displayLocation, displayTimeFormat
+	defer func() { displayLocation, displayTimeFormat = prevLoc, prevFormat }()
+	if err := setTimeDisplay("Mars/Olympus_Mons", defaultTimeFormat); err == nil {
+		t.Errorf("expected an error for an unknown zone")
+	}
+	if err := setTimeDisplay("UTC", ""); err == nil {
+		t.Errorf("expected an error for an empty layout")
+	}
+}
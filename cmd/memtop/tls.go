@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultCertExpiryWarn is how long before its server certificate expires a
+// TLS server is flagged.
+const defaultCertExpiryWarn = 30 * 24 * time.Hour
+
+// serverTLS, when set by -tls, wraps every server connection in TLS.
+var serverTLS *tls.Config
+
+// certExpiryWarn is the -tls-expiry-warn window.
+var certExpiryWarn = defaultCertExpiryWarn
+
+// tlsInfo is what the last TLS handshake with a server negotiated.
+type tlsInfo struct {
+	Version  string
+	Cipher   string
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+}
+
+// tlsHandshakes holds the last handshake per server address. Connections
+// are dialed deep inside the fetch functions, which know the address but
+// not the session, so the result is kept here for the panel to look up.
+var tlsHandshakes = struct {
+	sync.Mutex
+	byAddr map[string]tlsInfo
+}{byAddr: make(map[string]tlsInfo)}
+
+// newTLSConfig builds the client configuration from the -tls flags: a CA
+// file to verify servers against instead of the system roots, a client
+// certificate and key for servers that require one, the name to verify when
+// servers are dialed by IP, and whether to skip verification.
+func newTLSConfig(caFile, certFile, keyFile, serverName string, insecure bool) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: serverName, InsecureSkipVerify: insecure}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no certificates found", caFile)
+		}
+	}
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("-tls-cert and -tls-key must be given together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// startTLS runs the TLS handshake over conn when -tls is set and records
+// what it negotiated.
+func startTLS(ctx context.Context, conn net.Conn, addr string) (net.Conn, error) {
+	if serverTLS == nil {
+		return conn, nil
+	}
+	cfg := serverTLS
+	if cfg.ServerName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		cfg = cfg.Clone()
+		cfg.ServerName = host
+	}
+	tconn := tls.Client(conn, cfg)
+	if err := tconn.HandshakeContext(ctx); err != nil {
+		return nil, fmt.Errorf("tls handshake: %w", err)
+	}
+	recordTLS(addr, tconn.ConnectionState())
+	return tconn, nil
+}
+
+// recordTLS keeps the negotiated parameters and the server's leaf
+// certificate.
+func recordTLS(addr string, state tls.ConnectionState) {
+	info := tlsInfo{Version: tls.VersionName(state.Version), Cipher: tls.CipherSuiteName(state.CipherSuite)}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		info.Subject = leaf.Subject.CommonName
+		info.Issuer = leaf.Issuer.CommonName
+		info.NotAfter = leaf.NotAfter
+	}
+	tlsHandshakes.Lock()
+	defer tlsHandshakes.Unlock()
+	tlsHandshakes.byAddr[addr] = info
+}
+
+// lastTLS returns the last handshake with addr.
+func lastTLS(addr string) (tlsInfo, bool) {
+	tlsHandshakes.Lock()
+	defer tlsHandshakes.Unlock()
+	info, ok := tlsHandshakes.byAddr[addr]
+	return info, ok
+}
+
+// describeCertExpiry warns about a server certificate that expires within
+// the -tls-expiry-warn window, or already has; it returns "" otherwise.
+func describeCertExpiry(s *session, now time.Time) string {
+	info, ok := lastTLS(s.addr)
+	if !ok || info.NotAfter.IsZero() {
+		return ""
+	}
+	left := info.NotAfter.Sub(now)
+	switch {
+	case left <= 0:
+		return fmt.Sprintf("TLS certificate of %s expired %s ago", s.addr, formatUptime(-left.Seconds()))
+	case left <= certExpiryWarn:
+		return fmt.Sprintf("TLS certificate of %s expires in %s", s.addr, formatUptime(left.Seconds()))
+	}
+	return ""
+}
+
+// renderTLSPanel shows the negotiated protocol and cipher and the server
+// certificate, with its expiry highlighted inside the warning window.
+func renderTLSPanel(s *session) []panelLine {
+	if serverTLS == nil {
+		return nil
+	}
+	info, ok := lastTLS(s.addr)
+	if !ok {
+		return []panelLine{plainLine("Waiting for a handshake...")}
+	}
+	lines := []panelLine{
+		plainLine(fmt.Sprintf("%-9s %s", "protocol", info.Version)),
+		plainLine(fmt.Sprintf("%-9s %s", "cipher", info.Cipher)),
+	}
+	if info.NotAfter.IsZero() {
+		return lines
+	}
+	lines = append(lines,
+		plainLine(fmt.Sprintf("%-9s %s", "subject", info.Subject)),
+		plainLine(fmt.Sprintf("%-9s %s", "issuer", info.Issuer)),
+	)
+	expiry := plainLine(fmt.Sprintf("%-9s %s", "expires", formatTimestamp(info.NotAfter)))
+	if warning := describeCertExpiry(s, time.Now()); warning != "" {
+		expiry.Style = theme.warn
+		if time.Now().After(info.NotAfter) {
+			expiry.Style = theme.bad.Bold(true)
+		}
+	}
+	return append(lines, expiry)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"flag"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTLSStatsServer answers `stats` over TLS with a self-signed
+// certificate valid until notAfter.
+func startTLSStatsServer(t *testing.T, notAfter time.Time) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "cache-1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if _, err := bufio.NewReader(conn).ReadString('\n'); err == nil {
+					conn.Write([]byte("STAT uptime 10\r\nEND\r\n"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestFetchStatsOverTLSRecordsTheHandshake(t *testing.T) {
+	notAfter := time.Now().Add(5 * 24 * time.Hour).Truncate(time.Second)
+	addr := startTLSStatsServer(t, notAfter)
+	prev := serverTLS
+	t.Cleanup(func() { serverTLS = prev })
+	serverTLS = &tls.Config{InsecureSkipVerify: true}
+
+	stats, err := fetchStats(t.Context(), addr)
+	if err != nil {
+		t.Fatalf("fetch over TLS: %v", err)
+	}
+	if stats.Values["uptime"] != 10 {
+		t.Fatalf("uptime = %v", stats.Values["uptime"])
+	}
+	info, ok := lastTLS(addr)
+	if !ok || !strings.HasPrefix(info.Version, "TLS") || info.Cipher == "" || info.Subject != "cache-1" || !info.NotAfter.Equal(notAfter) {
+		t.Fatalf("handshake = %+v", info)
+	}
+
+	s := newSession(addr, time.Second)
+	if got := describeCertExpiry(s, time.Now()); !strings.Contains(got, "expires in 4d") {
+		t.Fatalf("expiry warning = %q", got)
+	}
+	if got := describeCertExpiry(s, notAfter.Add(-60*24*time.Hour)); got != "" {
+		t.Fatalf("no warning outside the window, got %q", got)
+	}
+	if got := describeCertExpiry(s, notAfter.Add(time.Hour)); !strings.Contains(got, "expired 01h 00m 00s ago") {
+		t.Fatalf("expired warning = %q", got)
+	}
+	lines := renderTLSPanel(s)
+	if last := lines[len(lines)-1]; !strings.HasPrefix(last.Text, "expires") || last.Style != theme.warn {
+		t.Fatalf("expiry line = %+v", last)
+	}
+
+	serverTLS = &tls.Config{}
+	if _, err := fetchStats(t.Context(), addr); err == nil || !strings.Contains(err.Error(), "tls handshake") {
+		t.Fatalf("an unverifiable certificate should fail the handshake, got %v", err)
+	}
+}
+
+func TestTLSOptionsRequireTLS(t *testing.T) {
+	savedDialer, savedProtocol, savedLineSize := serverDialer, statsProtocol, maxLineSize
+	defer func() { serverDialer, statsProtocol, maxLineSize = savedDialer, savedProtocol, savedLineSize }()
+
+	for _, args := range [][]string{
+		{"-tls-ca", "ca.pem"},
+		{"-tls-cert", "client.pem"},
+		{"-tls-key", "client.key"},
+		{"-tls-server-name", "cache.internal"},
+		{"-tls-insecure"},
+	} {
+		fs := flag.NewFlagSet("memtop", flag.ContinueOnError)
+		conn := addConnFlags(fs)
+		if err := fs.Parse(args); err != nil {
+			t.Fatalf("parse %q: %v", args, err)
+		}
+		if _, err := conn.apply(); err == nil || !strings.Contains(err.Error(), "require -tls") {
+			t.Errorf("%q without -tls: err = %v, want it rejected", args, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// Defaults for conditional recording: how much history before a trigger is
+// kept, and how long recording continues after the last one.
+const (
+	defaultTriggerPre  = time.Minute
+	defaultTriggerPost = time.Minute
+)
+
+// bufferedSample is one sampling pass held back until a trigger.
+type bufferedSample struct {
+	At      time.Time
+	Records []sampleRecord
+}
+
+// triggeredRecorder writes samples only around trouble: it keeps the last
+// pre of samples in memory and, when an event of a trigger kind (such as a
+// script alert) is logged, writes them out and keeps recording until post
+// has passed without another trigger. This captures data around anomalies
+// without recording around the clock.
+type triggeredRecorder struct {
+	out       *jsonlWriter
+	kinds     map[string]bool
+	pre, post time.Duration
+	now       func() time.Time
+
+	seq    int
+	until  time.Time
+	buffer []bufferedSample
+}
+
+func newTriggeredRecorder(out *jsonlWriter, kinds []string, pre, post time.Duration) *triggeredRecorder {
+	r := &triggeredRecorder{out: out, kinds: make(map[string]bool), pre: pre, post: post, now: time.Now}
+	for _, k := range kinds {
+		r.kinds[k] = true
+	}
+	return r
+}
+
+// parseTriggerKinds splits a comma-separated list of event kinds.
+func parseTriggerKinds(list string) []string {
+	var kinds []string
+	for _, k := range strings.Split(list, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			kinds = append(kinds, k)
+		}
+	}
+	return kinds
+}
+
+func (r *triggeredRecorder) write(u *ui) error {
+	now := r.now()
+	events, seq := u.events.since(r.seq)
+	r.seq = seq
+	for _, e := range events {
+		if r.kinds[e.Kind] {
+			r.until = now.Add(r.post)
+		}
+	}
+
+	records := sampleRecords(u)
+	if now.After(r.until) {
+		r.buffer = append(r.buffer, bufferedSample{At: now, Records: records})
+		cutoff := now.Add(-r.pre)
+		for len(r.buffer) > 0 && r.buffer[0].At.Before(cutoff) {
+			r.buffer = r.buffer[1:]
+		}
+		return nil
+	}
+	for _, b := range r.buffer {
+		if err := r.out.writeRecords(b.Records); err != nil {
+			return err
+		}
+	}
+	r.buffer = nil
+	return r.out.writeRecords(records)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTriggeredRecorderWritesAroundAlerts(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	u := newUI(time.Second, nil, sess)
+	var buf bytes.Buffer
+	r := newTriggeredRecorder(newJSONLWriter(&buf), parseTriggerKinds("alert, restart"), 2*time.Second, 2*time.Second)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	tick := func(items float64) {
+		sess.current = &statsSnapshot{Timestamp: now, Values: map[string]float64{"curr_items": items}}
+		if err := r.write(u); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		now = now.Add(time.Second)
+	}
+	recorded := func() []float64 {
+		var out []float64
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var rec sampleRecord
+			json.Unmarshal([]byte(line), &rec)
+			out = append(out, rec.Values["curr_items"])
+		}
+		return out
+	}
+
+	for i := 1; i <= 4; i++ {
+		tick(float64(i))
+	}
+	sess.logEvent(now, eventFlush, "not a trigger")
+	tick(5)
+	if buf.Len() != 0 {
+		t.Fatalf("nothing should be written before a trigger, got %q", buf.String())
+	}
+
+	sess.logEvent(now, eventAlert, "evictions spiking")
+	tick(6)
+	tick(7)
+	tick(8)
+	tick(9)
+	tick(10)
+	if got := recorded(); len(got) != 6 || got[0] != 3 || got[5] != 8 {
+		t.Fatalf("want the pre-trigger samples 3-5 and samples until the post window ends (6-8), got %v", got)
+	}
+}
+
+func TestEventLogSince(t *testing.T) {
+	l := newEventLog(3, nil)
+	for i := 0; i < 5; i++ {
+		l.add(event{Message: string(rune('a' + i))})
+	}
+	events, seq := l.since(1)
+	if seq != 5 || len(events) != 3 || events[0].Message != "c" {
+		t.Fatalf("since beyond the retained events = %v, %d", events, seq)
+	}
+	if events, _ := l.since(seq); len(events) != 0 {
+		t.Fatalf("nothing should be new, got %v", events)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isUDPStat reports whether a stat counts UDP traffic. Upstream memcached
+// has none, but builds and forks that serve gets over UDP add them, and
+// their names are all this panel can go by.
+func isUDPStat(name string) bool {
+	return strings.HasPrefix(name, "udp_") || strings.Contains(name, "_udp_") || strings.HasSuffix(name, "_udp")
+}
+
+// isUDPDrop reports whether a UDP stat counts dropped or discarded packets.
+func isUDPDrop(name string) bool {
+	return strings.Contains(name, "drop") || strings.Contains(name, "discard")
+}
+
+// isUDPReceived reports whether a UDP stat counts requests or packets taken
+// in, the traffic drops are measured against.
+func isUDPReceived(name string) bool {
+	if isUDPDrop(name) {
+		return false
+	}
+	for _, part := range []string{"recv", "received", "reqs", "requests", "packets_in"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// udpDropShare is the share of received UDP traffic dropped over the last
+// interval, in percent. It reports false without both kinds of counter or
+// without traffic.
+func udpDropShare(s *session) (float64, bool) {
+	var drops, received float64
+	var haveDrops bool
+	for name, rate := range s.rates {
+		switch {
+		case !isUDPStat(name):
+		case isUDPDrop(name):
+			drops, haveDrops = drops+rate, true
+		case isUDPReceived(name):
+			received += rate
+		}
+	}
+	if !haveDrops || received+drops <= 0 {
+		return 0, false
+	}
+	return drops / (received + drops) * 100, true
+}
+
+// renderUDPPanel lists the UDP counters of a server with UDP enabled, with
+// their rates and the share of traffic dropped: a get lost over UDP looks
+// like a miss to the client, so drops surface as misses nobody can find.
+// Without UDP counters it points at the kernel's, which catch the drops
+// memcached never sees. The panel is hidden while UDP is off.
+func renderUDPPanel(s *session) []panelLine {
+	if s.caps == nil || !s.caps.has(featureUDP) || s.current == nil {
+		return nil
+	}
+	var lines []panelLine
+	if s.caps.UDPPort > 0 {
+		lines = append(lines, plainLine(fmt.Sprintf("%-22s %.0f", "port", s.caps.UDPPort)))
+	}
+	var names []string
+	for name := range s.current.Values {
+		if isUDPStat(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return append(lines,
+			plainLine("The server reports no UDP counters."),
+			plainLine("Drops in the socket buffer show as"),
+			plainLine("RcvbufErrors in netstat -su on the host."))
+	}
+	sort.Strings(names)
+	nf := numberFormat{"udp"}
+	unit := rateUnit(s.interval)
+	for _, name := range names {
+		line := plainLine(fmt.Sprintf("%-22s %s  %s%s", name, nf.count(name, s.current.Values[name]),
+			nf.rate(name, displayRate(rateValue(s.rates, name), s.interval)), unit))
+		if isUDPDrop(name) && rateValue(s.rates, name) > 0 {
+			line.Style = theme.bad
+		}
+		lines = append(lines, line)
+	}
+	if share, ok := udpDropShare(s); ok {
+		line := plainLine(fmt.Sprintf("%-22s %s%%", "dropped", nf.percent("udp_drops", share)))
+		if share > 0 {
+			line.Style = theme.bad.Bold(true)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUDPPanel(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	sess.current = &statsSnapshot{Values: map[string]float64{"curr_items": 5}}
+	if lines := renderUDPPanel(sess); lines != nil {
+		t.Fatalf("panel should be hidden before detection: %+v", lines)
+	}
+	sess.caps = detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21"}},
+		&statsSnapshot{Values: map[string]float64{"udpport": 0}})
+	if lines := renderUDPPanel(sess); lines != nil {
+		t.Fatalf("panel should be hidden with UDP off: %+v", lines)
+	}
+
+	// UDP on, but upstream memcached counts nothing about it.
+	sess.caps = detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21"}},
+		&statsSnapshot{Values: map[string]float64{"udpport": 11211}})
+	lines := renderUDPPanel(sess)
+	if len(lines) != 4 || !strings.Contains(lines[0].Text, "11211") || !strings.Contains(lines[3].Text, "RcvbufErrors") {
+		t.Fatalf("unexpected panel without counters %+v", lines)
+	}
+
+	sess.current.Values["udp_packets_received"] = 10000
+	sess.current.Values["udp_packets_dropped"] = 50
+	sess.rates = map[string]float64{"udp_packets_received": 950, "udp_packets_dropped": 50}
+	lines = renderUDPPanel(sess)
+	var got []string
+	for _, l := range lines {
+		got = append(got, strings.Join(strings.Fields(l.Text), " "))
+	}
+	if want := "port 11211|udp_packets_dropped 50 50.00/s|udp_packets_received 10000 950.00/s|dropped 5.0%"; strings.Join(got, "|") != want {
+		t.Fatalf("panel = %q, want %q", strings.Join(got, "|"), want)
+	}
+	if lines[1].Style != theme.bad || lines[3].Style != theme.bad.Bold(true) || lines[2].Style != plainLine("").Style {
+		t.Fatalf("drops should be highlighted: %+v", lines)
+	}
+}
+
+func TestUDPStatsMarkTheFeature(t *testing.T) {
+	caps := detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.6.21", "udp_reqs": "3"}}, nil)
+	if !caps.has(featureUDP) {
+		t.Fatal("udp_* stats should mark UDP as enabled")
+	}
+	if isUDPStat("budget_used") || !isUDPStat("conn_udp_errors") {
+		t.Fatal("isUDPStat matched the wrong names")
+	}
+}
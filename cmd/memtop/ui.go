@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ui holds the interactive state shared by every monitored server: which
+// server and view are selected, pending input, and display preferences.
+type ui struct {
+	servers  []*session
+	selected int
+	interval time.Duration
+	events   *eventLog
+	// errors collects errors and warnings from every server for the log
+	// view.
+	errors *errorLog
+
+	// pollWorkers bounds how many servers are polled at once, and
+	// pollTimeout how long each server's poll may take (zero derives it
+	// from the interval).
+	pollWorkers int
+	pollTimeout time.Duration
+	// jitter, from -jitter, is the longest random delay before each
+	// server's poll, so several memtop instances watching one server
+	// don't all hit it on the same tick.
+	jitter time.Duration
+
+	view        view
+	prompt      *textPrompt
+	chooser     *columnChooser
+	detail      *statDetail
+	chartMode   chartMode
+	heatmap     heatmapMetric
+	statsOffset int
+	statsCursor int
+	// collapsed holds the stats table groups folded to their heading.
+	collapsed     map[string]bool
+	logOffset     int
+	clusterOffset int
+	groupBy       string
+	suspended     bool
+
+	// lastPass and lastPassWall are when the last sampling pass started, by
+	// the monotonic and the wall clock, to notice the system sleeping.
+	lastPass     time.Time
+	lastPassWall time.Time
+
+	// panes is the split layout, nil for the ordinary single view; focus is
+	// the pane that keys act on.
+	panes *paneNode
+	focus *paneNode
+
+	// plugins run against the selected server while the panels view shows.
+	plugins []*plugin
+	// baseline holds the stats loaded with -baseline, compared against in
+	// the stats view.
+	baseline map[string]float64
+	// columns are the stats table's columns, and configPath the -config
+	// file the column chooser saves them to.
+	columns    []string
+	configPath string
+	// viewRefresh is the least time between runs of each view collector,
+	// from -view-refresh; collectors not listed run every interval.
+	viewRefresh map[string]time.Duration
+	// admin enables keys that change server state, such as applying slab
+	// automove advice.
+	admin bool
+	// script, if set, runs for every server after each poll.
+	script *script
+	// frame remembers what is on screen so redraws only touch changed
+	// cells.
+	frame renderer
+	// keysTaken is set while a prompt, the column chooser, or the detail
+	// popup takes the keys, so the event goroutine doesn't abort a pass
+	// for a q or Esc meant for them. The main loop refreshes it between
+	// events.
+	keysTaken atomic.Bool
+	// panics restores the terminal when background work panics; nil
+	// without a screen.
+	panics *panicGuard
+	// control delivers remote control requests from -listen; nil when the
+	// endpoint is off.
+	control chan controlRequest
+	// sinks receive every completed sampling pass, with only the stats
+	// metrics matches when it is set.
+	sinks   sinkSet
+	metrics *regexp.Regexp
+	// focus tracks whether the terminal is in the foreground, for desktop
+	// notifications.
+	termFocus focusState
+}
+
+// newUI wires the sessions to one shared event log so notes and per-server
+// events end up on a single timeline.
+func newUI(interval time.Duration, events *eventLog, servers ...*session) *ui {
+	if events == nil {
+		events = newEventLog(defaultEventLimit, nil)
+	}
+	errors := newErrorLog(defaultErrorLimit, nil)
+	for _, s := range servers {
+		s.events = events
+		s.errors = errors
+	}
+	return &ui{
+		servers:     servers,
+		interval:    interval,
+		events:      events,
+		errors:      errors,
+		pollWorkers: defaultPollWorkers,
+		columns:     defaultStatsColumns,
+	}
+}
+
+// takingKeys reports whether keys go to a dialog rather than the view.
+func (u *ui) takingKeys() bool {
+	return u.prompt != nil || u.chooser != nil || u.detail != nil
+}
+
+// abortsPass reports whether key, read while a pass may be running, should
+// abort it: a quit key, unless a dialog is taking the keys.
+func (u *ui) abortsPass(key *tcell.EventKey) bool {
+	return isQuitKey(key) && !u.keysTaken.Load()
+}
+
+// current returns the server whose details are on screen.
+func (u *ui) current() *session {
+	return u.servers[u.selected]
+}
+
+// selectServer moves the selection by delta, wrapping around the fleet.
+func (u *ui) selectServer(delta int) {
+	n := len(u.servers)
+	u.selected = ((u.selected+delta)%n + n) % n
+}
+
+// addNote records a timestamped annotation; it shows up in the event log (and
+// its file) and as a marker on the history charts.
+func (u *ui) addNote(t time.Time, text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	u.events.add(event{Time: t, Kind: eventNote, Message: text})
+}
+
+// suspendedBanner is shown under the header while polling is suspended.
+const suspendedBanner = " SUSPENDED: polling paused, press p to resume "
+
+var suspendedStyle = theme.banner
+
+// toggleSuspend stops or restarts polling. While suspended memtop sends
+// nothing to any server, for maintenance where even `stats` calls must stop;
+// both transitions are recorded on the timeline.
+func (u *ui) toggleSuspend(t time.Time) {
+	u.suspended = !u.suspended
+	message := "polling resumed"
+	if u.suspended {
+		message = "polling suspended"
+	}
+	u.events.add(event{Time: t, Kind: eventSuspend, Message: message})
+}
+
+// tagKeys lists every tag name used across the fleet, sorted.
+func (u *ui) tagKeys() []string {
+	seen := make(map[string]bool)
+	for _, s := range u.servers {
+		for k := range s.tags {
+			seen[k] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cycleGroupBy advances the cluster grouping through "no grouping" and each
+// tag name in turn.
+func (u *ui) cycleGroupBy() {
+	options := append([]string{""}, u.tagKeys()...)
+	for i, k := range options {
+		if k == u.groupBy {
+			u.groupBy = options[(i+1)%len(options)]
+			return
+		}
+	}
+	u.groupBy = ""
+}
+
+// serverLabel describes the selected server for the header, including its
+// position in the fleet and its tags when more than one server is watched.
+func (u *ui) serverLabel() string {
+	s := u.current()
+	if len(u.servers) == 1 {
+		return s.addr
+	}
+	label := fmt.Sprintf("%s [%d/%d]", s.addr, u.selected+1, len(u.servers))
+	if tags := formatTags(s.tags); tags != "" {
+		label += " " + tags
+	}
+	return label
+}
+
+// formatTags renders tags as sorted key=value pairs.
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+tags[k])
+	}
+	return strings.Join(parts, " ")
+}
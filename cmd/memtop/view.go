@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// view identifies which screen memtop is currently showing.
+type view int
+
+const (
+	viewSummary view = iota
+	viewSlabs
+	viewPanels
+	viewStats
+	viewCluster
+	viewProxy
+	viewLog
+)
+
+// views lists the selectable views in the order of their number keys, with
+// the server feature each one depends on.
+var views = []struct {
+	view     view
+	name     string
+	requires feature
+}{
+	{viewSummary, "summary", featureNone},
+	{viewSlabs, "slabs", featureNone},
+	{viewPanels, "panels", featureNone},
+	{viewStats, "stats", featureNone},
+	{viewCluster, "cluster", featureNone},
+	{viewProxy, "proxy", featureProxy},
+	{viewLog, "log", featureNone},
+}
+
+// viewRequires returns the feature a view depends on.
+func viewRequires(v view) feature {
+	for _, entry := range views {
+		if entry.view == v {
+			return entry.requires
+		}
+	}
+	return featureNone
+}
+
+// viewName returns a view's display name.
+func viewName(v view) string {
+	for _, entry := range views {
+		if entry.view == v {
+			return entry.name
+		}
+	}
+	return ""
+}
+
+// viewForKey maps a number key to its view.
+func viewForKey(r rune) (view, bool) {
+	idx := int(r - '1')
+	if idx < 0 || idx >= len(views) {
+		return viewSummary, false
+	}
+	return views[idx].view, true
+}
+
+// viewHelp renders the footer hint listing the view keys, marking the active
+// one and, with a trailing "(n/a)", those the server s can't provide.
+func viewHelp(active view, s *session) string {
+	parts := make([]string, 0, len(views))
+	for i, v := range views {
+		name := v.name
+		if !s.supports(v.requires) {
+			name += " (n/a)"
+		}
+		if v.view == active {
+			name = "[" + name + "]"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", i+1, name))
+	}
+	return strings.Join(parts, " ")
+}
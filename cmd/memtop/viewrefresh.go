@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseViewRefresh reads per-view refresh intervals such as
+// "slabs=10s,proxy=5s": the least time between runs of each named view
+// collector, so expensive queries can poll slower than the summary.
+func parseViewRefresh(list string) (map[string]time.Duration, error) {
+	refresh := make(map[string]time.Duration)
+	for _, entry := range strings.Split(list, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("view refresh %q: want name=duration", entry)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("view refresh %q: %w", entry, err)
+		}
+		refresh[strings.TrimSpace(name)] = d
+	}
+	return refresh, validateViewRefresh(refresh)
+}
+
+// validateViewRefresh rejects names that are not view collectors and
+// negative intervals.
+func validateViewRefresh(refresh map[string]time.Duration) error {
+	for name, d := range refresh {
+		if !isViewCollector(name) {
+			return fmt.Errorf("view refresh: unknown view collector %q (want %s)", name, strings.Join(viewCollectorNames(), ", "))
+		}
+		if d < 0 {
+			return fmt.Errorf("view refresh: %s interval %s is negative", name, d)
+		}
+	}
+	return nil
+}
+
+func isViewCollector(name string) bool {
+	for _, c := range collectors {
+		if c.Name == name && c.Scope == collectForView {
+			return true
+		}
+	}
+	return false
+}
+
+// viewCollectorNames lists the collectors a refresh interval can be set for.
+func viewCollectorNames() []string {
+	var names []string
+	for _, c := range collectors {
+		if c.Scope == collectForView {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// collectorDue reports whether a view collector's refresh interval has
+// passed since it last ran against s. A collector that has never run is
+// always due, so switching to a view shows data at once.
+func (s *session) collectorDue(name string, every time.Duration, now time.Time) bool {
+	last, ok := s.lastCollected[name]
+	return !ok || every <= 0 || now.Sub(last) >= every
+}
+
+// markCollected notes that a view collector just ran against s.
+func (s *session) markCollected(name string, now time.Time) {
+	if s.lastCollected == nil {
+		s.lastCollected = make(map[string]time.Time)
+	}
+	s.lastCollected[name] = now
+}
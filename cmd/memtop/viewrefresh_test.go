@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseViewRefresh(t *testing.T) {
+	refresh, err := parseViewRefresh(" slabs=10s, proxy = 5s ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refresh["slabs"] != 10*time.Second || refresh["proxy"] != 5*time.Second || len(refresh) != 2 {
+		t.Fatalf("parsed %v", refresh)
+	}
+	for _, bad := range []string{"slabs", "slabs=fast", "summary=10s", "probe=10s", "slabs=-1s"} {
+		if _, err := parseViewRefresh(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestRunCollectorsHonoursViewRefresh(t *testing.T) {
+	var ran []string
+	record := func(name string) func(context.Context, *ui, *session) {
+		return func(context.Context, *ui, *session) { ran = append(ran, name) }
+	}
+	saved := collectors
+	defer func() { collectors = saved }()
+	collectors = []collector{
+		{Name: "slabs", Scope: collectForView, Views: []view{viewSlabs}, Collect: record("slabs")},
+		{Name: "plugins", Scope: collectForView, Views: []view{viewSlabs}, Collect: record("plugins")},
+	}
+
+	u := newUI(time.Second, nil, newSession("a:11211", time.Second))
+	u.view = viewSlabs
+	u.viewRefresh = map[string]time.Duration{"slabs": time.Hour}
+	runCollectors(context.Background(), u, u.current(), collectForView)
+	runCollectors(context.Background(), u, u.current(), collectForView)
+	if !slices.Equal(ran, []string{"slabs", "plugins", "plugins"}) {
+		t.Fatalf("collectors ran %q, want slabs once and plugins every time", ran)
+	}
+
+	ran = nil
+	u.current().lastCollected["slabs"] = time.Now().Add(-2 * time.Hour)
+	runCollectors(context.Background(), u, u.current(), collectForView)
+	if !slices.Contains(ran, "slabs") {
+		t.Fatalf("collectors ran %q, want slabs once its interval passed", ran)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// warmupWindow is how many recent interval hit ratios feed the recovery
+	// slope.
+	warmupWindow = 20
+	// warmupTolerance is how close, in percentage points, the hit ratio must
+	// get to its baseline for the warm-up to count as over.
+	warmupTolerance = 1.0
+	// warmupMaxAge drops a warm-up that never recovers, for instance because
+	// the workload changed and the old hit ratio is no longer reachable.
+	warmupMaxAge = time.Hour
+)
+
+// warmup follows the hit ratio back up after a restart or flush_all emptied
+// the cache.
+type warmup struct {
+	Cause    string // eventRestart or eventFlush
+	Started  time.Time
+	Baseline float64 // windowed hit ratio before the event, percent
+	times    []time.Time
+	ratios   []float64 // interval hit ratios since the event
+}
+
+// observeWarmup starts a warm-up when curr shows a restart or flush since
+// prev, using ratioBefore (the windowed hit ratio up to prev) as the target,
+// and feeds later samples to the one in progress until the hit ratio is back.
+func (s *session) observeWarmup(prev, curr *statsSnapshot, ratioBefore float64) {
+	if prev == nil {
+		return
+	}
+	cause := ""
+	switch {
+	case curr.Values["uptime"] < prev.Values["uptime"]:
+		cause = eventRestart
+	case curr.Values["cmd_flush"] > prev.Values["cmd_flush"]:
+		cause = eventFlush
+	}
+	if cause != "" {
+		s.warmup = nil
+		if !math.IsNaN(ratioBefore) {
+			s.warmup = &warmup{Cause: cause, Started: curr.Timestamp, Baseline: ratioBefore}
+		}
+		return
+	}
+	w := s.warmup
+	if w == nil {
+		return
+	}
+	if curr.Timestamp.Sub(w.Started) > warmupMaxAge {
+		s.warmup = nil
+		return
+	}
+	hits := curr.Values["get_hits"] - prev.Values["get_hits"]
+	misses := curr.Values["get_misses"] - prev.Values["get_misses"]
+	if hits+misses <= 0 {
+		return
+	}
+	ratio := hits / (hits + misses) * 100
+	if ratio >= w.Baseline-warmupTolerance {
+		s.warmup = nil
+		return
+	}
+	w.times = lastN(append(w.times, curr.Timestamp), warmupWindow)
+	w.ratios = lastN(append(w.ratios, ratio), warmupWindow)
+}
+
+// eta projects when the hit ratio reaches the baseline from the slope of the
+// recent interval ratios, measured from the last of them. It reports false
+// while there are too few samples or the ratio isn't rising.
+func (w *warmup) eta() (time.Duration, float64, bool) {
+	slope, ok := linearSlope(w.times, w.ratios)
+	if !ok || slope <= 0 {
+		return 0, slope, false
+	}
+	remaining := w.Baseline - w.ratios[len(w.ratios)-1]
+	return time.Duration(remaining / slope * float64(time.Second)), slope, true
+}
+
+// describeWarmup renders the warm-up line of the summary, or "" when no
+// warm-up is in progress.
+func describeWarmup(s *session, now time.Time) string {
+	w := s.warmup
+	if w == nil {
+		return ""
+	}
+	nf := numberFormat{"summary"}
+	cause := "restart"
+	if w.Cause == eventFlush {
+		cause = "flush_all"
+	}
+	text := fmt.Sprintf("Warm-up after %s %s ago: hit ratio ", cause, shortDuration(now.Sub(w.Started).Round(time.Second)))
+	if len(w.ratios) == 0 {
+		return text + fmt.Sprintf("back to %s%% expected, waiting for gets...", nf.percent("", w.Baseline))
+	}
+	text += fmt.Sprintf("%s%% of %s%%", nf.percent("", w.ratios[len(w.ratios)-1]), nf.percent("", w.Baseline))
+	eta, slope, ok := w.eta()
+	switch {
+	case ok:
+		return text + fmt.Sprintf(", %+.1f pts/min, back in ~%s", slope*60, formatUptime(eta.Seconds()))
+	case len(w.ratios) < 3:
+		return text + ", estimating..."
+	default:
+		return text + ", not recovering yet"
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWarmupEstimatesRecoveryAfterFlush(t *testing.T) {
+	s := newSession("a:11211", 10*time.Second)
+	newUI(10*time.Second, nil, s)
+	start := time.Now()
+	var hits, misses, step int
+	sample := func(gets, hitPercent, flushes int) {
+		hits += gets * hitPercent / 100
+		misses += gets - gets*hitPercent/100
+		step++
+		s.record(newStatsSnapshot(start.Add(time.Duration(step)*10*time.Second), map[string]string{
+			"uptime":     strconv.Itoa(1000 + step*10),
+			"cmd_flush":  strconv.Itoa(flushes),
+			"get_hits":   strconv.Itoa(hits),
+			"get_misses": strconv.Itoa(misses),
+		}), nil)
+	}
+	sample(1000, 90, 0)
+	sample(1000, 90, 0)
+	if describeWarmup(s, start) != "" {
+		t.Fatal("no warm-up expected before a flush")
+	}
+
+	sample(1000, 50, 1)
+	if s.warmup == nil || s.warmup.Cause != eventFlush || s.warmup.Baseline != 90 {
+		t.Fatalf("flush should start a warm-up: %+v", s.warmup)
+	}
+	sample(1000, 50, 1)
+	if got := describeWarmup(s, start.Add(40*time.Second)); got != "Warm-up after flush_all 10s ago: hit ratio 50.0% of 90.0%, estimating..." {
+		t.Fatalf("describeWarmup = %q", got)
+	}
+	sample(1000, 60, 1)
+	sample(1000, 70, 1)
+	// Ten points every ten seconds leaves 20 points to go: 20s.
+	if got := describeWarmup(s, start.Add(60*time.Second)); !strings.HasSuffix(got, "70.0% of 90.0%, +60.0 pts/min, back in ~00h 00m 20s") {
+		t.Fatalf("describeWarmup = %q", got)
+	}
+
+	for _, percent := range []int{55, 45, 40, 35} {
+		sample(1000, percent, 1)
+	}
+	if got := describeWarmup(s, start.Add(100*time.Second)); !strings.HasSuffix(got, "not recovering yet") {
+		t.Fatalf("describeWarmup = %q", got)
+	}
+
+	sample(1000, 90, 1)
+	if s.warmup != nil {
+		t.Fatalf("warm-up should end at the baseline: %+v", s.warmup)
+	}
+}
+
+func TestWarmupNeedsABaseline(t *testing.T) {
+	s := newSession("a:11211", 10*time.Second)
+	newUI(10*time.Second, nil, s)
+	start := time.Now()
+	s.record(newStatsSnapshot(start, map[string]string{"uptime": "1000"}), nil)
+	s.record(newStatsSnapshot(start.Add(10*time.Second), map[string]string{"uptime": "5"}), nil)
+	if s.warmup != nil {
+		t.Fatalf("a restart without a known hit ratio has nothing to return to: %+v", s.warmup)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxKeyLength is memcached's limit on key length.
+const maxKeyLength = 250
+
+// watchedKey is the latest meta-get result for one configured key.
+type watchedKey struct {
+	Key   string
+	Found bool
+	Size  int64
+	TTL   int64 // seconds remaining, -1 when the item never expires
+	Err   string
+}
+
+// validKey reports whether key can be sent as-is in a text protocol command.
+func validKey(key string) bool {
+	if key == "" || len(key) > maxKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if r <= ' ' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// fetchWatchedKeys looks up every key with a value-less meta-get (`mg <key>
+// s t`), pipelining the requests and ending them with a no-op so the replies
+// can be read in one pass.
+func fetchWatchedKeys(ctx context.Context, addr string, keys []string) ([]watchedKey, error) {
+	conn, err := dialServer(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var req strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&req, "mg %s s t\r\n", key)
+	}
+	req.WriteString("mn\r\n")
+	if _, err := fmt.Fprint(conn, req.String()); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	out := make([]watchedKey, 0, len(keys))
+	for _, key := range keys {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, parseMetaGet(key, line))
+	}
+	if line, err := readLine(r); err != nil || line != "MN" {
+		return nil, fmt.Errorf("unexpected meta no-op reply %q", line)
+	}
+	return out, nil
+}
+
+// parseMetaGet decodes a meta-get reply. Servers before 1.6.10 answer hits
+// with OK rather than HD.
+func parseMetaGet(key, line string) watchedKey {
+	w := watchedKey{Key: key, TTL: -1}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		w.Err = "empty reply"
+		return w
+	}
+	switch fields[0] {
+	case "EN":
+		return w
+	case "HD", "OK":
+		w.Found = true
+	default:
+		w.Err = line
+		return w
+	}
+	for _, flag := range fields[1:] {
+		value, err := strconv.ParseInt(flag[1:], 10, 64)
+		if err != nil {
+			continue
+		}
+		switch flag[0] {
+		case 's':
+			w.Size = value
+		case 't':
+			w.TTL = value
+		}
+	}
+	return w
+}
+
+// recordWatchedKeys stores the outcome of a lookup pass.
+func (s *session) recordWatchedKeys(keys []watchedKey, err error) {
+	if err != nil {
+		s.watchErr = err
+		return
+	}
+	s.watchErr = nil
+	s.watched = keys
+}
+
+// renderWatchedKeysPanel lists each watched key's presence, size and TTL. It
+// is hidden when no keys are configured.
+func renderWatchedKeysPanel(s *session) []panelLine {
+	if len(s.watchKeys) == 0 {
+		return nil
+	}
+	if !s.supports(featureMeta) {
+		return []panelLine{plainLine(notSupported)}
+	}
+	if s.watchErr != nil {
+		return []panelLine{plainLine(fmt.Sprintf("Error: %v", s.watchErr))}
+	}
+	if s.watched == nil {
+		return []panelLine{plainLine("Waiting for first lookup...")}
+	}
+	lines := make([]panelLine, 0, len(s.watched))
+	for _, w := range s.watched {
+		var text string
+		style := tcell.StyleDefault
+		switch {
+		case w.Err != "":
+			text, style = w.Err, eventStyle(eventConnLost)
+		case !w.Found:
+			text, style = "missing", eventStyle(eventFlush)
+		case w.TTL < 0:
+			text = fmt.Sprintf("%s  no expiry", formatBytes(float64(w.Size)))
+		default:
+			text = fmt.Sprintf("%s  ttl %s", formatBytes(float64(w.Size)), formatUptime(float64(w.TTL)))
+		}
+		lines = append(lines, panelLine{Text: fmt.Sprintf("%-20s %s", w.Key, text), Style: style})
+	}
+	return lines
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMetaGet(t *testing.T) {
+	tests := map[string]watchedKey{
+		"HD s1024 t300":                        {Key: "k", Found: true, Size: 1024, TTL: 300},
+		"OK s10 t-1":                           {Key: "k", Found: true, Size: 10, TTL: -1},
+		"EN":                                   {Key: "k", TTL: -1},
+		"CLIENT_ERROR bad command line format": {Key: "k", TTL: -1, Err: "CLIENT_ERROR bad command line format"},
+	}
+	for line, want := range tests {
+		if got := parseMetaGet("k", line); got != want {
+			t.Errorf("parseMetaGet(%q) = %+v, want %+v", line, got, want)
+		}
+	}
+}
+
+func TestFetchWatchedKeysPipelinesMetaGets(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	defer ln.Close()
+	commands := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		var seen []string
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+			seen = append(seen, line)
+			switch {
+			case line == "mn":
+				fmt.Fprint(conn, "MN\r\n")
+				commands <- seen
+				return
+			case strings.HasPrefix(line, "mg flags "):
+				fmt.Fprint(conn, "HD s2048 t-1\r\n")
+			default:
+				fmt.Fprint(conn, "EN\r\n")
+			}
+		}
+	}()
+
+	keys, err := fetchWatchedKeys(context.Background(), ln.Addr().String(), []string{"flags", "gone"})
+	if err != nil {
+		t.Fatalf("fetchWatchedKeys returned error: %v", err)
+	}
+	if got := <-commands; strings.Join(got, "|") != "mg flags s t|mg gone s t|mn" {
+		t.Fatalf("unexpected commands %q", got)
+	}
+	if len(keys) != 2 || !keys[0].Found || keys[0].Size != 2048 || keys[1].Found {
+		t.Fatalf("unexpected results %+v", keys)
+	}
+
+	sess := newSession(ln.Addr().String(), time.Second)
+	sess.watchKeys = []string{"flags", "gone"}
+	sess.recordWatchedKeys(keys, nil)
+	lines := renderWatchedKeysPanel(sess)
+	if len(lines) != 2 || !strings.Contains(lines[0].Text, "no expiry") || !strings.Contains(lines[1].Text, "missing") {
+		t.Fatalf("unexpected panel %+v", lines)
+	}
+}
+
+func TestWatchedKeysPanelGating(t *testing.T) {
+	sess := newSession("127.0.0.1:11211", time.Second)
+	if lines := renderWatchedKeysPanel(sess); lines != nil {
+		t.Fatalf("panel should be hidden without watched keys, got %+v", lines)
+	}
+	sess.watchKeys = []string{"flags"}
+	sess.caps = detectCapabilities(&statsSnapshot{Raw: map[string]string{"version": "1.5.22"}}, nil)
+	if lines := renderWatchedKeysPanel(sess); len(lines) != 1 || lines[0].Text != notSupported {
+		t.Fatalf("servers without meta commands should be marked unsupported, got %+v", lines)
+	}
+}
+
+func TestParseConfigValidatesWatchKeys(t *testing.T) {
+	cfg, err := parseConfig([]byte(`{"watch_keys": ["feature-flags"]}`))
+	if err != nil || len(cfg.WatchKeys) != 1 {
+		t.Fatalf("valid watch keys rejected: %v", err)
+	}
+	if _, err := parseConfig([]byte(`{"watch_keys": ["has space"]}`)); err == nil {
+		t.Fatalf("keys with whitespace should be rejected")
+	}
+}
@@ -2,9 +2,15 @@ module mymemcache-top
 
 go 1.24.2
 
+require (
+	github.com/gdamore/tcell/v2 v2.8.1
+	go.starlark.net v0.0.0-20250225190231-0d3f41d403af
+	golang.org/x/crypto v0.32.0
+	golang.org/x/net v0.34.0
+)
+
 require (
 	github.com/gdamore/encoding v1.0.1 // indirect
-	github.com/gdamore/tcell/v2 v2.8.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
 	github.com/mattn/go-runewidth v0.0.16 // indirect
 	github.com/rivo/uniseg v0.4.3 // indirect
@@ -0,0 +1,114 @@
+package memstats
+
+import "reflect"
+
+// Aggregation is how one stat combines across servers.
+type Aggregation int
+
+const (
+	// Sum adds the values: counters and additive gauges (bytes,
+	// limit_maxbytes, curr_items, curr_connections, threads, ...).
+	Sum Aggregation = iota
+	// Min keeps the smallest value: uptime, so the aggregate is as old as
+	// its youngest server, and flags such as accepting_conns, which are
+	// only true of the cluster when true of every server.
+	Min
+	// Max keeps the largest value: the server clock, and per-server states
+	// such as hash_is_expanding that matter when any server is in them.
+	Max
+	// Same keeps the value when every server reports the same one and
+	// clears it otherwise: pid, version, pointer_size.
+	Same
+)
+
+// String returns the rule's name as used in the agg struct tag.
+func (a Aggregation) String() string {
+	switch a {
+	case Min:
+		return "min"
+	case Max:
+		return "max"
+	case Same:
+		return "same"
+	default:
+		return "sum"
+	}
+}
+
+// parseAggregation reads an agg struct tag; untagged fields are summed.
+func parseAggregation(tag string) Aggregation {
+	for _, a := range []Aggregation{Min, Max, Same} {
+		if tag == a.String() {
+			return a
+		}
+	}
+	return Sum
+}
+
+// otherRules covers stats of the general reply that Stats has no field for
+// but whose sum would mislead.
+var otherRules = map[string]Aggregation{
+	"libevent":            Same,
+	"hash_power_level":    Max,
+	"hash_is_expanding":   Max,
+	"lru_crawler_running": Max,
+}
+
+// AggregationOf returns the rule for combining stat across servers. Stats
+// without a rule of their own, including ones newer than this package, are
+// summed, which is right for the counters that make up most of the reply.
+func AggregationOf(stat string) Aggregation {
+	if f, ok := fields[stat]; ok {
+		return f.agg
+	}
+	if a, ok := otherRules[stat]; ok {
+		return a
+	}
+	return Sum
+}
+
+// Aggregate combines the stats of several servers by each field's rule. The
+// result of no servers is zero.
+func Aggregate(servers ...Stats) Stats {
+	var total Stats
+	if len(servers) == 0 {
+		return total
+	}
+	out := reflect.ValueOf(&total).Elem()
+	for _, f := range fields {
+		dst := out.Field(f.index)
+		for i, s := range servers {
+			src := reflect.ValueOf(s).Field(f.index)
+			if i == 0 {
+				dst.Set(src)
+				continue
+			}
+			switch f.agg {
+			case Same:
+				if !dst.Equal(src) {
+					dst.SetZero()
+				}
+			case Min, Max:
+				if less(src, dst) == (f.agg == Min) && !src.Equal(dst) {
+					dst.Set(src)
+				}
+			default:
+				switch f.kind {
+				case reflect.Uint64:
+					dst.SetUint(dst.Uint() + src.Uint())
+				case reflect.Float64:
+					dst.SetFloat(dst.Float() + src.Float())
+				}
+			}
+		}
+	}
+	return total
+}
+
+// less compares two numeric field values of the same kind.
+func less(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Float64 {
+		return a.Float() < b.Float()
+	}
+	return a.Uint() < b.Uint()
+}
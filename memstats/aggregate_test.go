@@ -0,0 +1,48 @@
+package memstats
+
+import "testing"
+
+func TestAggregateFollowsEachFieldsRule(t *testing.T) {
+	busy := Stats{PID: 10, Uptime: 86400, Time: 1000, Version: "1.6.21", PointerSize: 64, AcceptingConns: 1,
+		GetHits: 900, GetMisses: 100, Bytes: 512, LimitMaxbytes: 1024, RusageUser: 1.5}
+	idle := Stats{PID: 11, Uptime: 60, Time: 1002, Version: "1.6.21", PointerSize: 64, AcceptingConns: 0,
+		GetMisses: 10, Bytes: 0, LimitMaxbytes: 3072, RusageUser: 0.5}
+
+	total := Aggregate(busy, idle)
+	if total.PID != 0 || total.Version != "1.6.21" || total.PointerSize != 64 {
+		t.Fatalf("same rule not applied: %+v", total)
+	}
+	if total.Uptime != 60 || total.AcceptingConns != 0 || total.Time != 1002 {
+		t.Fatalf("min and max rules not applied: %+v", total)
+	}
+	if total.GetHits != 900 || total.GetMisses != 110 || total.RusageUser != 2 {
+		t.Fatalf("sums wrong: %+v", total)
+	}
+	// The mean of the per-server ratios would be 0.45 and 0.25.
+	if got := total.HitRatio(); got != float64(900)/1010 {
+		t.Fatalf("aggregate hit ratio = %v", got)
+	}
+	if got := total.MemoryRatio(); got != 0.125 {
+		t.Fatalf("aggregate memory ratio = %v", got)
+	}
+
+	if Aggregate() != (Stats{}) || Aggregate(busy) != busy {
+		t.Fatal("aggregate of none or one server should be zero or that server")
+	}
+}
+
+func TestAggregationOf(t *testing.T) {
+	for stat, want := range map[string]Aggregation{
+		"cmd_get":           Sum,
+		"curr_connections":  Sum,
+		"uptime":            Min,
+		"time":              Max,
+		"version":           Same,
+		"hash_is_expanding": Max,
+		"some_future_stat":  Sum,
+	} {
+		if got := AggregationOf(stat); got != want {
+			t.Errorf("AggregationOf(%q) = %s, want %s", stat, got, want)
+		}
+	}
+}
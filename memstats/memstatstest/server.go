@@ -0,0 +1,221 @@
+// Package memstatstest provides a scriptable fake memcached server for tests
+// of code that reads server stats, such as memtop itself or programs built
+// on the memstats package.
+//
+// A Server speaks enough of the text protocol for stats tooling: it answers
+// `stats` and `stats <section>` with preloaded replies, any other command
+// with a canned reply or ERROR, and can be told to answer slowly or hang up
+// on clients to exercise timeouts and error handling.
+package memstatstest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake memcached server listening on a loopback port. Its
+// methods are safe to call while clients are connected.
+type Server struct {
+	ln net.Listener
+
+	mu        sync.Mutex
+	stats     map[string][]map[string]string
+	replies   map[string]string
+	latency   time.Duration
+	drop      int
+	commands  []string
+	conns     map[net.Conn]bool
+	closed    bool
+	waitGroup sync.WaitGroup
+}
+
+// NewServer starts a server on 127.0.0.1 with no stats loaded. It panics if
+// it cannot listen, like httptest.NewServer.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("memstatstest: failed to listen: %v", err))
+	}
+	s := &Server{
+		ln:      ln,
+		stats:   make(map[string][]map[string]string),
+		replies: make(map[string]string),
+		conns:   make(map[net.Conn]bool),
+	}
+	s.waitGroup.Add(1)
+	go s.serve()
+	return s
+}
+
+// Addr is the host:port clients connect to.
+func (s *Server) Addr() string {
+	return s.ln.Addr().String()
+}
+
+// Close stops the server, hangs up on connected clients, and waits for its
+// goroutines to finish.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	s.ln.Close()
+	s.waitGroup.Wait()
+}
+
+// SetStats loads the replies to `stats <section>`, the general `stats` for
+// an empty section. Each request gets the next reply and the last one
+// repeats, so a sequence of replies scripts counters moving between polls.
+// Without replies the section answers ERROR, like an unknown one.
+func (s *Server) SetStats(section string, replies ...map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(replies) == 0 {
+		delete(s.stats, section)
+		return
+	}
+	s.stats[section] = replies
+}
+
+// SetReply makes the server answer command, the request line without its
+// line ending, with reply, sent as is. Replies set here take precedence over
+// the stats ones.
+func (s *Server) SetReply(command, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replies[command] = reply
+}
+
+// SetLatency delays every reply by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// DropRequests makes the server hang up on the next n requests after reading
+// them, without answering, as a crashing or restarting server would.
+func (s *Server) DropRequests(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drop = n
+}
+
+// Disconnect hangs up on every connected client. The server keeps
+// accepting new connections.
+func (s *Server) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Commands returns the request lines received so far, oldest first.
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+func (s *Server) serve() {
+	defer s.waitGroup.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = true
+		s.waitGroup.Add(1)
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer s.waitGroup.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimRight(line, "\r\n")
+		reply, latency, drop := s.respond(command)
+		if drop || command == "quit" {
+			return
+		}
+		if latency > 0 {
+			time.Sleep(latency)
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+// respond records command and works out its reply.
+func (s *Server) respond(command string) (reply string, latency time.Duration, drop bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, command)
+	if s.drop > 0 {
+		s.drop--
+		return "", 0, true
+	}
+	if reply, ok := s.replies[command]; ok {
+		return reply, s.latency, false
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 || len(fields) > 2 || fields[0] != "stats" {
+		return "ERROR\r\n", s.latency, false
+	}
+	section := ""
+	if len(fields) == 2 {
+		section = fields[1]
+	}
+	replies := s.stats[section]
+	if len(replies) == 0 {
+		return "ERROR\r\n", s.latency, false
+	}
+	stats := replies[0]
+	if len(replies) > 1 {
+		s.stats[section] = replies[1:]
+	}
+	return FormatStats(stats), s.latency, false
+}
+
+// FormatStats renders stats as a stats reply, `STAT name value` lines in
+// name order followed by END.
+func FormatStats(stats map[string]string) string {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "STAT %s %s\r\n", name, stats[name])
+	}
+	b.WriteString("END\r\n")
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package memstatstest
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// request sends one command on a new connection and reads the reply up to
+// END, ERROR, or the connection closing.
+func request(t *testing.T, addr, command string) (string, error) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Write([]byte(command + "\r\n")); err != nil {
+		return "", err
+	}
+	var reply strings.Builder
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		reply.WriteString(line)
+		if err != nil {
+			return reply.String(), err
+		}
+		if line == "END\r\n" || line == "ERROR\r\n" {
+			return reply.String(), nil
+		}
+	}
+}
+
+func TestServerStepsThroughScriptedStatsReplies(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetStats("", map[string]string{"cmd_get": "1", "version": "1.6.21"}, map[string]string{"cmd_get": "5"})
+	s.SetStats("slabs", map[string]string{"1:chunk_size": "96"})
+
+	for _, want := range []string{
+		"STAT cmd_get 1\r\nSTAT version 1.6.21\r\nEND\r\n",
+		"STAT cmd_get 5\r\nEND\r\n",
+		"STAT cmd_get 5\r\nEND\r\n",
+	} {
+		if got, err := request(t, s.Addr(), "stats"); err != nil || got != want {
+			t.Fatalf("stats = %q, %v; want %q", got, err, want)
+		}
+	}
+	if got, _ := request(t, s.Addr(), "stats slabs"); got != "STAT 1:chunk_size 96\r\nEND\r\n" {
+		t.Fatalf("stats slabs = %q", got)
+	}
+	if got, _ := request(t, s.Addr(), "stats items"); got != "ERROR\r\n" {
+		t.Fatalf("unloaded section = %q, want ERROR", got)
+	}
+	want := []string{"stats", "stats", "stats", "stats slabs", "stats items"}
+	if got := s.Commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}
+
+func TestServerCannedRepliesLatencyAndDrops(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.SetStats("", map[string]string{"uptime": "10"})
+	s.SetReply("lru_crawler metadump all", "key=a exp=-1 la=1 cas=1 fetch=no cls=1 size=60\r\nEND\r\n")
+	if got, _ := request(t, s.Addr(), "lru_crawler metadump all"); !strings.HasPrefix(got, "key=a ") {
+		t.Fatalf("canned reply = %q", got)
+	}
+
+	s.SetLatency(100 * time.Millisecond)
+	start := time.Now()
+	request(t, s.Addr(), "stats")
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatalf("reply came after %s, want the latency", elapsed)
+	}
+	s.SetLatency(0)
+
+	s.DropRequests(1)
+	if got, err := request(t, s.Addr(), "stats"); err == nil || got != "" {
+		t.Fatalf("dropped request answered %q, %v", got, err)
+	}
+	if got, err := request(t, s.Addr(), "stats"); err != nil || got != "STAT uptime 10\r\nEND\r\n" {
+		t.Fatalf("request after the drop = %q, %v", got, err)
+	}
+}
+
+func TestServerDisconnectHangsUpOnClients(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	conn, err := net.Dial("tcp", s.Addr())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	// Wait until the server has the connection before hanging up.
+	conn.Write([]byte("version\r\n"))
+	r := bufio.NewReader(conn)
+	if line, _ := r.ReadString('\n'); line != "ERROR\r\n" {
+		t.Fatalf("version = %q, want ERROR", line)
+	}
+	s.Disconnect()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Fatalf("connection should be closed")
+	}
+}
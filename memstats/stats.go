@@ -0,0 +1,148 @@
+// Package memstats turns memcached's `stats` reply into typed fields.
+//
+// The reply is a list of name/value strings. Most values are 64-bit counters
+// that lose precision once they pass 2^53 as float64 (bytes_read and
+// bytes_written on a long-running server get there), so counters and gauges
+// are parsed as uint64 here. Consumers get compile-time checked field names
+// instead of string keys.
+//
+// Aggregate combines the stats of several servers into cluster totals. Each
+// stat has an aggregation rule (see AggregationOf): counters and additive
+// gauges such as bytes, limit_maxbytes, and curr_connections are summed,
+// uptime and accepting_conns take the minimum, time the maximum, and
+// identifying stats such as pid and version are kept only when every server
+// agrees. Rates of counters are summed the same way. Ratios are never
+// aggregated themselves: averaging per-server hit ratios would weigh an idle
+// server like a busy one, so HitRatio and MemoryRatio on an aggregate divide
+// the summed numerators by the summed denominators instead.
+package memstats
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Stats holds the well-known fields of the general `stats` reply. Each field
+// is tagged with the stat it comes from. A field is left zero when the server
+// doesn't report the stat (older versions lack some) or its value doesn't
+// parse.
+type Stats struct {
+	PID         uint64 `stat:"pid" agg:"same"`
+	Uptime      uint64 `stat:"uptime" agg:"min"` // seconds since the server started
+	Time        uint64 `stat:"time" agg:"max"`   // server's clock, Unix seconds
+	Version     string `stat:"version" agg:"same"`
+	PointerSize uint64 `stat:"pointer_size" agg:"same"`
+
+	RusageUser   float64 `stat:"rusage_user"`   // CPU seconds
+	RusageSystem float64 `stat:"rusage_system"` // CPU seconds
+	Threads      uint64  `stat:"threads"`
+
+	MaxConnections       uint64 `stat:"max_connections"`
+	CurrConnections      uint64 `stat:"curr_connections"`
+	TotalConnections     uint64 `stat:"total_connections"`
+	RejectedConnections  uint64 `stat:"rejected_connections"`
+	ConnectionStructures uint64 `stat:"connection_structures"`
+	AcceptingConns       uint64 `stat:"accepting_conns" agg:"min"`
+	ListenDisabledNum    uint64 `stat:"listen_disabled_num"`
+	ConnYields           uint64 `stat:"conn_yields"`
+
+	CmdGet   uint64 `stat:"cmd_get"`
+	CmdSet   uint64 `stat:"cmd_set"`
+	CmdFlush uint64 `stat:"cmd_flush"`
+	CmdTouch uint64 `stat:"cmd_touch"`
+
+	GetHits      uint64 `stat:"get_hits"`
+	GetMisses    uint64 `stat:"get_misses"`
+	GetExpired   uint64 `stat:"get_expired"`
+	GetFlushed   uint64 `stat:"get_flushed"`
+	DeleteHits   uint64 `stat:"delete_hits"`
+	DeleteMisses uint64 `stat:"delete_misses"`
+	IncrHits     uint64 `stat:"incr_hits"`
+	IncrMisses   uint64 `stat:"incr_misses"`
+	DecrHits     uint64 `stat:"decr_hits"`
+	DecrMisses   uint64 `stat:"decr_misses"`
+	CasHits      uint64 `stat:"cas_hits"`
+	CasMisses    uint64 `stat:"cas_misses"`
+	CasBadval    uint64 `stat:"cas_badval"`
+	TouchHits    uint64 `stat:"touch_hits"`
+	TouchMisses  uint64 `stat:"touch_misses"`
+	AuthCmds     uint64 `stat:"auth_cmds"`
+	AuthErrors   uint64 `stat:"auth_errors"`
+
+	BytesRead     uint64 `stat:"bytes_read"`
+	BytesWritten  uint64 `stat:"bytes_written"`
+	LimitMaxbytes uint64 `stat:"limit_maxbytes"`
+	Bytes         uint64 `stat:"bytes"`
+	CurrItems     uint64 `stat:"curr_items"`
+	TotalItems    uint64 `stat:"total_items"`
+
+	Evictions        uint64 `stat:"evictions"`
+	Reclaimed        uint64 `stat:"reclaimed"`
+	ExpiredUnfetched uint64 `stat:"expired_unfetched"`
+	EvictedUnfetched uint64 `stat:"evicted_unfetched"`
+	EvictedActive    uint64 `stat:"evicted_active"`
+}
+
+// field maps a stat name to the index of its Stats field.
+type field struct {
+	index int
+	kind  reflect.Kind
+	agg   Aggregation
+}
+
+var fields = func() map[string]field {
+	t := reflect.TypeFor[Stats]()
+	m := make(map[string]field, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		m[f.Tag.Get("stat")] = field{index: i, kind: f.Type.Kind(), agg: parseAggregation(f.Tag.Get("agg"))}
+	}
+	return m
+}()
+
+// Parse fills a Stats from the raw name/value pairs of a `stats` reply.
+// Stats it doesn't know are ignored; the caller keeps the raw map for those.
+func Parse(raw map[string]string) Stats {
+	var s Stats
+	v := reflect.ValueOf(&s).Elem()
+	for name, value := range raw {
+		f, ok := fields[name]
+		if !ok {
+			continue
+		}
+		switch f.kind {
+		case reflect.Uint64:
+			if n, err := strconv.ParseUint(value, 10, 64); err == nil {
+				v.Field(f.index).SetUint(n)
+			}
+		case reflect.Float64:
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				v.Field(f.index).SetFloat(n)
+			}
+		case reflect.String:
+			v.Field(f.index).SetString(value)
+		}
+	}
+	return s
+}
+
+// HitRatio is the share of gets that hit, from 0 to 1, or 0 before any get.
+// On an aggregate it is the ratio of the summed hits and gets, so busy
+// servers weigh more than idle ones.
+func (s Stats) HitRatio() float64 {
+	total := s.GetHits + s.GetMisses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.GetHits) / float64(total)
+}
+
+// MemoryRatio is the share of limit_maxbytes in use, from 0 to 1, or 0 when
+// the limit is unknown. On an aggregate it is the summed bytes against the
+// summed limits.
+func (s Stats) MemoryRatio() float64 {
+	if s.LimitMaxbytes == 0 {
+		return 0
+	}
+	return float64(s.Bytes) / float64(s.LimitMaxbytes)
+}
@@ -0,0 +1,40 @@
+package memstats
+
+import "testing"
+
+func TestParseKeepsFullCounterPrecision(t *testing.T) {
+	s := Parse(map[string]string{
+		"version":        "1.6.21",
+		"uptime":         "3600",
+		"bytes_read":     "9007199254740993", // 2^53 + 1, not representable as float64
+		"get_hits":       "90",
+		"get_misses":     "10",
+		"rusage_user":    "1.250000",
+		"evictions":      "not a number",
+		"unknown_future": "7",
+	})
+	if s.Version != "1.6.21" || s.Uptime != 3600 || s.RusageUser != 1.25 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	if s.BytesRead != 9007199254740993 {
+		t.Fatalf("bytes_read = %d, want 9007199254740993", s.BytesRead)
+	}
+	if s.Evictions != 0 {
+		t.Fatalf("unparsable evictions = %d, want 0", s.Evictions)
+	}
+	if got := s.HitRatio(); got != 0.9 {
+		t.Fatalf("hit ratio = %v, want 0.9", got)
+	}
+	if got := (Stats{}).HitRatio(); got != 0 {
+		t.Fatalf("hit ratio without gets = %v, want 0", got)
+	}
+}
+
+func TestEveryFieldIsTagged(t *testing.T) {
+	if len(fields) == 0 {
+		t.Fatal("no fields")
+	}
+	if _, ok := fields[""]; ok {
+		t.Fatal("a Stats field has no stat tag")
+	}
+}